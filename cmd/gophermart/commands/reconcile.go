@@ -0,0 +1,74 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"gofermart/internal/gophermart/config"
+	"gofermart/internal/gophermart/core/application"
+)
+
+// reconcileCmd prints a JSON report of the wallets that don't add up. It
+// exits with status 1 while discrepancies are left unfixed.
+var reconcileCmd = &cobra.Command{
+	Use:   "reconcile",
+	Short: "Check balances against the ledger, orders and withdrawals",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fix, err := cmd.Flags().GetBool("fix")
+		if err != nil {
+			return fmt.Errorf("can't get fix flag: %w", err)
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("can't load config: %w", err)
+		}
+
+		if cfg.DB.URI == "" {
+			return errors.New("database uri is required: the memory store is not shared with the server")
+		}
+
+		newStore, err := openStore(cfg)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = newStore.Close()
+		}()
+
+		newApplication := application.NewApplication(application.Config{
+			Repo:   newStore,
+			Logger: *zap.NewNop().Sugar(),
+		})
+
+		report, err := newApplication.Reconcile(cmd.Context(), fix)
+		if err != nil {
+			return fmt.Errorf("can't reconcile: %w", err)
+		}
+
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return fmt.Errorf("can't write report: %w", err)
+		}
+
+		if len(report.Discrepancies) > report.Fixed {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("%d of %d discrepancies left", len(report.Discrepancies)-report.Fixed,
+				len(report.Discrepancies))
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	reconcileCmd.Flags().Bool("fix", false, "write adjustment entries for ledger discrepancies")
+	rootCmd.AddCommand(reconcileCmd)
+}
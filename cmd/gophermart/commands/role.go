@@ -0,0 +1,55 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"gofermart/internal/gophermart/config"
+	"gofermart/internal/gophermart/core/application"
+)
+
+// setRoleCmd bootstraps the first admin, who can then manage roles through
+// the admin API.
+var setRoleCmd = &cobra.Command{
+	Use:   "set-role <login> <user|support|admin>",
+	Short: "Change the role of an account",
+	Args:  cobra.ExactArgs(2), //nolint:mnd // login and role
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("can't load config: %w", err)
+		}
+
+		if cfg.DB.URI == "" {
+			return errors.New("database uri is required: the memory store is not shared with the server")
+		}
+
+		newStore, err := openStore(cfg)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = newStore.Close()
+		}()
+
+		newApplication := application.NewApplication(application.Config{
+			Repo:   newStore,
+			Logger: *zap.NewNop().Sugar(),
+		})
+
+		if err := newApplication.SetUserRole(cmd.Context(), "cli", args[0], args[1]); err != nil {
+			return fmt.Errorf("can't set role: %w", err)
+		}
+
+		fmt.Printf("Role of %s set to %s\n", args[0], args[1])
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(setRoleCmd)
+}
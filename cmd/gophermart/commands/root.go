@@ -1,7 +1,9 @@
 package commands
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -16,6 +18,8 @@ import (
 	"gofermart/internal/gophermart/config"
 	"gofermart/internal/gophermart/core/application"
 	"gofermart/internal/gophermart/core/client"
+	"gofermart/internal/gophermart/core/keyring"
+	"gofermart/internal/gophermart/core/model"
 	"gofermart/internal/gophermart/infra/api/rest"
 	"gofermart/internal/gophermart/infra/store"
 	"gofermart/internal/gophermart/infra/store/memory"
@@ -45,21 +49,7 @@ var rootCmd = &cobra.Command{
 			}
 		}()
 
-		var (
-			memoryConfig = &memory.Config{}
-			dbConfig     *postgresql.Config
-		)
-
-		if cfg.DB.URI != "" {
-			dbConfig = &postgresql.Config{
-				Dsn: cfg.DB.URI,
-			}
-		}
-
-		newStore, err := store.NewStore(store.Config{
-			Memory:     memoryConfig,
-			Postgresql: dbConfig,
-		})
+		newStore, err := openStore(cfg)
 		if err != nil {
 			logger.Fatal("can't create store", zap.Error(err))
 		}
@@ -68,29 +58,120 @@ var rootCmd = &cobra.Command{
 
 		newClient := client.NewClient(cfg.Accrual.System.Address, cfg.Accrual.System.Limit)
 
+		keys, err := loadKeyRing(cfg.Auth.Keys)
+		if err != nil {
+			logger.Fatal("can't load signing keys", zap.Error(err))
+		}
+
+		passwordPolicy, err := loadPasswordPolicy(cfg.Auth.Password)
+		if err != nil {
+			logger.Fatal("can't load password policy", zap.Error(err))
+		}
+
+		withdrawThreshold, err := loadWithdrawThreshold(cfg.Auth.TOTP)
+		if err != nil {
+			logger.Fatal("can't load withdraw threshold", zap.Error(err))
+		}
+
+		tiers, err := loadTiers(cfg.Points.Tiers)
+		if err != nil {
+			logger.Fatal("can't load tiers", zap.Error(err))
+		}
+
+		transferLimits, err := loadTransferLimits(cfg.Points.Transfers)
+		if err != nil {
+			logger.Fatal("can't load transfer limits", zap.Error(err))
+		}
+
+		referrals, err := loadReferrals(cfg.Points.Referrals)
+		if err != nil {
+			logger.Fatal("can't load referrals", zap.Error(err))
+		}
+
+		withdrawLimits, err := loadWithdrawLimits(cfg.Points.Withdrawals)
+		if err != nil {
+			logger.Fatal("can't load withdrawal limits", zap.Error(err))
+		}
+
+		wallets, err := application.NewWallets(cfg.Points.Wallets.Sources)
+		if err != nil {
+			logger.Fatal("can't load wallets", zap.Error(err))
+		}
+
 		newApplication := application.NewApplication(application.Config{
-			Repo:   newStore,
-			Client: newClient,
-			Logger: *logger.Sugar(),
-			Secret: cfg.Secret,
+			Repo:       newStore,
+			Client:     newClient,
+			Logger:     *logger.Sugar(),
+			Keys:       keys,
+			Secret:     cfg.Secret,
+			AccessTTL:  cfg.Auth.AccessTokenTTL,
+			RefreshTTL: cfg.Auth.RefreshTokenTTL,
+
+			LoginProtection: loginProtection(cfg.Auth.LoginProtection),
+			PasswordPolicy:  passwordPolicy,
+			PasswordHashing: application.PasswordHashing{
+				Algorithm:     cfg.Auth.Password.Algorithm,
+				BcryptCost:    cfg.Auth.Password.BcryptCost,
+				Argon2Time:    cfg.Auth.Password.Argon2Time,
+				Argon2Memory:  cfg.Auth.Password.Argon2Memory,
+				Argon2Threads: cfg.Auth.Password.Argon2Threads,
+			},
+			TOTP: application.TOTPSettings{
+				Issuer:            cfg.Auth.TOTP.Issuer,
+				MFATokenTTL:       cfg.Auth.TOTP.MFATokenTTL,
+				WithdrawThreshold: withdrawThreshold,
+			},
+			IdempotencyKeyTTL: cfg.Server.IdempotencyKeyTTL,
+			HoldTTL:           cfg.Server.WithdrawHoldTTL,
+
+			PointsExpiry: application.PointsExpiry{
+				Months:       cfg.Points.ExpiryMonths,
+				NoticePeriod: cfg.Points.NoticePeriod,
+			},
+			Tiers:          tiers,
+			TransferLimits: transferLimits,
+			Referrals:      referrals,
+			WithdrawLimits: withdrawLimits,
+			Wallets:        wallets,
 		})
 
 		const (
-			pollInterval = time.Second
+			pollInterval          = time.Second
+			defaultTierRecompute  = time.Hour
+			defaultReconciliation = 24 * time.Hour
 		)
 
 		poll := time.NewTicker(pollInterval)
 		defer poll.Stop()
 
+		tierInterval := cfg.Points.Tiers.RecomputeInterval
+		if tierInterval <= 0 {
+			tierInterval = defaultTierRecompute
+		}
+
+		tierTicker := time.NewTicker(tierInterval)
+		defer tierTicker.Stop()
+
+		reconcileInterval := cfg.Points.Reconciliation.Interval
+		if reconcileInterval <= 0 {
+			reconcileInterval = defaultReconciliation
+		}
+
+		reconcileTicker := time.NewTicker(reconcileInterval)
+		defer reconcileTicker.Stop()
+
 		ctx, cancel := context.WithCancel(context.Background())
 		defer cancel()
 
 		go newApplication.RunWorker(ctx, poll.C)
+		go newApplication.RunTierWorker(ctx, tierTicker.C)
+		go newApplication.RunReconciliationWorker(ctx, reconcileTicker.C)
 
 		api := rest.NewRouter(rest.Config{
-			Server: newApplication,
-			Port:   getPortFromAddress(cfg.Server.Address),
-			Logger: *logger.Sugar(),
+			Server:          newApplication,
+			Port:            getPortFromAddress(cfg.Server.Address),
+			Logger:          *logger.Sugar(),
+			TokenPrecedence: cfg.Auth.TokenPrecedence,
 		})
 
 		stop := make(chan os.Signal, 1)
@@ -112,6 +193,257 @@ var rootCmd = &cobra.Command{
 	},
 }
 
+func openStore(cfg *config.Config) (store.Store, error) {
+	var (
+		memoryConfig = &memory.Config{}
+		dbConfig     *postgresql.Config
+	)
+
+	if cfg.DB.URI != "" {
+		dbConfig = &postgresql.Config{
+			Dsn: cfg.DB.URI,
+		}
+	}
+
+	newStore, err := store.NewStore(store.Config{
+		Memory:     memoryConfig,
+		Postgresql: dbConfig,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("can't create store: %w", err)
+	}
+
+	return newStore, nil
+}
+
+func loginProtection(conf config.LoginProtectionConfig) application.LoginProtection {
+	return application.LoginProtection{
+		Window:       conf.Window,
+		BaseDelay:    conf.BaseDelay,
+		MaxDelay:     conf.MaxDelay,
+		LockDuration: conf.LockDuration,
+		DelayAfter:   conf.DelayAfter,
+		LockAfter:    conf.LockAfter,
+		IPLockAfter:  conf.IPLockAfter,
+	}
+}
+
+func loadPasswordPolicy(conf config.PasswordConfig) (application.PasswordPolicy, error) {
+	policy := application.PasswordPolicy{
+		MinLength:     conf.MinLength,
+		RequireUpper:  conf.RequireUpper,
+		RequireLower:  conf.RequireLower,
+		RequireDigit:  conf.RequireDigit,
+		RequireSymbol: conf.RequireSymbol,
+	}
+
+	if conf.DenylistFile == "" {
+		return policy, nil
+	}
+
+	file, err := os.Open(conf.DenylistFile)
+	if err != nil {
+		return application.PasswordPolicy{}, fmt.Errorf("can't open password denylist: %w", err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	policy.Denylist = make(map[string]struct{})
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if password := strings.TrimSpace(scanner.Text()); password != "" {
+			policy.Denylist[strings.ToLower(password)] = struct{}{}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return application.PasswordPolicy{}, fmt.Errorf("can't read password denylist: %w", err)
+	}
+
+	return policy, nil
+}
+
+func loadWithdrawThreshold(conf config.TOTPConfig) (model.Money, error) {
+	if conf.WithdrawThreshold == "" {
+		return 0, nil
+	}
+
+	threshold, err := model.ParseMoney(conf.WithdrawThreshold)
+	if err != nil {
+		return 0, fmt.Errorf("can't parse withdraw threshold: %w", err)
+	}
+
+	return threshold, nil
+}
+
+func loadTiers(conf config.TiersConfig) (application.Tiers, error) {
+	tiers := make([]application.Tier, 0, len(conf.Levels))
+	for _, level := range conf.Levels {
+		tier := application.Tier{Name: level.Name}
+
+		threshold, err := model.ParseMoney(level.Threshold)
+		if err != nil {
+			return nil, fmt.Errorf("can't parse threshold of tier %q: %w", level.Name, err)
+		}
+		tier.Threshold = threshold
+
+		multiplier, err := model.ParseMultiplier(level.Multiplier)
+		if err != nil {
+			return nil, fmt.Errorf("can't parse multiplier of tier %q: %w", level.Name, err)
+		}
+		tier.Multiplier = multiplier
+
+		tiers = append(tiers, tier)
+	}
+
+	result, err := application.NewTiers(tiers)
+	if err != nil {
+		return nil, fmt.Errorf("can't load tiers: %w", err)
+	}
+
+	return result, nil
+}
+
+func loadTransferLimits(conf config.TransfersConfig) (application.TransferLimits, error) {
+	var limits application.TransferLimits
+
+	for _, limit := range []struct {
+		value  string
+		target *model.Money
+	}{
+		{value: conf.DailySentLimit, target: &limits.DailySent},
+		{value: conf.DailyReceivedLimit, target: &limits.DailyReceived},
+	} {
+		if limit.value == "" {
+			continue
+		}
+
+		amount, err := model.ParseMoney(limit.value)
+		if err != nil {
+			return application.TransferLimits{}, fmt.Errorf("can't parse transfer limit: %w", err)
+		}
+		*limit.target = amount
+	}
+
+	return limits, nil
+}
+
+func loadWithdrawLimits(conf config.WithdrawalsConfig) (application.WithdrawLimits, error) {
+	if conf.PasswordCooldown < 0 || conf.SessionCooldown < 0 {
+		return application.WithdrawLimits{}, errors.New("withdrawal cooldowns can't be negative")
+	}
+
+	limits := application.WithdrawLimits{
+		PasswordCooldown: conf.PasswordCooldown,
+		SessionCooldown:  conf.SessionCooldown,
+	}
+
+	for _, limit := range []struct {
+		value  string
+		target *model.Money
+	}{
+		{value: conf.Min, target: &limits.Min},
+		{value: conf.Max, target: &limits.Max},
+		{value: conf.DailyLimit, target: &limits.Daily},
+		{value: conf.MonthlyLimit, target: &limits.Monthly},
+	} {
+		if limit.value == "" {
+			continue
+		}
+
+		amount, err := model.ParseMoney(limit.value)
+		if err != nil {
+			return application.WithdrawLimits{}, fmt.Errorf("can't parse withdrawal limit: %w", err)
+		}
+
+		if amount < 0 {
+			return application.WithdrawLimits{}, fmt.Errorf("withdrawal limit %s is negative", amount)
+		}
+		*limit.target = amount
+	}
+
+	if limits.Max > 0 && limits.Min > limits.Max {
+		return application.WithdrawLimits{}, fmt.Errorf("withdrawal minimum %s is above the maximum %s",
+			limits.Min, limits.Max)
+	}
+
+	return limits, nil
+}
+
+func loadReferrals(conf config.ReferralsConfig) (application.Referrals, error) {
+	if conf.MaxPerReferrer < 0 {
+		return application.Referrals{}, fmt.Errorf("max referrals per referrer %d is negative", conf.MaxPerReferrer)
+	}
+
+	referrals := application.Referrals{MaxPerReferrer: conf.MaxPerReferrer}
+
+	for _, bonus := range []struct {
+		value  string
+		target *model.Money
+	}{
+		{value: conf.ReferrerBonus, target: &referrals.ReferrerBonus},
+		{value: conf.ReferredBonus, target: &referrals.ReferredBonus},
+	} {
+		if bonus.value == "" {
+			continue
+		}
+
+		amount, err := model.ParseMoney(bonus.value)
+		if err != nil {
+			return application.Referrals{}, fmt.Errorf("can't parse referral bonus: %w", err)
+		}
+
+		if amount < 0 {
+			return application.Referrals{}, fmt.Errorf("referral bonus %s is negative", amount)
+		}
+		*bonus.target = amount
+	}
+
+	return referrals, nil
+}
+
+// loadKeyRing returns nil when no keys are configured, so the application
+// falls back to the HMAC secret.
+func loadKeyRing(conf config.KeysConfig) (*keyring.Ring, error) {
+	if len(conf.Items) == 0 {
+		return nil, nil //nolint:nilnil // no key ring is a valid configuration
+	}
+
+	keys := make([]keyring.KeyConfig, 0, len(conf.Items))
+	for _, item := range conf.Items {
+		key := keyring.KeyConfig{
+			ID:             item.ID,
+			Algorithm:      item.Algorithm,
+			PrivateKeyFile: item.PrivateKey,
+			PublicKeyFile:  item.PublicKey,
+		}
+
+		if item.RetiredAt != "" {
+			retiredAt, err := time.Parse(time.RFC3339, item.RetiredAt)
+			if err != nil {
+				return nil, fmt.Errorf("can't parse retired_at of key %q: %w", item.ID, err)
+			}
+
+			key.RetiredAt = &retiredAt
+		}
+
+		keys = append(keys, key)
+	}
+
+	ring, err := keyring.Load(keyring.Config{
+		SigningKey:  conf.SigningKey,
+		Keys:        keys,
+		RetireGrace: conf.RetireGrace,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("can't load key ring: %w", err)
+	}
+
+	return ring, nil
+}
+
 func getPortFromAddress(address string) int64 {
 	const portSplitLen = 2
 
@@ -0,0 +1,53 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"gofermart/internal/gophermart/config"
+	"gofermart/internal/gophermart/core/application"
+)
+
+var unlockCmd = &cobra.Command{
+	Use:   "unlock <login>",
+	Short: "Clear failed login attempts and the lockout of an account",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("can't load config: %w", err)
+		}
+
+		if cfg.DB.URI == "" {
+			return errors.New("database uri is required: the memory store is not shared with the server")
+		}
+
+		newStore, err := openStore(cfg)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = newStore.Close()
+		}()
+
+		newApplication := application.NewApplication(application.Config{
+			Repo:   newStore,
+			Logger: *zap.NewNop().Sugar(),
+		})
+
+		if err := newApplication.UnlockUser(cmd.Context(), args[0]); err != nil {
+			return fmt.Errorf("can't unlock user: %w", err)
+		}
+
+		fmt.Printf("User %s unlocked\n", args[0])
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(unlockCmd)
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -12,10 +13,85 @@ type Config struct {
 	DB        DatabaseConfig  `mapstructure:"database"`
 	Migration MigrationConfig `mapstructure:"migration"`
 	Accrual   AccrualConfig   `mapstructure:"accrual"`
+	Auth      AuthConfig      `mapstructure:"auth"`
+	Points    PointsConfig    `mapstructure:"points"`
 }
 
 type ServerConfig struct {
 	Address string `mapstructure:"address"`
+	// IdempotencyKeyTTL is how long responses are kept for replay.
+	IdempotencyKeyTTL time.Duration `mapstructure:"idempotency_key_ttl"`
+	// WithdrawHoldTTL is how long a withdrawal hold waits for a capture.
+	WithdrawHoldTTL time.Duration `mapstructure:"withdraw_hold_ttl"`
+}
+
+type PointsConfig struct {
+	// ExpiryMonths is how long accrued points stay spendable; 0 keeps them.
+	ExpiryMonths int `mapstructure:"expiry_months"`
+	// NoticePeriod is how far ahead the balance reports expiring points.
+	NoticePeriod time.Duration     `mapstructure:"notice_period"`
+	Tiers        TiersConfig       `mapstructure:"tiers"`
+	Transfers    TransfersConfig   `mapstructure:"transfers"`
+	Referrals    ReferralsConfig   `mapstructure:"referrals"`
+	Withdrawals  WithdrawalsConfig `mapstructure:"withdrawals"`
+	Wallets      WalletsConfig     `mapstructure:"wallets"`
+	// Reconciliation schedules the worker that checks the balances.
+	Reconciliation ReconciliationConfig `mapstructure:"reconciliation"`
+}
+
+type ReconciliationConfig struct {
+	Interval time.Duration `mapstructure:"interval"`
+}
+
+// WalletsConfig maps accrual sources reported by the accrual system to the
+// currencies of the wallets their points go to. Accruals of other sources
+// go to the default wallet.
+type WalletsConfig struct {
+	Sources map[string]string `mapstructure:"sources"`
+}
+
+// WithdrawalsConfig holds the risk limits on withdrawals. The amounts are
+// decimals with at most two fractional digits, Daily and Monthly cap the
+// withdrawals per user and UTC day or month. Withdrawals are refused for
+// the cooldowns after a password change and after a new login. Empty or
+// zero values don't apply.
+type WithdrawalsConfig struct {
+	Min              string        `mapstructure:"min"`
+	Max              string        `mapstructure:"max"`
+	DailyLimit       string        `mapstructure:"daily_limit"`
+	MonthlyLimit     string        `mapstructure:"monthly_limit"`
+	PasswordCooldown time.Duration `mapstructure:"password_cooldown"`
+	SessionCooldown  time.Duration `mapstructure:"session_cooldown"`
+}
+
+// ReferralsConfig sets the bonuses credited to the referrer and the
+// referred user once the first order of the latter is processed, as
+// decimals. MaxPerReferrer caps the users registering with one code, 0
+// means no cap.
+type ReferralsConfig struct {
+	ReferrerBonus  string `mapstructure:"referrer_bonus"`
+	ReferredBonus  string `mapstructure:"referred_bonus"`
+	MaxPerReferrer int    `mapstructure:"max_per_referrer"`
+}
+
+// TransfersConfig caps transfers per user and UTC day. The limits are
+// decimals with at most two fractional digits, empty or 0 means no limit.
+type TransfersConfig struct {
+	DailySentLimit     string `mapstructure:"daily_sent_limit"`
+	DailyReceivedLimit string `mapstructure:"daily_received_limit"`
+}
+
+type TiersConfig struct {
+	RecomputeInterval time.Duration `mapstructure:"recompute_interval"`
+	Levels            []TierConfig  `mapstructure:"levels"`
+}
+
+// TierConfig is a loyalty tier. Threshold and Multiplier are decimals with
+// at most two fractional digits.
+type TierConfig struct {
+	Name       string `mapstructure:"name"`
+	Threshold  string `mapstructure:"threshold"`
+	Multiplier string `mapstructure:"multiplier"`
 }
 
 type DatabaseConfig struct {
@@ -34,6 +110,65 @@ type AccrualConfig struct {
 	} `mapstructure:"system"`
 }
 
+type AuthConfig struct {
+	AccessTokenTTL  time.Duration `mapstructure:"access_token_ttl"`
+	RefreshTokenTTL time.Duration `mapstructure:"refresh_token_ttl"`
+	TokenPrecedence string        `mapstructure:"token_precedence"`
+	Keys            KeysConfig    `mapstructure:"keys"`
+
+	LoginProtection LoginProtectionConfig `mapstructure:"login_protection"`
+	Password        PasswordConfig        `mapstructure:"password"`
+	TOTP            TOTPConfig            `mapstructure:"totp"`
+}
+
+type TOTPConfig struct {
+	Issuer      string        `mapstructure:"issuer"`
+	MFATokenTTL time.Duration `mapstructure:"mfa_token_ttl"`
+	// WithdrawThreshold is the sum above which a withdrawal needs a code,
+	// a decimal with at most two fractional digits.
+	WithdrawThreshold string `mapstructure:"withdraw_threshold"`
+}
+
+type PasswordConfig struct {
+	// DenylistFile is a text file with one common password per line.
+	DenylistFile  string `mapstructure:"denylist_file"`
+	Algorithm     string `mapstructure:"algorithm"`
+	MinLength     int    `mapstructure:"min_length"`
+	BcryptCost    int    `mapstructure:"bcrypt_cost"`
+	Argon2Time    uint32 `mapstructure:"argon2_time"`
+	Argon2Memory  uint32 `mapstructure:"argon2_memory"`
+	Argon2Threads uint8  `mapstructure:"argon2_threads"`
+	RequireUpper  bool   `mapstructure:"require_upper"`
+	RequireLower  bool   `mapstructure:"require_lower"`
+	RequireDigit  bool   `mapstructure:"require_digit"`
+	RequireSymbol bool   `mapstructure:"require_symbol"`
+}
+
+type LoginProtectionConfig struct {
+	Window       time.Duration `mapstructure:"window"`
+	BaseDelay    time.Duration `mapstructure:"base_delay"`
+	MaxDelay     time.Duration `mapstructure:"max_delay"`
+	LockDuration time.Duration `mapstructure:"lock_duration"`
+	DelayAfter   int           `mapstructure:"delay_after"`
+	LockAfter    int           `mapstructure:"lock_after"`
+	IPLockAfter  int           `mapstructure:"ip_lock_after"`
+}
+
+type KeysConfig struct {
+	SigningKey  string        `mapstructure:"signing_key"`
+	Items       []KeyConfig   `mapstructure:"items"`
+	RetireGrace time.Duration `mapstructure:"retire_grace"`
+}
+
+type KeyConfig struct {
+	ID         string `mapstructure:"id"`
+	Algorithm  string `mapstructure:"algorithm"`
+	PrivateKey string `mapstructure:"private_key"`
+	PublicKey  string `mapstructure:"public_key"`
+	// RetiredAt is an RFC 3339 timestamp; empty means the key is active.
+	RetiredAt string `mapstructure:"retired_at"`
+}
+
 func Load() (*Config, error) {
 	var cfg Config
 	err := viper.Unmarshal(&cfg)
@@ -0,0 +1,88 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+// GetAccount looks up a user for the admin API.
+func (a *Application) GetAccount(ctx context.Context, login string) (model.AccountResponse, error) {
+	account, err := a.repo.GetUser(ctx, login)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return model.AccountResponse{}, fmt.Errorf("user %s: %w", login, ErrUserNotFound)
+		}
+
+		return model.AccountResponse{}, fmt.Errorf("can't get user: %w", err)
+	}
+
+	return model.AccountResponse{
+		Login:     account.Login,
+		Role:      account.Role,
+		Disabled:  account.Disabled,
+		CreatedAt: account.CreatedAt,
+	}, nil
+}
+
+// SetUserRole changes the role of a user. The user keeps the old role until
+// their access token is refreshed.
+func (a *Application) SetUserRole(ctx context.Context, actor, login, role string) error {
+	switch role {
+	case model.RoleUser, model.RoleSupport, model.RoleAdmin, model.RolePartner:
+	default:
+		return fmt.Errorf("role %q: %w", role, ErrInvalidRole)
+	}
+
+	if err := a.repo.SetUserRole(ctx, login, role); err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return fmt.Errorf("user %s: %w", login, ErrUserNotFound)
+		}
+
+		return fmt.Errorf("can't set user role: %w", err)
+	}
+
+	a.logger.Infof("role of %s set to %s by %s", login, role, actor)
+
+	return nil
+}
+
+// DisableUser blocks logins of a user and signs them out everywhere.
+func (a *Application) DisableUser(ctx context.Context, actor, login string) error {
+	if err := a.repo.SetUserDisabled(ctx, login, true); err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return fmt.Errorf("user %s: %w", login, ErrUserNotFound)
+		}
+
+		return fmt.Errorf("can't disable user: %w", err)
+	}
+
+	if err := a.repo.RevokeUserRefreshTokens(ctx, login, ""); err != nil {
+		return fmt.Errorf("can't revoke refresh tokens: %w", err)
+	}
+
+	if err := a.repo.RevokeUserSessions(ctx, login, ""); err != nil {
+		return fmt.Errorf("can't revoke sessions: %w", err)
+	}
+
+	a.logger.Infof("user %s disabled by %s", login, actor)
+
+	return nil
+}
+
+func (a *Application) EnableUser(ctx context.Context, actor, login string) error {
+	if err := a.repo.SetUserDisabled(ctx, login, false); err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return fmt.Errorf("user %s: %w", login, ErrUserNotFound)
+		}
+
+		return fmt.Errorf("can't enable user: %w", err)
+	}
+
+	a.logger.Infof("user %s enabled by %s", login, actor)
+
+	return nil
+}
@@ -0,0 +1,147 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+const (
+	apiKeyPrefix    = "gm_"
+	apiKeyPrefixLen = 8
+)
+
+var apiKeyScopes = []string{
+	model.ScopeOrdersRead,
+	model.ScopeOrdersWrite,
+	model.ScopeBalanceRead,
+	model.ScopeWithdrawalsRead,
+	model.ScopeWithdrawalsWrite,
+}
+
+// CreateAPIKey issues a new key. The key is returned once and can't be
+// recovered afterwards.
+func (a *Application) CreateAPIKey(
+	ctx context.Context,
+	login string,
+	request model.CreateAPIKeyRequest,
+) (model.CreatedAPIKeyResponse, error) {
+	if len(request.Scopes) == 0 {
+		return model.CreatedAPIKeyResponse{}, fmt.Errorf("no scopes requested: %w", ErrInvalidScope)
+	}
+
+	for _, scope := range request.Scopes {
+		if !slices.Contains(apiKeyScopes, scope) {
+			return model.CreatedAPIKeyResponse{}, fmt.Errorf("scope %q: %w", scope, ErrInvalidScope)
+		}
+	}
+
+	secret, err := generateRefreshToken()
+	if err != nil {
+		return model.CreatedAPIKeyResponse{}, fmt.Errorf("can't generate api key: %w", err)
+	}
+
+	scopes := slices.Clone(request.Scopes)
+	slices.Sort(scopes)
+
+	key := apiKeyPrefix + secret
+	stored := model.APIKey{
+		ID:        uuid.NewString(),
+		Login:     login,
+		Name:      request.Name,
+		Prefix:    key[:len(apiKeyPrefix)+apiKeyPrefixLen],
+		KeyHash:   hashToken(key),
+		Scopes:    slices.Compact(scopes),
+		CreatedAt: time.Now(),
+	}
+
+	if err := a.repo.SaveAPIKey(ctx, stored); err != nil {
+		return model.CreatedAPIKeyResponse{}, fmt.Errorf("can't save api key: %w", err)
+	}
+
+	return model.CreatedAPIKeyResponse{
+		Key:            key,
+		APIKeyResponse: apiKeyResponse(stored),
+	}, nil
+}
+
+func (a *Application) ListAPIKeys(ctx context.Context, login string) ([]model.APIKeyResponse, error) {
+	keys, err := a.repo.GetUserAPIKeys(ctx, login)
+	if err != nil {
+		return nil, fmt.Errorf("can't get api keys: %w", err)
+	}
+
+	result := make([]model.APIKeyResponse, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, apiKeyResponse(key))
+	}
+
+	return result, nil
+}
+
+func (a *Application) RevokeAPIKey(ctx context.Context, login, id string) error {
+	if err := a.repo.RevokeAPIKey(ctx, login, id); err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return fmt.Errorf("api key %s: %w", id, ErrAPIKeyNotFound)
+		}
+
+		return fmt.Errorf("can't revoke api key: %w", err)
+	}
+
+	return nil
+}
+
+// ValidateAPIKey returns the owner of a key together with the scopes the
+// key was granted. API keys never carry more than the user role.
+func (a *Application) ValidateAPIKey(ctx context.Context, key string) (model.Principal, error) {
+	stored, err := a.repo.GetAPIKey(ctx, hashToken(key))
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return model.Principal{}, fmt.Errorf("api key not found: %w", ErrInvalidToken)
+		}
+
+		return model.Principal{}, fmt.Errorf("can't get api key: %w", err)
+	}
+
+	if stored.RevokedAt != nil {
+		return model.Principal{}, fmt.Errorf("api key %s: %w", stored.ID, ErrTokenRevoked)
+	}
+
+	account, err := a.repo.GetUser(ctx, stored.Login)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return model.Principal{}, fmt.Errorf("user not found: %w", ErrInvalidToken)
+		}
+
+		return model.Principal{}, fmt.Errorf("can't get user: %w", err)
+	}
+
+	if account.Disabled {
+		return model.Principal{}, ErrUserDisabled
+	}
+
+	if err := a.repo.TouchAPIKey(ctx, stored.ID, time.Now()); err != nil {
+		a.logger.Errorf("can't update last use of api key %s: %v", stored.ID, err)
+	}
+
+	return model.Principal{Login: stored.Login, Role: model.RoleUser, Scopes: stored.Scopes}, nil
+}
+
+func apiKeyResponse(key model.APIKey) model.APIKeyResponse {
+	return model.APIKeyResponse{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		CreatedAt:  key.CreatedAt,
+		LastUsedAt: key.LastUsedAt,
+		RevokedAt:  key.RevokedAt,
+	}
+}
@@ -0,0 +1,25 @@
+package application
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"gofermart/internal/gophermart/infra/store/memory"
+)
+
+// newMemoryApplication returns an application backed by the memory store.
+func newMemoryApplication(t *testing.T, protection LoginProtection) *Application {
+	t.Helper()
+
+	store, err := memory.New()
+	require.NoError(t, err)
+
+	return NewApplication(Config{
+		Repo:            store,
+		Logger:          *zap.NewNop().Sugar(),
+		Secret:          "secret",
+		LoginProtection: protection,
+	})
+}
@@ -2,71 +2,386 @@ package application
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/google/uuid"
 
 	"gofermart/internal/gophermart/core/model"
 	"gofermart/internal/gophermart/core/repositories"
 )
 
-func (a *Application) UserRegister(ctx context.Context, request model.User) (string, error) {
-	newPassword, err := bcrypt.GenerateFromPassword([]byte(request.Password), bcrypt.DefaultCost)
+func (a *Application) UserRegister(
+	ctx context.Context,
+	request model.User,
+	client model.ClientInfo,
+) (model.AuthTokens, error) {
+	if strings.HasPrefix(request.Login, deletedLoginPrefix) {
+		return model.AuthTokens{}, fmt.Errorf("login prefix %q is reserved: %w", deletedLoginPrefix, ErrUserExists)
+	}
+
+	if err := a.passwordPolicy.validate(request.Password); err != nil {
+		return model.AuthTokens{}, err
+	}
+
+	referrer, err := a.referrer(ctx, request.ReferralCode, client)
 	if err != nil {
-		return "", fmt.Errorf("can't hash password: %w", err)
+		return model.AuthTokens{}, err
 	}
 
-	if err := a.repo.CreateUser(ctx, request.Login, string(newPassword)); err != nil {
+	newPassword, err := a.passwordHashing.hash(request.Password)
+	if err != nil {
+		return model.AuthTokens{}, err
+	}
+
+	if err := a.repo.CreateUser(ctx, request.Login, newPassword); err != nil {
 		if errors.Is(err, repositories.ErrDuplicate) {
-			return "", fmt.Errorf("user already exists: %w", ErrUserExists)
+			return model.AuthTokens{}, fmt.Errorf("user already exists: %w", ErrUserExists)
+		}
+
+		return model.AuthTokens{}, fmt.Errorf("can't create user: %w", err)
+	}
+
+	if referrer != "" {
+		a.saveReferral(ctx, referrer, request.Login)
+	}
+
+	sessionID, err := a.startSession(ctx, request.Login, client)
+	if err != nil {
+		return model.AuthTokens{}, err
+	}
+
+	tokens, err := a.issueTokens(ctx, model.Principal{
+		Login:     request.Login,
+		Role:      model.RoleUser,
+		SessionID: sessionID,
+	})
+	if err != nil {
+		return model.AuthTokens{}, fmt.Errorf("can't issue tokens: %w", err)
+	}
+
+	return tokens, nil
+}
+
+func (a *Application) UserLogin(
+	ctx context.Context,
+	request model.User,
+	client model.ClientInfo,
+) (model.AuthTokens, error) {
+	attempt, err := a.reserveLoginAttempt(ctx, request.Login, client)
+	if err != nil {
+		return model.AuthTokens{}, err
+	}
+
+	account, err := a.repo.GetUser(ctx, request.Login)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			if err := a.failLoginAttempt(ctx, attempt); err != nil {
+				return model.AuthTokens{}, err
+			}
+
+			return model.AuthTokens{}, fmt.Errorf("user not found: %w", ErrUserNotFound)
+		}
+
+		return model.AuthTokens{}, fmt.Errorf("can't get user: %w", err)
+	}
+
+	ok, err := a.passwordHashing.verify(account.Password, request.Password)
+	if err != nil {
+		return model.AuthTokens{}, fmt.Errorf("can't verify password: %w", err)
+	}
+
+	if !ok {
+		if err := a.failLoginAttempt(ctx, attempt); err != nil {
+			return model.AuthTokens{}, err
+		}
+
+		return model.AuthTokens{}, fmt.Errorf("invalid password: %w", ErrIncorrectPass)
+	}
+
+	if err := a.succeedLoginAttempt(ctx, attempt); err != nil {
+		return model.AuthTokens{}, err
+	}
+
+	// Checked only after the password so the status of an account is not
+	// revealed to someone who doesn't own it.
+	if account.Disabled {
+		return model.AuthTokens{}, fmt.Errorf("login of %s rejected: %w", request.Login, ErrUserDisabled)
+	}
+
+	a.upgradePasswordHash(ctx, request.Login, account.Password, request.Password)
+
+	if err := a.requireMFA(ctx, request.Login); err != nil {
+		return model.AuthTokens{}, err
+	}
+
+	sessionID, err := a.startSession(ctx, request.Login, client)
+	if err != nil {
+		return model.AuthTokens{}, err
+	}
+
+	tokens, err := a.issueTokens(ctx, principalOf(account, sessionID))
+	if err != nil {
+		return model.AuthTokens{}, fmt.Errorf("can't issue tokens: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// upgradePasswordHash rehashes a verified password whose stored hash uses
+// an outdated algorithm or cost. Failing to do so must not fail the login.
+func (a *Application) upgradePasswordHash(ctx context.Context, login, hash, password string) {
+	if !a.passwordHashing.needsRehash(hash) {
+		return
+	}
+
+	newHash, err := a.passwordHashing.hash(password)
+	if err != nil {
+		a.logger.Errorf("can't rehash password of %s: %v", login, err)
+		return
+	}
+
+	if err := a.repo.UpdateUserPassword(ctx, login, newHash); err != nil {
+		a.logger.Errorf("can't store rehashed password of %s: %v", login, err)
+	}
+}
+
+// ChangePassword replaces the password after checking the old one and logs
+// out every other device: their sessions and refresh tokens are revoked, so
+// only the session the password was changed from stays signed in.
+func (a *Application) ChangePassword(ctx context.Context, login, sessionID string,
+	request model.ChangePasswordRequest) error {
+	password, err := a.repo.GetUserPassword(ctx, login)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return fmt.Errorf("user not found: %w", ErrUserNotFound)
 		}
 
-		return "", fmt.Errorf("can't create user: %w", err)
+		return fmt.Errorf("can't get user password: %w", err)
 	}
 
-	token, err := a.generateJwtToken(request.Login)
+	ok, err := a.passwordHashing.verify(password, request.OldPassword)
 	if err != nil {
-		return "", fmt.Errorf("can't generate token: %w", err)
+		return fmt.Errorf("can't verify password: %w", err)
 	}
 
-	return token, nil
+	if !ok {
+		return fmt.Errorf("invalid old password: %w", ErrIncorrectPass)
+	}
+
+	if request.NewPassword == request.OldPassword {
+		return &PasswordPolicyError{Violations: []string{"different from the current password"}}
+	}
+
+	if err := a.passwordPolicy.validate(request.NewPassword); err != nil {
+		return err
+	}
+
+	newHash, err := a.passwordHashing.hash(request.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	if err := a.repo.ChangeUserPassword(ctx, login, newHash, time.Now().UTC()); err != nil {
+		return fmt.Errorf("can't update password: %w", err)
+	}
+
+	if err := a.repo.RevokeUserRefreshTokens(ctx, login, sessionID); err != nil {
+		return fmt.Errorf("can't revoke refresh tokens: %w", err)
+	}
+
+	if err := a.repo.RevokeUserSessions(ctx, login, sessionID); err != nil {
+		return fmt.Errorf("can't revoke sessions: %w", err)
+	}
+
+	return nil
 }
 
-func (a *Application) UserLogin(ctx context.Context, request model.User) (string, error) {
-	password, err := a.repo.GetUserPassword(ctx, request.Login)
+// RefreshTokens exchanges a refresh token for a new token pair. Every refresh
+// token is single use: presenting one that was already rotated revokes the
+// whole family, since either the client or an attacker holds a stolen copy.
+func (a *Application) RefreshTokens(ctx context.Context, refreshToken string) (model.AuthTokens, error) {
+	tokenHash := hashToken(refreshToken)
+
+	stored, err := a.repo.GetRefreshToken(ctx, tokenHash)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return model.AuthTokens{}, fmt.Errorf("refresh token not found: %w", ErrInvalidToken)
+		}
+
+		return model.AuthTokens{}, fmt.Errorf("can't get refresh token: %w", err)
+	}
+
+	if stored.Revoked {
+		return model.AuthTokens{}, fmt.Errorf("refresh token family revoked: %w", ErrTokenRevoked)
+	}
+
+	if stored.UsedAt != nil {
+		return model.AuthTokens{}, a.revokeReusedFamily(ctx, stored)
+	}
+
+	if time.Now().After(stored.ExpiresAt) {
+		return model.AuthTokens{}, fmt.Errorf("refresh token expired: %w", ErrInvalidToken)
+	}
+
+	if err := a.repo.UseRefreshToken(ctx, tokenHash); err != nil {
+		if errors.Is(err, repositories.ErrAlreadyUsed) {
+			return model.AuthTokens{}, a.revokeReusedFamily(ctx, stored)
+		}
+
+		return model.AuthTokens{}, fmt.Errorf("can't use refresh token: %w", err)
+	}
+
+	account, err := a.repo.GetUser(ctx, stored.Login)
 	if err != nil {
 		if errors.Is(err, repositories.ErrNotFound) {
-			return "", fmt.Errorf("user not found: %w", ErrUserNotFound)
+			return model.AuthTokens{}, fmt.Errorf("user not found: %w", ErrInvalidToken)
 		}
 
-		return "", fmt.Errorf("can't get user password: %w", err)
+		return model.AuthTokens{}, fmt.Errorf("can't get user: %w", err)
+	}
+
+	if account.Disabled {
+		return model.AuthTokens{}, fmt.Errorf("refresh of %s rejected: %w", stored.Login, ErrUserDisabled)
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(password), []byte(request.Password)); err != nil {
-		return "", fmt.Errorf("invalid password: %w, %w", err, ErrIncorrectPass)
+	if err := a.resumeSession(ctx, stored); err != nil {
+		return model.AuthTokens{}, err
 	}
 
-	token, err := a.generateJwtToken(request.Login)
+	tokens, err := a.issueTokens(ctx, principalOf(account, stored.FamilyID))
 	if err != nil {
-		return "", fmt.Errorf("can't generate token: %w", err)
+		return model.AuthTokens{}, fmt.Errorf("can't issue tokens: %w", err)
+	}
+
+	return tokens, nil
+}
+
+func (a *Application) revokeReusedFamily(ctx context.Context, token model.RefreshToken) error {
+	if err := a.repo.RevokeRefreshTokenFamily(ctx, token.FamilyID); err != nil {
+		return fmt.Errorf("can't revoke refresh token family: %w", err)
+	}
+
+	a.logger.Warnf("refresh token reuse detected for user %s, family %s revoked", token.Login, token.FamilyID)
+
+	return ErrRefreshTokenReuse
+}
+
+// UserLogout revokes the access token until it expires and ends its
+// session. A refresh token given along is revoked with its family, which
+// covers tokens issued before sessions were recorded.
+func (a *Application) UserLogout(ctx context.Context, accessToken, refreshToken string) error {
+	claims, err := a.parseToken(accessToken)
+	if err != nil {
+		return err
+	}
+
+	if claims.Purpose != "" {
+		return fmt.Errorf("%s token used for logout: %w", claims.Purpose, ErrInvalidToken)
+	}
+
+	if claims.ID != "" && claims.ExpiresAt != nil {
+		if err := a.repo.RevokeToken(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+			return fmt.Errorf("can't revoke access token: %w", err)
+		}
+	}
+
+	if claims.SessionID != "" {
+		if err := a.RevokeSession(ctx, claims.Issuer, claims.SessionID); err != nil &&
+			!errors.Is(err, ErrSessionNotFound) {
+			return err
+		}
+	}
+
+	if refreshToken == "" {
+		return nil
+	}
+
+	stored, err := a.repo.GetRefreshToken(ctx, hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil
+		}
+
+		return fmt.Errorf("can't get refresh token: %w", err)
+	}
+
+	if stored.Login != claims.Issuer {
+		return nil
+	}
+
+	if err := a.repo.RevokeRefreshTokenFamily(ctx, stored.FamilyID); err != nil {
+		return fmt.Errorf("can't revoke refresh token family: %w", err)
 	}
 
-	return token, nil
+	return nil
 }
 
-func (a *Application) generateJwtToken(login string) (string, error) {
-	claims := &jwt.RegisteredClaims{
-		Issuer:    login,
-		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+// issueTokens issues a token pair within the session of the principal. The
+// refresh token joins the session's family.
+func (a *Application) issueTokens(ctx context.Context, principal model.Principal) (model.AuthTokens, error) {
+	now := time.Now()
+
+	accessToken, err := a.generateJwtToken(principal, now.Add(a.accessTTL))
+	if err != nil {
+		return model.AuthTokens{}, fmt.Errorf("can't generate token: %w", err)
+	}
+
+	refreshToken, err := generateRefreshToken()
+	if err != nil {
+		return model.AuthTokens{}, fmt.Errorf("can't generate refresh token: %w", err)
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	secret := []byte(a.secret)
+	refreshExpiresAt := now.Add(a.refreshTTL)
+	if err := a.repo.SaveRefreshToken(ctx, model.RefreshToken{
+		TokenHash: hashToken(refreshToken),
+		Login:     principal.Login,
+		FamilyID:  principal.SessionID,
+		ExpiresAt: refreshExpiresAt,
+		CreatedAt: now,
+	}); err != nil {
+		return model.AuthTokens{}, fmt.Errorf("can't save refresh token: %w", err)
+	}
+
+	return model.AuthTokens{
+		AccessToken:      accessToken,
+		AccessExpiresAt:  now.Add(a.accessTTL),
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: refreshExpiresAt,
+	}, nil
+}
+
+// tokenClaims are the claims of an access token. The role is fixed when the
+// token is issued, so a role change applies from the next refresh.
+type tokenClaims struct {
+	Role string `json:"role,omitempty"`
+	// Purpose is set on tokens that are not access tokens, such as the
+	// token that carries a login over to its second factor step.
+	Purpose   string `json:"purpose,omitempty"`
+	SessionID string `json:"sid,omitempty"`
+	jwt.RegisteredClaims
+}
+
+func (a *Application) generateJwtToken(principal model.Principal, expiresAt time.Time) (string, error) {
+	claims := &tokenClaims{
+		Role:      principal.Role,
+		SessionID: principal.SessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Issuer:    principal.Login,
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
 
-	tokenString, err := token.SignedString(secret)
+	tokenString, err := a.keys.Sign(claims)
 	if err != nil {
 		return "", fmt.Errorf("can't sign token: %w", err)
 	}
@@ -74,32 +389,86 @@ func (a *Application) generateJwtToken(login string) (string, error) {
 	return tokenString, nil
 }
 
-func (a *Application) ValidateToken(tokenString string) (string, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return []byte(a.secret), nil
-	})
+// ValidateToken returns the caller an access token was issued to. Tokens of
+// disabled users are rejected right away rather than when they expire, and
+// the role is the current one of the account, not the one in the token, so
+// a demotion takes effect on the next request.
+func (a *Application) ValidateToken(ctx context.Context, tokenString string) (model.Principal, error) {
+	claims, err := a.parseToken(tokenString)
 	if err != nil {
-		return "", fmt.Errorf("can't parse token: %w", err)
+		return model.Principal{}, err
 	}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok || !token.Valid {
-		return "", errors.New("invalid token claims")
+	if claims.Purpose != "" {
+		return model.Principal{}, fmt.Errorf("%s token used for access: %w", claims.Purpose, ErrInvalidToken)
 	}
 
-	if claims["exp"] == nil {
-		return "", errors.New("invalid token claims")
+	if claims.ID != "" {
+		revoked, err := a.repo.IsTokenRevoked(ctx, claims.ID)
+		if err != nil {
+			return model.Principal{}, fmt.Errorf("can't check token revocation: %w", err)
+		}
+
+		if revoked {
+			return model.Principal{}, ErrTokenRevoked
+		}
 	}
 
-	exp, ok := claims["exp"].(float64)
-	if !ok || time.Now().Unix() > int64(exp) {
-		return "", errors.New("token expired")
+	if err := a.checkSession(ctx, claims); err != nil {
+		return model.Principal{}, err
 	}
 
-	login, ok := claims["iss"].(string)
-	if !ok {
-		return "", errors.New("invalid token claims")
+	account, err := a.repo.GetUser(ctx, claims.Issuer)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return model.Principal{}, fmt.Errorf("user not found: %w", ErrInvalidToken)
+		}
+
+		return model.Principal{}, fmt.Errorf("can't get user: %w", err)
 	}
 
-	return login, nil
+	if account.Disabled {
+		return model.Principal{}, ErrUserDisabled
+	}
+
+	return principalOf(account, claims.SessionID), nil
+}
+
+func (a *Application) JWKS() model.JWKSet {
+	return a.keys.JWKS()
+}
+
+func (a *Application) parseToken(tokenString string) (*tokenClaims, error) {
+	claims := &tokenClaims{}
+
+	token, err := jwt.ParseWithClaims(tokenString, claims, a.keys.Keyfunc)
+	if err != nil {
+		return nil, fmt.Errorf("can't parse token: %w, %w", err, ErrInvalidToken)
+	}
+
+	if !token.Valid || claims.ExpiresAt == nil || claims.Issuer == "" {
+		return nil, fmt.Errorf("invalid token claims: %w", ErrInvalidToken)
+	}
+
+	return claims, nil
+}
+
+func principalOf(account model.Account, sessionID string) model.Principal {
+	return model.Principal{Login: account.Login, Role: account.Role, SessionID: sessionID}
+}
+
+func generateRefreshToken() (string, error) {
+	const refreshTokenBytes = 32
+
+	buf := make([]byte, refreshTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("can't read random bytes: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
@@ -0,0 +1,33 @@
+package application
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gofermart/internal/gophermart/core/model"
+)
+
+func TestValidateTokenRole(t *testing.T) {
+	ctx := context.Background()
+	a := newMemoryApplication(t, LoginProtection{})
+
+	require.NoError(t, a.repo.CreateUser(ctx, "login", "hash"))
+	require.NoError(t, a.SetUserRole(ctx, "root", "login", model.RoleAdmin))
+
+	tokens, err := a.issueTokens(ctx, model.Principal{Login: "login", Role: model.RoleAdmin})
+	require.NoError(t, err)
+
+	principal, err := a.ValidateToken(ctx, tokens.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, model.RoleAdmin, principal.Role)
+
+	// The token still says admin, the account doesn't any more.
+	require.NoError(t, a.SetUserRole(ctx, "root", "login", model.RoleUser))
+
+	principal, err = a.ValidateToken(ctx, tokens.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, model.RoleUser, principal.Role)
+}
@@ -2,7 +2,10 @@ package application
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
+	"strconv"
+	"time"
 
 	"gofermart/internal/gophermart/core/model"
 )
@@ -13,8 +16,151 @@ func (a *Application) UserBalance(ctx context.Context, login string) (model.User
 		return model.UserBalanceResponse{}, fmt.Errorf("can't get user balance: %w", err)
 	}
 
+	expiring, err := a.repo.GetExpiringPoints(ctx, login, time.Now().Add(a.pointsExpiry.NoticePeriod))
+	if err != nil {
+		return model.UserBalanceResponse{}, fmt.Errorf("can't get expiring points: %w", err)
+	}
+
 	return model.UserBalanceResponse{
-		Current:   convertToPounds(balance.Amount),
-		Withdrawn: convertToPounds(balance.Withdraw),
+		Current:      balance.Amount,
+		Held:         balance.Held,
+		Withdrawn:    balance.Withdraw,
+		ExpiringSoon: expiring,
+		Debt:         balance.Debt,
 	}, nil
 }
+
+const (
+	defaultHistoryLimit = 50
+	maxHistoryLimit     = 100
+)
+
+// UserBalanceHistory returns a page of credits and debits of the available
+// balance, oldest first, with the balance after each of them.
+func (a *Application) UserBalanceHistory(ctx context.Context, login string,
+	request model.BalanceHistoryRequest) (model.BalanceHistoryResponse, error) {
+	filter, err := historyFilter(login, request)
+	if err != nil {
+		return model.BalanceHistoryResponse{}, err
+	}
+
+	// One extra entry tells whether there is a next page.
+	filter.Limit++
+
+	history, err := a.repo.GetBalanceHistory(ctx, filter)
+	if err != nil {
+		return model.BalanceHistoryResponse{}, fmt.Errorf("can't get balance history: %w", err)
+	}
+
+	var response model.BalanceHistoryResponse
+	if len(history) == filter.Limit {
+		history = history[:len(history)-1]
+		response.NextCursor = encodeHistoryCursor(history[len(history)-1].ID)
+	}
+
+	response.Items = make([]model.BalanceHistoryItem, 0, len(history))
+	for _, entry := range history {
+		item := model.BalanceHistoryItem{
+			Type:      model.HistoryTypeCredit,
+			Kind:      entry.Kind,
+			Order:     entry.OrderID,
+			Amount:    entry.Amount,
+			Balance:   entry.Balance,
+			CreatedAt: entry.CreatedAt,
+		}
+
+		if entry.Amount < 0 {
+			item.Type = model.HistoryTypeDebit
+			item.Amount = -item.Amount
+		}
+
+		response.Items = append(response.Items, item)
+	}
+
+	return response, nil
+}
+
+func historyFilter(login string, request model.BalanceHistoryRequest) (model.BalanceHistoryFilter, error) {
+	filter := model.BalanceHistoryFilter{
+		Login: login,
+		Type:  request.Type,
+		Limit: request.Limit,
+	}
+
+	var err error
+	if filter.Currency, err = model.ParseCurrency(request.Currency); err != nil {
+		return model.BalanceHistoryFilter{}, fmt.Errorf("currency %q: %w", request.Currency, ErrInvalidFilter)
+	}
+
+	switch request.Type {
+	case "", model.HistoryTypeCredit, model.HistoryTypeDebit:
+	default:
+		return model.BalanceHistoryFilter{}, fmt.Errorf("type %q: %w", request.Type, ErrInvalidFilter)
+	}
+
+	switch {
+	case request.Limit < 0:
+		return model.BalanceHistoryFilter{}, fmt.Errorf("limit %d: %w", request.Limit, ErrInvalidFilter)
+	case request.Limit == 0:
+		filter.Limit = defaultHistoryLimit
+	case request.Limit > maxHistoryLimit:
+		filter.Limit = maxHistoryLimit
+	}
+
+	if filter.From, err = parseHistoryTime(request.From, false); err != nil {
+		return model.BalanceHistoryFilter{}, err
+	}
+
+	if filter.To, err = parseHistoryTime(request.To, true); err != nil {
+		return model.BalanceHistoryFilter{}, err
+	}
+
+	if request.Cursor != "" {
+		if filter.AfterID, err = decodeHistoryCursor(request.Cursor); err != nil {
+			return model.BalanceHistoryFilter{}, err
+		}
+	}
+
+	return filter, nil
+}
+
+// parseHistoryTime accepts an RFC 3339 timestamp or a date. A date used as
+// the exclusive upper bound covers the whole day.
+func parseHistoryTime(value string, upper bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return nil, fmt.Errorf("time %q: %w", value, ErrInvalidFilter)
+	}
+
+	if upper {
+		t = t.AddDate(0, 0, 1)
+	}
+
+	return &t, nil
+}
+
+func encodeHistoryCursor(id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(id, 10)))
+}
+
+func decodeHistoryCursor(cursor string) (int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, fmt.Errorf("cursor %q: %w", cursor, ErrInvalidFilter)
+	}
+
+	id, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil || id < 0 {
+		return 0, fmt.Errorf("cursor %q: %w", cursor, ErrInvalidFilter)
+	}
+
+	return id, nil
+}
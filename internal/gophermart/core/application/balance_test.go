@@ -0,0 +1,58 @@
+package application
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gofermart/internal/gophermart/core/model"
+)
+
+func TestHistoryFilter(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		filter, err := historyFilter("login", model.BalanceHistoryRequest{})
+		require.NoError(t, err)
+
+		assert.Equal(t, model.BalanceHistoryFilter{
+			Login: "login", Currency: model.DefaultCurrency, Limit: defaultHistoryLimit,
+		}, filter)
+	})
+
+	t.Run("dates and cursor", func(t *testing.T) {
+		filter, err := historyFilter("login", model.BalanceHistoryRequest{
+			From:   "2024-03-01",
+			To:     "2024-03-31T12:00:00Z",
+			Type:   model.HistoryTypeDebit,
+			Cursor: encodeHistoryCursor(42),
+			Limit:  1000,
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), *filter.From)
+		assert.Equal(t, time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC), *filter.To)
+		assert.Equal(t, int64(42), filter.AfterID)
+		assert.Equal(t, maxHistoryLimit, filter.Limit)
+	})
+
+	t.Run("date upper bound covers the day", func(t *testing.T) {
+		filter, err := historyFilter("login", model.BalanceHistoryRequest{To: "2024-03-31"})
+		require.NoError(t, err)
+
+		assert.Equal(t, time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), *filter.To)
+	})
+
+	for name, request := range map[string]model.BalanceHistoryRequest{
+		"type":     {Type: "refund"},
+		"limit":    {Limit: -1},
+		"from":     {From: "yesterday"},
+		"cursor":   {Cursor: "!!"},
+		"currency": {Currency: "A-B"},
+	} {
+		t.Run("invalid "+name, func(t *testing.T) {
+			_, err := historyFilter("login", request)
+			require.ErrorIs(t, err, ErrInvalidFilter)
+		})
+	}
+}
@@ -6,10 +6,68 @@ var (
 	ErrUserNotFound  = errors.New("user not found")
 	ErrUserExists    = errors.New("user exists")
 	ErrIncorrectPass = errors.New("incorrect password")
+	ErrWeakPassword  = errors.New("weak password")
+	ErrUserDisabled  = errors.New("user disabled")
+	ErrInvalidRole   = errors.New("invalid role")
+	ErrInvalidTiers  = errors.New("invalid tiers")
+
+	ErrTooManyAttempts = errors.New("too many login attempts")
+	ErrAccountLocked   = errors.New("account locked")
+
+	ErrInvalidToken      = errors.New("invalid token")
+	ErrTokenRevoked      = errors.New("token revoked")
+	ErrRefreshTokenReuse = errors.New("refresh token reuse detected")
+
+	ErrMFARequired        = errors.New("second factor required")
+	ErrInvalidMFACode     = errors.New("invalid second factor code")
+	ErrTOTPRequired       = errors.New("totp code required")
+	ErrTOTPAlreadyEnabled = errors.New("totp already enabled")
+	ErrTOTPNotEnabled     = errors.New("totp not enabled")
+
+	ErrSessionNotFound = errors.New("session not found")
+
+	ErrInvalidIdempotencyKey       = errors.New("invalid idempotency key")
+	ErrIdempotencyKeyReused        = errors.New("idempotency key reused with a different request")
+	ErrIdempotentRequestInProgress = errors.New("request with this idempotency key is in progress")
+
+	ErrInvalidScope   = errors.New("invalid scope")
+	ErrAPIKeyNotFound = errors.New("api key not found")
 
 	ErrOrderAlreadyExists       = errors.New("order already exists")
 	ErrOrderExistsOnAnotherUser = errors.New("order exists on another user")
 	ErrInvalidOrderID           = errors.New("invalid order id")
 	ErrInsufficientFunds        = errors.New("insufficient funds")
+	ErrInvalidAmount            = errors.New("invalid amount")
+	ErrInvalidFilter            = errors.New("invalid filter")
 	ErrNotFound                 = errors.New("not found")
+
+	ErrWithdrawExists  = errors.New("withdrawal for order exists")
+	ErrHoldNotFound    = errors.New("hold not found")
+	ErrHoldNotActive   = errors.New("hold is not active")
+	ErrPartnerNotFound = errors.New("partner not found")
+
+	ErrWithdrawBelowMinimum  = errors.New("withdrawal is below the minimum")
+	ErrWithdrawAboveMaximum  = errors.New("withdrawal is above the maximum")
+	ErrWithdrawLimitExceeded = errors.New("withdrawal limit exceeded")
+	ErrWithdrawCooldown      = errors.New("withdrawals are paused after a security change")
+
+	ErrWithdrawNotFound = errors.New("withdrawal not found")
+	ErrNotRefundable    = errors.New("withdrawal can't be refunded")
+	ErrRefundExceeded   = errors.New("refund exceeds what is left of the withdrawal")
+	ErrRefundConflict   = errors.New("refund id reused with a different refund")
+
+	ErrInvalidPromotion  = errors.New("invalid promotion")
+	ErrPromotionNotFound = errors.New("promotion not found")
+
+	ErrInvalidReferralCode  = errors.New("invalid referral code")
+	ErrSelfReferral         = errors.New("can't register with your own referral code")
+	ErrReferralLimitReached = errors.New("referral code has reached its limit")
+
+	ErrSelfTransfer          = errors.New("can't transfer points to yourself")
+	ErrRecipientNotFound     = errors.New("recipient not found")
+	ErrTransferLimitExceeded = errors.New("daily transfer limit exceeded")
+
+	ErrTransactionNotFound = errors.New("ledger transaction not found")
+	ErrAlreadyReversed     = errors.New("ledger transaction already reversed")
+	ErrNotReversible       = errors.New("ledger transaction can't be reversed")
 )
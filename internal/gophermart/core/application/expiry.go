@@ -0,0 +1,60 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+// PointsExpiry configures how long accrued points stay spendable. Points
+// of an order expire Months after the accrual, and the balance reports
+// those expiring within NoticePeriod. Zero Months keeps points forever.
+type PointsExpiry struct {
+	Months       int
+	NoticePeriod time.Duration
+}
+
+func (e PointsExpiry) withDefaults() PointsExpiry {
+	const defaultNoticePeriod = 30 * 24 * time.Hour
+
+	if e.Months < 0 {
+		e.Months = 0
+	}
+	if e.NoticePeriod <= 0 {
+		e.NoticePeriod = defaultNoticePeriod
+	}
+
+	return e
+}
+
+// expiresAt returns when points accrued at now expire, or nil if they
+// never do.
+func (e PointsExpiry) expiresAt(now time.Time) *time.Time {
+	if e.Months == 0 {
+		return nil
+	}
+
+	expiresAt := now.AddDate(0, e.Months, 0)
+	return &expiresAt
+}
+
+// expirePoints takes expired points off the balances. Lots spent in the
+// meantime are skipped.
+func (a *Application) expirePoints(ctx context.Context) {
+	now := time.Now()
+
+	lots, err := a.repo.GetExpiredLots(ctx, now)
+	if err != nil {
+		a.logger.Errorf("can't get expired points: %v", err)
+		return
+	}
+
+	for _, lot := range lots {
+		err := a.repo.ExpireLot(ctx, lot, now)
+		if err != nil && !errors.Is(err, repositories.ErrInvalidState) {
+			a.logger.Errorf("can't expire points of order %s: %v", lot.OrderID, err)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+// HoldWithdraw reserves points for an order. They leave the available
+// balance at once but are only withdrawn when the hold is captured.
+func (a *Application) HoldWithdraw(ctx context.Context, login string,
+	request model.WithdrawRequest) (model.WithdrawHoldResponse, error) {
+	if request.Sum <= 0 {
+		return model.WithdrawHoldResponse{}, fmt.Errorf("sum %s: %w", request.Sum, ErrInvalidAmount)
+	}
+
+	if !isValidOrderID(request.Order) {
+		return model.WithdrawHoldResponse{}, fmt.Errorf("invalid order id: %w", ErrInvalidOrderID)
+	}
+
+	currency, err := model.ParseCurrency(request.Currency)
+	if err != nil {
+		return model.WithdrawHoldResponse{}, err
+	}
+
+	if err := a.checkPartner(ctx, request.Partner); err != nil {
+		return model.WithdrawHoldResponse{}, err
+	}
+
+	if err := a.checkWithdrawTOTP(ctx, login, request.Sum, request.TOTPCode); err != nil {
+		return model.WithdrawHoldResponse{}, err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(a.holdTTL)
+
+	caps, err := a.checkWithdrawLimits(ctx, login, currency, request.Sum, now)
+	if err != nil {
+		return model.WithdrawHoldResponse{}, err
+	}
+
+	err = a.repo.HoldWithdraw(ctx, login, model.Withdraw{
+		OrderID:   request.Order,
+		Amount:    request.Sum,
+		Currency:  currency,
+		Partner:   request.Partner,
+		ExpiresAt: &expiresAt,
+		Caps:      caps,
+	})
+	if err != nil {
+		return model.WithdrawHoldResponse{}, withdrawError(request.Order, "can't hold withdrawal", err)
+	}
+
+	return model.WithdrawHoldResponse{
+		Order:     request.Order,
+		Status:    model.WithdrawStatusHeld,
+		Currency:  currency,
+		Sum:       request.Sum,
+		CreatedAt: now,
+		ExpiresAt: &expiresAt,
+	}, nil
+}
+
+// CaptureWithdraw withdraws the points of an active hold.
+func (a *Application) CaptureWithdraw(ctx context.Context, login, orderID string) error {
+	if err := a.repo.CaptureWithdraw(ctx, login, orderID, time.Now()); err != nil {
+		return holdError(orderID, "can't capture hold", err)
+	}
+
+	return nil
+}
+
+// ReleaseWithdraw returns the points of an active hold to the available
+// balance.
+func (a *Application) ReleaseWithdraw(ctx context.Context, login, orderID string) error {
+	if err := a.repo.ReleaseWithdraw(ctx, login, orderID, model.WithdrawStatusReleased); err != nil {
+		return holdError(orderID, "can't release hold", err)
+	}
+
+	return nil
+}
+
+func holdError(orderID, message string, err error) error {
+	switch {
+	case errors.Is(err, repositories.ErrNotFound):
+		return fmt.Errorf("order %s: %w", orderID, ErrHoldNotFound)
+	case errors.Is(err, repositories.ErrInvalidState):
+		return fmt.Errorf("order %s: %w", orderID, ErrHoldNotActive)
+	}
+
+	return fmt.Errorf("%s: %w", message, err)
+}
+
+// expireHolds releases the holds nobody captured in time.
+func (a *Application) expireHolds(ctx context.Context) {
+	holds, err := a.repo.GetExpiredHolds(ctx, time.Now())
+	if err != nil {
+		a.logger.Errorf("can't get expired holds: %v", err)
+		return
+	}
+
+	for _, hold := range holds {
+		err := a.repo.ReleaseWithdraw(ctx, hold.Login, hold.OrderID, model.WithdrawStatusExpired)
+		if err != nil && !errors.Is(err, repositories.ErrInvalidState) {
+			a.logger.Errorf("can't expire hold %s: %v", hold.OrderID, err)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+const maxIdempotencyKeyLength = 255
+
+// StartIdempotentRequest claims key for a request of login. A non-nil
+// result is the response to an earlier request with the same key, which
+// the caller replays instead of handling the request again.
+func (a *Application) StartIdempotentRequest(ctx context.Context, login, key,
+	fingerprint string) (*model.IdempotentResponse, error) {
+	if key == "" || len(key) > maxIdempotencyKeyLength {
+		return nil, ErrInvalidIdempotencyKey
+	}
+
+	now := time.Now()
+	err := a.repo.SaveIdempotencyKey(ctx, model.IdempotencyKey{
+		Login:       login,
+		Key:         key,
+		Fingerprint: fingerprint,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(a.idempotencyKeyTTL),
+	})
+	if err == nil {
+		return nil, nil
+	}
+
+	if !errors.Is(err, repositories.ErrDuplicate) {
+		return nil, fmt.Errorf("can't save idempotency key: %w", err)
+	}
+
+	record, err := a.repo.GetIdempotencyKey(ctx, login, key)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			// The first request failed and released the key meanwhile.
+			return nil, ErrIdempotentRequestInProgress
+		}
+
+		return nil, fmt.Errorf("can't get idempotency key: %w", err)
+	}
+
+	if record.Fingerprint != fingerprint {
+		return nil, ErrIdempotencyKeyReused
+	}
+
+	if record.CompletedAt == nil {
+		return nil, ErrIdempotentRequestInProgress
+	}
+
+	return &record.Response, nil
+}
+
+// FinishIdempotentRequest stores the response to replay for key. Server
+// errors release the key instead, so that the client can retry.
+func (a *Application) FinishIdempotentRequest(ctx context.Context, login, key string,
+	response model.IdempotentResponse) error {
+	if response.StatusCode >= http.StatusInternalServerError {
+		if err := a.repo.DeleteIdempotencyKey(ctx, login, key); err != nil {
+			return fmt.Errorf("can't delete idempotency key: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := a.repo.CompleteIdempotencyKey(ctx, login, key, response, time.Now()); err != nil {
+		return fmt.Errorf("can't complete idempotency key: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+// UserLedger lists the ledger entries of a user for the admin API.
+func (a *Application) UserLedger(ctx context.Context, login string) ([]model.LedgerEntryResponse, error) {
+	entries, err := a.repo.GetUserLedger(ctx, login)
+	if err != nil {
+		return nil, fmt.Errorf("can't get user ledger: %w", err)
+	}
+
+	list := make([]model.LedgerEntryResponse, 0, len(entries))
+	for _, entry := range entries {
+		list = append(list, model.LedgerEntryResponse{
+			ID:            entry.ID,
+			TransactionID: entry.TransactionID,
+			Kind:          entry.Kind,
+			Account:       entry.Account,
+			Currency:      model.WalletCurrency(entry.Currency),
+			Order:         entry.OrderID,
+			Reference:     entry.Reference,
+			Description:   entry.Description,
+			Amount:        entry.Amount,
+			CreatedAt:     entry.CreatedAt,
+		})
+	}
+
+	return list, nil
+}
+
+// AdjustBalance credits (positive amount) or debits (negative amount) the
+// available balance of a user against the adjustment account.
+func (a *Application) AdjustBalance(ctx context.Context, actor, login string,
+	request model.AdjustBalanceRequest) (model.LedgerTransactionResponse, error) {
+	if request.Amount == 0 {
+		return model.LedgerTransactionResponse{}, ErrInvalidAmount
+	}
+
+	currency, err := model.ParseCurrency(request.Currency)
+	if err != nil {
+		return model.LedgerTransactionResponse{}, err
+	}
+
+	transaction := model.AdjustmentTransaction(uuid.NewString(), login, request.Amount, request.Reason)
+	transaction.Currency = currency
+	if err := a.repo.AddLedgerTransaction(ctx, transaction); err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrNotFound):
+			return model.LedgerTransactionResponse{}, fmt.Errorf("user %s: %w", login, ErrUserNotFound)
+		case errors.Is(err, repositories.ErrInsufficientFunds):
+			return model.LedgerTransactionResponse{}, ErrInsufficientFunds
+		}
+
+		return model.LedgerTransactionResponse{}, fmt.Errorf("can't adjust balance: %w", err)
+	}
+
+	a.logger.Infof("%s wallet of %s adjusted by %s in %s by %s: %s",
+		currency, login, request.Amount, transaction.ID, actor, request.Reason)
+
+	return model.LedgerTransactionResponse{ID: transaction.ID}, nil
+}
+
+// ReverseTransaction posts the opposite of a ledger transaction. Every
+// transaction can be reversed once, reversals themselves can't be.
+func (a *Application) ReverseTransaction(ctx context.Context, actor, id string,
+	request model.ReverseTransactionRequest) (model.LedgerTransactionResponse, error) {
+	original, err := a.repo.GetLedgerTransaction(ctx, id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return model.LedgerTransactionResponse{}, fmt.Errorf("transaction %s: %w", id, ErrTransactionNotFound)
+		}
+
+		return model.LedgerTransactionResponse{}, fmt.Errorf("can't get ledger transaction: %w", err)
+	}
+
+	if !original.Reversible() {
+		return model.LedgerTransactionResponse{}, fmt.Errorf("transaction %s: %w", id, ErrNotReversible)
+	}
+
+	reversal := original.Reversal(uuid.NewString(), request.Reason)
+	if err := a.repo.AddLedgerTransaction(ctx, reversal); err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrDuplicate):
+			return model.LedgerTransactionResponse{}, fmt.Errorf("transaction %s: %w", id, ErrAlreadyReversed)
+		case errors.Is(err, repositories.ErrInsufficientFunds):
+			return model.LedgerTransactionResponse{}, ErrInsufficientFunds
+		}
+
+		return model.LedgerTransactionResponse{}, fmt.Errorf("can't reverse transaction: %w", err)
+	}
+
+	a.logger.Infof("transaction %s reversed in %s by %s: %s", id, reversal.ID, actor, request.Reason)
+
+	return model.LedgerTransactionResponse{ID: reversal.ID}, nil
+}
@@ -65,7 +65,7 @@ func (a *Application) UserOrders(ctx context.Context, userLogin string) ([]model
 	for _, order := range orders {
 		response = append(response, model.OrderResponse{
 			Number:     order.OrderID,
-			Accrual:    convertToPounds(order.Amount),
+			Accrual:    order.Amount,
 			Status:     order.Status,
 			UploadedAt: order.CreatedAt,
 		})
@@ -0,0 +1,233 @@
+package application
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	HashBcrypt   = "bcrypt"
+	HashArgon2id = "argon2id"
+)
+
+// PasswordPolicy is enforced on new passwords only; existing ones keep
+// working until they are changed.
+type PasswordPolicy struct {
+	Denylist      map[string]struct{}
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+}
+
+type PasswordPolicyError struct {
+	Violations []string
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return "password policy violated: " + strings.Join(e.Violations, ", ")
+}
+
+func (e *PasswordPolicyError) Unwrap() error {
+	return ErrWeakPassword
+}
+
+func (p PasswordPolicy) validate(password string) error {
+	var (
+		violations                              []string
+		hasUpper, hasLower, hasDigit, hasSymbol bool
+	)
+
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+
+	if len([]rune(password)) < p.MinLength {
+		violations = append(violations, fmt.Sprintf("at least %d characters", p.MinLength))
+	}
+	if p.RequireUpper && !hasUpper {
+		violations = append(violations, "an upper-case letter")
+	}
+	if p.RequireLower && !hasLower {
+		violations = append(violations, "a lower-case letter")
+	}
+	if p.RequireDigit && !hasDigit {
+		violations = append(violations, "a digit")
+	}
+	if p.RequireSymbol && !hasSymbol {
+		violations = append(violations, "a symbol")
+	}
+	if _, ok := p.Denylist[strings.ToLower(password)]; ok {
+		violations = append(violations, "not a commonly used password")
+	}
+
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+
+	return nil
+}
+
+// PasswordHashing selects the algorithm for new hashes. Hashes made with
+// another algorithm or weaker parameters are upgraded on the next login.
+type PasswordHashing struct {
+	Algorithm     string
+	BcryptCost    int
+	Argon2Time    uint32
+	Argon2Memory  uint32
+	Argon2Threads uint8
+}
+
+func (h PasswordHashing) withDefaults() PasswordHashing {
+	const (
+		defaultArgon2Time    = 1
+		defaultArgon2Memory  = 64 * 1024
+		defaultArgon2Threads = 4
+	)
+
+	if h.Algorithm == "" {
+		h.Algorithm = HashBcrypt
+	}
+	if h.BcryptCost == 0 {
+		h.BcryptCost = bcrypt.DefaultCost
+	}
+	if h.Argon2Time == 0 {
+		h.Argon2Time = defaultArgon2Time
+	}
+	if h.Argon2Memory == 0 {
+		h.Argon2Memory = defaultArgon2Memory
+	}
+	if h.Argon2Threads == 0 {
+		h.Argon2Threads = defaultArgon2Threads
+	}
+
+	return h
+}
+
+const (
+	argon2SaltLen = 16
+	argon2KeyLen  = 32
+)
+
+func (h PasswordHashing) hash(password string) (string, error) {
+	if h.Algorithm != HashArgon2id {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), h.BcryptCost)
+		if err != nil {
+			return "", fmt.Errorf("can't hash password: %w", err)
+		}
+
+		return string(hash), nil
+	}
+
+	salt := make([]byte, argon2SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("can't read random bytes: %w", err)
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.Argon2Time, h.Argon2Memory, h.Argon2Threads, argon2KeyLen)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version,
+		h.Argon2Memory, h.Argon2Time, h.Argon2Threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// verify reports whether password matches hash, whichever supported
+// algorithm produced it.
+func (h PasswordHashing) verify(hash, password string) (bool, error) {
+	if !strings.HasPrefix(hash, "$argon2id$") {
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("can't compare bcrypt hash: %w", err)
+		}
+
+		return true, nil
+	}
+
+	params, salt, key, err := parseArgon2id(hash)
+	if err != nil {
+		return false, err
+	}
+
+	//nolint:gosec // key length is a small constant stored by us
+	candidate := argon2.IDKey([]byte(password), salt, params.Argon2Time, params.Argon2Memory,
+		params.Argon2Threads, uint32(len(key)))
+
+	return subtle.ConstantTimeCompare(key, candidate) == 1, nil
+}
+
+func (h PasswordHashing) needsRehash(hash string) bool {
+	if !strings.HasPrefix(hash, "$argon2id$") {
+		if h.Algorithm != HashBcrypt {
+			return true
+		}
+
+		cost, err := bcrypt.Cost([]byte(hash))
+		return err != nil || cost < h.BcryptCost
+	}
+
+	if h.Algorithm != HashArgon2id {
+		return true
+	}
+
+	params, _, _, err := parseArgon2id(hash)
+	if err != nil {
+		return true
+	}
+
+	return params.Argon2Time < h.Argon2Time ||
+		params.Argon2Memory < h.Argon2Memory ||
+		params.Argon2Threads < h.Argon2Threads
+}
+
+func parseArgon2id(hash string) (PasswordHashing, []byte, []byte, error) {
+	const argon2Parts = 6
+
+	parts := strings.Split(hash, "$")
+	if len(parts) != argon2Parts {
+		return PasswordHashing{}, nil, nil, errors.New("malformed argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return PasswordHashing{}, nil, nil, errors.New("unsupported argon2id version")
+	}
+
+	params := PasswordHashing{Algorithm: HashArgon2id}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d",
+		&params.Argon2Memory, &params.Argon2Time, &params.Argon2Threads); err != nil {
+		return PasswordHashing{}, nil, nil, fmt.Errorf("malformed argon2id parameters: %w", err)
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return PasswordHashing{}, nil, nil, fmt.Errorf("malformed argon2id salt: %w", err)
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return PasswordHashing{}, nil, nil, fmt.Errorf("malformed argon2id key: %w", err)
+	}
+
+	return params, salt, key, nil
+}
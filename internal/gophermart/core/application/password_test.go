@@ -0,0 +1,90 @@
+package application
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestPasswordHashing(t *testing.T) {
+	bcryptHashing := PasswordHashing{Algorithm: HashBcrypt, BcryptCost: bcrypt.MinCost}.withDefaults()
+	argonHashing := PasswordHashing{Algorithm: HashArgon2id, Argon2Memory: 8 * 1024}.withDefaults()
+
+	for _, hashing := range []PasswordHashing{bcryptHashing, argonHashing} {
+		t.Run(hashing.Algorithm, func(t *testing.T) {
+			hash, err := hashing.hash("secret")
+			require.NoError(t, err)
+
+			ok, err := hashing.verify(hash, "secret")
+			require.NoError(t, err)
+			assert.True(t, ok)
+
+			ok, err = hashing.verify(hash, "other")
+			require.NoError(t, err)
+			assert.False(t, ok)
+
+			assert.False(t, hashing.needsRehash(hash))
+		})
+	}
+
+	t.Run("upgrade bcrypt to argon2id", func(t *testing.T) {
+		hash, err := bcryptHashing.hash("secret")
+		require.NoError(t, err)
+
+		assert.True(t, argonHashing.needsRehash(hash))
+
+		ok, err := argonHashing.verify(hash, "secret")
+		require.NoError(t, err)
+		assert.True(t, ok, "old hashes keep verifying after the algorithm changes")
+	})
+
+	t.Run("upgrade weaker parameters", func(t *testing.T) {
+		hash, err := argonHashing.hash("secret")
+		require.NoError(t, err)
+
+		stronger := argonHashing
+		stronger.Argon2Time++
+		assert.True(t, stronger.needsRehash(hash))
+
+		cheap, err := bcryptHashing.hash("secret")
+		require.NoError(t, err)
+
+		costly := bcryptHashing
+		costly.BcryptCost++
+		assert.True(t, costly.needsRehash(cheap))
+	})
+
+	t.Run("malformed argon2id hash", func(t *testing.T) {
+		_, err := argonHashing.verify("$argon2id$v=19$broken", "secret")
+		require.Error(t, err)
+	})
+}
+
+func TestPasswordPolicy(t *testing.T) {
+	policy := PasswordPolicy{
+		MinLength:     8,
+		RequireUpper:  true,
+		RequireLower:  true,
+		RequireDigit:  true,
+		RequireSymbol: true,
+		Denylist:      map[string]struct{}{"p@ssw0rd!a": {}},
+	}
+
+	require.NoError(t, policy.validate("Gopher-2025"))
+
+	err := policy.validate("short")
+	require.ErrorIs(t, err, ErrWeakPassword)
+
+	var policyErr *PasswordPolicyError
+	require.ErrorAs(t, err, &policyErr)
+	assert.Len(t, policyErr.Violations, 4)
+
+	err = policy.validate("P@ssw0rd!A")
+	require.ErrorIs(t, err, ErrWeakPassword)
+	assert.True(t, strings.Contains(err.Error(), "commonly used"))
+
+	require.NoError(t, PasswordPolicy{}.validate("x"), "the zero policy accepts any non-empty password")
+}
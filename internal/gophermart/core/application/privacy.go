@@ -0,0 +1,105 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+const deletedLoginPrefix = "deleted-"
+
+// ExportUserData collects the personal data of a user. Secrets such as
+// password hashes, key hashes and the TOTP secret are left out.
+func (a *Application) ExportUserData(ctx context.Context, login string) (model.UserExport, error) {
+	profile, err := a.GetAccount(ctx, login)
+	if err != nil {
+		return model.UserExport{}, err
+	}
+
+	balance, err := a.UserBalance(ctx, login)
+	if err != nil {
+		return model.UserExport{}, err
+	}
+
+	orders, err := a.UserOrders(ctx, login)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return model.UserExport{}, err
+	}
+
+	withdrawals, err := a.UserWithdrawals(ctx, login)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return model.UserExport{}, err
+	}
+
+	sessions, err := a.ListSessions(ctx, login, "")
+	if err != nil {
+		return model.UserExport{}, err
+	}
+
+	apiKeys, err := a.ListAPIKeys(ctx, login)
+	if err != nil {
+		return model.UserExport{}, err
+	}
+
+	_, totpEnabled, err := a.enabledTOTP(ctx, login)
+	if err != nil {
+		return model.UserExport{}, err
+	}
+
+	return model.UserExport{
+		ExportedAt:  time.Now(),
+		Profile:     profile,
+		Balance:     balance,
+		Orders:      orders,
+		Withdrawals: withdrawals,
+		Sessions:    sessions,
+		APIKeys:     apiKeys,
+		TOTPEnabled: totpEnabled,
+	}, nil
+}
+
+// DeleteUser erases a user after checking the password. Orders, withdrawals
+// and the balance are kept for accounting under an anonymous login; the
+// credentials, tokens, keys and sessions are removed.
+func (a *Application) DeleteUser(ctx context.Context, login string, request model.DeleteAccountRequest) error {
+	account, err := a.repo.GetUser(ctx, login)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return fmt.Errorf("user not found: %w", ErrUserNotFound)
+		}
+
+		return fmt.Errorf("can't get user: %w", err)
+	}
+
+	ok, err := a.passwordHashing.verify(account.Password, request.Password)
+	if err != nil {
+		return fmt.Errorf("can't verify password: %w", err)
+	}
+
+	if !ok {
+		return fmt.Errorf("invalid password: %w", ErrIncorrectPass)
+	}
+
+	anonymized := deletedLoginPrefix + uuid.NewString()
+	if err := a.repo.DeleteUser(ctx, login, anonymized); err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return fmt.Errorf("user not found: %w", ErrUserNotFound)
+		}
+
+		return fmt.Errorf("can't delete user: %w", err)
+	}
+
+	if err := a.repo.ResetLoginAttempts(ctx, loginAttemptKey(login)); err != nil {
+		return fmt.Errorf("can't reset login attempts: %w", err)
+	}
+
+	a.logger.Infof("user deleted, records kept as %s", anonymized)
+
+	return nil
+}
@@ -0,0 +1,249 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+// accrualFacts is what the conditions of promotions are checked against.
+type accrualFacts struct {
+	tier   string
+	amount model.Money
+	// orders counts the processed orders of the user with this one.
+	orders int
+}
+
+func (a *Application) CreatePromotion(ctx context.Context, actor string,
+	request model.PromotionRequest) (model.PromotionResponse, error) {
+	promotion, err := a.promotionFromRequest(uuid.NewString(), request)
+	if err != nil {
+		return model.PromotionResponse{}, err
+	}
+
+	if err := a.repo.CreatePromotion(ctx, promotion); err != nil {
+		return model.PromotionResponse{}, fmt.Errorf("can't create promotion: %w", err)
+	}
+
+	a.logger.Infof("promotion %s %q created by %s", promotion.ID, promotion.Name, actor)
+
+	return a.Promotion(ctx, promotion.ID)
+}
+
+func (a *Application) UpdatePromotion(ctx context.Context, actor, id string,
+	request model.PromotionRequest) (model.PromotionResponse, error) {
+	promotion, err := a.promotionFromRequest(id, request)
+	if err != nil {
+		return model.PromotionResponse{}, err
+	}
+
+	if err := a.repo.UpdatePromotion(ctx, promotion); err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return model.PromotionResponse{}, fmt.Errorf("promotion %s: %w", id, ErrPromotionNotFound)
+		}
+
+		return model.PromotionResponse{}, fmt.Errorf("can't update promotion: %w", err)
+	}
+
+	a.logger.Infof("promotion %s %q updated by %s", promotion.ID, promotion.Name, actor)
+
+	return a.Promotion(ctx, promotion.ID)
+}
+
+// DeletePromotion stops a promotion. The bonuses it awarded stay in the
+// ledger and in its awards.
+func (a *Application) DeletePromotion(ctx context.Context, actor, id string) error {
+	if err := a.repo.DeletePromotion(ctx, id); err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return fmt.Errorf("promotion %s: %w", id, ErrPromotionNotFound)
+		}
+
+		return fmt.Errorf("can't delete promotion: %w", err)
+	}
+
+	a.logger.Infof("promotion %s deleted by %s", id, actor)
+
+	return nil
+}
+
+func (a *Application) Promotion(ctx context.Context, id string) (model.PromotionResponse, error) {
+	promotion, err := a.repo.GetPromotion(ctx, id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return model.PromotionResponse{}, fmt.Errorf("promotion %s: %w", id, ErrPromotionNotFound)
+		}
+
+		return model.PromotionResponse{}, fmt.Errorf("can't get promotion: %w", err)
+	}
+
+	return promotionResponse(promotion), nil
+}
+
+func (a *Application) Promotions(ctx context.Context) ([]model.PromotionResponse, error) {
+	promotions, err := a.repo.GetPromotions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("can't get promotions: %w", err)
+	}
+
+	result := make([]model.PromotionResponse, 0, len(promotions))
+	for _, promotion := range promotions {
+		result = append(result, promotionResponse(promotion))
+	}
+
+	return result, nil
+}
+
+// PromotionAwards lists the bonuses a promotion awarded, oldest first.
+func (a *Application) PromotionAwards(ctx context.Context, id string) ([]model.PromotionAwardResponse, error) {
+	awards, err := a.repo.GetPromotionAwards(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("can't get promotion awards: %w", err)
+	}
+
+	result := make([]model.PromotionAwardResponse, 0, len(awards))
+	for _, award := range awards {
+		result = append(result, model.PromotionAwardResponse{
+			TransactionID: award.TransactionID,
+			Order:         award.OrderID,
+			Login:         award.Login,
+			Amount:        award.Amount,
+			CreatedAt:     award.CreatedAt,
+		})
+	}
+
+	return result, nil
+}
+
+func (a *Application) promotionFromRequest(id string, request model.PromotionRequest) (model.Promotion, error) {
+	promotion := model.Promotion{
+		ID:         id,
+		Name:       request.Name,
+		Effect:     request.Effect,
+		StartsAt:   request.StartsAt,
+		EndsAt:     request.EndsAt,
+		Conditions: request.Conditions,
+	}
+
+	switch request.Effect {
+	case model.PromotionEffectFixed:
+		if request.Bonus <= 0 || request.Multiplier != 0 {
+			return model.Promotion{}, fmt.Errorf("fixed bonus needs a positive bonus only: %w", ErrInvalidPromotion)
+		}
+		promotion.Bonus = request.Bonus
+	case model.PromotionEffectMultiplier:
+		if request.Multiplier <= model.UnitMultiplier || request.Bonus != 0 {
+			return model.Promotion{}, fmt.Errorf("multiplier must be above 1 and come without bonus: %w",
+				ErrInvalidPromotion)
+		}
+		promotion.Multiplier = request.Multiplier
+	default:
+		return model.Promotion{}, fmt.Errorf("effect %q: %w", request.Effect, ErrInvalidPromotion)
+	}
+
+	if request.EndsAt != nil && !request.EndsAt.After(request.StartsAt) {
+		return model.Promotion{}, fmt.Errorf("promotion ends before it starts: %w", ErrInvalidPromotion)
+	}
+
+	conditions := request.Conditions
+	if conditions.MinAccrual < 0 || conditions.MinOrders < 0 {
+		return model.Promotion{}, fmt.Errorf("negative condition: %w", ErrInvalidPromotion)
+	}
+
+	if conditions.Tier != "" && !slices.ContainsFunc(a.tiers, func(tier Tier) bool {
+		return tier.Name == conditions.Tier
+	}) {
+		return model.Promotion{}, fmt.Errorf("tier %q: %w", conditions.Tier, ErrInvalidPromotion)
+	}
+
+	return promotion, nil
+}
+
+func promotionResponse(promotion model.Promotion) model.PromotionResponse {
+	return model.PromotionResponse{
+		ID:         promotion.ID,
+		Name:       promotion.Name,
+		Effect:     promotion.Effect,
+		StartsAt:   promotion.StartsAt,
+		EndsAt:     promotion.EndsAt,
+		Conditions: promotion.Conditions,
+		Bonus:      promotion.Bonus,
+		Multiplier: promotion.Multiplier,
+		CreatedAt:  promotion.CreatedAt,
+	}
+}
+
+// promotionAwards evaluates the active promotions against an accrual of
+// login and returns the bonuses of those it meets.
+func (a *Application) promotionAwards(ctx context.Context, login string,
+	facts accrualFacts) ([]model.PromotionAward, error) {
+	promotions, err := a.repo.GetActivePromotions(ctx, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("can't get active promotions: %w", err)
+	}
+
+	if len(promotions) == 0 {
+		return nil, nil
+	}
+
+	orders, err := a.repo.GetUserOrders(ctx, login)
+	if err != nil {
+		return nil, fmt.Errorf("can't get orders: %w", err)
+	}
+
+	facts.orders = 1
+	for _, order := range orders {
+		if order.Status == model.OrderStatusDone {
+			facts.orders++
+		}
+	}
+
+	var awards []model.PromotionAward
+	for _, promotion := range promotions {
+		if !promotionApplies(promotion.Conditions, facts) {
+			continue
+		}
+
+		if amount := promotionBonus(promotion, facts.amount); amount > 0 {
+			awards = append(awards, model.PromotionAward{
+				PromotionID: promotion.ID,
+				Name:        promotion.Name,
+				Amount:      amount,
+				FirstOrder:  promotion.Conditions.FirstOrder,
+			})
+		}
+	}
+
+	return awards, nil
+}
+
+func promotionApplies(conditions model.PromotionConditions, facts accrualFacts) bool {
+	switch {
+	case conditions.FirstOrder && facts.orders != 1:
+		return false
+	case facts.orders < conditions.MinOrders:
+		return false
+	case facts.amount < conditions.MinAccrual:
+		return false
+	case conditions.Tier != "" && conditions.Tier != facts.tier:
+		return false
+	}
+
+	return true
+}
+
+// promotionBonus is what promotion awards for an accrual of amount. The
+// multiplier applies to the accrual without other bonuses.
+func promotionBonus(promotion model.Promotion, amount model.Money) model.Money {
+	if promotion.Effect == model.PromotionEffectMultiplier {
+		return promotion.Multiplier.Bonus(amount)
+	}
+
+	return promotion.Bonus
+}
@@ -0,0 +1,136 @@
+package application
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gofermart/internal/gophermart/core/model"
+)
+
+func TestPromotionApplies(t *testing.T) {
+	facts := accrualFacts{tier: "silver", amount: 50000, orders: 3}
+
+	tests := []struct {
+		name       string
+		conditions model.PromotionConditions
+		want       bool
+	}{
+		{name: "no conditions", want: true},
+		{name: "first order", conditions: model.PromotionConditions{FirstOrder: true}},
+		{name: "order count reached", conditions: model.PromotionConditions{MinOrders: 3}, want: true},
+		{name: "order count missed", conditions: model.PromotionConditions{MinOrders: 4}},
+		{name: "accrual reached", conditions: model.PromotionConditions{MinAccrual: 50000}, want: true},
+		{name: "accrual missed", conditions: model.PromotionConditions{MinAccrual: 50001}},
+		{name: "tier", conditions: model.PromotionConditions{Tier: "silver", MinOrders: 2}, want: true},
+		{name: "other tier", conditions: model.PromotionConditions{Tier: "gold"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, promotionApplies(tt.conditions, facts))
+		})
+	}
+
+	assert.True(t, promotionApplies(model.PromotionConditions{FirstOrder: true}, accrualFacts{orders: 1}))
+}
+
+func TestPromotionBonus(t *testing.T) {
+	double := model.Promotion{Effect: model.PromotionEffectMultiplier, Multiplier: 200}
+	assert.Equal(t, model.Money(12345), promotionBonus(double, 12345))
+
+	oneAndHalf := model.Promotion{Effect: model.PromotionEffectMultiplier, Multiplier: 150}
+	assert.Equal(t, model.Money(6172), promotionBonus(oneAndHalf, 12345))
+
+	fixed := model.Promotion{Effect: model.PromotionEffectFixed, Bonus: 10000}
+	assert.Equal(t, model.Money(10000), promotionBonus(fixed, 12345))
+}
+
+func TestPromotionFromRequest(t *testing.T) {
+	tiers, err := NewTiers([]Tier{{Name: "bronze", Multiplier: 100}, {Name: "gold", Threshold: 100, Multiplier: 110}})
+	require.NoError(t, err)
+
+	a := &Application{tiers: tiers}
+	startsAt := time.Date(2025, 6, 7, 0, 0, 0, 0, time.UTC)
+	endsAt := startsAt.Add(48 * time.Hour)
+
+	promotion, err := a.promotionFromRequest("p-1", model.PromotionRequest{
+		Name: "double weekend", Effect: model.PromotionEffectMultiplier, Multiplier: 200,
+		StartsAt: startsAt, EndsAt: &endsAt, Conditions: model.PromotionConditions{Tier: "gold"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, model.Multiplier(200), promotion.Multiplier)
+
+	for name, request := range map[string]model.PromotionRequest{
+		"fixed without bonus":  {Effect: model.PromotionEffectFixed, StartsAt: startsAt},
+		"fixed and multiplier": {Effect: model.PromotionEffectFixed, Bonus: 100, Multiplier: 200, StartsAt: startsAt},
+		"multiplier of one":    {Effect: model.PromotionEffectMultiplier, Multiplier: 100, StartsAt: startsAt},
+		"unknown effect":       {Effect: "discount", StartsAt: startsAt},
+		"ends before start":    {Effect: model.PromotionEffectFixed, Bonus: 100, StartsAt: endsAt, EndsAt: &startsAt},
+		"unknown tier": {Effect: model.PromotionEffectFixed, Bonus: 100, StartsAt: startsAt,
+			Conditions: model.PromotionConditions{Tier: "platinum"}},
+		"negative orders": {Effect: model.PromotionEffectFixed, Bonus: 100, StartsAt: startsAt,
+			Conditions: model.PromotionConditions{MinOrders: -1}},
+	} {
+		_, err := a.promotionFromRequest("p-2", request)
+		assert.ErrorIs(t, err, ErrInvalidPromotion, name)
+	}
+}
+
+// barrierClient accrues every order once all of them were sent, so that
+// they are processed at the same time.
+type barrierClient struct {
+	sent    sync.WaitGroup
+	accrual model.Money
+}
+
+func (c *barrierClient) SendOrder(ctx context.Context, orderID string) (model.ClientResponse, error) {
+	c.sent.Done()
+	c.sent.Wait()
+
+	return model.ClientResponse{OrderID: orderID, Status: model.OrderStatusDone, Accrual: &c.accrual}, nil
+}
+
+func TestFirstOrderPromotionAwardedOnce(t *testing.T) {
+	ctx := context.Background()
+	a := newMemoryApplication(t, LoginProtection{})
+
+	orders := []string{"12345678903", "2377225624"}
+	client := &barrierClient{accrual: 10000}
+	client.sent.Add(len(orders))
+	a.client = client
+
+	promotion, err := a.CreatePromotion(ctx, "admin", model.PromotionRequest{
+		StartsAt: time.Now().Add(-time.Hour), Name: "welcome", Effect: model.PromotionEffectFixed, Bonus: 500,
+		Conditions: model.PromotionConditions{FirstOrder: true},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, a.repo.CreateUser(ctx, "login", "hash"))
+	for _, orderID := range orders {
+		require.NoError(t, a.repo.SaveOrder(ctx, "login", model.OrderRequest{ID: orderID, Status: model.OrderStatusNew}))
+	}
+
+	var wg sync.WaitGroup
+	for _, orderID := range orders {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, a.processOrder(ctx, model.Order{OrderID: orderID}))
+		}()
+	}
+	wg.Wait()
+
+	awards, err := a.repo.GetPromotionAwards(ctx, promotion.ID)
+	require.NoError(t, err)
+	assert.Len(t, awards, 1)
+
+	// Both orders are credited all the same.
+	balance, err := a.repo.GetUserBalance(ctx, "login")
+	require.NoError(t, err)
+	assert.Equal(t, model.Money(20500), balance.Amount)
+}
@@ -0,0 +1,131 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"gofermart/internal/gophermart/core/model"
+)
+
+// Reconcile checks every wallet: what the ledger booked against the orders
+// and withdrawals behind it, and the balance snapshot against the ledger.
+// With fix, the ledger is brought in line with the orders and withdrawals
+// by adjustment entries. The snapshot follows every entry, so a snapshot
+// that drifted from the ledger is only reported.
+func (a *Application) Reconcile(ctx context.Context, fix bool) (model.ReconciliationReport, error) {
+	report := model.ReconciliationReport{StartedAt: time.Now()}
+
+	audits, err := a.repo.GetWalletAudits(ctx)
+	if err != nil {
+		return model.ReconciliationReport{}, fmt.Errorf("can't get wallet audits: %w", err)
+	}
+
+	report.Wallets = len(audits)
+	report.Discrepancies = make([]model.BalanceDiscrepancy, 0)
+	for _, audit := range audits {
+		for _, check := range reconcileChecks(audit) {
+			discrepancy := model.BalanceDiscrepancy{
+				Login:      audit.Login,
+				Currency:   audit.Currency,
+				Check:      check.name,
+				Expected:   check.expected,
+				Actual:     check.actual,
+				Difference: check.expected - check.actual,
+			}
+
+			if fix && check.account != "" {
+				transaction := model.ReconciliationTransaction(uuid.NewString(), audit.Login, check.account,
+					check.credit(discrepancy.Difference), "reconciliation: "+check.name)
+				transaction.Currency = audit.Currency
+
+				if err := a.repo.AddLedgerTransaction(ctx, transaction); err != nil {
+					discrepancy.Error = err.Error()
+				} else {
+					discrepancy.TransactionID = transaction.ID
+					report.Fixed++
+				}
+			}
+
+			report.Discrepancies = append(report.Discrepancies, discrepancy)
+		}
+	}
+
+	report.FinishedAt = time.Now()
+
+	return report, nil
+}
+
+// reconcileCheck compares an expected and an actual amount of a wallet.
+// Discrepancies of checks with an account are fixed by crediting the
+// available balance against it.
+type reconcileCheck struct {
+	name     string
+	account  string
+	expected model.Money
+	actual   model.Money
+	// credit turns the difference into the amount to credit.
+	credit func(difference model.Money) model.Money
+}
+
+// reconcileChecks returns the checks audit fails.
+func reconcileChecks(audit model.WalletAudit) []reconcileCheck {
+	same := func(d model.Money) model.Money { return d }
+	opposite := func(d model.Money) model.Money { return -d }
+
+	checks := []reconcileCheck{
+		// Points the orders credit but the ledger didn't book are owed to
+		// the user.
+		{name: model.ReconcileCheckAccrued, account: model.LedgerAccountAccrual,
+			expected: audit.Accrued, actual: audit.Booked, credit: same},
+		// Withdrawals and holds the ledger missed are taken off the
+		// available balance.
+		{name: model.ReconcileCheckWithdrawn, account: model.LedgerAccountWithdrawn,
+			expected: audit.Withdrawn, actual: audit.Ledger.Withdraw, credit: opposite},
+		{name: model.ReconcileCheckHeld, account: model.LedgerAccountHeld,
+			expected: audit.Held, actual: audit.Ledger.Held, credit: opposite},
+		{name: model.ReconcileCheckSnapshotAvailable, expected: audit.Ledger.Amount, actual: audit.Snapshot.Amount},
+		{name: model.ReconcileCheckSnapshotHeld, expected: audit.Ledger.Held, actual: audit.Snapshot.Held},
+		{name: model.ReconcileCheckSnapshotWithdrawn, expected: audit.Ledger.Withdraw, actual: audit.Snapshot.Withdraw},
+	}
+
+	var failed []reconcileCheck
+	for _, check := range checks {
+		if check.expected != check.actual {
+			failed = append(failed, check)
+		}
+	}
+
+	return failed
+}
+
+// RunReconciliationWorker reconciles all wallets on every tick and logs
+// what doesn't add up. It never fixes anything, that is left to the
+// reconcile command.
+func (a *Application) RunReconciliationWorker(ctx context.Context, tick <-chan time.Time) {
+	for {
+		select {
+		case <-tick:
+			a.reconcile(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (a *Application) reconcile(ctx context.Context) {
+	report, err := a.Reconcile(ctx, false)
+	if err != nil {
+		a.logger.Errorf("can't reconcile balances: %v", err)
+		return
+	}
+
+	for _, d := range report.Discrepancies {
+		a.logger.Warnf("balance discrepancy: login %s, currency %s, check %s, expected %s, actual %s",
+			d.Login, d.Currency, d.Check, d.Expected, d.Actual)
+	}
+
+	a.logger.Infof("reconciled %d wallets, %d discrepancies", report.Wallets, len(report.Discrepancies))
+}
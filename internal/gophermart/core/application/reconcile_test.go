@@ -0,0 +1,43 @@
+package application
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gofermart/internal/gophermart/core/model"
+)
+
+func TestReconcileChecks(t *testing.T) {
+	t.Run("consistent wallet", func(t *testing.T) {
+		balance := model.UserBalance{Amount: 300, Held: 50, Withdraw: 150}
+
+		assert.Empty(t, reconcileChecks(model.WalletAudit{
+			Snapshot: balance, Ledger: balance, Accrued: 500, Booked: 500, Withdrawn: 150, Held: 50,
+		}))
+	})
+
+	t.Run("discrepancies", func(t *testing.T) {
+		checks := reconcileChecks(model.WalletAudit{
+			Snapshot:  model.UserBalance{Amount: 280, Withdraw: 100},
+			Ledger:    model.UserBalance{Amount: 300, Withdraw: 100},
+			Accrued:   400,
+			Booked:    500,
+			Withdrawn: 150,
+		})
+		require.Len(t, checks, 3)
+
+		// Booked more than the orders credit: the surplus is taken back.
+		assert.Equal(t, model.ReconcileCheckAccrued, checks[0].name)
+		assert.Equal(t, model.Money(-100), checks[0].credit(checks[0].expected-checks[0].actual))
+
+		// A withdrawal the ledger missed is taken off the available balance.
+		assert.Equal(t, model.ReconcileCheckWithdrawn, checks[1].name)
+		assert.Equal(t, model.Money(-50), checks[1].credit(checks[1].expected-checks[1].actual))
+
+		// Snapshot drift can't be fixed by entries.
+		assert.Equal(t, model.ReconcileCheckSnapshotAvailable, checks[2].name)
+		assert.Empty(t, checks[2].account)
+	})
+}
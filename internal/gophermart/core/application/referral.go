@@ -0,0 +1,175 @@
+package application
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+const (
+	referralCodeBytes    = 5
+	referralCodeAttempts = 3
+)
+
+// Referrals configures the referral programme. Both bonuses are credited
+// once the first order of a referred user is processed. MaxPerReferrer
+// caps the users that may register with one code, zero means no cap.
+type Referrals struct {
+	ReferrerBonus  model.Money
+	ReferredBonus  model.Money
+	MaxPerReferrer int
+}
+
+// bonus returns what a processed order credits for a referral, nil if the
+// programme credits nothing.
+func (r Referrals) bonus() *model.ReferralBonus {
+	if r.ReferrerBonus == 0 && r.ReferredBonus == 0 {
+		return nil
+	}
+
+	return &model.ReferralBonus{Referrer: r.ReferrerBonus, Referred: r.ReferredBonus}
+}
+
+// UserReferrals returns the referral code of login, which is created on
+// the first call, and the users that registered with it.
+func (a *Application) UserReferrals(ctx context.Context, login string) (model.ReferralsResponse, error) {
+	code, err := a.referralCode(ctx, login)
+	if err != nil {
+		return model.ReferralsResponse{}, err
+	}
+
+	referrals, err := a.repo.GetReferrals(ctx, login)
+	if err != nil {
+		return model.ReferralsResponse{}, fmt.Errorf("can't get referrals: %w", err)
+	}
+
+	response := model.ReferralsResponse{
+		Code:      code,
+		Referrals: make([]model.ReferralResponse, 0, len(referrals)),
+	}
+
+	for _, referral := range referrals {
+		response.Referrals = append(response.Referrals, model.ReferralResponse{
+			CreatedAt:  referral.CreatedAt,
+			RewardedAt: referral.RewardedAt,
+			Login:      referral.Referred,
+			Rewarded:   referral.RewardedAt != nil,
+		})
+	}
+
+	if a.referrals.MaxPerReferrer > 0 {
+		remaining := max(a.referrals.MaxPerReferrer-len(referrals), 0)
+		response.Remaining = &remaining
+	}
+
+	return response, nil
+}
+
+func (a *Application) referralCode(ctx context.Context, login string) (string, error) {
+	for range referralCodeAttempts {
+		code, err := a.repo.GetReferralCode(ctx, login)
+		if err != nil {
+			if errors.Is(err, repositories.ErrNotFound) {
+				return "", fmt.Errorf("user %s: %w", login, ErrUserNotFound)
+			}
+
+			return "", fmt.Errorf("can't get referral code: %w", err)
+		}
+
+		if code != "" {
+			return code, nil
+		}
+
+		code, err = generateReferralCode()
+		if err != nil {
+			return "", err
+		}
+
+		// A code taken by another user is retried with a new one, a code
+		// set concurrently for login is read back on the next attempt.
+		if err := a.repo.SetReferralCode(ctx, login, code); err != nil && !errors.Is(err, repositories.ErrDuplicate) {
+			return "", fmt.Errorf("can't set referral code: %w", err)
+		}
+	}
+
+	return "", fmt.Errorf("can't find a free referral code for %s", login)
+}
+
+// referrer returns the owner of the referral code a user registers with,
+// empty if there is no code. The code is refused if the referrer has a
+// session from the address the user registers from, as that is most
+// likely the same person, or has used up their referrals.
+func (a *Application) referrer(ctx context.Context, code string, client model.ClientInfo) (string, error) {
+	code = normalizeReferralCode(code)
+	if code == "" {
+		return "", nil
+	}
+
+	referrer, err := a.repo.GetReferrer(ctx, code)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return "", ErrInvalidReferralCode
+		}
+
+		return "", fmt.Errorf("can't get referrer: %w", err)
+	}
+
+	if client.IP != "" {
+		sessions, err := a.repo.GetUserSessions(ctx, referrer)
+		if err != nil {
+			return "", fmt.Errorf("can't get sessions: %w", err)
+		}
+
+		for _, session := range sessions {
+			if session.IP == client.IP {
+				return "", ErrSelfReferral
+			}
+		}
+	}
+
+	if a.referrals.MaxPerReferrer > 0 {
+		referrals, err := a.repo.GetReferrals(ctx, referrer)
+		if err != nil {
+			return "", fmt.Errorf("can't get referrals: %w", err)
+		}
+
+		if len(referrals) >= a.referrals.MaxPerReferrer {
+			return "", ErrReferralLimitReached
+		}
+	}
+
+	return referrer, nil
+}
+
+// saveReferral records that login registered with the code of referrer.
+// The user exists by then, so a referral lost to a concurrent registration
+// using up the cap is logged rather than failing the registration.
+func (a *Application) saveReferral(ctx context.Context, referrer, login string) {
+	err := a.repo.CreateReferral(ctx, model.Referral{Referrer: referrer, Referred: login},
+		a.referrals.MaxPerReferrer)
+	if err != nil {
+		a.logger.Errorf("can't save referral of %s by %s: %v", login, referrer, err)
+		return
+	}
+
+	a.logger.Infof("%s registered with the referral code of %s", login, referrer)
+}
+
+func generateReferralCode() (string, error) {
+	buf := make([]byte, referralCodeBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("can't read random bytes: %w", err)
+	}
+
+	return base32.StdEncoding.EncodeToString(buf), nil
+}
+
+func normalizeReferralCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
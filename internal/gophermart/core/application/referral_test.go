@@ -0,0 +1,23 @@
+package application
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gofermart/internal/gophermart/core/model"
+)
+
+func TestReferralCode(t *testing.T) {
+	code, err := generateReferralCode()
+	require.NoError(t, err)
+	assert.Len(t, code, 8)
+	assert.Equal(t, code, normalizeReferralCode(" "+code+" "))
+	assert.Equal(t, "ABCD2345", normalizeReferralCode("abcd2345"))
+}
+
+func TestReferralsBonus(t *testing.T) {
+	assert.Nil(t, Referrals{MaxPerReferrer: 10}.bonus())
+	assert.Equal(t, &model.ReferralBonus{Referrer: 100}, Referrals{ReferrerBonus: 100}.bonus())
+}
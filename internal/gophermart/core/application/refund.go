@@ -0,0 +1,56 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+// RefundWithdraw returns points of a processed withdrawal to its owner.
+// Repeating a refund with the same ID returns the stored refund instead of
+// posting it twice. A partner only finds the withdrawals made at it, admins
+// pass an empty partner.
+func (a *Application) RefundWithdraw(ctx context.Context, actor, partner, orderID string,
+	request model.RefundWithdrawRequest) (model.WithdrawRefundResponse, error) {
+	if request.Amount < 0 {
+		return model.WithdrawRefundResponse{}, fmt.Errorf("amount %s: %w", request.Amount, ErrInvalidAmount)
+	}
+
+	refund, withdraw, err := a.repo.RefundWithdraw(ctx, model.WithdrawRefund{
+		ID:      request.ID,
+		OrderID: orderID,
+		Actor:   actor,
+		Partner: partner,
+		Reason:  request.Reason,
+		Amount:  request.Amount,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrNotFound):
+			return model.WithdrawRefundResponse{}, fmt.Errorf("order %s: %w", orderID, ErrWithdrawNotFound)
+		case errors.Is(err, repositories.ErrInvalidState):
+			return model.WithdrawRefundResponse{}, fmt.Errorf("order %s: %w", orderID, ErrNotRefundable)
+		case errors.Is(err, repositories.ErrInsufficientFunds):
+			return model.WithdrawRefundResponse{}, fmt.Errorf("order %s: %w", orderID, ErrRefundExceeded)
+		case errors.Is(err, repositories.ErrDuplicate):
+			return model.WithdrawRefundResponse{}, fmt.Errorf("refund %s: %w", request.ID, ErrRefundConflict)
+		}
+
+		return model.WithdrawRefundResponse{}, fmt.Errorf("can't refund withdrawal: %w", err)
+	}
+
+	a.logger.Infof("withdrawal %s refunded by %s in %s by %s: %s",
+		orderID, refund.Amount, refund.ID, refund.Actor, refund.Reason)
+
+	return model.WithdrawRefundResponse{
+		ID:        refund.ID,
+		Order:     refund.OrderID,
+		Status:    withdraw.Status,
+		Amount:    refund.Amount,
+		Refunded:  withdraw.Refunded,
+		CreatedAt: refund.CreatedAt,
+	}, nil
+}
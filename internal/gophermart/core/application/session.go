@@ -0,0 +1,146 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+// sessionTouchInterval limits how often the last-seen time of a session is
+// written; it only has to be accurate enough for the session list.
+const sessionTouchInterval = time.Minute
+
+func (a *Application) startSession(ctx context.Context, login string, client model.ClientInfo) (string, error) {
+	now := time.Now()
+	session := model.Session{
+		ID:         uuid.NewString(),
+		Login:      login,
+		UserAgent:  client.UserAgent,
+		IP:         client.IP,
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}
+
+	if err := a.repo.SaveSession(ctx, session); err != nil {
+		return "", fmt.Errorf("can't save session: %w", err)
+	}
+
+	return session.ID, nil
+}
+
+// resumeSession checks the session of a refresh token before it is
+// rotated. Refresh token families from before sessions were recorded get
+// a session on their first refresh.
+func (a *Application) resumeSession(ctx context.Context, token model.RefreshToken) error {
+	session, err := a.repo.GetSession(ctx, token.FamilyID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		now := time.Now()
+		if err := a.repo.SaveSession(ctx, model.Session{
+			ID:         token.FamilyID,
+			Login:      token.Login,
+			CreatedAt:  token.CreatedAt,
+			LastSeenAt: now,
+		}); err != nil {
+			return fmt.Errorf("can't save session: %w", err)
+		}
+
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("can't get session: %w", err)
+	}
+
+	if session.RevokedAt != nil {
+		return fmt.Errorf("session %s: %w", session.ID, ErrTokenRevoked)
+	}
+
+	a.touchSession(ctx, session)
+
+	return nil
+}
+
+// checkSession rejects access tokens whose session was revoked.
+func (a *Application) checkSession(ctx context.Context, claims *tokenClaims) error {
+	if claims.SessionID == "" {
+		return nil
+	}
+
+	session, err := a.repo.GetSession(ctx, claims.SessionID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return fmt.Errorf("session %s: %w", claims.SessionID, ErrInvalidToken)
+		}
+
+		return fmt.Errorf("can't get session: %w", err)
+	}
+
+	if session.Login != claims.Issuer {
+		return fmt.Errorf("session %s of another user: %w", claims.SessionID, ErrInvalidToken)
+	}
+
+	if session.RevokedAt != nil {
+		return fmt.Errorf("session %s: %w", claims.SessionID, ErrTokenRevoked)
+	}
+
+	a.touchSession(ctx, session)
+
+	return nil
+}
+
+func (a *Application) touchSession(ctx context.Context, session model.Session) {
+	now := time.Now()
+	if now.Sub(session.LastSeenAt) < sessionTouchInterval {
+		return
+	}
+
+	if err := a.repo.TouchSession(ctx, session.ID, now); err != nil {
+		a.logger.Errorf("can't update last seen of session %s: %v", session.ID, err)
+	}
+}
+
+// ListSessions returns the active sessions of a user, marking the one the
+// request was made from.
+func (a *Application) ListSessions(ctx context.Context, login, currentID string) ([]model.SessionResponse, error) {
+	sessions, err := a.repo.GetUserSessions(ctx, login)
+	if err != nil {
+		return nil, fmt.Errorf("can't get sessions: %w", err)
+	}
+
+	result := make([]model.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		result = append(result, model.SessionResponse{
+			ID:         session.ID,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			Current:    session.ID == currentID,
+		})
+	}
+
+	return result, nil
+}
+
+// RevokeSession signs a device out: its access tokens are rejected from now
+// on and its refresh tokens can't be used.
+func (a *Application) RevokeSession(ctx context.Context, login, id string) error {
+	if err := a.repo.RevokeSession(ctx, login, id); err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return fmt.Errorf("session %s: %w", id, ErrSessionNotFound)
+		}
+
+		return fmt.Errorf("can't revoke session: %w", err)
+	}
+
+	if err := a.repo.RevokeRefreshTokenFamily(ctx, id); err != nil {
+		return fmt.Errorf("can't revoke refresh token family: %w", err)
+	}
+
+	return nil
+}
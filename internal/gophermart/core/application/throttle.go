@@ -0,0 +1,221 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gofermart/internal/gophermart/core/model"
+)
+
+// LoginProtection configures the failed-login tracker. Failures are
+// counted per login and per client IP inside Window. From DelayAfter
+// failures on each attempt must wait BaseDelay, doubled per further failure
+// up to MaxDelay. From LockAfter failures the account is locked for
+// LockDuration, from IPLockAfter failures the client IP. Zero values fall
+// back to the defaults.
+type LoginProtection struct {
+	Window       time.Duration
+	BaseDelay    time.Duration
+	MaxDelay     time.Duration
+	LockDuration time.Duration
+	DelayAfter   int
+	LockAfter    int
+	IPLockAfter  int
+}
+
+type LoginThrottledError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return fmt.Sprintf("%v, retry after %s", e.Err, e.RetryAfter)
+}
+
+func (e *LoginThrottledError) Unwrap() error {
+	return e.Err
+}
+
+func (p LoginProtection) withDefaults() LoginProtection {
+	const (
+		defaultWindow       = 15 * time.Minute
+		defaultBaseDelay    = time.Second
+		defaultMaxDelay     = time.Minute
+		defaultLockDuration = 15 * time.Minute
+		defaultDelayAfter   = 3
+		defaultLockAfter    = 10
+		defaultIPLockAfter  = 50
+	)
+
+	if p.Window <= 0 {
+		p.Window = defaultWindow
+	}
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = defaultBaseDelay
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = defaultMaxDelay
+	}
+	if p.LockDuration <= 0 {
+		p.LockDuration = defaultLockDuration
+	}
+	if p.DelayAfter <= 0 {
+		p.DelayAfter = defaultDelayAfter
+	}
+	if p.LockAfter <= 0 {
+		p.LockAfter = defaultLockAfter
+	}
+	if p.IPLockAfter <= 0 {
+		p.IPLockAfter = defaultIPLockAfter
+	}
+
+	return p
+}
+
+// delay is how long a client has to wait after the given number of
+// failures before the next attempt is evaluated.
+func (p LoginProtection) delay(failures int) time.Duration {
+	if failures < p.DelayAfter {
+		return 0
+	}
+
+	delay := p.BaseDelay
+	for i := p.DelayAfter; i < failures && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, p.MaxDelay)
+}
+
+func loginAttemptKey(login string) string {
+	return "login:" + login
+}
+
+func ipAttemptKey(ip string) string {
+	return "ip:" + ip
+}
+
+// attemptKey is a key failures are counted against and what it counted
+// once an attempt was reserved.
+type attemptKey struct {
+	lastFailureAt time.Time
+	key           string
+	lockAfter     int
+	failures      int
+}
+
+// loginAttempt is an attempt counted as failed until it succeeds.
+type loginAttempt struct {
+	login string
+	keys  []attemptKey
+}
+
+// attemptKeys are the keys attempts of login from client count against.
+// Step-up checks come without a client IP and count against the login only.
+func (a *Application) attemptKeys(login string, client model.ClientInfo) []attemptKey {
+	keys := []attemptKey{{key: loginAttemptKey(login), lockAfter: a.loginProtection.LockAfter}}
+	if client.IP != "" {
+		keys = append(keys, attemptKey{key: ipAttemptKey(client.IP), lockAfter: a.loginProtection.IPLockAfter})
+	}
+
+	return keys
+}
+
+// reserveLoginAttempt runs before the credentials are verified, so
+// throttled clients never reach bcrypt. The attempt is counted as failed
+// right away: attempts made in parallel pass the check on the same
+// failures, but counting them one after another only lets the first one
+// through while the others have to wait out the delay.
+func (a *Application) reserveLoginAttempt(ctx context.Context, login string,
+	client model.ClientInfo) (loginAttempt, error) {
+	now := time.Now()
+	keys := a.attemptKeys(login, client)
+
+	seen := make([]int, len(keys))
+	for i, key := range keys {
+		attempts, err := a.repo.GetLoginAttempts(ctx, key.key)
+		if err != nil {
+			return loginAttempt{}, fmt.Errorf("can't get login attempts: %w", err)
+		}
+
+		if attempts.LockedUntil != nil && now.Before(*attempts.LockedUntil) {
+			return loginAttempt{}, &LoginThrottledError{Err: ErrAccountLocked, RetryAfter: attempts.LockedUntil.Sub(now)}
+		}
+
+		if now.Sub(attempts.LastFailureAt) > a.loginProtection.Window {
+			continue
+		}
+
+		retryAt := attempts.LastFailureAt.Add(a.loginProtection.delay(attempts.Failures))
+		if now.Before(retryAt) {
+			return loginAttempt{}, &LoginThrottledError{Err: ErrTooManyAttempts, RetryAfter: retryAt.Sub(now)}
+		}
+
+		seen[i] = attempts.Failures
+	}
+
+	for i := range keys {
+		attempts, err := a.repo.RecordLoginFailure(ctx, keys[i].key, a.loginProtection.Window)
+		if err != nil {
+			return loginAttempt{}, fmt.Errorf("can't record login failure: %w", err)
+		}
+
+		keys[i].failures = attempts.Failures
+		keys[i].lastFailureAt = attempts.LastFailureAt
+
+		// Another attempt was counted since the check and took the turn.
+		if delay := a.loginProtection.delay(attempts.Failures - 1); attempts.Failures > seen[i]+1 && delay > 0 {
+			return loginAttempt{}, &LoginThrottledError{Err: ErrTooManyAttempts, RetryAfter: delay}
+		}
+	}
+
+	return loginAttempt{login: login, keys: keys}, nil
+}
+
+// failLoginAttempt locks the keys the failed attempt brought to their
+// limit.
+func (a *Application) failLoginAttempt(ctx context.Context, attempt loginAttempt) error {
+	for _, key := range attempt.keys {
+		if key.failures < key.lockAfter {
+			continue
+		}
+
+		if err := a.repo.LockLogin(ctx, key.key, key.lastFailureAt.Add(a.loginProtection.LockDuration)); err != nil {
+			return fmt.Errorf("can't lock login: %w", err)
+		}
+
+		a.logger.Warnf("%s locked after %d failed attempts", key.key, key.failures)
+	}
+
+	return nil
+}
+
+// succeedLoginAttempt clears the failures of the login and takes the
+// attempt back from the client IP.
+func (a *Application) succeedLoginAttempt(ctx context.Context, attempt loginAttempt) error {
+	for _, key := range attempt.keys {
+		if key.key == loginAttemptKey(attempt.login) {
+			if err := a.repo.ResetLoginAttempts(ctx, key.key); err != nil {
+				return fmt.Errorf("can't reset login attempts: %w", err)
+			}
+
+			continue
+		}
+
+		if err := a.repo.ReleaseLoginAttempt(ctx, key.key); err != nil {
+			return fmt.Errorf("can't release login attempt: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// UnlockUser clears the failed attempts and the lockout of an account.
+func (a *Application) UnlockUser(ctx context.Context, login string) error {
+	if err := a.repo.ResetLoginAttempts(ctx, loginAttemptKey(login)); err != nil {
+		return fmt.Errorf("can't reset login attempts: %w", err)
+	}
+
+	return nil
+}
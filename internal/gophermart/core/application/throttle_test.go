@@ -0,0 +1,82 @@
+package application
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gofermart/internal/gophermart/core/model"
+)
+
+func TestReserveLoginAttempt(t *testing.T) {
+	ctx := context.Background()
+	client := model.ClientInfo{IP: "203.0.113.7"}
+
+	t.Run("parallel attempts", func(t *testing.T) {
+		a := newMemoryApplication(t, LoginProtection{DelayAfter: 1, BaseDelay: time.Hour})
+
+		const attempts = 10
+
+		var wg sync.WaitGroup
+		errs := make([]error, attempts)
+		for i := range attempts {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, errs[i] = a.reserveLoginAttempt(ctx, "login", client)
+			}()
+		}
+		wg.Wait()
+
+		var passed int
+		for _, err := range errs {
+			if err == nil {
+				passed++
+				continue
+			}
+
+			assert.ErrorIs(t, err, ErrTooManyAttempts)
+		}
+		assert.Equal(t, 1, passed, "only one attempt gets past the delay")
+	})
+
+	t.Run("ip lock", func(t *testing.T) {
+		a := newMemoryApplication(t, LoginProtection{DelayAfter: 100, LockAfter: 100, IPLockAfter: 3})
+
+		for _, login := range []string{"alice", "bob", "carol"} {
+			attempt, err := a.reserveLoginAttempt(ctx, login, client)
+			require.NoError(t, err)
+			require.NoError(t, a.failLoginAttempt(ctx, attempt))
+		}
+
+		_, err := a.reserveLoginAttempt(ctx, "dave", client)
+		require.ErrorIs(t, err, ErrAccountLocked)
+
+		// Other clients may still log in.
+		_, err = a.reserveLoginAttempt(ctx, "dave", model.ClientInfo{IP: "198.51.100.1"})
+		require.NoError(t, err)
+	})
+
+	t.Run("successful attempts", func(t *testing.T) {
+		a := newMemoryApplication(t, LoginProtection{DelayAfter: 100, LockAfter: 100, IPLockAfter: 2})
+
+		for _, login := range []string{"alice", "bob", "carol"} {
+			attempt, err := a.reserveLoginAttempt(ctx, login, client)
+			require.NoError(t, err)
+			require.NoError(t, a.succeedLoginAttempt(ctx, attempt))
+		}
+
+		attempts, err := a.repo.GetLoginAttempts(ctx, ipAttemptKey(client.IP))
+		require.NoError(t, err)
+		assert.Zero(t, attempts.Failures)
+		assert.Nil(t, attempts.LockedUntil)
+
+		attempts, err = a.repo.GetLoginAttempts(ctx, loginAttemptKey("alice"))
+		require.NoError(t, err)
+		assert.Zero(t, attempts.Failures)
+	})
+}
@@ -0,0 +1,179 @@
+package application
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"gofermart/internal/gophermart/core/model"
+)
+
+// tierWindowMonths is how far back accruals count towards a tier.
+const tierWindowMonths = 12
+
+// Tier is a loyalty level. Users whose accruals over the last twelve months
+// reach Threshold get Multiplier applied to their new accruals.
+type Tier struct {
+	Name       string
+	Threshold  model.Money
+	Multiplier model.Multiplier
+}
+
+// Tiers is a ladder of tiers by ascending threshold. The first tier starts
+// at zero and is where users without a known tier are.
+type Tiers []Tier
+
+// NewTiers validates tiers and sorts them by threshold. Without tiers all
+// users share a single tier that leaves accruals as they are.
+func NewTiers(tiers []Tier) (Tiers, error) {
+	if len(tiers) == 0 {
+		return Tiers{{Name: "standard", Multiplier: model.UnitMultiplier}}, nil
+	}
+
+	sorted := slices.Clone(tiers)
+	slices.SortStableFunc(sorted, func(a, b Tier) int {
+		return cmp.Compare(a.Threshold, b.Threshold)
+	})
+
+	if sorted[0].Threshold != 0 {
+		return nil, fmt.Errorf("lowest tier %q must start at 0: %w", sorted[0].Name, ErrInvalidTiers)
+	}
+
+	names := make(map[string]struct{}, len(sorted))
+	for i, tier := range sorted {
+		if tier.Name == "" {
+			return nil, fmt.Errorf("tier without name: %w", ErrInvalidTiers)
+		}
+
+		if _, ok := names[tier.Name]; ok {
+			return nil, fmt.Errorf("tier %q is defined twice: %w", tier.Name, ErrInvalidTiers)
+		}
+		names[tier.Name] = struct{}{}
+
+		if tier.Multiplier < model.UnitMultiplier {
+			return nil, fmt.Errorf("multiplier of tier %q is below 1: %w", tier.Name, ErrInvalidTiers)
+		}
+
+		if i > 0 && tier.Threshold == sorted[i-1].Threshold {
+			return nil, fmt.Errorf("tiers %q and %q share a threshold: %w",
+				sorted[i-1].Name, tier.Name, ErrInvalidTiers)
+		}
+	}
+
+	return sorted, nil
+}
+
+// byName returns the tier called name, falling back to the lowest one for
+// users not placed yet or placed in a tier that was removed since.
+func (t Tiers) byName(name string) (Tier, int) {
+	i := slices.IndexFunc(t, func(tier Tier) bool {
+		return tier.Name == name
+	})
+	if i < 0 {
+		return t[0], 0
+	}
+
+	return t[i], i
+}
+
+// forAccrued returns the highest tier accrued reaches.
+func (t Tiers) forAccrued(accrued model.Money) Tier {
+	tier := t[0]
+	for _, next := range t[1:] {
+		if accrued < next.Threshold {
+			break
+		}
+		tier = next
+	}
+
+	return tier
+}
+
+// bonus is what the multiplier adds to amount.
+func (t Tier) bonus(amount model.Money) model.Money {
+	return t.Multiplier.Bonus(amount)
+}
+
+// UserTier returns the tier of the user and how far they are from the next
+// one.
+func (a *Application) UserTier(ctx context.Context, login string) (model.TierResponse, error) {
+	userTier, err := a.repo.GetUserTier(ctx, login, time.Now().AddDate(0, -tierWindowMonths, 0))
+	if err != nil {
+		return model.TierResponse{}, fmt.Errorf("can't get user tier: %w", err)
+	}
+
+	tier, i := a.tiers.byName(userTier.Tier)
+
+	response := model.TierResponse{
+		Tier:       tier.Name,
+		Accrued:    userTier.Accrued,
+		Multiplier: tier.Multiplier,
+	}
+
+	if i+1 < len(a.tiers) {
+		next := a.tiers[i+1]
+		response.Next = &model.TierProgress{
+			Tier:      next.Name,
+			Threshold: next.Threshold,
+			Remaining: max(next.Threshold-userTier.Accrued, 0),
+		}
+	}
+
+	return response, nil
+}
+
+// RunTierWorker recomputes the tiers of all users on every tick.
+func (a *Application) RunTierWorker(ctx context.Context, tick <-chan time.Time) {
+	for {
+		select {
+		case <-tick:
+			a.recomputeTiers(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// recomputeTiers moves users up or down to the tier their accruals over the
+// last twelve months reach.
+func (a *Application) recomputeTiers(ctx context.Context) {
+	userTiers, err := a.repo.GetUserTiers(ctx, time.Now().AddDate(0, -tierWindowMonths, 0))
+	if err != nil {
+		a.logger.Errorf("can't get user tiers: %v", err)
+		return
+	}
+
+	for _, userTier := range userTiers {
+		tier := a.tiers.forAccrued(userTier.Accrued)
+		if tier.Name == userTier.Tier {
+			continue
+		}
+
+		if err := a.repo.SetUserTier(ctx, userTier.Login, tier.Name); err != nil {
+			a.logger.Errorf("can't set tier of %s: %v", userTier.Login, err)
+			continue
+		}
+
+		a.logger.Infof("user %s moved from tier %q to %q", userTier.Login, userTier.Tier, tier.Name)
+	}
+}
+
+// accrualTier returns the owner of the order and the tier new accruals of
+// the order are multiplied by.
+func (a *Application) accrualTier(ctx context.Context, orderID string) (string, Tier, error) {
+	login, err := a.repo.GetOrderLogin(ctx, orderID)
+	if err != nil {
+		return "", Tier{}, fmt.Errorf("can't get order login: %w", err)
+	}
+
+	userTier, err := a.repo.GetUserTier(ctx, login, time.Now().AddDate(0, -tierWindowMonths, 0))
+	if err != nil {
+		return "", Tier{}, fmt.Errorf("can't get user tier: %w", err)
+	}
+
+	tier, _ := a.tiers.byName(userTier.Tier)
+
+	return login, tier, nil
+}
@@ -0,0 +1,67 @@
+package application
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gofermart/internal/gophermart/core/model"
+)
+
+func TestTiers(t *testing.T) {
+	tiers, err := NewTiers([]Tier{
+		{Name: "gold", Threshold: 500000, Multiplier: 110},
+		{Name: "bronze", Multiplier: 100},
+		{Name: "silver", Threshold: 100000, Multiplier: 105},
+	})
+	require.NoError(t, err)
+
+	t.Run("sorted by threshold", func(t *testing.T) {
+		assert.Equal(t, []string{"bronze", "silver", "gold"},
+			[]string{tiers[0].Name, tiers[1].Name, tiers[2].Name})
+	})
+
+	t.Run("tier for accruals", func(t *testing.T) {
+		assert.Equal(t, "bronze", tiers.forAccrued(0).Name)
+		assert.Equal(t, "bronze", tiers.forAccrued(99999).Name)
+		assert.Equal(t, "silver", tiers.forAccrued(100000).Name)
+		assert.Equal(t, "gold", tiers.forAccrued(700000).Name)
+	})
+
+	t.Run("unknown tier falls back to the lowest", func(t *testing.T) {
+		tier, i := tiers.byName("platinum")
+		assert.Equal(t, "bronze", tier.Name)
+		assert.Equal(t, 0, i)
+
+		tier, i = tiers.byName("gold")
+		assert.Equal(t, "gold", tier.Name)
+		assert.Equal(t, 2, i)
+	})
+
+	t.Run("bonus is rounded down", func(t *testing.T) {
+		assert.Equal(t, model.Money(0), tiers[0].bonus(12345))
+		assert.Equal(t, model.Money(617), tiers[1].bonus(12345))
+		assert.Equal(t, model.Money(1234), tiers[2].bonus(12345))
+	})
+
+	t.Run("default tier", func(t *testing.T) {
+		tiers, err := NewTiers(nil)
+		require.NoError(t, err)
+		require.Len(t, tiers, 1)
+		assert.Equal(t, model.Money(0), tiers[0].bonus(12345))
+	})
+
+	t.Run("invalid tiers", func(t *testing.T) {
+		for name, tiers := range map[string][]Tier{
+			"lowest above zero":  {{Name: "bronze", Threshold: 100, Multiplier: 100}},
+			"duplicate name":     {{Name: "bronze", Multiplier: 100}, {Name: "bronze", Threshold: 100, Multiplier: 105}},
+			"shared threshold":   {{Name: "bronze", Multiplier: 100}, {Name: "silver", Multiplier: 105}},
+			"multiplier below 1": {{Name: "bronze", Multiplier: 90}},
+			"no name":            {{Multiplier: 100}},
+		} {
+			_, err := NewTiers(tiers)
+			assert.ErrorIs(t, err, ErrInvalidTiers, name)
+		}
+	})
+}
@@ -0,0 +1,353 @@
+package application
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+	"gofermart/internal/gophermart/core/totp"
+)
+
+const (
+	purposeMFA = "mfa"
+
+	recoveryCodeCount = 10
+	recoveryCodeBytes = 5
+)
+
+// TOTPSettings configures two-factor authentication. Withdrawals of users
+// with TOTP enabled need a code once they exceed WithdrawThreshold.
+type TOTPSettings struct {
+	Issuer            string
+	MFATokenTTL       time.Duration
+	WithdrawThreshold model.Money
+}
+
+func (s TOTPSettings) withDefaults() TOTPSettings {
+	const defaultMFATokenTTL = 5 * time.Minute
+
+	if s.Issuer == "" {
+		s.Issuer = "Gophermart"
+	}
+	if s.MFATokenTTL <= 0 {
+		s.MFATokenTTL = defaultMFATokenTTL
+	}
+
+	return s
+}
+
+// MFARequiredError is returned by UserLogin when the password was right but
+// the user has to finish the login with a second factor.
+type MFARequiredError struct {
+	Token string
+}
+
+func (e *MFARequiredError) Error() string {
+	return ErrMFARequired.Error()
+}
+
+func (e *MFARequiredError) Unwrap() error {
+	return ErrMFARequired
+}
+
+// EnrollTOTP starts the enrolment with a new secret. The second factor is
+// enforced only after ConfirmTOTP; until then enrolment can be restarted.
+func (a *Application) EnrollTOTP(ctx context.Context, login string) (model.TOTPEnrollResponse, error) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return model.TOTPEnrollResponse{}, fmt.Errorf("can't generate totp secret: %w", err)
+	}
+
+	if err := a.repo.SaveTOTP(ctx, model.TOTP{
+		Login:     login,
+		Secret:    secret,
+		CreatedAt: time.Now(),
+	}); err != nil {
+		if errors.Is(err, repositories.ErrDuplicate) {
+			return model.TOTPEnrollResponse{}, ErrTOTPAlreadyEnabled
+		}
+
+		return model.TOTPEnrollResponse{}, fmt.Errorf("can't save totp: %w", err)
+	}
+
+	return model.TOTPEnrollResponse{
+		Secret: secret,
+		URI:    a.totp.URI(a.totpSettings.Issuer, login, secret),
+	}, nil
+}
+
+// ConfirmTOTP enables the second factor once the user proves the app works
+// and returns the recovery codes. They are shown only this once.
+func (a *Application) ConfirmTOTP(ctx context.Context, login, code string) (model.RecoveryCodesResponse, error) {
+	stored, err := a.repo.GetTOTP(ctx, login)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return model.RecoveryCodesResponse{}, ErrTOTPNotEnabled
+		}
+
+		return model.RecoveryCodesResponse{}, fmt.Errorf("can't get totp: %w", err)
+	}
+
+	if stored.ConfirmedAt != nil {
+		return model.RecoveryCodesResponse{}, ErrTOTPAlreadyEnabled
+	}
+
+	step, ok, err := a.totp.Validate(stored.Secret, code, stored.LastStep)
+	if err != nil {
+		return model.RecoveryCodesResponse{}, fmt.Errorf("can't validate totp code: %w", err)
+	}
+
+	if !ok {
+		return model.RecoveryCodesResponse{}, ErrInvalidMFACode
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for range recoveryCodeCount {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return model.RecoveryCodesResponse{}, err
+		}
+
+		codes = append(codes, code)
+		hashes = append(hashes, hashToken(normalizeRecoveryCode(code)))
+	}
+
+	if err := a.repo.ConfirmTOTP(ctx, login, step, hashes); err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return model.RecoveryCodesResponse{}, ErrTOTPAlreadyEnabled
+		}
+
+		return model.RecoveryCodesResponse{}, fmt.Errorf("can't confirm totp: %w", err)
+	}
+
+	return model.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// DisableTOTP turns the second factor off. It takes a code so that a
+// stolen session alone can't do it, and wrong codes are throttled like
+// login codes.
+func (a *Application) DisableTOTP(ctx context.Context, login, code string) error {
+	stored, enabled, err := a.enabledTOTP(ctx, login)
+	if err != nil {
+		return err
+	}
+
+	if !enabled {
+		return ErrTOTPNotEnabled
+	}
+
+	if err := a.checkSecondFactor(ctx, stored, code, model.ClientInfo{}); err != nil {
+		return err
+	}
+
+	if err := a.repo.DeleteTOTP(ctx, login); err != nil {
+		return fmt.Errorf("can't delete totp: %w", err)
+	}
+
+	return nil
+}
+
+// CompleteMFALogin is the second step of a login for users with TOTP.
+// Wrong codes count as failed logins, so guessing is throttled the same
+// way as guessing passwords.
+func (a *Application) CompleteMFALogin(
+	ctx context.Context,
+	request model.MFALoginRequest,
+	client model.ClientInfo,
+) (model.AuthTokens, error) {
+	claims, err := a.parseToken(request.MFAToken)
+	if err != nil {
+		return model.AuthTokens{}, err
+	}
+
+	if claims.Purpose != purposeMFA {
+		return model.AuthTokens{}, fmt.Errorf("not an mfa token: %w", ErrInvalidToken)
+	}
+
+	login := claims.Issuer
+	account, err := a.repo.GetUser(ctx, login)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return model.AuthTokens{}, fmt.Errorf("user not found: %w", ErrInvalidToken)
+		}
+
+		return model.AuthTokens{}, fmt.Errorf("can't get user: %w", err)
+	}
+
+	if account.Disabled {
+		return model.AuthTokens{}, fmt.Errorf("login of %s rejected: %w", login, ErrUserDisabled)
+	}
+
+	stored, enabled, err := a.enabledTOTP(ctx, login)
+	if err != nil {
+		return model.AuthTokens{}, err
+	}
+
+	if !enabled {
+		return model.AuthTokens{}, fmt.Errorf("totp of %s was disabled: %w", login, ErrInvalidToken)
+	}
+
+	if err := a.checkSecondFactor(ctx, stored, request.Code, client); err != nil {
+		return model.AuthTokens{}, err
+	}
+
+	sessionID, err := a.startSession(ctx, login, client)
+	if err != nil {
+		return model.AuthTokens{}, err
+	}
+
+	tokens, err := a.issueTokens(ctx, principalOf(account, sessionID))
+	if err != nil {
+		return model.AuthTokens{}, fmt.Errorf("can't issue tokens: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// requireMFA returns the error that ends the first login step if the user
+// has TOTP enabled, nil otherwise.
+func (a *Application) requireMFA(ctx context.Context, login string) error {
+	_, enabled, err := a.enabledTOTP(ctx, login)
+	if err != nil {
+		return err
+	}
+
+	if !enabled {
+		return nil
+	}
+
+	now := time.Now()
+	token, err := a.keys.Sign(&tokenClaims{
+		Purpose: purposeMFA,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Issuer:    login,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(a.totpSettings.MFATokenTTL)),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("can't sign mfa token: %w", err)
+	}
+
+	return &MFARequiredError{Token: token}
+}
+
+// checkWithdrawTOTP asks for a fresh code before points above the threshold
+// leave the account, by a withdrawal or a transfer, if the user has TOTP
+// enabled. Wrong codes are throttled like login codes, so a stolen session
+// can't be used to guess them.
+func (a *Application) checkWithdrawTOTP(ctx context.Context, login string, amount model.Money, code string) error {
+	if amount <= a.totpSettings.WithdrawThreshold {
+		return nil
+	}
+
+	stored, enabled, err := a.enabledTOTP(ctx, login)
+	if err != nil {
+		return err
+	}
+
+	if !enabled {
+		return nil
+	}
+
+	if code == "" {
+		return ErrTOTPRequired
+	}
+
+	return a.checkSecondFactor(ctx, stored, code, model.ClientInfo{})
+}
+
+func (a *Application) enabledTOTP(ctx context.Context, login string) (model.TOTP, bool, error) {
+	stored, err := a.repo.GetTOTP(ctx, login)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return model.TOTP{}, false, nil
+		}
+
+		return model.TOTP{}, false, fmt.Errorf("can't get totp: %w", err)
+	}
+
+	return stored, stored.ConfirmedAt != nil, nil
+}
+
+// checkSecondFactor verifies code as an attempt to log in as the owner of
+// stored: wrong codes count as failed logins and a locked login takes no
+// codes at all.
+func (a *Application) checkSecondFactor(ctx context.Context, stored model.TOTP, code string,
+	client model.ClientInfo) error {
+	attempt, err := a.reserveLoginAttempt(ctx, stored.Login, client)
+	if err != nil {
+		return err
+	}
+
+	if err := a.verifySecondFactor(ctx, stored, code); err != nil {
+		if errors.Is(err, ErrInvalidMFACode) {
+			if err := a.failLoginAttempt(ctx, attempt); err != nil {
+				return err
+			}
+		}
+
+		return err
+	}
+
+	return a.succeedLoginAttempt(ctx, attempt)
+}
+
+// verifySecondFactor accepts a TOTP code newer than the last accepted one
+// or an unused recovery code.
+func (a *Application) verifySecondFactor(ctx context.Context, stored model.TOTP, code string) error {
+	step, ok, err := a.totp.Validate(stored.Secret, code, stored.LastStep)
+	if err != nil {
+		return fmt.Errorf("can't validate totp code: %w", err)
+	}
+
+	if ok {
+		if err := a.repo.UseTOTPStep(ctx, stored.Login, step); err != nil {
+			if errors.Is(err, repositories.ErrAlreadyUsed) {
+				return ErrInvalidMFACode
+			}
+
+			return fmt.Errorf("can't use totp step: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := a.repo.UseRecoveryCode(ctx, stored.Login, hashToken(normalizeRecoveryCode(code))); err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return ErrInvalidMFACode
+		}
+
+		return fmt.Errorf("can't use recovery code: %w", err)
+	}
+
+	a.logger.Infof("recovery code used by %s", stored.Login)
+
+	return nil
+}
+
+func generateRecoveryCode() (string, error) {
+	buf := make([]byte, recoveryCodeBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("can't read random bytes: %w", err)
+	}
+
+	code := strings.ToLower(base32.StdEncoding.EncodeToString(buf))
+
+	return code[:4] + "-" + code[4:], nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
@@ -0,0 +1,78 @@
+package application
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// enableTOTP turns TOTP on for login and returns a code that is wrong for
+// the current step.
+func enableTOTP(t *testing.T, a *Application, login string) string {
+	t.Helper()
+	ctx := context.Background()
+
+	enrolled, err := a.EnrollTOTP(ctx, login)
+	require.NoError(t, err)
+
+	code, err := a.totp.Code(enrolled.Secret, a.totp.Step(time.Now()))
+	require.NoError(t, err)
+
+	_, err = a.ConfirmTOTP(ctx, login, code)
+	require.NoError(t, err)
+
+	wrong := []byte(code)
+	wrong[len(wrong)-1] = '0' + (wrong[len(wrong)-1]-'0'+5)%10
+
+	return string(wrong)
+}
+
+func TestSecondFactorLockout(t *testing.T) {
+	ctx := context.Background()
+	protection := LoginProtection{DelayAfter: 100, LockAfter: 3}
+
+	tests := []struct {
+		name  string
+		check func(a *Application, code string) error
+	}{
+		{
+			name: "withdraw",
+			check: func(a *Application, code string) error {
+				return a.checkWithdrawTOTP(ctx, "login", 1000, code)
+			},
+		},
+		{
+			name: "disable totp",
+			check: func(a *Application, code string) error {
+				return a.DisableTOTP(ctx, "login", code)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newMemoryApplication(t, protection)
+			wrong := enableTOTP(t, a, "login")
+
+			for range protection.LockAfter {
+				require.ErrorIs(t, tt.check(a, wrong), ErrInvalidMFACode)
+			}
+
+			// Locked: not even the code is looked at any more.
+			err := tt.check(a, wrong)
+			require.ErrorIs(t, err, ErrAccountLocked)
+
+			var throttled *LoginThrottledError
+			require.ErrorAs(t, err, &throttled)
+			assert.Positive(t, throttled.RetryAfter)
+
+			// The lock is the one logins are held to.
+			attempts, err := a.repo.GetLoginAttempts(ctx, loginAttemptKey("login"))
+			require.NoError(t, err)
+			assert.NotNil(t, attempts.LockedUntil)
+		})
+	}
+}
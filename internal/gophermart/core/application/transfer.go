@@ -0,0 +1,75 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+// TransferLimits caps the points a user may send and receive in transfers
+// per UTC day. Zero limits don't apply.
+type TransferLimits struct {
+	DailySent     model.Money
+	DailyReceived model.Money
+}
+
+// TransferPoints moves points from the available balance of login to the
+// one of the recipient. Points leave the account as with a withdrawal, so
+// the same second factor and cooldowns apply. The recipient gets the points
+// with the expiry they had for the sender.
+func (a *Application) TransferPoints(ctx context.Context, login string,
+	request model.TransferRequest) (model.TransferResponse, error) {
+	if request.Amount <= 0 {
+		return model.TransferResponse{}, fmt.Errorf("amount %s: %w", request.Amount, ErrInvalidAmount)
+	}
+
+	if request.Recipient == login {
+		return model.TransferResponse{}, ErrSelfTransfer
+	}
+
+	if err := a.checkWithdrawTOTP(ctx, login, request.Amount, request.TOTPCode); err != nil {
+		return model.TransferResponse{}, err
+	}
+
+	now := time.Now()
+	if err := a.checkWithdrawCooldown(ctx, login, now); err != nil {
+		return model.TransferResponse{}, err
+	}
+
+	transfer := model.Transfer{
+		ID:            uuid.NewString(),
+		Sender:        login,
+		Recipient:     request.Recipient,
+		Amount:        request.Amount,
+		Since:         now.UTC().Truncate(24 * time.Hour),
+		SentLimit:     a.transferLimits.DailySent,
+		ReceivedLimit: a.transferLimits.DailyReceived,
+	}
+
+	if err := a.repo.TransferPoints(ctx, transfer); err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrNotFound):
+			return model.TransferResponse{}, fmt.Errorf("user %s: %w", request.Recipient, ErrRecipientNotFound)
+		case errors.Is(err, repositories.ErrInsufficientFunds):
+			return model.TransferResponse{}, ErrInsufficientFunds
+		case errors.Is(err, repositories.ErrLimitExceeded):
+			return model.TransferResponse{}, ErrTransferLimitExceeded
+		}
+
+		return model.TransferResponse{}, fmt.Errorf("can't transfer points: %w", err)
+	}
+
+	a.logger.Infof("%s transferred %s to %s in %s", login, request.Amount, request.Recipient, transfer.ID)
+
+	return model.TransferResponse{
+		ID:        transfer.ID,
+		Recipient: request.Recipient,
+		Amount:    request.Amount,
+	}, nil
+}
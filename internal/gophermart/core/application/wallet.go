@@ -0,0 +1,101 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"gofermart/internal/gophermart/core/model"
+)
+
+// Wallets routes accruals to wallets by the source the accrual system
+// reports for an order. Accruals of sources that aren't mapped go to the
+// default wallet.
+type Wallets map[string]string
+
+// NewWallets validates the currencies sources map to. Sources are matched
+// case-insensitively.
+func NewWallets(sources map[string]string) (Wallets, error) {
+	wallets := make(Wallets, len(sources))
+	for source, code := range sources {
+		currency, err := model.ParseCurrency(code)
+		if err != nil {
+			return nil, fmt.Errorf("wallet of source %q: %w", source, err)
+		}
+
+		wallets[strings.ToLower(source)] = currency
+	}
+
+	return wallets, nil
+}
+
+// currency returns the currency of the wallet accruals of source go to.
+func (w Wallets) currency(source string) string {
+	if currency, ok := w[strings.ToLower(source)]; ok {
+		return currency
+	}
+
+	return model.DefaultCurrency
+}
+
+// UserWallets returns the balances of all wallets of login, the default
+// one first.
+func (a *Application) UserWallets(ctx context.Context, login string) ([]model.WalletResponse, error) {
+	wallets, err := a.repo.GetUserWallets(ctx, login)
+	if err != nil {
+		return nil, fmt.Errorf("can't get user wallets: %w", err)
+	}
+
+	list := make([]model.WalletResponse, 0, len(wallets))
+	for _, wallet := range wallets {
+		response := model.WalletResponse{
+			Currency: wallet.Currency,
+			UserBalanceResponse: model.UserBalanceResponse{
+				Current:   wallet.Amount,
+				Held:      wallet.Held,
+				Withdrawn: wallet.Withdraw,
+				Debt:      wallet.Debt,
+			},
+		}
+
+		// Only points of the default wallet expire.
+		if wallet.Currency == model.DefaultCurrency {
+			response.ExpiringSoon, err = a.repo.GetExpiringPoints(ctx, login,
+				time.Now().Add(a.pointsExpiry.NoticePeriod))
+			if err != nil {
+				return nil, fmt.Errorf("can't get expiring points: %w", err)
+			}
+		}
+
+		list = append(list, response)
+	}
+
+	return list, nil
+}
+
+// walletBalance returns the balance of the wallet of login in currency. A
+// wallet that was never credited is empty.
+func (a *Application) walletBalance(ctx context.Context, login, currency string) (model.UserBalance, error) {
+	if currency == model.DefaultCurrency {
+		balance, err := a.repo.GetUserBalance(ctx, login)
+		if err != nil {
+			return model.UserBalance{}, fmt.Errorf("can't get user balance: %w", err)
+		}
+
+		return balance, nil
+	}
+
+	wallets, err := a.repo.GetUserWallets(ctx, login)
+	if err != nil {
+		return model.UserBalance{}, fmt.Errorf("can't get user wallets: %w", err)
+	}
+
+	for _, wallet := range wallets {
+		if wallet.Currency == currency {
+			return wallet.UserBalance, nil
+		}
+	}
+
+	return model.UserBalance{}, nil
+}
@@ -0,0 +1,26 @@
+package application
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gofermart/internal/gophermart/core/model"
+)
+
+func TestWallets(t *testing.T) {
+	wallets, err := NewWallets(map[string]string{"Acme": "acme", "partner-shop": "SHOP"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "ACME", wallets.currency("ACME"))
+	assert.Equal(t, "SHOP", wallets.currency("partner-shop"))
+	assert.Equal(t, model.DefaultCurrency, wallets.currency(""))
+	assert.Equal(t, model.DefaultCurrency, wallets.currency("unknown"))
+
+	// Without wallets every accrual goes to the default one.
+	assert.Equal(t, model.DefaultCurrency, Wallets(nil).currency("acme"))
+
+	_, err = NewWallets(map[string]string{"acme": "A-1"})
+	require.ErrorIs(t, err, model.ErrInvalidCurrency)
+}
@@ -2,18 +2,211 @@ package application
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
 )
 
+// WithdrawLimits are the risk limits on withdrawals, holds included. Min
+// and Max bound a single withdrawal, Daily and Monthly what a user may
+// withdraw per UTC day and month. Withdrawals are refused for
+// PasswordCooldown after a password change and for SessionCooldown after a
+// new session is opened. Zero values don't apply.
+type WithdrawLimits struct {
+	Min              model.Money
+	Max              model.Money
+	Daily            model.Money
+	Monthly          model.Money
+	PasswordCooldown time.Duration
+	SessionCooldown  time.Duration
+}
+
+// WithdrawBlockedError is returned when a withdrawal is refused by a cap or
+// a cooldown that lifts by itself. RetryAfter is how long to wait, zero
+// when waiting won't help or isn't known.
+type WithdrawBlockedError struct {
+	Err        error
+	Reason     string
+	RetryAfter time.Duration
+}
+
+func (e *WithdrawBlockedError) Error() string {
+	return e.Reason + ": " + e.Err.Error()
+}
+
+func (e *WithdrawBlockedError) Unwrap() error {
+	return e.Err
+}
+
+// withdrawWindow is a cap on withdrawals that is reset at ends.
+type withdrawWindow struct {
+	model.WithdrawCap
+	ends   time.Time
+	reason string
+}
+
+func (l WithdrawLimits) windows(now time.Time) []withdrawWindow {
+	now = now.UTC()
+	day := now.Truncate(24 * time.Hour)
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	var windows []withdrawWindow
+	if l.Daily > 0 {
+		windows = append(windows, withdrawWindow{
+			WithdrawCap: model.WithdrawCap{Since: day, Limit: l.Daily},
+			ends:        day.AddDate(0, 0, 1),
+			reason:      "daily limit",
+		})
+	}
+
+	if l.Monthly > 0 {
+		windows = append(windows, withdrawWindow{
+			WithdrawCap: model.WithdrawCap{Since: month, Limit: l.Monthly},
+			ends:        month.AddDate(0, 1, 0),
+			reason:      "monthly limit",
+		})
+	}
+
+	return windows
+}
+
+// checkWithdrawLimits applies the withdrawal limits to a withdrawal of
+// amount and returns the caps the store has to enforce along with the
+// balance, so that concurrent withdrawals can't both pass them.
+func (a *Application) checkWithdrawLimits(ctx context.Context, login, currency string, amount model.Money,
+	now time.Time) ([]model.WithdrawCap, error) {
+	limits := a.withdrawLimits
+
+	if limits.Min > 0 && amount < limits.Min {
+		return nil, fmt.Errorf("sum %s, minimum %s: %w", amount, limits.Min, ErrWithdrawBelowMinimum)
+	}
+
+	if limits.Max > 0 && amount > limits.Max {
+		return nil, fmt.Errorf("sum %s, maximum %s: %w", amount, limits.Max, ErrWithdrawAboveMaximum)
+	}
+
+	if err := a.checkWithdrawCooldown(ctx, login, now); err != nil {
+		return nil, err
+	}
+
+	windows := limits.windows(now)
+	caps := make([]model.WithdrawCap, 0, len(windows))
+	for _, w := range windows {
+		withdrawn, err := a.repo.GetUserWithdrawn(ctx, login, currency, w.Since)
+		if err != nil {
+			return nil, fmt.Errorf("can't get withdrawn points: %w", err)
+		}
+
+		if withdrawn+amount > w.Limit {
+			blocked := &WithdrawBlockedError{Err: ErrWithdrawLimitExceeded, Reason: w.reason}
+			if amount <= w.Limit {
+				blocked.RetryAfter = w.ends.Sub(now)
+			}
+
+			return nil, blocked
+		}
+
+		caps = append(caps, w.WithdrawCap)
+	}
+
+	return caps, nil
+}
+
+// checkWithdrawCooldown refuses withdrawals shortly after the password was
+// changed or a session was opened, which is when a taken over account
+// would be drained.
+func (a *Application) checkWithdrawCooldown(ctx context.Context, login string, now time.Time) error {
+	limits := a.withdrawLimits
+
+	if limits.PasswordCooldown > 0 {
+		account, err := a.repo.GetUser(ctx, login)
+		if err != nil {
+			return fmt.Errorf("can't get user: %w", err)
+		}
+
+		if account.PasswordChangedAt != nil {
+			if left := account.PasswordChangedAt.Add(limits.PasswordCooldown).Sub(now); left > 0 {
+				return &WithdrawBlockedError{Err: ErrWithdrawCooldown, Reason: "password changed", RetryAfter: left}
+			}
+		}
+	}
+
+	if limits.SessionCooldown > 0 {
+		sessions, err := a.repo.GetUserSessions(ctx, login)
+		if err != nil {
+			return fmt.Errorf("can't get user sessions: %w", err)
+		}
+
+		var left time.Duration
+		for _, session := range sessions {
+			left = max(left, session.CreatedAt.Add(limits.SessionCooldown).Sub(now))
+		}
+
+		if left > 0 {
+			return &WithdrawBlockedError{Err: ErrWithdrawCooldown, Reason: "new session", RetryAfter: left}
+		}
+	}
+
+	return nil
+}
+
+// checkPartner makes sure the partner a withdrawal names is a partner
+// account, since it will be allowed to refund the withdrawal.
+func (a *Application) checkPartner(ctx context.Context, partner string) error {
+	if partner == "" {
+		return nil
+	}
+
+	account, err := a.repo.GetUser(ctx, partner)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return fmt.Errorf("partner %s: %w", partner, ErrPartnerNotFound)
+		}
+
+		return fmt.Errorf("can't get partner: %w", err)
+	}
+
+	if account.Role != model.RolePartner {
+		return fmt.Errorf("partner %s: %w", partner, ErrPartnerNotFound)
+	}
+
+	return nil
+}
+
+// withdrawError maps the store errors of a withdrawal or hold.
+func withdrawError(orderID, message string, err error) error {
+	switch {
+	case errors.Is(err, repositories.ErrInsufficientFunds):
+		return ErrInsufficientFunds
+	case errors.Is(err, repositories.ErrDuplicate):
+		return fmt.Errorf("order %s: %w", orderID, ErrWithdrawExists)
+	case errors.Is(err, repositories.ErrLimitExceeded):
+		// Another withdrawal got in between the check and the store.
+		return &WithdrawBlockedError{Err: ErrWithdrawLimitExceeded, Reason: "concurrent withdrawal"}
+	}
+
+	return fmt.Errorf("%s: %w", message, err)
+}
+
 func (a *Application) UserWithdraw(ctx context.Context, login string, request model.WithdrawRequest) error {
-	balance, err := a.repo.GetUserBalance(ctx, login)
+	if request.Sum <= 0 {
+		return fmt.Errorf("sum %s: %w", request.Sum, ErrInvalidAmount)
+	}
+
+	currency, err := model.ParseCurrency(request.Currency)
+	if err != nil {
+		return err
+	}
+
+	balance, err := a.walletBalance(ctx, login, currency)
 	if err != nil {
-		return fmt.Errorf("can't get user balance: %w", err)
+		return err
 	}
 
-	if balance.Amount < convertToPence(request.Sum) {
+	if balance.Amount < request.Sum {
 		return ErrInsufficientFunds
 	}
 
@@ -21,11 +214,27 @@ func (a *Application) UserWithdraw(ctx context.Context, login string, request mo
 		return fmt.Errorf("invalid order id: %w", ErrInvalidOrderID)
 	}
 
+	if err := a.checkPartner(ctx, request.Partner); err != nil {
+		return err
+	}
+
+	if err := a.checkWithdrawTOTP(ctx, login, request.Sum, request.TOTPCode); err != nil {
+		return err
+	}
+
+	caps, err := a.checkWithdrawLimits(ctx, login, currency, request.Sum, time.Now())
+	if err != nil {
+		return err
+	}
+
 	if err := a.repo.UserWithdraw(ctx, login, model.Withdraw{
-		Amount:  convertToPence(request.Sum),
-		OrderID: request.Order,
+		Amount:   request.Sum,
+		OrderID:  request.Order,
+		Currency: currency,
+		Partner:  request.Partner,
+		Caps:     caps,
 	}); err != nil {
-		return fmt.Errorf("can't withdraw: %w", err)
+		return withdrawError(request.Order, "can't withdraw", err)
 	}
 
 	return nil
@@ -39,9 +248,16 @@ func (a *Application) UserWithdrawals(ctx context.Context, login string) ([]mode
 
 	list := make([]model.WithdrawResponse, 0, len(withdrawals))
 	for _, w := range withdrawals {
+		if !w.Processed() {
+			continue
+		}
+
 		list = append(list, model.WithdrawResponse{
 			Order:       w.OrderID,
-			Sum:         convertToPounds(w.Amount),
+			Status:      w.Status,
+			Currency:    model.WalletCurrency(w.Currency),
+			Sum:         w.Amount,
+			Refunded:    w.Refunded,
 			ProcessedAt: w.CreatedAt,
 		})
 	}
@@ -0,0 +1,42 @@
+package application
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+func TestWithdrawLimitsWindows(t *testing.T) {
+	now := time.Date(2024, 2, 29, 18, 30, 0, 0, time.FixedZone("UTC+3", 3*60*60))
+
+	t.Run("no caps", func(t *testing.T) {
+		assert.Empty(t, WithdrawLimits{Min: 100, Max: 1000}.windows(now))
+	})
+
+	t.Run("daily and monthly", func(t *testing.T) {
+		windows := WithdrawLimits{Daily: 500, Monthly: 5000}.windows(now)
+		require.Len(t, windows, 2)
+
+		day := time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)
+		assert.Equal(t, model.WithdrawCap{Since: day, Limit: 500}, windows[0].WithdrawCap)
+		assert.Equal(t, day.AddDate(0, 0, 1), windows[0].ends)
+
+		month := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+		assert.Equal(t, model.WithdrawCap{Since: month, Limit: 5000}, windows[1].WithdrawCap)
+		assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), windows[1].ends)
+	})
+}
+
+func TestWithdrawError(t *testing.T) {
+	err := withdrawError("order", "can't withdraw", repositories.ErrLimitExceeded)
+
+	var blocked *WithdrawBlockedError
+	require.ErrorAs(t, err, &blocked)
+	assert.ErrorIs(t, err, ErrWithdrawLimitExceeded)
+	assert.Zero(t, blocked.RetryAfter)
+}
@@ -4,10 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"sync"
+	"time"
 
 	"gofermart/internal/gophermart/core/client"
 	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
 )
 
 func (a *Application) handleOrders(ctx context.Context) {
@@ -77,14 +80,61 @@ func (a *Application) processOrder(ctx context.Context, order model.Order) error
 		return fmt.Errorf("can't send order %s: %w", order.OrderID, err)
 	}
 
-	var amount int
+	var amount model.Money
 	if resp.Accrual != nil {
-		amount = convertToPence(*resp.Accrual)
+		amount = *resp.Accrual
 	}
 
-	if err := a.repo.SetBalance(ctx, order.OrderID, resp.Status, amount); err != nil {
+	accrual := model.Accrual{
+		OrderID:  order.OrderID,
+		Status:   resp.Status,
+		Amount:   amount,
+		Currency: a.wallets.currency(resp.Source),
+	}
+
+	// Tiers, promotions and expiry only apply to the default wallet.
+	if accrual.Currency == model.DefaultCurrency {
+		accrual.ExpiresAt = a.pointsExpiry.expiresAt(time.Now())
+	}
+
+	if amount > 0 && accrual.Currency == model.DefaultCurrency {
+		login, tier, err := a.accrualTier(ctx, order.OrderID)
+		if err != nil {
+			return fmt.Errorf("can't get tier of order %s: %w", order.OrderID, err)
+		}
+
+		accrual.Tier = tier.Name
+		accrual.Bonus = tier.bonus(amount)
+
+		// Promotions are awarded on the first accrual of an order, the
+		// store only corrects what it credited later.
+		if order.Amount == 0 {
+			accrual.Promotions, err = a.promotionAwards(ctx, login, accrualFacts{tier: tier.Name, amount: amount})
+			if err != nil {
+				return fmt.Errorf("can't evaluate promotions of order %s: %w", order.OrderID, err)
+			}
+		}
+	}
+
+	if resp.Status == model.OrderStatusDone {
+		accrual.Referral = a.referrals.bonus()
+	}
+
+	err = a.repo.SetBalance(ctx, accrual)
+	if errors.Is(err, repositories.ErrDuplicate) && slices.ContainsFunc(accrual.Promotions, isFirstOrderAward) {
+		// Another order of the user processed at the same time was its
+		// first one.
+		accrual.Promotions = slices.DeleteFunc(accrual.Promotions, isFirstOrderAward)
+		err = a.repo.SetBalance(ctx, accrual)
+	}
+
+	if err != nil {
 		return fmt.Errorf("can't save balance %s: %w", order.OrderID, err)
 	}
 
 	return nil
 }
+
+func isFirstOrderAward(award model.PromotionAward) bool {
+	return award.FirstOrder
+}
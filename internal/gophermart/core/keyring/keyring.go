@@ -0,0 +1,189 @@
+package keyring
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"gofermart/internal/gophermart/core/model"
+)
+
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+var (
+	ErrUnknownKey    = errors.New("unknown signing key")
+	ErrKeyRetired    = errors.New("signing key retired")
+	ErrAlgMismatch   = errors.New("signing algorithm does not match key")
+	ErrNoSigningKey  = errors.New("no signing key")
+	ErrUnsupported   = errors.New("unsupported algorithm")
+	ErrDuplicateKeys = errors.New("duplicate key id")
+)
+
+// Key is one entry of the ring. SignKey is nil for verify-only keys, e.g.
+// keys kept around so that tokens issued before a rotation stay valid.
+type Key struct {
+	RetiredAt *time.Time
+	SignKey   interface{}
+	VerifyKey interface{}
+	ID        string
+	Algorithm string
+}
+
+type Ring struct {
+	now       func() time.Time
+	keys      map[string]Key
+	signingID string
+	grace     time.Duration
+}
+
+// New builds a ring that signs with the key signingID. A retired key keeps
+// verifying tokens for the grace period after its RetiredAt and is rejected
+// afterwards.
+func New(keys []Key, signingID string, grace time.Duration) (*Ring, error) {
+	ring := &Ring{
+		now:       time.Now,
+		keys:      make(map[string]Key, len(keys)),
+		signingID: signingID,
+		grace:     grace,
+	}
+
+	for _, key := range keys {
+		if _, ok := ring.keys[key.ID]; ok {
+			return nil, fmt.Errorf("key %q: %w", key.ID, ErrDuplicateKeys)
+		}
+
+		if signingMethod(key.Algorithm) == nil {
+			return nil, fmt.Errorf("key %q, algorithm %q: %w", key.ID, key.Algorithm, ErrUnsupported)
+		}
+
+		ring.keys[key.ID] = key
+	}
+
+	signing, ok := ring.keys[signingID]
+	if !ok || signing.SignKey == nil || signing.RetiredAt != nil {
+		return nil, fmt.Errorf("key %q: %w", signingID, ErrNoSigningKey)
+	}
+
+	return ring, nil
+}
+
+// FromSecret is the ring used when no keys are configured: the legacy
+// HMAC secret under an empty key id, which also matches tokens without kid.
+func FromSecret(secret string) *Ring {
+	return &Ring{
+		now: time.Now,
+		keys: map[string]Key{
+			"": {
+				Algorithm: AlgHS256,
+				SignKey:   []byte(secret),
+				VerifyKey: []byte(secret),
+			},
+		},
+	}
+}
+
+// Sign signs claims with the current signing key and sets its kid header.
+func (r *Ring) Sign(claims jwt.Claims) (string, error) {
+	key := r.keys[r.signingID]
+
+	token := jwt.NewWithClaims(signingMethod(key.Algorithm), claims)
+	if key.ID != "" {
+		token.Header["kid"] = key.ID
+	}
+
+	signed, err := token.SignedString(key.SignKey)
+	if err != nil {
+		return "", fmt.Errorf("can't sign token: %w", err)
+	}
+
+	return signed, nil
+}
+
+// Keyfunc resolves the verification key for a parsed token. It refuses
+// tokens whose alg header differs from the algorithm bound to the key, so a
+// public key can never be used as an HMAC secret.
+func (r *Ring) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	key, ok := r.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("kid %q: %w", kid, ErrUnknownKey)
+	}
+
+	if token.Method == nil || token.Method.Alg() != key.Algorithm {
+		return nil, fmt.Errorf("kid %q: %w", kid, ErrAlgMismatch)
+	}
+
+	if !r.usable(key) {
+		return nil, fmt.Errorf("kid %q: %w", kid, ErrKeyRetired)
+	}
+
+	return key.VerifyKey, nil
+}
+
+// JWKS publishes the public halves of the asymmetric keys that can still
+// verify tokens. HMAC keys are never published.
+func (r *Ring) JWKS() model.JWKSet {
+	set := model.JWKSet{Keys: make([]model.JWK, 0, len(r.keys))}
+
+	for _, key := range r.keys {
+		if !r.usable(key) {
+			continue
+		}
+
+		switch pub := key.VerifyKey.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, model.JWK{
+				Kty: "RSA",
+				Kid: key.ID,
+				Alg: key.Algorithm,
+				Use: "sig",
+				N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, model.JWK{
+				Kty: "OKP",
+				Kid: key.ID,
+				Alg: key.Algorithm,
+				Use: "sig",
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(pub),
+			})
+		}
+	}
+
+	sort.Slice(set.Keys, func(i, j int) bool {
+		return set.Keys[i].Kid < set.Keys[j].Kid
+	})
+
+	return set
+}
+
+func (r *Ring) usable(key Key) bool {
+	return key.RetiredAt == nil || r.now().Before(key.RetiredAt.Add(r.grace))
+}
+
+func signingMethod(alg string) jwt.SigningMethod {
+	switch alg {
+	case AlgHS256:
+		return jwt.SigningMethodHS256
+	case AlgRS256:
+		return jwt.SigningMethodRS256
+	case AlgEdDSA:
+		return jwt.SigningMethodEdDSA
+	default:
+		return nil
+	}
+}
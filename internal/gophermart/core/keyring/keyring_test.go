@@ -0,0 +1,175 @@
+package keyring
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newClaims() *jwt.RegisteredClaims {
+	return &jwt.RegisteredClaims{
+		Issuer:    "login",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}
+}
+
+func parse(ring *Ring, token string) error {
+	_, err := jwt.ParseWithClaims(token, &jwt.RegisteredClaims{}, ring.Keyfunc)
+	return err //nolint:wrapcheck // test helper
+}
+
+func TestRing_SignAndVerify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	keys := []Key{
+		{ID: "rsa", Algorithm: AlgRS256, SignKey: rsaKey, VerifyKey: &rsaKey.PublicKey},
+		{ID: "ed", Algorithm: AlgEdDSA, SignKey: edPrivate, VerifyKey: edPublic},
+	}
+
+	for _, signing := range []string{"rsa", "ed"} {
+		t.Run(signing, func(t *testing.T) {
+			ring, err := New(keys, signing, 0)
+			require.NoError(t, err)
+
+			token, err := ring.Sign(newClaims())
+			require.NoError(t, err)
+
+			parsed, _ := jwt.Parse(token, ring.Keyfunc)
+			assert.Equal(t, signing, parsed.Header["kid"])
+
+			other, err := New(keys, map[string]string{"rsa": "ed", "ed": "rsa"}[signing], 0)
+			require.NoError(t, err)
+			require.NoError(t, parse(other, token), "a token verifies with any active key of the ring")
+		})
+	}
+}
+
+func TestRing_Keyfunc(t *testing.T) {
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	t.Run("unknown kid", func(t *testing.T) {
+		ring, err := New([]Key{{ID: "a", Algorithm: AlgEdDSA, SignKey: edPrivate, VerifyKey: edPublic}}, "a", 0)
+		require.NoError(t, err)
+
+		token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, newClaims())
+		token.Header["kid"] = "b"
+		signed, err := token.SignedString(edPrivate)
+		require.NoError(t, err)
+
+		require.ErrorIs(t, parse(ring, signed), ErrUnknownKey)
+	})
+
+	t.Run("algorithm mismatch", func(t *testing.T) {
+		ring, err := New([]Key{{ID: "a", Algorithm: AlgEdDSA, SignKey: edPrivate, VerifyKey: edPublic}}, "a", 0)
+		require.NoError(t, err)
+
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, newClaims())
+		token.Header["kid"] = "a"
+		signed, err := token.SignedString([]byte(edPublic))
+		require.NoError(t, err)
+
+		require.ErrorIs(t, parse(ring, signed), ErrAlgMismatch)
+	})
+
+	t.Run("retired key within and after grace", func(t *testing.T) {
+		retiredAt := time.Now().Add(-time.Hour)
+		keys := []Key{
+			{ID: "old", Algorithm: AlgHS256, SignKey: []byte("old"), VerifyKey: []byte("old"), RetiredAt: &retiredAt},
+			{ID: "new", Algorithm: AlgHS256, SignKey: []byte("new"), VerifyKey: []byte("new")},
+		}
+
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, newClaims())
+		token.Header["kid"] = "old"
+		signed, err := token.SignedString([]byte("old"))
+		require.NoError(t, err)
+
+		withinGrace, err := New(keys, "new", 2*time.Hour)
+		require.NoError(t, err)
+		require.NoError(t, parse(withinGrace, signed))
+
+		afterGrace, err := New(keys, "new", time.Minute)
+		require.NoError(t, err)
+		require.ErrorIs(t, parse(afterGrace, signed), ErrKeyRetired)
+	})
+
+	t.Run("retired key can't sign", func(t *testing.T) {
+		retiredAt := time.Now()
+		_, err := New([]Key{{ID: "a", Algorithm: AlgHS256, SignKey: []byte("a"), VerifyKey: []byte("a"),
+			RetiredAt: &retiredAt}}, "a", time.Hour)
+		require.ErrorIs(t, err, ErrNoSigningKey)
+	})
+}
+
+func TestRing_FromSecret(t *testing.T) {
+	ring := FromSecret("secret")
+
+	token, err := ring.Sign(newClaims())
+	require.NoError(t, err)
+
+	parsed, err := jwt.Parse(token, ring.Keyfunc)
+	require.NoError(t, err)
+	assert.NotContains(t, parsed.Header, "kid")
+	assert.Empty(t, ring.JWKS().Keys)
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	rsaFile := filepath.Join(dir, "rsa.pem")
+	require.NoError(t, os.WriteFile(rsaFile, pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(rsaKey),
+	}), 0o600))
+
+	edPublic, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	edDER, err := x509.MarshalPKIXPublicKey(edPublic)
+	require.NoError(t, err)
+
+	edFile := filepath.Join(dir, "ed.pub.pem")
+	require.NoError(t, os.WriteFile(edFile, pem.EncodeToMemory(&pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: edDER,
+	}), 0o600))
+
+	ring, err := Load(Config{
+		SigningKey: "rsa",
+		Keys: []KeyConfig{
+			{ID: "rsa", Algorithm: AlgRS256, PrivateKeyFile: rsaFile},
+			{ID: "ed", Algorithm: AlgEdDSA, PublicKeyFile: edFile},
+		},
+	})
+	require.NoError(t, err)
+
+	jwks := ring.JWKS()
+	require.Len(t, jwks.Keys, 2)
+	assert.Equal(t, "OKP", jwks.Keys[0].Kty)
+	assert.Equal(t, "Ed25519", jwks.Keys[0].Crv)
+	assert.Equal(t, "RSA", jwks.Keys[1].Kty)
+	assert.Equal(t, "AQAB", jwks.Keys[1].E)
+
+	_, err = Load(Config{
+		SigningKey: "ed",
+		Keys:       []KeyConfig{{ID: "ed", Algorithm: AlgEdDSA, PublicKeyFile: edFile}},
+	})
+	require.ErrorIs(t, err, ErrNoSigningKey)
+}
@@ -0,0 +1,135 @@
+package keyring
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+type Config struct {
+	SigningKey  string
+	Keys        []KeyConfig
+	RetireGrace time.Duration
+}
+
+// KeyConfig names the files of one key. PrivateKeyFile holds a PEM private
+// key, or the raw secret for HS256. PublicKeyFile alone makes a verify-only
+// asymmetric key.
+type KeyConfig struct {
+	RetiredAt      *time.Time
+	ID             string
+	Algorithm      string
+	PrivateKeyFile string
+	PublicKeyFile  string
+}
+
+func Load(conf Config) (*Ring, error) {
+	keys := make([]Key, 0, len(conf.Keys))
+
+	for _, keyConf := range conf.Keys {
+		key, err := loadKey(keyConf)
+		if err != nil {
+			return nil, fmt.Errorf("can't load key %q: %w", keyConf.ID, err)
+		}
+
+		keys = append(keys, key)
+	}
+
+	return New(keys, conf.SigningKey, conf.RetireGrace)
+}
+
+func loadKey(conf KeyConfig) (Key, error) {
+	key := Key{
+		ID:        conf.ID,
+		Algorithm: conf.Algorithm,
+		RetiredAt: conf.RetiredAt,
+	}
+
+	if conf.PrivateKeyFile != "" {
+		data, err := os.ReadFile(conf.PrivateKeyFile)
+		if err != nil {
+			return Key{}, fmt.Errorf("can't read private key: %w", err)
+		}
+
+		if err := key.setPrivate(data); err != nil {
+			return Key{}, err
+		}
+	}
+
+	if conf.PublicKeyFile != "" {
+		data, err := os.ReadFile(conf.PublicKeyFile)
+		if err != nil {
+			return Key{}, fmt.Errorf("can't read public key: %w", err)
+		}
+
+		if err := key.setPublic(data); err != nil {
+			return Key{}, err
+		}
+	}
+
+	if key.VerifyKey == nil {
+		return Key{}, fmt.Errorf("no key material for %q", conf.ID)
+	}
+
+	return key, nil
+}
+
+func (k *Key) setPrivate(data []byte) error {
+	switch k.Algorithm {
+	case AlgHS256:
+		secret := bytes.TrimSpace(data)
+		k.SignKey, k.VerifyKey = secret, secret
+	case AlgRS256:
+		private, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+		if err != nil {
+			return fmt.Errorf("can't parse rsa private key: %w", err)
+		}
+
+		k.SignKey, k.VerifyKey = private, &private.PublicKey
+	case AlgEdDSA:
+		private, err := jwt.ParseEdPrivateKeyFromPEM(data)
+		if err != nil {
+			return fmt.Errorf("can't parse ed25519 private key: %w", err)
+		}
+
+		signer, ok := private.(ed25519.PrivateKey)
+		if !ok {
+			return fmt.Errorf("not an ed25519 private key: %w", ErrUnsupported)
+		}
+
+		k.SignKey, k.VerifyKey = signer, signer.Public()
+	default:
+		return fmt.Errorf("algorithm %q: %w", k.Algorithm, ErrUnsupported)
+	}
+
+	return nil
+}
+
+func (k *Key) setPublic(data []byte) error {
+	var (
+		public crypto.PublicKey
+		err    error
+	)
+
+	switch k.Algorithm {
+	case AlgRS256:
+		public, err = jwt.ParseRSAPublicKeyFromPEM(data)
+	case AlgEdDSA:
+		public, err = jwt.ParseEdPublicKeyFromPEM(data)
+	default:
+		return fmt.Errorf("public key for algorithm %q: %w", k.Algorithm, ErrUnsupported)
+	}
+
+	if err != nil {
+		return fmt.Errorf("can't parse public key: %w", err)
+	}
+
+	k.VerifyKey = public
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package model
+
+import "time"
+
+const (
+	ScopeOrdersRead       = "orders:read"
+	ScopeOrdersWrite      = "orders:write"
+	ScopeBalanceRead      = "balance:read"
+	ScopeWithdrawalsRead  = "withdrawals:read"
+	ScopeWithdrawalsWrite = "withdrawals:write"
+)
+
+// APIKey is a long-lived credential of a user. Only the hash of the key is
+// stored; Prefix is kept in clear so the owner can tell keys apart.
+type APIKey struct {
+	CreatedAt  time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+	ID         string
+	Login      string
+	Name       string
+	Prefix     string
+	KeyHash    string
+	Scopes     []string
+}
+
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name" binding:"required"`
+	Scopes []string `json:"scopes" binding:"required"`
+}
+
+type APIKeyResponse struct {
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+}
+
+// CreatedAPIKeyResponse is the only response that carries the key itself.
+type CreatedAPIKeyResponse struct {
+	Key string `json:"key"`
+	APIKeyResponse
+}
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+type LoginAttempts struct {
+	LastFailureAt time.Time
+	LockedUntil   *time.Time
+	Key           string
+	Failures      int
+}
+
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
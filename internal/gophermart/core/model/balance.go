@@ -1,11 +1,77 @@
 package model
 
+import "time"
+
 type UserBalance struct {
-	Amount   int
-	Withdraw int
+	Amount   Money
+	Held     Money
+	Withdraw Money
+	// Debt is what clawbacks took beyond the available balance and future
+	// accruals pay back.
+	Debt Money
 }
 
 type UserBalanceResponse struct {
-	Current   float64 `json:"current"`
-	Withdrawn float64 `json:"withdrawn"`
+	Current   Money `json:"current"`
+	Held      Money `json:"held"`
+	Withdrawn Money `json:"withdrawn"`
+	// ExpiringSoon is the part of Current that expires within the notice
+	// period.
+	ExpiringSoon Money `json:"expiring_soon"`
+	Debt         Money `json:"debt"`
+}
+
+const (
+	HistoryTypeCredit = "credit"
+	HistoryTypeDebit  = "debit"
+)
+
+type BalanceHistoryRequest struct {
+	// From and To are RFC 3339 timestamps or dates, To is exclusive.
+	From   string `form:"from"`
+	To     string `form:"to"`
+	Type   string `form:"type"`
+	Cursor string `form:"cursor"`
+	// Currency is the wallet, the default one if empty.
+	Currency string `form:"currency"`
+	Limit    int    `form:"limit"`
+}
+
+// BalanceHistoryFilter selects postings to the available balance of Login
+// in Currency, oldest first and starting after AfterID. Nil bounds are
+// open.
+type BalanceHistoryFilter struct {
+	From     *time.Time
+	To       *time.Time
+	Login    string
+	Currency string
+	Type     string
+	AfterID  int64
+	Limit    int
+}
+
+// BalanceHistoryEntry is a posting to the available balance together with
+// the balance after it.
+type BalanceHistoryEntry struct {
+	CreatedAt     time.Time
+	TransactionID string
+	Kind          string
+	OrderID       string
+	ID            int64
+	Amount        Money
+	Balance       Money
+}
+
+type BalanceHistoryItem struct {
+	CreatedAt time.Time `json:"created_at"`
+	Type      string    `json:"type"`
+	Kind      string    `json:"kind"`
+	Order     string    `json:"order,omitempty"`
+	Amount    Money     `json:"amount"`
+	Balance   Money     `json:"balance"`
+}
+
+type BalanceHistoryResponse struct {
+	NextCursor string               `json:"next_cursor,omitempty"`
+	Items      []BalanceHistoryItem `json:"items"`
 }
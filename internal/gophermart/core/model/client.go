@@ -1,7 +1,10 @@
 package model
 
 type ClientResponse struct {
-	Accrual *float64 `json:"accrual"`
-	OrderID string   `json:"order"`
-	Status  string   `json:"status"`
+	Accrual *Money `json:"accrual"`
+	OrderID string `json:"order"`
+	Status  string `json:"status"`
+	// Source names the partner programme the accrual comes from, empty for
+	// gophermart's own.
+	Source string `json:"source,omitempty"`
 }
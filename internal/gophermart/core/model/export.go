@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// UserExport is everything stored about a user, for data subject requests.
+type UserExport struct {
+	ExportedAt  time.Time           `json:"exported_at"`
+	Profile     AccountResponse     `json:"profile"`
+	Balance     UserBalanceResponse `json:"balance"`
+	Orders      []OrderResponse     `json:"orders"`
+	Withdrawals []WithdrawResponse  `json:"withdrawals"`
+	Sessions    []SessionResponse   `json:"sessions"`
+	APIKeys     []APIKeyResponse    `json:"api_keys"`
+	TOTPEnabled bool                `json:"totp_enabled"`
+}
+
+type DeleteAccountRequest struct {
+	Password string `json:"password" binding:"required"`
+}
@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// IdempotencyKey is a client supplied key under which the first response
+// to a request is kept, so that retries of the request get the same answer.
+type IdempotencyKey struct {
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+	CompletedAt *time.Time
+	Login       string
+	Key         string
+	Fingerprint string
+	Response    IdempotentResponse
+}
+
+type IdempotentResponse struct {
+	ContentType string
+	Body        []byte
+	StatusCode  int
+}
@@ -0,0 +1,413 @@
+package model
+
+import (
+	"slices"
+	"time"
+)
+
+// Ledger accounts. user.* accounts hold the points of a user, system.*
+// accounts are the counterparties points come from.
+const (
+	LedgerAccountAvailable = "user.available"
+	LedgerAccountHeld      = "user.held"
+	LedgerAccountWithdrawn = "user.withdrawn"
+	// LedgerAccountDebt holds clawbacks the available balance couldn't
+	// cover, as a negative amount.
+	LedgerAccountDebt       = "user.debt"
+	LedgerAccountAccrual    = "system.accrual"
+	LedgerAccountAdjustment = "system.adjustment"
+	LedgerAccountExpiry     = "system.expiry"
+	LedgerAccountTierBonus  = "system.tier_bonus"
+	LedgerAccountPromotion  = "system.promotion"
+	LedgerAccountReferral   = "system.referral"
+)
+
+// LedgerDescriptionOpeningBalance describes the adjustments the migration
+// to the ledger opened the wallets that predate it with.
+const LedgerDescriptionOpeningBalance = "opening balance"
+
+const (
+	LedgerKindAccrual    = "accrual"
+	LedgerKindWithdrawal = "withdrawal"
+	LedgerKindAdjustment = "adjustment"
+	LedgerKindReversal   = "reversal"
+	LedgerKindHold       = "hold"
+	LedgerKindCapture    = "capture"
+	LedgerKindRelease    = "release"
+	LedgerKindRefund     = "refund"
+	LedgerKindExpiry     = "expiry"
+	LedgerKindTierBonus  = "tier_bonus"
+	LedgerKindPromotion  = "promotion"
+	LedgerKindTransfer   = "transfer"
+	LedgerKindCorrection = "correction"
+	LedgerKindRepayment  = "debt_repayment"
+	LedgerKindReferral   = "referral"
+)
+
+// LedgerTransaction is an atomic, balanced set of postings: the amounts of
+// its postings sum to zero. Transactions are never changed once written,
+// mistakes are corrected by a reversal.
+type LedgerTransaction struct {
+	CreatedAt time.Time
+	ID        string
+	Kind      string
+	OrderID   string
+	// Currency is the wallet every posting goes to, empty for the default
+	// one. A transaction balances within its currency.
+	Currency string
+	// Reference is the ID of the transaction a reversal undoes.
+	Reference   string
+	Description string
+	Postings    []LedgerPosting
+	// ExpiresAt, if set, turns the points the transaction credits to
+	// available balances into lots that expire then. It is not stored with
+	// the entries.
+	ExpiresAt *time.Time
+}
+
+type LedgerPosting struct {
+	Login   string
+	Account string
+	Amount  Money
+}
+
+// LedgerEntry is a stored posting together with its transaction.
+type LedgerEntry struct {
+	CreatedAt     time.Time
+	TransactionID string
+	Kind          string
+	Login         string
+	Account       string
+	Currency      string
+	OrderID       string
+	Reference     string
+	Description   string
+	ID            int64
+	Amount        Money
+}
+
+type LedgerEntryResponse struct {
+	CreatedAt     time.Time `json:"created_at"`
+	TransactionID string    `json:"transaction_id"`
+	Kind          string    `json:"kind"`
+	Account       string    `json:"account"`
+	Currency      string    `json:"currency"`
+	Order         string    `json:"order,omitempty"`
+	Reference     string    `json:"reference,omitempty"`
+	Description   string    `json:"description,omitempty"`
+	ID            int64     `json:"id"`
+	Amount        Money     `json:"amount"`
+}
+
+type AdjustBalanceRequest struct {
+	Reason string `json:"reason" binding:"required"`
+	// Currency is the wallet to adjust, the default one if empty.
+	Currency string `json:"currency"`
+	Amount   Money  `json:"amount" binding:"required"`
+}
+
+type ReverseTransactionRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+type LedgerTransactionResponse struct {
+	ID string `json:"id"`
+}
+
+func AccrualTransaction(id, login, orderID string, amount Money) LedgerTransaction {
+	return LedgerTransaction{
+		ID:      id,
+		Kind:    LedgerKindAccrual,
+		OrderID: orderID,
+		Postings: []LedgerPosting{
+			{Login: login, Account: LedgerAccountAvailable, Amount: amount},
+			{Login: login, Account: LedgerAccountAccrual, Amount: -amount},
+		},
+	}
+}
+
+func WithdrawalTransaction(id, login, orderID string, amount Money) LedgerTransaction {
+	return LedgerTransaction{
+		ID:      id,
+		Kind:    LedgerKindWithdrawal,
+		OrderID: orderID,
+		Postings: []LedgerPosting{
+			{Login: login, Account: LedgerAccountAvailable, Amount: -amount},
+			{Login: login, Account: LedgerAccountWithdrawn, Amount: amount},
+		},
+	}
+}
+
+// HoldTransaction reserves amount of the available balance until the hold
+// is captured or released.
+func HoldTransaction(id, login, orderID string, amount Money) LedgerTransaction {
+	return LedgerTransaction{
+		ID:      id,
+		Kind:    LedgerKindHold,
+		OrderID: orderID,
+		Postings: []LedgerPosting{
+			{Login: login, Account: LedgerAccountAvailable, Amount: -amount},
+			{Login: login, Account: LedgerAccountHeld, Amount: amount},
+		},
+	}
+}
+
+func CaptureTransaction(id, login, orderID string, amount Money) LedgerTransaction {
+	return LedgerTransaction{
+		ID:      id,
+		Kind:    LedgerKindCapture,
+		OrderID: orderID,
+		Postings: []LedgerPosting{
+			{Login: login, Account: LedgerAccountHeld, Amount: -amount},
+			{Login: login, Account: LedgerAccountWithdrawn, Amount: amount},
+		},
+	}
+}
+
+func ReleaseTransaction(id, login, orderID string, amount Money) LedgerTransaction {
+	return LedgerTransaction{
+		ID:      id,
+		Kind:    LedgerKindRelease,
+		OrderID: orderID,
+		Postings: []LedgerPosting{
+			{Login: login, Account: LedgerAccountHeld, Amount: -amount},
+			{Login: login, Account: LedgerAccountAvailable, Amount: amount},
+		},
+	}
+}
+
+// RefundTransaction returns withdrawn points to the available balance.
+func RefundTransaction(id, login, orderID string, amount Money, description string) LedgerTransaction {
+	return LedgerTransaction{
+		ID:          id,
+		Kind:        LedgerKindRefund,
+		OrderID:     orderID,
+		Description: description,
+		Postings: []LedgerPosting{
+			{Login: login, Account: LedgerAccountWithdrawn, Amount: -amount},
+			{Login: login, Account: LedgerAccountAvailable, Amount: amount},
+		},
+	}
+}
+
+// ExpiryTransaction takes the expired rest of a lot off the available
+// balance.
+func ExpiryTransaction(id, login, orderID string, amount Money) LedgerTransaction {
+	return LedgerTransaction{
+		ID:          id,
+		Kind:        LedgerKindExpiry,
+		OrderID:     orderID,
+		Description: "points expired",
+		Postings: []LedgerPosting{
+			{Login: login, Account: LedgerAccountAvailable, Amount: -amount},
+			{Login: login, Account: LedgerAccountExpiry, Amount: amount},
+		},
+	}
+}
+
+// TierBonusTransaction credits what the multiplier of tier adds to the
+// accrual of an order.
+func TierBonusTransaction(id, login, orderID string, amount Money, tier string) LedgerTransaction {
+	return LedgerTransaction{
+		ID:          id,
+		Kind:        LedgerKindTierBonus,
+		OrderID:     orderID,
+		Description: "tier " + tier,
+		Postings: []LedgerPosting{
+			{Login: login, Account: LedgerAccountAvailable, Amount: amount},
+			{Login: login, Account: LedgerAccountTierBonus, Amount: -amount},
+		},
+	}
+}
+
+// PromotionTransaction credits the bonus a promotion awards for an order.
+func PromotionTransaction(id, login, orderID string, amount Money, promotion string) LedgerTransaction {
+	return LedgerTransaction{
+		ID:          id,
+		Kind:        LedgerKindPromotion,
+		OrderID:     orderID,
+		Description: "promotion " + promotion,
+		Postings: []LedgerPosting{
+			{Login: login, Account: LedgerAccountAvailable, Amount: amount},
+			{Login: login, Account: LedgerAccountPromotion, Amount: -amount},
+		},
+	}
+}
+
+// ReferralTransaction credits the bonus login gets for a referral, the
+// order being the first processed one of the referred user.
+func ReferralTransaction(id, login, orderID string, amount Money) LedgerTransaction {
+	return LedgerTransaction{
+		ID:          id,
+		Kind:        LedgerKindReferral,
+		OrderID:     orderID,
+		Description: "referral bonus",
+		Postings: []LedgerPosting{
+			{Login: login, Account: LedgerAccountAvailable, Amount: amount},
+			{Login: login, Account: LedgerAccountReferral, Amount: -amount},
+		},
+	}
+}
+
+// TransferTransaction moves amount from the available balance of sender to
+// the one of recipient.
+func TransferTransaction(id, sender, recipient string, amount Money) LedgerTransaction {
+	return LedgerTransaction{
+		ID:          id,
+		Kind:        LedgerKindTransfer,
+		Description: "points transfer",
+		Postings: []LedgerPosting{
+			{Login: sender, Account: LedgerAccountAvailable, Amount: -amount},
+			{Login: recipient, Account: LedgerAccountAvailable, Amount: amount},
+		},
+	}
+}
+
+// CorrectionTransaction books a change by amount of what source credited
+// login for an order. A clawback takes what it can off the available
+// balance and books the rest as debt, so it needs the available balance.
+func CorrectionTransaction(id, login, orderID, source string, amount, available Money,
+	description string) LedgerTransaction {
+	var postings []LedgerPosting
+	switch {
+	case amount >= 0 || available >= -amount:
+		postings = append(postings, LedgerPosting{Login: login, Account: LedgerAccountAvailable, Amount: amount})
+	case available > 0:
+		postings = append(postings,
+			LedgerPosting{Login: login, Account: LedgerAccountAvailable, Amount: -available},
+			LedgerPosting{Login: login, Account: LedgerAccountDebt, Amount: amount + available})
+	default:
+		postings = append(postings, LedgerPosting{Login: login, Account: LedgerAccountDebt, Amount: amount})
+	}
+
+	return LedgerTransaction{
+		ID:          id,
+		Kind:        LedgerKindCorrection,
+		OrderID:     orderID,
+		Description: description,
+		Postings:    append(postings, LedgerPosting{Login: login, Account: source, Amount: -amount}),
+	}
+}
+
+// RepaymentTransaction pays back amount of the debt of login out of the
+// available balance.
+func RepaymentTransaction(id, login, orderID string, amount Money) LedgerTransaction {
+	return LedgerTransaction{
+		ID:          id,
+		Kind:        LedgerKindRepayment,
+		OrderID:     orderID,
+		Description: "debt repaid",
+		Postings: []LedgerPosting{
+			{Login: login, Account: LedgerAccountAvailable, Amount: -amount},
+			{Login: login, Account: LedgerAccountDebt, Amount: amount},
+		},
+	}
+}
+
+func AdjustmentTransaction(id, login string, amount Money, description string) LedgerTransaction {
+	return LedgerTransaction{
+		ID:          id,
+		Kind:        LedgerKindAdjustment,
+		Description: description,
+		Postings: []LedgerPosting{
+			{Login: login, Account: LedgerAccountAvailable, Amount: amount},
+			{Login: login, Account: LedgerAccountAdjustment, Amount: -amount},
+		},
+	}
+}
+
+// ReconciliationTransaction is an adjustment that credits amount to the
+// available balance of login against account, to bring account in line
+// with the orders or withdrawals it books.
+func ReconciliationTransaction(id, login, account string, amount Money, description string) LedgerTransaction {
+	return LedgerTransaction{
+		ID:          id,
+		Kind:        LedgerKindAdjustment,
+		Description: description,
+		Postings: []LedgerPosting{
+			{Login: login, Account: LedgerAccountAvailable, Amount: amount},
+			{Login: login, Account: account, Amount: -amount},
+		},
+	}
+}
+
+// BooksOrders tells whether e books points the orders of its wallet credit:
+// accruals, tier bonuses and fixes against them, and the opening balances
+// of migrated wallets, which came from orders accrued before the ledger.
+// Reversals are left out, a reversed accrual stays booked for its order.
+func (e LedgerEntry) BooksOrders() bool {
+	switch {
+	case e.Kind == LedgerKindReversal:
+		return false
+	case e.Account == LedgerAccountAccrual, e.Account == LedgerAccountTierBonus:
+		return true
+	}
+
+	return e.Kind == LedgerKindAdjustment && e.Account == LedgerAccountAdjustment &&
+		e.Description == LedgerDescriptionOpeningBalance
+}
+
+// Reversible tells whether t may be undone by a reversal. Holds are settled
+// by capturing or releasing them instead, withdrawals are given back by
+// refunds, which are tracked on the withdrawal, and corrections and
+// repayments follow the accrual of their order.
+func (t LedgerTransaction) Reversible() bool {
+	switch t.Kind {
+	case LedgerKindReversal, LedgerKindWithdrawal, LedgerKindHold, LedgerKindCapture, LedgerKindRelease,
+		LedgerKindRefund, LedgerKindCorrection, LedgerKindRepayment:
+		return false
+	}
+
+	return true
+}
+
+// Reversal returns a transaction that undoes t.
+func (t LedgerTransaction) Reversal(id, description string) LedgerTransaction {
+	postings := make([]LedgerPosting, 0, len(t.Postings))
+	for _, p := range t.Postings {
+		postings = append(postings, LedgerPosting{Login: p.Login, Account: p.Account, Amount: -p.Amount})
+	}
+
+	return LedgerTransaction{
+		ID:          id,
+		Kind:        LedgerKindReversal,
+		OrderID:     t.OrderID,
+		Currency:    t.Currency,
+		Reference:   t.ID,
+		Description: description,
+		Postings:    postings,
+	}
+}
+
+func (t LedgerTransaction) Balanced() bool {
+	var sum Money
+	for _, p := range t.Postings {
+		sum += p.Amount
+	}
+
+	return len(t.Postings) > 0 && sum == 0
+}
+
+// Delta is the change t makes to the account of login.
+func (t LedgerTransaction) Delta(login, account string) Money {
+	var delta Money
+	for _, p := range t.Postings {
+		if p.Login == login && p.Account == account {
+			delta += p.Amount
+		}
+	}
+
+	return delta
+}
+
+// Logins lists the users t posts to, in posting order.
+func (t LedgerTransaction) Logins() []string {
+	var logins []string
+	for _, p := range t.Postings {
+		if !slices.Contains(logins, p.Login) {
+			logins = append(logins, p.Login)
+		}
+	}
+
+	return logins
+}
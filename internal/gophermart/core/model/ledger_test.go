@@ -0,0 +1,18 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLedgerTransaction_Reversible(t *testing.T) {
+	assert.True(t, AccrualTransaction("tx-1", "login", "12345678903", 100).Reversible())
+	assert.True(t, AdjustmentTransaction("tx-2", "login", 100, "goodwill").Reversible())
+
+	// A reversal would give the points back without marking the withdrawal
+	// refunded, so it could be refunded a second time.
+	assert.False(t, WithdrawalTransaction("tx-3", "login", "79927398713", 100).Reversible())
+	assert.False(t, RefundTransaction("tx-4", "login", "79927398713", 100, "refund").Reversible())
+	assert.False(t, AccrualTransaction("tx-1", "login", "12345678903", 100).Reversal("tx-5", "mistake").Reversible())
+}
@@ -0,0 +1,56 @@
+package model
+
+import "time"
+
+// PointsLot is what is left of the points credited by one accrual. Debits
+// of the available balance consume the lots that expire first, whatever is
+// left when a lot expires is taken off the balance.
+type PointsLot struct {
+	CreatedAt     time.Time
+	ExpiresAt     time.Time
+	TransactionID string
+	Login         string
+	OrderID       string
+	ID            int64
+	Amount        Money
+	Remaining     Money
+}
+
+// PointsLotUse is what a hold or withdrawal took off a lot. Releasing or
+// refunding its order puts the points back into the lot, so they keep their
+// expiry.
+type PointsLotUse struct {
+	Login   string
+	OrderID string
+	LotID   int64
+	Amount  Money
+}
+
+// RecordsLotUse tells whether the lots t takes points off are recorded
+// against its order to be restored later.
+func (t LedgerTransaction) RecordsLotUse() bool {
+	return t.Kind == LedgerKindHold || t.Kind == LedgerKindWithdrawal
+}
+
+// RestoresLots tells whether t returns points that a hold or withdrawal of
+// its order took off the lots.
+func (t LedgerTransaction) RestoresLots() bool {
+	return t.Kind == LedgerKindRelease || t.Kind == LedgerKindRefund
+}
+
+// LotRecipient is the login a transfer credits, empty for other
+// transactions. The points a transfer takes off the lots of the sender are
+// put into new lots of the recipient with the same expiry.
+func (t LedgerTransaction) LotRecipient() string {
+	if t.Kind != LedgerKindTransfer {
+		return ""
+	}
+
+	for _, p := range t.Postings {
+		if p.Account == LedgerAccountAvailable && p.Amount > 0 {
+			return p.Login
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,112 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Money is an amount of points in hundredths. It never goes through
+// float64: JSON numbers are parsed and written digit by digit.
+type Money int64
+
+const (
+	hundredths = 100
+	fracWidth  = 2
+)
+
+var (
+	ErrInvalidMoney   = errors.New("invalid amount")
+	ErrMoneyPrecision = errors.New("amount has more than two fractional digits")
+)
+
+// ParseMoney parses a decimal such as "729.98", "-5" or "0.5".
+func ParseMoney(s string) (Money, error) {
+	amount, err := parseHundredths(s, ErrInvalidMoney, ErrMoneyPrecision)
+
+	return Money(amount), err
+}
+
+// parseHundredths parses a decimal with at most two fractional digits into
+// hundredths. invalid and precision are the errors of the type it is for.
+func parseHundredths(s string, invalid, precision error) (int64, error) {
+	digits, negative := strings.CutPrefix(s, "-")
+
+	whole, frac, hasFrac := strings.Cut(digits, ".")
+	if whole == "" || hasFrac && frac == "" || !isDigits(whole) || !isDigits(frac) {
+		return 0, fmt.Errorf("%q: %w", s, invalid)
+	}
+
+	if len(frac) > fracWidth {
+		return 0, fmt.Errorf("%q: %w", s, precision)
+	}
+
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || units > math.MaxInt64/hundredths-1 {
+		return 0, fmt.Errorf("%q: %w", s, invalid)
+	}
+
+	cents, _ := strconv.ParseInt(frac+strings.Repeat("0", fracWidth-len(frac)), 10, 64)
+
+	value := units*hundredths + cents
+	if negative {
+		value = -value
+	}
+
+	return value, nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// String formats m without trailing fractional zeros: "5", "5.5", "5.05".
+func (m Money) String() string {
+	return formatHundredths(int64(m))
+}
+
+func formatHundredths(value int64) string {
+	sign := ""
+	abs := uint64(value) //nolint:gosec // the sign is handled below
+	if value < 0 {
+		sign = "-"
+		abs = uint64(-value) //nolint:gosec // the sign is handled above
+	}
+
+	whole, cents := abs/hundredths, abs%hundredths
+	if cents == 0 {
+		return sign + strconv.FormatUint(whole, 10)
+	}
+
+	frac := strings.TrimRight(fmt.Sprintf("%02d", cents), "0")
+
+	return sign + strconv.FormatUint(whole, 10) + "." + frac
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON accepts JSON numbers only, an exponent is not allowed.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	amount, err := ParseMoney(string(data))
+	if err != nil {
+		return err
+	}
+
+	*m = amount
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMoney(t *testing.T) {
+	for input, want := range map[string]Money{
+		"0":       0,
+		"0.29":    29,
+		"729.98":  72998,
+		"5.5":     550,
+		"-5.05":   -505,
+		"1000000": 100000000,
+	} {
+		t.Run(input, func(t *testing.T) {
+			amount, err := ParseMoney(input)
+			require.NoError(t, err)
+			assert.Equal(t, want, amount)
+		})
+	}
+
+	for _, input := range []string{"", "-", ".5", "5.", "1e2", "+5", "5,00", "1.2.3", "99999999999999999999"} {
+		t.Run("invalid "+input, func(t *testing.T) {
+			_, err := ParseMoney(input)
+			require.ErrorIs(t, err, ErrInvalidMoney)
+		})
+	}
+
+	_, err := ParseMoney("0.001")
+	require.ErrorIs(t, err, ErrMoneyPrecision)
+}
+
+func TestMoney_String(t *testing.T) {
+	assert.Equal(t, "0", Money(0).String())
+	assert.Equal(t, "5", Money(500).String())
+	assert.Equal(t, "5.5", Money(550).String())
+	assert.Equal(t, "5.05", Money(505).String())
+	assert.Equal(t, "-0.29", Money(-29).String())
+}
+
+func TestMoney_JSON(t *testing.T) {
+	var request WithdrawRequest
+	require.NoError(t, json.Unmarshal([]byte(`{"order":"2377225624","sum":729.98}`), &request))
+	assert.Equal(t, Money(72998), request.Sum)
+
+	data, err := json.Marshal(WithdrawResponse{Sum: request.Sum})
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"sum":729.98`)
+
+	err = json.Unmarshal([]byte(`{"sum":0.291}`), &request)
+	require.ErrorIs(t, err, ErrMoneyPrecision)
+
+	err = json.Unmarshal([]byte(`{"sum":"5"}`), &request)
+	require.ErrorIs(t, err, ErrInvalidMoney)
+}
@@ -0,0 +1,60 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Multiplier is a factor in percent: 150 is 1.5. Like Money it never goes
+// through float64 and is written as a decimal with at most two fractional digits.
+type Multiplier int64
+
+// UnitMultiplier leaves amounts as they are.
+const UnitMultiplier Multiplier = hundredths
+
+var ErrInvalidMultiplier = errors.New("invalid multiplier")
+
+// ParseMultiplier parses a non-negative decimal such as "1.5" or "2".
+func ParseMultiplier(s string) (Multiplier, error) {
+	factor, err := parseHundredths(s, ErrInvalidMultiplier, ErrInvalidMultiplier)
+	if err != nil {
+		return 0, err
+	}
+
+	if factor < 0 {
+		return 0, fmt.Errorf("%q: %w", s, ErrInvalidMultiplier)
+	}
+
+	return Multiplier(factor), nil
+}
+
+// String formats m without trailing fractional zeros: "1", "1.5", "1.05".
+func (m Multiplier) String() string {
+	return formatHundredths(int64(m))
+}
+
+// Bonus is what m adds to amount on top of amount itself, rounded down to
+// whole hundredths.
+func (m Multiplier) Bonus(amount Money) Money {
+	return amount * Money(m-UnitMultiplier) / hundredths
+}
+
+func (m Multiplier) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON accepts JSON numbers only, an exponent is not allowed.
+func (m *Multiplier) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	factor, err := ParseMultiplier(string(data))
+	if err != nil {
+		return err
+	}
+
+	*m = factor
+
+	return nil
+}
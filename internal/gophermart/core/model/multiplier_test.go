@@ -0,0 +1,54 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMultiplier(t *testing.T) {
+	for input, want := range map[string]Multiplier{
+		"0":    0,
+		"1":    100,
+		"1.5":  150,
+		"1.05": 105,
+		"2":    200,
+	} {
+		t.Run(input, func(t *testing.T) {
+			factor, err := ParseMultiplier(input)
+			require.NoError(t, err)
+			assert.Equal(t, want, factor)
+		})
+	}
+
+	for _, input := range []string{"", ".5", "1e2", "-1.5", "1.005", "99999999999999999999"} {
+		t.Run("invalid "+input, func(t *testing.T) {
+			_, err := ParseMultiplier(input)
+			require.ErrorIs(t, err, ErrInvalidMultiplier)
+		})
+	}
+}
+
+func TestMultiplier_Bonus(t *testing.T) {
+	assert.Equal(t, Money(0), UnitMultiplier.Bonus(12345))
+	assert.Equal(t, Money(617), Multiplier(105).Bonus(12345))
+	assert.Equal(t, Money(12345), Multiplier(200).Bonus(12345))
+}
+
+func TestMultiplier_JSON(t *testing.T) {
+	var request PromotionRequest
+	require.NoError(t, json.Unmarshal([]byte(`{"effect":"multiplier","multiplier":1.5}`), &request))
+	assert.Equal(t, Multiplier(150), request.Multiplier)
+
+	data, err := json.Marshal(PromotionResponse{Multiplier: 105})
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"multiplier":1.05`)
+
+	err = json.Unmarshal([]byte(`{"multiplier":1.505}`), &request)
+	require.ErrorIs(t, err, ErrInvalidMultiplier)
+
+	err = json.Unmarshal([]byte(`{"multiplier":"2"}`), &request)
+	require.ErrorIs(t, err, ErrInvalidMultiplier)
+}
@@ -7,18 +7,38 @@ type OrderRequest struct {
 	Status string
 }
 
+// Accrual is the result of an order from the accrual system. Bonus is
+// credited on top of Amount for the loyalty tier of the user, Promotions
+// are the awards of the promotions the accrual meets. Amount and Bonus are
+// totals: the store credits what they differ from the last accrual of the
+// order by. Referral, if set, rewards the referral of the user unless it
+// is rewarded already.
+type Accrual struct {
+	ExpiresAt *time.Time
+	Referral  *ReferralBonus
+	OrderID   string
+	Status    string
+	// Currency is the wallet the order is credited to, empty for the
+	// default one. It is fixed by the first credit of the order.
+	Currency   string
+	Tier       string
+	Promotions []PromotionAward
+	Amount     Money
+	Bonus      Money
+}
+
 type OrderResponse struct {
 	UploadedAt time.Time `json:"uploaded_at"`
 	Number     string    `json:"number"`
 	Status     string    `json:"status"`
-	Accrual    float64   `json:"accrual,omitempty"`
+	Accrual    Money     `json:"accrual,omitempty"`
 }
 
 type Order struct {
 	CreatedAt time.Time
 	OrderID   string
 	Status    string
-	Amount    int
+	Amount    Money
 }
 
 const (
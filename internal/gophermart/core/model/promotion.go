@@ -0,0 +1,77 @@
+package model
+
+import "time"
+
+const (
+	PromotionEffectFixed      = "fixed"
+	PromotionEffectMultiplier = "multiplier"
+)
+
+// Promotion is a campaign rule. It awards a bonus for accruals between
+// StartsAt and EndsAt that meet all of its conditions.
+type Promotion struct {
+	CreatedAt  time.Time
+	StartsAt   time.Time
+	EndsAt     *time.Time
+	ID         string
+	Name       string
+	Effect     string
+	Conditions PromotionConditions
+	Bonus      Money
+	Multiplier Multiplier
+}
+
+// PromotionConditions are the conditions of a promotion. Zero values don't
+// restrict anything.
+type PromotionConditions struct {
+	Tier       string `json:"tier,omitempty"`
+	MinAccrual Money  `json:"min_accrual,omitempty"`
+	// MinOrders is the number of processed orders the user must reach with
+	// the order, counting it.
+	MinOrders  int  `json:"min_orders,omitempty"`
+	FirstOrder bool `json:"first_order,omitempty"`
+}
+
+// PromotionAward is a bonus a promotion credited for an order.
+type PromotionAward struct {
+	CreatedAt     time.Time
+	PromotionID   string
+	Name          string
+	TransactionID string
+	OrderID       string
+	Login         string
+	Amount        Money
+	// FirstOrder marks the award of a first order promotion. The store
+	// grants it to a user once, however many orders are processed at once.
+	FirstOrder bool
+}
+
+type PromotionRequest struct {
+	StartsAt   time.Time           `json:"starts_at" binding:"required"`
+	EndsAt     *time.Time          `json:"ends_at"`
+	Name       string              `json:"name" binding:"required,max=255"`
+	Effect     string              `json:"effect" binding:"required,oneof=fixed multiplier"`
+	Conditions PromotionConditions `json:"conditions"`
+	Bonus      Money               `json:"bonus"`
+	Multiplier Multiplier          `json:"multiplier"`
+}
+
+type PromotionResponse struct {
+	CreatedAt  time.Time           `json:"created_at"`
+	StartsAt   time.Time           `json:"starts_at"`
+	EndsAt     *time.Time          `json:"ends_at,omitempty"`
+	ID         string              `json:"id"`
+	Name       string              `json:"name"`
+	Effect     string              `json:"effect"`
+	Conditions PromotionConditions `json:"conditions"`
+	Bonus      Money               `json:"bonus,omitempty"`
+	Multiplier Multiplier          `json:"multiplier,omitempty"`
+}
+
+type PromotionAwardResponse struct {
+	CreatedAt     time.Time `json:"created_at"`
+	TransactionID string    `json:"transaction_id"`
+	Order         string    `json:"order"`
+	Login         string    `json:"login"`
+	Amount        Money     `json:"amount"`
+}
@@ -0,0 +1,55 @@
+package model
+
+import "time"
+
+// Reconciliation checks. The ledger is checked against the orders and
+// withdrawals it books, the balance snapshot against the ledger.
+const (
+	ReconcileCheckAccrued   = "accrued"
+	ReconcileCheckWithdrawn = "withdrawn"
+	ReconcileCheckHeld      = "held"
+
+	ReconcileCheckSnapshotAvailable = "snapshot.available"
+	ReconcileCheckSnapshotHeld      = "snapshot.held"
+	ReconcileCheckSnapshotWithdrawn = "snapshot.withdrawn"
+)
+
+// WalletAudit is everything a wallet is reconciled from.
+type WalletAudit struct {
+	Login    string
+	Currency string
+	// Snapshot is the stored balance, Ledger the sums of the user accounts.
+	Snapshot UserBalance
+	Ledger   UserBalance
+	// Accrued is what the orders of the wallet credit with tier bonuses,
+	// Booked what the ledger booked for them.
+	Accrued Money
+	Booked  Money
+	// Withdrawn is what the withdrawals took net of refunds, Held what
+	// active holds reserve.
+	Withdrawn Money
+	Held      Money
+}
+
+// BalanceDiscrepancy is a check a wallet failed. Difference is Expected
+// minus Actual.
+type BalanceDiscrepancy struct {
+	Login      string `json:"login"`
+	Currency   string `json:"currency"`
+	Check      string `json:"check"`
+	Expected   Money  `json:"expected"`
+	Actual     Money  `json:"actual"`
+	Difference Money  `json:"difference"`
+	// TransactionID is the adjustment that fixed the discrepancy.
+	TransactionID string `json:"transaction_id,omitempty"`
+	// Error tells why a discrepancy wasn't fixed.
+	Error string `json:"error,omitempty"`
+}
+
+type ReconciliationReport struct {
+	StartedAt     time.Time            `json:"started_at"`
+	FinishedAt    time.Time            `json:"finished_at"`
+	Discrepancies []BalanceDiscrepancy `json:"discrepancies"`
+	Wallets       int                  `json:"wallets"`
+	Fixed         int                  `json:"fixed"`
+}
@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+// Referral links a user to the referrer whose code they registered with.
+// RewardedAt and OrderID are set once the first order of the referred user
+// is processed and both got their bonus.
+type Referral struct {
+	CreatedAt  time.Time
+	RewardedAt *time.Time
+	Referrer   string
+	Referred   string
+	OrderID    string
+}
+
+// ReferralBonus is what the first processed order of a referred user
+// credits the referrer and the referred user.
+type ReferralBonus struct {
+	Referrer Money
+	Referred Money
+}
+
+type ReferralsResponse struct {
+	Code      string             `json:"code"`
+	Referrals []ReferralResponse `json:"referrals"`
+	// Remaining is how many more users may register with the code, it is
+	// omitted if there is no cap.
+	Remaining *int `json:"remaining,omitempty"`
+}
+
+type ReferralResponse struct {
+	CreatedAt  time.Time  `json:"created_at"`
+	RewardedAt *time.Time `json:"rewarded_at,omitempty"`
+	Login      string     `json:"login"`
+	Rewarded   bool       `json:"rewarded"`
+}
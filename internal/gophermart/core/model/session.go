@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// Session is one login of a user on a device. Its ID is the refresh token
+// family and the sid claim of every access token issued within it.
+type Session struct {
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	RevokedAt  *time.Time
+	ID         string
+	Login      string
+	UserAgent  string
+	IP         string
+}
+
+type SessionResponse struct {
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	Current    bool      `json:"current"`
+}
@@ -0,0 +1,22 @@
+package model
+
+// UserTier is the loyalty tier of a user with the accruals it is based on.
+type UserTier struct {
+	Login   string
+	Tier    string
+	Accrued Money
+}
+
+type TierResponse struct {
+	Next *TierProgress `json:"next,omitempty"`
+	Tier string        `json:"tier"`
+	// Accrued is the sum of accruals over the last twelve months.
+	Accrued    Money      `json:"accrued"`
+	Multiplier Multiplier `json:"multiplier"`
+}
+
+type TierProgress struct {
+	Tier      string `json:"tier"`
+	Threshold Money  `json:"threshold"`
+	Remaining Money  `json:"remaining"`
+}
@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+type AuthTokens struct {
+	AccessExpiresAt  time.Time
+	RefreshExpiresAt time.Time
+	AccessToken      string
+	RefreshToken     string
+}
+
+type RefreshToken struct {
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	UsedAt    *time.Time
+	TokenHash string
+	Login     string
+	FamilyID  string
+	Revoked   bool
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
@@ -0,0 +1,38 @@
+package model
+
+import "time"
+
+// TOTP is the second factor of a user. It protects logins and large
+// withdrawals only once ConfirmedAt is set. LastStep is the last time step
+// a code was accepted for and guards against replays.
+type TOTP struct {
+	CreatedAt   time.Time
+	ConfirmedAt *time.Time
+	Login       string
+	Secret      string
+	LastStep    int64
+}
+
+type TOTPEnrollResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+// TOTPCodeRequest takes a code from the authenticator app or a recovery code.
+type TOTPCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type MFALoginRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+type MFARequiredResponse struct {
+	MFAToken    string `json:"mfa_token"`
+	MFARequired bool   `json:"mfa_required"`
+}
@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// Transfer moves points between users. The sender may send at most
+// SentLimit and the recipient receive at most ReceivedLimit in transfers
+// since Since, zero limits don't apply.
+type Transfer struct {
+	Since         time.Time
+	ID            string
+	Sender        string
+	Recipient     string
+	Amount        Money
+	SentLimit     Money
+	ReceivedLimit Money
+}
+
+type TransferRequest struct {
+	Recipient string `json:"recipient" binding:"required"`
+	Amount    Money  `json:"amount" binding:"required"`
+
+	// TOTPCode is taken from the X-TOTP-Code header.
+	TOTPCode string `json:"-"`
+}
+
+type TransferResponse struct {
+	ID        string `json:"id"`
+	Recipient string `json:"recipient"`
+	Amount    Money  `json:"amount"`
+}
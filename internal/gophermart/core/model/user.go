@@ -1,6 +1,57 @@
 package model
 
+import "time"
+
+const (
+	RoleUser    = "user"
+	RoleSupport = "support"
+	RoleAdmin   = "admin"
+	// RolePartner is held by the accounts of shops, which may refund
+	// withdrawals spent with them.
+	RolePartner = "partner"
+)
+
 type User struct {
 	Login    string `json:"login" binding:"required"`
 	Password string `json:"password" binding:"required"`
+	// ReferralCode is the optional code of the referrer on registration.
+	ReferralCode string `json:"referral_code,omitempty"`
+}
+
+// Account is a user as stored. Password holds the hash.
+type Account struct {
+	CreatedAt time.Time
+	// PasswordChangedAt is when the user last changed the password, nil if
+	// never.
+	PasswordChangedAt *time.Time
+	Login             string
+	Password          string
+	Role              string
+	Disabled          bool
+}
+
+// Principal is the authenticated caller of a request. Scopes is set only
+// for callers authenticated with an API key, SessionID only for callers
+// authenticated with an access token.
+type Principal struct {
+	Login     string
+	Role      string
+	SessionID string
+	Scopes    []string
+}
+
+type AccountResponse struct {
+	CreatedAt time.Time `json:"created_at"`
+	Login     string    `json:"login"`
+	Role      string    `json:"role"`
+	Disabled  bool      `json:"disabled"`
+}
+
+type ChangePasswordRequest struct {
+	OldPassword string `json:"old_password" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+type SetRoleRequest struct {
+	Role string `json:"role" binding:"required"`
 }
@@ -0,0 +1,53 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultCurrency is the currency of gophermart points. Every user has a
+// wallet in it, wallets in other currencies, such as the points of partner
+// programmes, are opened by their first posting.
+const DefaultCurrency = "POINTS"
+
+var ErrInvalidCurrency = errors.New("invalid currency")
+
+var currencyCode = regexp.MustCompile(`^[A-Z][A-Z0-9_]{1,15}$`)
+
+// ParseCurrency validates a currency code given by a client. The code is
+// case-insensitive and empty means the default currency.
+func ParseCurrency(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return DefaultCurrency, nil
+	}
+
+	if !currencyCode.MatchString(code) {
+		return "", fmt.Errorf("currency %q: %w", code, ErrInvalidCurrency)
+	}
+
+	return code, nil
+}
+
+// WalletCurrency is the currency of a wallet, transaction or withdrawal
+// whose currency field may be left empty for the default one.
+func WalletCurrency(currency string) string {
+	if currency == "" {
+		return DefaultCurrency
+	}
+
+	return currency
+}
+
+// Wallet is the balance of a user in one currency.
+type Wallet struct {
+	Currency string
+	UserBalance
+}
+
+type WalletResponse struct {
+	Currency string `json:"currency"`
+	UserBalanceResponse
+}
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCurrency(t *testing.T) {
+	for input, want := range map[string]string{
+		"":           DefaultCurrency,
+		"points":     DefaultCurrency,
+		" acme_x ":   "ACME_X",
+		"PARTNER2":   "PARTNER2",
+		"ab":         "AB",
+		"Z123456789": "Z123456789",
+	} {
+		t.Run(input, func(t *testing.T) {
+			currency, err := ParseCurrency(input)
+			require.NoError(t, err)
+			assert.Equal(t, want, currency)
+		})
+	}
+
+	for _, input := range []string{"a", "1ABC", "AC-ME", "ABCDEFGHIJKLMNOPQ", "ÄPFEL"} {
+		t.Run("invalid "+input, func(t *testing.T) {
+			_, err := ParseCurrency(input)
+			require.ErrorIs(t, err, ErrInvalidCurrency)
+		})
+	}
+}
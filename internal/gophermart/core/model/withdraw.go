@@ -3,18 +3,130 @@ package model
 import "time"
 
 type WithdrawRequest struct {
-	Order string  `json:"order"`
-	Sum   float64 `json:"sum"`
+	Order string `json:"order"`
+	// Currency is the wallet to debit, the default one if empty.
+	Currency string `json:"currency,omitempty"`
+	// Partner is the login of the partner shop the order is placed at. Only
+	// that partner may refund the withdrawal, admins may refund any.
+	Partner string `json:"partner,omitempty"`
+	Sum     Money  `json:"sum"`
+
+	// TOTPCode is taken from the X-TOTP-Code header.
+	TOTPCode string `json:"-"`
 }
 
+// Withdrawal states. A plain withdrawal is captured right away, a hold
+// reserves the points until it is captured, released or expires. Captured
+// withdrawals can be refunded in parts.
+const (
+	WithdrawStatusHeld              = "HELD"
+	WithdrawStatusCaptured          = "CAPTURED"
+	WithdrawStatusReleased          = "RELEASED"
+	WithdrawStatusExpired           = "EXPIRED"
+	WithdrawStatusPartiallyRefunded = "PARTIALLY_REFUNDED"
+	WithdrawStatusRefunded          = "REFUNDED"
+)
+
 type Withdraw struct {
 	CreatedAt time.Time
+	// ExpiresAt is set for holds only.
+	ExpiresAt *time.Time
+	Login     string
 	OrderID   string
-	Amount    int
+	Status    string
+	// Currency is the wallet the points come from, empty for the default
+	// one.
+	Currency string
+	// Partner is the partner shop the order is placed at, if any.
+	Partner string
+	// Caps are checked by the store together with the balance, they are
+	// not stored.
+	Caps     []WithdrawCap
+	Amount   Money
+	Refunded Money
+}
+
+// WithdrawCap caps what a user may withdraw since Since, active holds
+// included.
+type WithdrawCap struct {
+	Since time.Time
+	Limit Money
+}
+
+// Withdrawn tells whether the points of w count against withdrawal caps:
+// everything but released and expired holds.
+func (w Withdraw) Withdrawn() bool {
+	return w.Status != WithdrawStatusReleased && w.Status != WithdrawStatusExpired
+}
+
+// Processed tells whether the points of w were withdrawn, refunds included.
+func (w Withdraw) Processed() bool {
+	switch w.Status {
+	case WithdrawStatusCaptured, WithdrawStatusPartiallyRefunded, WithdrawStatusRefunded:
+		return true
+	}
+
+	return false
+}
+
+// RefundedStatus is the status of a processed withdrawal of amount after
+// refunded points were returned.
+func RefundedStatus(amount, refunded Money) string {
+	switch {
+	case refunded == 0:
+		return WithdrawStatusCaptured
+	case refunded < amount:
+		return WithdrawStatusPartiallyRefunded
+	}
+
+	return WithdrawStatusRefunded
 }
 
 type WithdrawResponse struct {
 	ProcessedAt time.Time `json:"processed_at"`
 	Order       string    `json:"order"`
-	Sum         float64   `json:"sum"`
+	Currency    string    `json:"currency"`
+	Status      string    `json:"status"`
+	Sum         Money     `json:"sum"`
+	Refunded    Money     `json:"refunded,omitempty"`
+}
+
+type WithdrawHoldResponse struct {
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Order     string     `json:"order"`
+	Currency  string     `json:"currency"`
+	Status    string     `json:"status"`
+	Sum       Money      `json:"sum"`
+}
+
+// RefundWithdrawRequest returns Amount points of a withdrawal, or all that
+// is left of it when Amount is zero. Retrying with the same ID is safe.
+type RefundWithdrawRequest struct {
+	ID     string `json:"id" binding:"required,max=64"`
+	Reason string `json:"reason" binding:"required"`
+	Amount Money  `json:"amount"`
+}
+
+type WithdrawRefund struct {
+	CreatedAt     time.Time
+	ID            string
+	TransactionID string
+	OrderID       string
+	Login         string
+	Actor         string
+	// Partner limits a refund to the withdrawals at that partner, empty for
+	// admins. It is not stored.
+	Partner string
+	Reason  string
+	Amount  Money
+}
+
+type WithdrawRefundResponse struct {
+	CreatedAt time.Time `json:"created_at"`
+	ID        string    `json:"id"`
+	Order     string    `json:"order"`
+	Status    string    `json:"status"`
+	Amount    Money     `json:"amount"`
+	Refunded  Money     `json:"refunded"`
 }
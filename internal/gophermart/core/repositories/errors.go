@@ -6,4 +6,7 @@ var (
 	ErrNotFound          = errors.New("not found")
 	ErrDuplicate         = errors.New("duplicate")
 	ErrInsufficientFunds = errors.New("insufficient funds")
+	ErrAlreadyUsed       = errors.New("already used")
+	ErrInvalidState      = errors.New("invalid state")
+	ErrLimitExceeded     = errors.New("limit exceeded")
 )
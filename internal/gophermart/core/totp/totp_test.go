@@ -0,0 +1,108 @@
+package totp
+
+import (
+	"encoding/base32"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfcSecret is the SHA1 seed of the RFC 6238 test vectors.
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func fixedClock(t time.Time) func() time.Time {
+	return func() time.Time { return t }
+}
+
+func TestGenerator_Code(t *testing.T) {
+	// The RFC lists 8-digit codes; the 6-digit ones are their last digits.
+	vectors := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	g := New(nil)
+	for _, v := range vectors {
+		code, err := g.Code(rfcSecret, g.Step(time.Unix(v.unix, 0)))
+		require.NoError(t, err)
+		assert.Equal(t, v.code, code, "time %d", v.unix)
+	}
+
+	_, err := g.Code("not base32!", 1)
+	require.ErrorIs(t, err, ErrInvalidSecret)
+}
+
+func TestGenerator_Validate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	g := New(fixedClock(now))
+	current := g.Step(now)
+
+	t.Run("current step", func(t *testing.T) {
+		step, ok, err := g.Validate(rfcSecret, "050471", 0)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, current, step)
+	})
+
+	t.Run("previous step within skew", func(t *testing.T) {
+		code, err := g.Code(rfcSecret, current-1)
+		require.NoError(t, err)
+
+		step, ok, err := g.Validate(rfcSecret, code, 0)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, current-1, step)
+	})
+
+	t.Run("outside skew", func(t *testing.T) {
+		code, err := g.Code(rfcSecret, current-2)
+		require.NoError(t, err)
+
+		_, ok, err := g.Validate(rfcSecret, code, 0)
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("replay", func(t *testing.T) {
+		_, ok, err := g.Validate(rfcSecret, "050471", current)
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("wrong code", func(t *testing.T) {
+		_, ok, err := g.Validate(rfcSecret, "000000", 0)
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		_, ok, err = g.Validate(rfcSecret, "12345", 0)
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+}
+
+func TestGenerateSecretAndURI(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+
+	g := New(nil)
+	_, err = g.Code(secret, 1)
+	require.NoError(t, err)
+
+	uri, err := url.Parse(g.URI("Gophermart", "user", secret))
+	require.NoError(t, err)
+	assert.Equal(t, "otpauth", uri.Scheme)
+	assert.Equal(t, "totp", uri.Host)
+	assert.Equal(t, "/Gophermart:user", uri.Path)
+	assert.Equal(t, secret, uri.Query().Get("secret"))
+	assert.Equal(t, "6", uri.Query().Get("digits"))
+	assert.Equal(t, "30", uri.Query().Get("period"))
+}
@@ -0,0 +1,159 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"gofermart/internal/gophermart/core/application"
+	"gofermart/internal/gophermart/core/model"
+)
+
+const targetLoginParam = "login"
+
+func (h *handler) adminUser(c *gin.Context) {
+	account, err := h.server.GetAccount(context.TODO(), c.Param(targetLoginParam))
+	if err != nil {
+		h.adminError(c, "failed to get user", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, account)
+}
+
+func (h *handler) adminUserOrders(c *gin.Context) {
+	login, ok := h.adminTarget(c)
+	if !ok {
+		return
+	}
+
+	orders, err := h.server.UserOrders(context.TODO(), login)
+	if err != nil {
+		if errors.Is(err, application.ErrNotFound) {
+			c.Writer.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		h.adminError(c, "failed to get orders", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, orders)
+}
+
+func (h *handler) adminUserBalance(c *gin.Context) {
+	login, ok := h.adminTarget(c)
+	if !ok {
+		return
+	}
+
+	balance, err := h.server.UserBalance(context.TODO(), login)
+	if err != nil {
+		h.adminError(c, "failed to get balance", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, balance)
+}
+
+func (h *handler) adminUserWithdrawals(c *gin.Context) {
+	login, ok := h.adminTarget(c)
+	if !ok {
+		return
+	}
+
+	list, err := h.server.UserWithdrawals(context.TODO(), login)
+	if err != nil {
+		if errors.Is(err, application.ErrNotFound) {
+			c.Writer.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		h.adminError(c, "failed to get user withdrawals", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+func (h *handler) adminDisableUser(c *gin.Context) {
+	err := h.server.DisableUser(context.TODO(), c.GetString(loginKey), c.Param(targetLoginParam))
+	if err != nil {
+		h.adminError(c, "failed to disable user", err)
+		return
+	}
+
+	c.Writer.WriteHeader(http.StatusOK)
+}
+
+func (h *handler) adminEnableUser(c *gin.Context) {
+	err := h.server.EnableUser(context.TODO(), c.GetString(loginKey), c.Param(targetLoginParam))
+	if err != nil {
+		h.adminError(c, "failed to enable user", err)
+		return
+	}
+
+	c.Writer.WriteHeader(http.StatusOK)
+}
+
+func (h *handler) adminUnlockUser(c *gin.Context) {
+	login, ok := h.adminTarget(c)
+	if !ok {
+		return
+	}
+
+	if err := h.server.UnlockUser(context.TODO(), login); err != nil {
+		h.adminError(c, "failed to unlock user", err)
+		return
+	}
+
+	h.logger.Infof("user %s unlocked by %s", login, c.GetString(loginKey))
+
+	c.Writer.WriteHeader(http.StatusOK)
+}
+
+func (h *handler) adminSetUserRole(c *gin.Context) {
+	var request model.SetRoleRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		h.logger.Errorf("failed to bind request: %v", err)
+		c.Writer.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err := h.server.SetUserRole(context.TODO(), c.GetString(loginKey), c.Param(targetLoginParam), request.Role)
+	if err != nil {
+		if errors.Is(err, application.ErrInvalidRole) {
+			c.JSON(http.StatusBadRequest, model.ErrorResponse{Error: err.Error()})
+			return
+		}
+
+		h.adminError(c, "failed to set user role", err)
+		return
+	}
+
+	c.Writer.WriteHeader(http.StatusOK)
+}
+
+// adminTarget resolves the user named in the path, so that lookups of an
+// unknown login answer 404 instead of an empty result.
+func (h *handler) adminTarget(c *gin.Context) (string, bool) {
+	account, err := h.server.GetAccount(context.TODO(), c.Param(targetLoginParam))
+	if err != nil {
+		h.adminError(c, "failed to get user", err)
+		return "", false
+	}
+
+	return account.Login, true
+}
+
+func (h *handler) adminError(c *gin.Context, msg string, err error) {
+	if errors.Is(err, application.ErrUserNotFound) {
+		c.Writer.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	h.logger.Errorf("%s: %v", msg, err)
+	c.Writer.WriteHeader(http.StatusInternalServerError)
+}
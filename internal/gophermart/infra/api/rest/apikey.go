@@ -0,0 +1,62 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"gofermart/internal/gophermart/core/application"
+	"gofermart/internal/gophermart/core/model"
+)
+
+func (h *handler) createAPIKey(c *gin.Context) {
+	var request model.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		h.logger.Errorf("failed to bind request: %v", err)
+		c.Writer.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	key, err := h.server.CreateAPIKey(context.TODO(), c.GetString(loginKey), request)
+	if err != nil {
+		if errors.Is(err, application.ErrInvalidScope) {
+			c.JSON(http.StatusBadRequest, model.ErrorResponse{Error: err.Error()})
+			return
+		}
+
+		h.logger.Errorf("failed to create api key: %v", err)
+		c.Writer.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusCreated, key)
+}
+
+func (h *handler) listAPIKeys(c *gin.Context) {
+	keys, err := h.server.ListAPIKeys(context.TODO(), c.GetString(loginKey))
+	if err != nil {
+		h.logger.Errorf("failed to list api keys: %v", err)
+		c.Writer.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+func (h *handler) revokeAPIKey(c *gin.Context) {
+	err := h.server.RevokeAPIKey(context.TODO(), c.GetString(loginKey), c.Param("id"))
+	if err != nil {
+		if errors.Is(err, application.ErrAPIKeyNotFound) {
+			c.Writer.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		h.logger.Errorf("failed to revoke api key: %v", err)
+		c.Writer.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	c.Writer.WriteHeader(http.StatusNoContent)
+}
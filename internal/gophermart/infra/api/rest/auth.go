@@ -3,7 +3,10 @@ package rest
 import (
 	"context"
 	"errors"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
@@ -12,7 +15,12 @@ import (
 )
 
 const (
-	cookieMaxAge = 3600
+	accessCookie  = "Authorization"
+	refreshCookie = "Refresh"
+
+	refreshCookiePath = "/api/user"
+
+	bearerScheme = "Bearer"
 )
 
 func (h *handler) userRegister(c *gin.Context) {
@@ -23,21 +31,34 @@ func (h *handler) userRegister(c *gin.Context) {
 		return
 	}
 
-	token, err := h.server.UserRegister(context.TODO(), request)
+	tokens, err := h.server.UserRegister(context.TODO(), request, clientInfo(c))
 	if err != nil {
 		if errors.Is(err, application.ErrUserExists) {
 			c.Writer.WriteHeader(http.StatusConflict)
 			return
 		}
 
+		if errors.Is(err, application.ErrWeakPassword) || errors.Is(err, application.ErrInvalidReferralCode) {
+			c.JSON(http.StatusBadRequest, model.ErrorResponse{Error: err.Error()})
+			return
+		}
+
+		if errors.Is(err, application.ErrSelfReferral) {
+			c.JSON(http.StatusForbidden, model.ErrorResponse{Error: err.Error()})
+			return
+		}
+
+		if errors.Is(err, application.ErrReferralLimitReached) {
+			c.JSON(http.StatusUnprocessableEntity, model.ErrorResponse{Error: err.Error()})
+			return
+		}
+
 		h.logger.Errorf("failed to register user: %v", err)
 		c.Writer.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
-	c.SetCookie("Authorization", token, cookieMaxAge, "/", "", false, true)
-
-	c.Writer.WriteHeader(http.StatusOK)
+	writeTokens(c, tokens)
 }
 
 func (h *handler) userLogin(c *gin.Context) {
@@ -48,19 +69,204 @@ func (h *handler) userLogin(c *gin.Context) {
 		return
 	}
 
-	token, err := h.server.UserLogin(context.TODO(), request)
+	tokens, err := h.server.UserLogin(context.TODO(), request, clientInfo(c))
+	if err != nil {
+		var mfaRequired *application.MFARequiredError
+		if errors.As(err, &mfaRequired) {
+			c.JSON(http.StatusAccepted, model.MFARequiredResponse{MFARequired: true, MFAToken: mfaRequired.Token})
+			return
+		}
+
+		h.loginError(c, err)
+		return
+	}
+
+	writeTokens(c, tokens)
+}
+
+func (h *handler) userLoginMFA(c *gin.Context) {
+	var request model.MFALoginRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		h.logger.Errorf("failed to bind request: %v", err)
+		c.Writer.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	tokens, err := h.server.CompleteMFALogin(context.TODO(), request, clientInfo(c))
+	if err != nil {
+		h.loginError(c, err)
+		return
+	}
+
+	writeTokens(c, tokens)
+}
+
+func (h *handler) loginError(c *gin.Context, err error) {
+	if throttledError(c, err) {
+		return
+	}
+
+	if errors.Is(err, application.ErrUserNotFound) ||
+		errors.Is(err, application.ErrIncorrectPass) ||
+		errors.Is(err, application.ErrInvalidMFACode) ||
+		errors.Is(err, application.ErrInvalidToken) {
+		c.Writer.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	if errors.Is(err, application.ErrUserDisabled) {
+		c.Writer.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	h.logger.Errorf("failed to login user: %v", err)
+	c.Writer.WriteHeader(http.StatusInternalServerError)
+}
+
+// throttledError answers a login or second factor attempt that was turned
+// down for too many failures and tells whether err was one.
+func throttledError(c *gin.Context, err error) bool {
+	var throttled *application.LoginThrottledError
+	if !errors.As(err, &throttled) {
+		return false
+	}
+
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+
+	if errors.Is(err, application.ErrAccountLocked) {
+		c.Writer.WriteHeader(http.StatusLocked)
+		return true
+	}
+
+	c.Writer.WriteHeader(http.StatusTooManyRequests)
+
+	return true
+}
+
+func (h *handler) userRefresh(c *gin.Context) {
+	refreshToken := refreshTokenFromRequest(c)
+	if refreshToken == "" {
+		c.Writer.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	tokens, err := h.server.RefreshTokens(context.TODO(), refreshToken)
 	if err != nil {
-		if errors.Is(err, application.ErrUserNotFound) ||
-			errors.Is(err, application.ErrIncorrectPass) {
+		if errors.Is(err, application.ErrInvalidToken) ||
+			errors.Is(err, application.ErrTokenRevoked) ||
+			errors.Is(err, application.ErrRefreshTokenReuse) {
+			clearTokenCookies(c)
 			c.Writer.WriteHeader(http.StatusUnauthorized)
 			return
 		}
 
-		h.logger.Errorf("failed to login user: %v", err)
+		if errors.Is(err, application.ErrUserDisabled) {
+			clearTokenCookies(c)
+			c.Writer.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		h.logger.Errorf("failed to refresh tokens: %v", err)
+		c.Writer.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeTokens(c, tokens)
+}
+
+func (h *handler) userChangePassword(c *gin.Context) {
+	var request model.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		h.logger.Errorf("failed to bind request: %v", err)
+		c.Writer.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err := h.server.ChangePassword(context.TODO(), c.GetString(loginKey), c.GetString(sessionKey), request)
+	if err != nil {
+		if errors.Is(err, application.ErrIncorrectPass) {
+			c.Writer.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		if errors.Is(err, application.ErrWeakPassword) {
+			c.JSON(http.StatusBadRequest, model.ErrorResponse{Error: err.Error()})
+			return
+		}
+
+		h.logger.Errorf("failed to change password: %v", err)
+		c.Writer.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	c.Writer.WriteHeader(http.StatusOK)
+}
+
+func (h *handler) userLogout(c *gin.Context) {
+	err := h.server.UserLogout(context.TODO(), c.GetString(tokenKey), refreshTokenFromRequest(c))
+	if err != nil {
+		h.logger.Errorf("failed to logout user: %v", err)
 		c.Writer.WriteHeader(http.StatusInternalServerError)
+		return
 	}
 
-	c.SetCookie("Authorization", token, cookieMaxAge, "/", "", false, true)
+	clearTokenCookies(c)
 
 	c.Writer.WriteHeader(http.StatusOK)
 }
+
+func clientInfo(c *gin.Context) model.ClientInfo {
+	return model.ClientInfo{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+}
+
+// refreshTokenFromRequest takes the refresh token from its cookie or,
+// for clients without a cookie jar, from the JSON body.
+func refreshTokenFromRequest(c *gin.Context) string {
+	if cookie, err := c.Cookie(refreshCookie); err == nil && cookie != "" {
+		return cookie
+	}
+
+	var request model.RefreshRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		return ""
+	}
+
+	return request.RefreshToken
+}
+
+// writeTokens hands the tokens out both as cookies for browsers and as
+// an Authorization header and JSON body for clients without a cookie jar.
+func writeTokens(c *gin.Context, tokens model.AuthTokens) {
+	setTokenCookies(c, tokens)
+
+	c.Header("Authorization", bearerScheme+" "+tokens.AccessToken)
+	c.JSON(http.StatusOK, model.TokenResponse{
+		AccessToken:  tokens.AccessToken,
+		TokenType:    bearerScheme,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    int64(secondsUntil(tokens.AccessExpiresAt)),
+	})
+}
+
+func setTokenCookies(c *gin.Context, tokens model.AuthTokens) {
+	c.SetCookie(accessCookie, tokens.AccessToken, secondsUntil(tokens.AccessExpiresAt), "/", "", false, true)
+	c.SetCookie(refreshCookie, tokens.RefreshToken, secondsUntil(tokens.RefreshExpiresAt),
+		refreshCookiePath, "", false, true)
+}
+
+func clearTokenCookies(c *gin.Context) {
+	c.SetCookie(accessCookie, "", -1, "/", "", false, true)
+	c.SetCookie(refreshCookie, "", -1, refreshCookiePath, "", false, true)
+}
+
+func secondsUntil(t time.Time) int {
+	return int(time.Until(t).Seconds())
+}
+
+func (h *handler) jwks(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.server.JWKS())
+}
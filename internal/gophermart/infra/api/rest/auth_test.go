@@ -0,0 +1,90 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"gofermart/internal/gophermart/core/model"
+)
+
+func TestHandler_WriteTokens(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tokens := model.AuthTokens{
+		AccessToken:      "access-token",
+		RefreshToken:     "refresh-token",
+		AccessExpiresAt:  time.Now().Add(15 * time.Minute),
+		RefreshExpiresAt: time.Now().Add(24 * time.Hour),
+	}
+
+	tests := []struct {
+		name  string
+		path  string
+		body  string
+		setup func(server *MockServer)
+	}{
+		{
+			name: "login",
+			path: "/api/user/login",
+			body: `{"login":"login","password":"password"}`,
+			setup: func(server *MockServer) {
+				server.On("UserLogin", mock.Anything, model.User{Login: "login", Password: "password"}, mock.Anything).
+					Return(tokens, nil)
+			},
+		},
+		{
+			name: "refresh without cookies",
+			path: "/api/user/refresh",
+			body: `{"refresh_token":"old-refresh-token"}`,
+			setup: func(server *MockServer) {
+				server.On("RefreshTokens", mock.Anything, "old-refresh-token").Return(tokens, nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := new(MockServer)
+			tt.setup(server)
+
+			h := &handler{server: server, logger: *zap.NewNop().Sugar()}
+			router := gin.New()
+			router.POST("/api/user/login", h.userLogin)
+			router.POST("/api/user/refresh", h.userRefresh)
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body)))
+
+			require.Equal(t, http.StatusOK, recorder.Code)
+			assert.Equal(t, "Bearer access-token", recorder.Header().Get("Authorization"))
+
+			var response model.TokenResponse
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+			assert.Equal(t, "access-token", response.AccessToken)
+			assert.Equal(t, "refresh-token", response.RefreshToken)
+			assert.Equal(t, bearerScheme, response.TokenType)
+			assert.InDelta(t, (15 * time.Minute).Seconds(), response.ExpiresIn, 5)
+
+			// Browsers still get the cookies.
+			result := recorder.Result()
+			defer result.Body.Close()
+
+			cookies := make(map[string]string)
+			for _, cookie := range result.Cookies() {
+				cookies[cookie.Name] = cookie.Value
+			}
+			assert.Equal(t, map[string]string{accessCookie: "access-token", refreshCookie: "refresh-token"}, cookies)
+			server.AssertExpectations(t)
+		})
+	}
+}
@@ -2,9 +2,13 @@ package rest
 
 import (
 	"context"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+
+	"gofermart/internal/gophermart/core/application"
+	"gofermart/internal/gophermart/core/model"
 )
 
 const loginKey = "login"
@@ -21,3 +25,41 @@ func (h *handler) userBalance(c *gin.Context) {
 
 	c.JSON(http.StatusOK, balance)
 }
+
+func (h *handler) userWallets(c *gin.Context) {
+	login := c.GetString(loginKey)
+
+	wallets, err := h.server.UserWallets(context.TODO(), login)
+	if err != nil {
+		h.logger.Errorf("failed to get wallets: %v", err)
+		c.Writer.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, wallets)
+}
+
+func (h *handler) userBalanceHistory(c *gin.Context) {
+	login := c.GetString(loginKey)
+
+	var request model.BalanceHistoryRequest
+	if err := c.ShouldBindQuery(&request); err != nil {
+		h.logger.Errorf("failed to bind request: %v", err)
+		c.Writer.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	history, err := h.server.UserBalanceHistory(context.TODO(), login, request)
+	if err != nil {
+		if errors.Is(err, application.ErrInvalidFilter) {
+			c.JSON(http.StatusBadRequest, model.ErrorResponse{Error: err.Error()})
+			return
+		}
+
+		h.logger.Errorf("failed to get balance history: %v", err)
+		c.Writer.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
@@ -0,0 +1,89 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"gofermart/internal/gophermart/core/application"
+	"gofermart/internal/gophermart/core/model"
+)
+
+func (h *handler) userHoldWithdraw(c *gin.Context) {
+	login := c.GetString(loginKey)
+
+	var request model.WithdrawRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		if errors.Is(err, model.ErrInvalidMoney) || errors.Is(err, model.ErrMoneyPrecision) {
+			c.JSON(http.StatusBadRequest, model.ErrorResponse{Error: err.Error()})
+			return
+		}
+
+		h.logger.Errorf("failed to bind request: %v", err)
+		c.Writer.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	request.TOTPCode = c.GetHeader(totpCodeHeader)
+
+	hold, err := h.server.HoldWithdraw(context.TODO(), login, request)
+	if err != nil {
+		if h.withdrawLimitError(c, err) || throttledError(c, err) {
+			return
+		}
+
+		switch {
+		case errors.Is(err, application.ErrTOTPRequired), errors.Is(err, application.ErrInvalidMFACode):
+			c.JSON(http.StatusForbidden, model.ErrorResponse{Error: err.Error()})
+		case errors.Is(err, application.ErrInvalidAmount), errors.Is(err, model.ErrInvalidCurrency),
+			errors.Is(err, application.ErrPartnerNotFound):
+			c.JSON(http.StatusBadRequest, model.ErrorResponse{Error: err.Error()})
+		case errors.Is(err, application.ErrInsufficientFunds):
+			c.Writer.WriteHeader(http.StatusPaymentRequired)
+		case errors.Is(err, application.ErrInvalidOrderID):
+			c.Writer.WriteHeader(http.StatusUnprocessableEntity)
+		case errors.Is(err, application.ErrWithdrawExists):
+			c.JSON(http.StatusConflict, model.ErrorResponse{Error: err.Error()})
+		default:
+			h.logger.Errorf("failed to hold withdrawal: %v", err)
+			c.Writer.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, hold)
+}
+
+func (h *handler) userCaptureHold(c *gin.Context) {
+	err := h.server.CaptureWithdraw(context.TODO(), c.GetString(loginKey), c.Param("order"))
+	if err != nil {
+		h.holdError(c, "failed to capture hold", err)
+		return
+	}
+
+	c.Writer.WriteHeader(http.StatusOK)
+}
+
+func (h *handler) userReleaseHold(c *gin.Context) {
+	err := h.server.ReleaseWithdraw(context.TODO(), c.GetString(loginKey), c.Param("order"))
+	if err != nil {
+		h.holdError(c, "failed to release hold", err)
+		return
+	}
+
+	c.Writer.WriteHeader(http.StatusOK)
+}
+
+func (h *handler) holdError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, application.ErrHoldNotFound):
+		c.JSON(http.StatusNotFound, model.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, application.ErrHoldNotActive):
+		c.JSON(http.StatusConflict, model.ErrorResponse{Error: err.Error()})
+	default:
+		h.logger.Errorf("%s: %v", message, err)
+		c.Writer.WriteHeader(http.StatusInternalServerError)
+	}
+}
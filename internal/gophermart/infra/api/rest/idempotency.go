@@ -0,0 +1,115 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"gofermart/internal/gophermart/core/application"
+	"gofermart/internal/gophermart/core/model"
+)
+
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// recordingResponseWriter keeps a copy of the response body next to
+// writing it out.
+type recordingResponseWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingResponseWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data) //nolint:wrapcheck // the writer is only wrapped
+}
+
+func (w *recordingResponseWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s) //nolint:wrapcheck // the writer is only wrapped
+}
+
+// idempotencyMiddleware replays the first response to requests that repeat
+// an Idempotency-Key of the same user. Requests without the header pass
+// through. It has to run after authentication.
+func (h *handler) idempotencyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(idempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		body, err := c.GetRawData()
+		if err != nil {
+			h.logger.Errorf("failed to read body: %v", err)
+			c.Writer.WriteHeader(http.StatusBadRequest)
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		login := c.GetString(loginKey)
+
+		replay, err := h.server.StartIdempotentRequest(context.TODO(), login, key,
+			requestFingerprint(c.Request, body))
+		if err != nil {
+			switch {
+			case errors.Is(err, application.ErrInvalidIdempotencyKey):
+				c.JSON(http.StatusBadRequest, model.ErrorResponse{Error: err.Error()})
+			case errors.Is(err, application.ErrIdempotencyKeyReused):
+				c.JSON(http.StatusUnprocessableEntity, model.ErrorResponse{Error: err.Error()})
+			case errors.Is(err, application.ErrIdempotentRequestInProgress):
+				c.JSON(http.StatusConflict, model.ErrorResponse{Error: err.Error()})
+			default:
+				h.logger.Errorf("failed to start idempotent request: %v", err)
+				c.Writer.WriteHeader(http.StatusInternalServerError)
+			}
+			c.Abort()
+			return
+		}
+
+		if replay != nil {
+			c.Header(idempotentReplayedHeader, "true")
+			if len(replay.Body) == 0 {
+				c.Writer.WriteHeader(replay.StatusCode)
+			} else {
+				c.Data(replay.StatusCode, replay.ContentType, replay.Body)
+			}
+			c.Abort()
+			return
+		}
+
+		writer := &recordingResponseWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		c.Next()
+
+		err = h.server.FinishIdempotentRequest(context.TODO(), login, key, model.IdempotentResponse{
+			StatusCode:  writer.Status(),
+			ContentType: writer.Header().Get("Content-Type"),
+			Body:        writer.body.Bytes(),
+		})
+		if err != nil {
+			h.logger.Errorf("failed to finish idempotent request: %v", err)
+		}
+	}
+}
+
+// requestFingerprint tells retries of a request from another request that
+// reuses the key.
+func requestFingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + "\n" + r.URL.Path + "\n"))
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
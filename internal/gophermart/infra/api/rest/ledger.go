@@ -0,0 +1,79 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"gofermart/internal/gophermart/core/application"
+	"gofermart/internal/gophermart/core/model"
+)
+
+func (h *handler) adminUserLedger(c *gin.Context) {
+	login, ok := h.adminTarget(c)
+	if !ok {
+		return
+	}
+
+	entries, err := h.server.UserLedger(context.TODO(), login)
+	if err != nil {
+		h.adminError(c, "failed to get user ledger", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+func (h *handler) adminAdjustBalance(c *gin.Context) {
+	var request model.AdjustBalanceRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		h.logger.Errorf("failed to bind request: %v", err)
+		c.Writer.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	transaction, err := h.server.AdjustBalance(context.TODO(), c.GetString(loginKey),
+		c.Param(targetLoginParam), request)
+	if err != nil {
+		switch {
+		case errors.Is(err, application.ErrInvalidAmount), errors.Is(err, model.ErrInvalidCurrency):
+			c.JSON(http.StatusBadRequest, model.ErrorResponse{Error: err.Error()})
+		case errors.Is(err, application.ErrInsufficientFunds):
+			c.JSON(http.StatusUnprocessableEntity, model.ErrorResponse{Error: err.Error()})
+		default:
+			h.adminError(c, "failed to adjust balance", err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, transaction)
+}
+
+func (h *handler) adminReverseTransaction(c *gin.Context) {
+	var request model.ReverseTransactionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		h.logger.Errorf("failed to bind request: %v", err)
+		c.Writer.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	transaction, err := h.server.ReverseTransaction(context.TODO(), c.GetString(loginKey), c.Param("id"), request)
+	if err != nil {
+		switch {
+		case errors.Is(err, application.ErrTransactionNotFound):
+			c.Writer.WriteHeader(http.StatusNotFound)
+		case errors.Is(err, application.ErrAlreadyReversed), errors.Is(err, application.ErrNotReversible):
+			c.JSON(http.StatusConflict, model.ErrorResponse{Error: err.Error()})
+		case errors.Is(err, application.ErrInsufficientFunds):
+			c.JSON(http.StatusUnprocessableEntity, model.ErrorResponse{Error: err.Error()})
+		default:
+			h.logger.Errorf("failed to reverse transaction: %v", err)
+			c.Writer.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, transaction)
+}
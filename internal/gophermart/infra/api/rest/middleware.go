@@ -2,9 +2,11 @@ package rest
 
 import (
 	"compress/gzip"
+	"context"
 	"fmt"
 	"io"
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -94,26 +96,133 @@ func (h *handler) responseGzipMiddleware() gin.HandlerFunc {
 	}
 }
 
+const (
+	tokenKey   = "token"
+	roleKey    = "role"
+	sessionKey = "session"
+
+	apiKeyHeader = "X-API-Key"
+	apiKeyScheme = "ApiKey"
+)
+
+// validationJWTMiddleware accepts access tokens only. Routes that partners
+// may call with an API key use authMiddleware instead.
 func (h *handler) validationJWTMiddleware() gin.HandlerFunc {
+	return h.authenticateJWT
+}
+
+// authMiddleware accepts an access token or an API key that was granted
+// scope. Either way the caller ends up under loginKey.
+func (h *handler) authMiddleware(scope string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		cookie, err := c.Cookie("Authorization")
+		key := apiKeyFromRequest(c)
+		if key == "" {
+			h.authenticateJWT(c)
+			return
+		}
+
+		principal, err := h.server.ValidateAPIKey(context.TODO(), key)
 		if err != nil {
-			h.logger.Errorf("failed to get cookie: %v", err)
+			h.logger.Errorf("failed to validate api key: %v", err)
 			c.Writer.WriteHeader(http.StatusUnauthorized)
 			c.Abort()
 			return
 		}
 
-		login, err := h.server.ValidateToken(cookie)
-		if err != nil {
-			h.logger.Errorf("failed to validate token: %v", err)
-			c.Writer.WriteHeader(http.StatusUnauthorized)
+		if !slices.Contains(principal.Scopes, scope) {
+			h.logger.Warnf("api key of %s lacks scope %s", principal.Login, scope)
+			c.Writer.WriteHeader(http.StatusForbidden)
 			c.Abort()
 			return
 		}
 
-		c.Set(loginKey, login)
+		c.Set(loginKey, principal.Login)
+		c.Set(roleKey, principal.Role)
+
+		c.Next()
+	}
+}
+
+func (h *handler) authenticateJWT(c *gin.Context) {
+	token := h.tokenFromRequest(c)
+	if token == "" {
+		h.logger.Error("failed to get token: no bearer header or cookie")
+		c.Writer.WriteHeader(http.StatusUnauthorized)
+		c.Abort()
+		return
+	}
+
+	principal, err := h.server.ValidateToken(context.TODO(), token)
+	if err != nil {
+		h.logger.Errorf("failed to validate token: %v", err)
+		c.Writer.WriteHeader(http.StatusUnauthorized)
+		c.Abort()
+		return
+	}
+
+	c.Set(loginKey, principal.Login)
+	c.Set(roleKey, principal.Role)
+	c.Set(sessionKey, principal.SessionID)
+	c.Set(tokenKey, token)
+
+	c.Next()
+}
+
+func apiKeyFromRequest(c *gin.Context) string {
+	if key := c.GetHeader(apiKeyHeader); key != "" {
+		return strings.TrimSpace(key)
+	}
+
+	scheme, key, ok := strings.Cut(c.GetHeader("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, apiKeyScheme) {
+		return ""
+	}
+
+	return strings.TrimSpace(key)
+}
+
+// requireRole lets the request through only if the caller has one of the
+// roles. It must run after validationJWTMiddleware.
+func (h *handler) requireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !slices.Contains(roles, c.GetString(roleKey)) {
+			h.logger.Warnf("user %s with role %q denied access to %s",
+				c.GetString(loginKey), c.GetString(roleKey), c.FullPath())
+			c.Writer.WriteHeader(http.StatusForbidden)
+			c.Abort()
+			return
+		}
 
 		c.Next()
 	}
 }
+
+// tokenFromRequest returns the first token found in the order set by the
+// configured precedence. A malformed header is not a reason to fall back to
+// the cookie: the client meant to use the header.
+func (h *handler) tokenFromRequest(c *gin.Context) string {
+	cookie, _ := c.Cookie(accessCookie)
+
+	if h.tokenPrecedence == TokenFromCookie {
+		if cookie != "" {
+			return cookie
+		}
+
+		return bearerToken(c)
+	}
+
+	if c.GetHeader("Authorization") != "" {
+		return bearerToken(c)
+	}
+
+	return cookie
+}
+
+func bearerToken(c *gin.Context) string {
+	scheme, token, ok := strings.Cut(c.GetHeader("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, bearerScheme) {
+		return ""
+	}
+
+	return strings.TrimSpace(token)
+}
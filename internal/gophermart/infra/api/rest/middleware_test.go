@@ -0,0 +1,73 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+
+	"gofermart/internal/gophermart/core/model"
+)
+
+func TestHandler_TokenFromRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		precedence string
+		header     string
+		cookie     string
+		want       string
+	}{
+		{name: "header only", header: "Bearer header-token", want: "header-token"},
+		{name: "cookie only", cookie: "cookie-token", want: "cookie-token"},
+		{name: "header wins by default", header: "Bearer header-token", cookie: "cookie-token", want: "header-token"},
+		{name: "header wins", precedence: TokenFromHeader, header: "bearer header-token", cookie: "cookie-token",
+			want: "header-token"},
+		{name: "cookie wins", precedence: TokenFromCookie, header: "Bearer header-token", cookie: "cookie-token",
+			want: "cookie-token"},
+		{name: "header without cookie", precedence: TokenFromCookie, header: "Bearer header-token",
+			want: "header-token"},
+		{name: "malformed header", header: "Basic dXNlcjpwYXNz", cookie: "cookie-token"},
+		{name: "no token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := new(MockServer)
+			if tt.want != "" {
+				server.On("ValidateToken", mock.Anything, tt.want).
+					Return(model.Principal{Login: "login", SessionID: "session"}, nil)
+			}
+
+			h := &handler{server: server, logger: *zap.NewNop().Sugar(), tokenPrecedence: tt.precedence}
+			router := gin.New()
+			router.GET("/", h.validationJWTMiddleware(), func(c *gin.Context) {
+				c.String(http.StatusOK, c.GetString(loginKey))
+			})
+
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				request.Header.Set("Authorization", tt.header)
+			}
+			if tt.cookie != "" {
+				request.AddCookie(&http.Cookie{Name: accessCookie, Value: tt.cookie})
+			}
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+
+			if tt.want == "" {
+				assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+			} else {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+				assert.Equal(t, "login", recorder.Body.String())
+			}
+			server.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,33 @@
+//nolint:wrapcheck,forcetypeassert
+package rest
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+
+	"gofermart/internal/gophermart/core/model"
+)
+
+// MockServer mocks the calls the tests make. Any other call panics on the
+// nil ServerService.
+type MockServer struct {
+	ServerService
+	mock.Mock
+}
+
+func (m *MockServer) UserLogin(ctx context.Context, request model.User,
+	client model.ClientInfo) (model.AuthTokens, error) {
+	args := m.Called(ctx, request, client)
+	return args.Get(0).(model.AuthTokens), args.Error(1)
+}
+
+func (m *MockServer) RefreshTokens(ctx context.Context, refreshToken string) (model.AuthTokens, error) {
+	args := m.Called(ctx, refreshToken)
+	return args.Get(0).(model.AuthTokens), args.Error(1)
+}
+
+func (m *MockServer) ValidateToken(ctx context.Context, tokenString string) (model.Principal, error) {
+	args := m.Called(ctx, tokenString)
+	return args.Get(0).(model.Principal), args.Error(1)
+}
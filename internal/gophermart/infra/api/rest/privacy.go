@@ -0,0 +1,112 @@
+package rest
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"gofermart/internal/gophermart/core/application"
+	"gofermart/internal/gophermart/core/model"
+)
+
+const exportFormatZip = "zip"
+
+func (h *handler) userExport(c *gin.Context) {
+	export, err := h.server.ExportUserData(context.TODO(), c.GetString(loginKey))
+	if err != nil {
+		h.logger.Errorf("failed to export user data: %v", err)
+		c.Writer.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if c.Query("format") != exportFormatZip {
+		c.Header("Content-Disposition", `attachment; filename="gophermart-export.json"`)
+		c.JSON(http.StatusOK, export)
+		return
+	}
+
+	archive, err := exportArchive(export)
+	if err != nil {
+		h.logger.Errorf("failed to build export archive: %v", err)
+		c.Writer.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="gophermart-export.zip"`)
+	c.Data(http.StatusOK, "application/zip", archive)
+}
+
+// exportArchive splits the export into one JSON file per section.
+func exportArchive(export model.UserExport) ([]byte, error) {
+	files := []struct {
+		data any
+		name string
+	}{
+		{name: "profile.json", data: struct {
+			model.AccountResponse
+			TOTPEnabled bool `json:"totp_enabled"`
+		}{export.Profile, export.TOTPEnabled}},
+		{name: "balance.json", data: export.Balance},
+		{name: "orders.json", data: export.Orders},
+		{name: "withdrawals.json", data: export.Withdrawals},
+		{name: "sessions.json", data: export.Sessions},
+		{name: "api_keys.json", data: export.APIKeys},
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+
+	for _, file := range files {
+		w, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     file.name,
+			Method:   zip.Deflate,
+			Modified: export.ExportedAt,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("can't create %s: %w", file.name, err)
+		}
+
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.data); err != nil {
+			return nil, fmt.Errorf("can't encode %s: %w", file.name, err)
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, fmt.Errorf("can't close archive: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (h *handler) userDelete(c *gin.Context) {
+	var request model.DeleteAccountRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		h.logger.Errorf("failed to bind request: %v", err)
+		c.Writer.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err := h.server.DeleteUser(context.TODO(), c.GetString(loginKey), request)
+	if err != nil {
+		if errors.Is(err, application.ErrIncorrectPass) {
+			c.Writer.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		h.logger.Errorf("failed to delete user: %v", err)
+		c.Writer.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	clearTokenCookies(c)
+
+	c.Writer.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,114 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"gofermart/internal/gophermart/core/application"
+	"gofermart/internal/gophermart/core/model"
+)
+
+func (h *handler) adminCreatePromotion(c *gin.Context) {
+	var request model.PromotionRequest
+	if !h.bindPromotion(c, &request) {
+		return
+	}
+
+	promotion, err := h.server.CreatePromotion(context.TODO(), c.GetString(loginKey), request)
+	if err != nil {
+		h.promotionError(c, "failed to create promotion", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, promotion)
+}
+
+func (h *handler) adminUpdatePromotion(c *gin.Context) {
+	var request model.PromotionRequest
+	if !h.bindPromotion(c, &request) {
+		return
+	}
+
+	promotion, err := h.server.UpdatePromotion(context.TODO(), c.GetString(loginKey), c.Param("id"), request)
+	if err != nil {
+		h.promotionError(c, "failed to update promotion", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, promotion)
+}
+
+func (h *handler) adminDeletePromotion(c *gin.Context) {
+	if err := h.server.DeletePromotion(context.TODO(), c.GetString(loginKey), c.Param("id")); err != nil {
+		h.promotionError(c, "failed to delete promotion", err)
+		return
+	}
+
+	c.Writer.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) adminPromotions(c *gin.Context) {
+	promotions, err := h.server.Promotions(context.TODO())
+	if err != nil {
+		h.promotionError(c, "failed to get promotions", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, promotions)
+}
+
+func (h *handler) adminPromotion(c *gin.Context) {
+	promotion, err := h.server.Promotion(context.TODO(), c.Param("id"))
+	if err != nil {
+		h.promotionError(c, "failed to get promotion", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, promotion)
+}
+
+func (h *handler) adminPromotionAwards(c *gin.Context) {
+	if _, err := h.server.Promotion(context.TODO(), c.Param("id")); err != nil {
+		h.promotionError(c, "failed to get promotion", err)
+		return
+	}
+
+	awards, err := h.server.PromotionAwards(context.TODO(), c.Param("id"))
+	if err != nil {
+		h.promotionError(c, "failed to get promotion awards", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, awards)
+}
+
+func (h *handler) bindPromotion(c *gin.Context, request *model.PromotionRequest) bool {
+	if err := c.ShouldBindJSON(request); err != nil {
+		if errors.Is(err, model.ErrInvalidMoney) || errors.Is(err, model.ErrMoneyPrecision) ||
+			errors.Is(err, model.ErrInvalidMultiplier) {
+			c.JSON(http.StatusBadRequest, model.ErrorResponse{Error: err.Error()})
+			return false
+		}
+
+		h.logger.Errorf("failed to bind request: %v", err)
+		c.Writer.WriteHeader(http.StatusBadRequest)
+		return false
+	}
+
+	return true
+}
+
+func (h *handler) promotionError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, application.ErrPromotionNotFound):
+		c.Writer.WriteHeader(http.StatusNotFound)
+	case errors.Is(err, application.ErrInvalidPromotion):
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{Error: err.Error()})
+	default:
+		h.logger.Errorf("%s: %v", message, err)
+		c.Writer.WriteHeader(http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,19 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *handler) userReferrals(c *gin.Context) {
+	referrals, err := h.server.UserReferrals(context.TODO(), c.GetString(loginKey))
+	if err != nil {
+		h.logger.Errorf("failed to get referrals: %v", err)
+		c.Writer.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, referrals)
+}
@@ -0,0 +1,53 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"gofermart/internal/gophermart/core/application"
+	"gofermart/internal/gophermart/core/model"
+)
+
+// refundWithdrawal serves both admins and partners. Partners may only
+// refund the withdrawals made at them, others are not found.
+func (h *handler) refundWithdrawal(c *gin.Context) {
+	var request model.RefundWithdrawRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		if errors.Is(err, model.ErrInvalidMoney) || errors.Is(err, model.ErrMoneyPrecision) {
+			c.JSON(http.StatusBadRequest, model.ErrorResponse{Error: err.Error()})
+			return
+		}
+
+		h.logger.Errorf("failed to bind request: %v", err)
+		c.Writer.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	actor, partner := c.GetString(loginKey), ""
+	if c.GetString(roleKey) == model.RolePartner {
+		partner = actor
+	}
+
+	refund, err := h.server.RefundWithdraw(context.TODO(), actor, partner, c.Param("order"), request)
+	if err != nil {
+		switch {
+		case errors.Is(err, application.ErrWithdrawNotFound):
+			c.Writer.WriteHeader(http.StatusNotFound)
+		case errors.Is(err, application.ErrInvalidAmount):
+			c.JSON(http.StatusBadRequest, model.ErrorResponse{Error: err.Error()})
+		case errors.Is(err, application.ErrNotRefundable), errors.Is(err, application.ErrRefundConflict):
+			c.JSON(http.StatusConflict, model.ErrorResponse{Error: err.Error()})
+		case errors.Is(err, application.ErrRefundExceeded):
+			c.JSON(http.StatusUnprocessableEntity, model.ErrorResponse{Error: err.Error()})
+		default:
+			h.logger.Errorf("failed to refund withdrawal: %v", err)
+			c.Writer.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, refund)
+}
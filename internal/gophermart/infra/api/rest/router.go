@@ -12,23 +12,86 @@ import (
 )
 
 type ServerService interface {
-	UserRegister(ctx context.Context, request model.User) (string, error)
-	UserLogin(ctx context.Context, request model.User) (string, error)
-	ValidateToken(tokenString string) (string, error)
+	UserRegister(ctx context.Context, request model.User, client model.ClientInfo) (model.AuthTokens, error)
+	UserLogin(ctx context.Context, request model.User, client model.ClientInfo) (model.AuthTokens, error)
+	CompleteMFALogin(ctx context.Context, request model.MFALoginRequest, client model.ClientInfo) (model.AuthTokens, error)
+	RefreshTokens(ctx context.Context, refreshToken string) (model.AuthTokens, error)
+	UserLogout(ctx context.Context, accessToken, refreshToken string) error
+	ChangePassword(ctx context.Context, login, sessionID string, request model.ChangePasswordRequest) error
+	ValidateToken(ctx context.Context, tokenString string) (model.Principal, error)
+	JWKS() model.JWKSet
+
+	ExportUserData(ctx context.Context, login string) (model.UserExport, error)
+	DeleteUser(ctx context.Context, login string, request model.DeleteAccountRequest) error
+	UserReferrals(ctx context.Context, login string) (model.ReferralsResponse, error)
+
+	ListSessions(ctx context.Context, login, currentID string) ([]model.SessionResponse, error)
+	RevokeSession(ctx context.Context, login, id string) error
+
+	EnrollTOTP(ctx context.Context, login string) (model.TOTPEnrollResponse, error)
+	ConfirmTOTP(ctx context.Context, login, code string) (model.RecoveryCodesResponse, error)
+	DisableTOTP(ctx context.Context, login, code string) error
+
+	CreateAPIKey(ctx context.Context, login string, request model.CreateAPIKeyRequest) (model.CreatedAPIKeyResponse, error)
+	ListAPIKeys(ctx context.Context, login string) ([]model.APIKeyResponse, error)
+	RevokeAPIKey(ctx context.Context, login, id string) error
+	ValidateAPIKey(ctx context.Context, key string) (model.Principal, error)
+
+	StartIdempotentRequest(ctx context.Context, login, key, fingerprint string) (*model.IdempotentResponse, error)
+	FinishIdempotentRequest(ctx context.Context, login, key string, response model.IdempotentResponse) error
+
+	GetAccount(ctx context.Context, login string) (model.AccountResponse, error)
+	SetUserRole(ctx context.Context, actor, login, role string) error
+	DisableUser(ctx context.Context, actor, login string) error
+	EnableUser(ctx context.Context, actor, login string) error
+	UnlockUser(ctx context.Context, login string) error
+
+	UserLedger(ctx context.Context, login string) ([]model.LedgerEntryResponse, error)
+	AdjustBalance(ctx context.Context, actor, login string,
+		request model.AdjustBalanceRequest) (model.LedgerTransactionResponse, error)
+	ReverseTransaction(ctx context.Context, actor, id string,
+		request model.ReverseTransactionRequest) (model.LedgerTransactionResponse, error)
+
+	CreatePromotion(ctx context.Context, actor string, request model.PromotionRequest) (model.PromotionResponse, error)
+	UpdatePromotion(ctx context.Context, actor, id string,
+		request model.PromotionRequest) (model.PromotionResponse, error)
+	DeletePromotion(ctx context.Context, actor, id string) error
+	Promotion(ctx context.Context, id string) (model.PromotionResponse, error)
+	Promotions(ctx context.Context) ([]model.PromotionResponse, error)
+	PromotionAwards(ctx context.Context, id string) ([]model.PromotionAwardResponse, error)
 
 	UserOrder(ctx context.Context, userLogin, orderID string) error
 	UserOrders(ctx context.Context, userLogin string) ([]model.OrderResponse, error)
 
 	UserBalance(ctx context.Context, login string) (model.UserBalanceResponse, error)
+	UserBalanceHistory(ctx context.Context, login string,
+		request model.BalanceHistoryRequest) (model.BalanceHistoryResponse, error)
+	UserWallets(ctx context.Context, login string) ([]model.WalletResponse, error)
+
+	UserTier(ctx context.Context, login string) (model.TierResponse, error)
 
 	UserWithdraw(ctx context.Context, login string, request model.WithdrawRequest) error
 	UserWithdrawals(ctx context.Context, login string) ([]model.WithdrawResponse, error)
+	HoldWithdraw(ctx context.Context, login string, request model.WithdrawRequest) (model.WithdrawHoldResponse, error)
+	CaptureWithdraw(ctx context.Context, login, orderID string) error
+	ReleaseWithdraw(ctx context.Context, login, orderID string) error
+	RefundWithdraw(ctx context.Context, actor, partner, orderID string,
+		request model.RefundWithdrawRequest) (model.WithdrawRefundResponse, error)
+	TransferPoints(ctx context.Context, login string, request model.TransferRequest) (model.TransferResponse, error)
 }
 
+const (
+	TokenFromHeader = "header"
+	TokenFromCookie = "cookie"
+)
+
 type Config struct {
 	Server ServerService
 	Logger zap.SugaredLogger
-	Port   int64
+	// TokenPrecedence decides which source wins when a request carries both
+	// a bearer header and a cookie: TokenFromHeader (default) or TokenFromCookie.
+	TokenPrecedence string
+	Port            int64
 }
 
 type Router struct {
@@ -36,14 +99,16 @@ type Router struct {
 }
 
 type handler struct {
-	server ServerService
-	logger zap.SugaredLogger
+	server          ServerService
+	logger          zap.SugaredLogger
+	tokenPrecedence string
 }
 
 func NewRouter(conf Config) *Router {
 	h := &handler{
-		server: conf.Server,
-		logger: conf.Logger,
+		server:          conf.Server,
+		logger:          conf.Logger,
+		tokenPrecedence: conf.TokenPrecedence,
 	}
 
 	router := gin.New()
@@ -52,30 +117,105 @@ func NewRouter(conf Config) *Router {
 	router.Use(h.mwDecompress())
 	router.Use(h.responseGzipMiddleware())
 
+	router.GET("/.well-known/jwks.json", h.jwks)
+
 	userGroup := router.Group("/api/user")
 	{
 		userGroup.POST("/register", h.userRegister)
 		userGroup.POST("/login", h.userLogin)
+		userGroup.POST("/login/2fa", h.userLoginMFA)
+		userGroup.POST("/refresh", h.userRefresh)
+		userGroup.POST("/logout", h.validationJWTMiddleware(), h.userLogout)
+		userGroup.PUT("/password", h.validationJWTMiddleware(), h.userChangePassword)
+		userGroup.GET("/export", h.validationJWTMiddleware(), h.userExport)
+		userGroup.DELETE("", h.validationJWTMiddleware(), h.userDelete)
+		userGroup.GET("/referrals", h.validationJWTMiddleware(), h.userReferrals)
+	}
+
+	sessionGroup := router.Group("/api/user/sessions")
+	{
+		sessionGroup.Use(h.validationJWTMiddleware())
+		sessionGroup.GET("", h.listSessions)
+		sessionGroup.DELETE("/:id", h.revokeSession)
+	}
+
+	totpGroup := router.Group("/api/user/2fa/totp")
+	{
+		totpGroup.Use(h.validationJWTMiddleware())
+		totpGroup.POST("", h.enrollTOTP)
+		totpGroup.POST("/confirm", h.confirmTOTP)
+		totpGroup.DELETE("", h.disableTOTP)
+	}
+
+	apiKeyGroup := router.Group("/api/user/api-keys")
+	{
+		apiKeyGroup.Use(h.validationJWTMiddleware())
+		apiKeyGroup.POST("", h.createAPIKey)
+		apiKeyGroup.GET("", h.listAPIKeys)
+		apiKeyGroup.DELETE("/:id", h.revokeAPIKey)
 	}
 
 	ordersGroup := router.Group("/api/user/orders")
 	{
-		ordersGroup.Use(h.validationJWTMiddleware())
-		ordersGroup.POST("", h.userOrder)
-		ordersGroup.GET("", h.userOrders)
+		ordersGroup.POST("", h.authMiddleware(model.ScopeOrdersWrite), h.idempotencyMiddleware(), h.userOrder)
+		ordersGroup.GET("", h.authMiddleware(model.ScopeOrdersRead), h.userOrders)
 	}
 
 	balanceGroup := router.Group("/api/user/balance")
 	{
-		balanceGroup.Use(h.validationJWTMiddleware())
-		balanceGroup.GET("", h.userBalance)
-		balanceGroup.POST("/withdraw", h.userWithdraw)
+		balanceGroup.GET("", h.authMiddleware(model.ScopeBalanceRead), h.userBalance)
+		balanceGroup.GET("/history", h.authMiddleware(model.ScopeBalanceRead), h.userBalanceHistory)
+		balanceGroup.POST("/withdraw", h.authMiddleware(model.ScopeWithdrawalsWrite), h.idempotencyMiddleware(),
+			h.userWithdraw)
+		balanceGroup.POST("/holds", h.authMiddleware(model.ScopeWithdrawalsWrite), h.idempotencyMiddleware(),
+			h.userHoldWithdraw)
+		balanceGroup.POST("/holds/:order/capture", h.authMiddleware(model.ScopeWithdrawalsWrite),
+			h.userCaptureHold)
+		balanceGroup.POST("/holds/:order/release", h.authMiddleware(model.ScopeWithdrawalsWrite),
+			h.userReleaseHold)
+		balanceGroup.POST("/transfer", h.authMiddleware(model.ScopeWithdrawalsWrite), h.idempotencyMiddleware(),
+			h.userTransfer)
 	}
 
+	router.GET("/api/user/tier", h.authMiddleware(model.ScopeBalanceRead), h.userTier)
+	router.GET("/api/user/wallets", h.authMiddleware(model.ScopeBalanceRead), h.userWallets)
+
 	withdrawGroup := router.Group("/api/user/withdrawals")
 	{
-		withdrawGroup.Use(h.validationJWTMiddleware())
-		withdrawGroup.GET("", h.userWithdrawals)
+		withdrawGroup.GET("", h.authMiddleware(model.ScopeWithdrawalsRead), h.userWithdrawals)
+	}
+
+	adminGroup := router.Group("/api/admin")
+	{
+		adminGroup.Use(h.validationJWTMiddleware(), h.requireRole(model.RoleSupport, model.RoleAdmin))
+
+		adminUserGroup := adminGroup.Group("/users/:login")
+		adminUserGroup.GET("", h.adminUser)
+		adminUserGroup.GET("/orders", h.adminUserOrders)
+		adminUserGroup.GET("/balance", h.adminUserBalance)
+		adminUserGroup.GET("/withdrawals", h.adminUserWithdrawals)
+		adminUserGroup.POST("/disable", h.adminDisableUser)
+		adminUserGroup.POST("/enable", h.adminEnableUser)
+		adminUserGroup.POST("/unlock", h.adminUnlockUser)
+		adminUserGroup.PUT("/role", h.requireRole(model.RoleAdmin), h.adminSetUserRole)
+		adminUserGroup.GET("/ledger", h.adminUserLedger)
+		adminUserGroup.POST("/adjustments", h.requireRole(model.RoleAdmin), h.adminAdjustBalance)
+
+		adminGroup.POST("/ledger/:id/reverse", h.requireRole(model.RoleAdmin), h.adminReverseTransaction)
+		adminGroup.POST("/withdrawals/:order/refunds", h.requireRole(model.RoleAdmin), h.refundWithdrawal)
+
+		adminGroup.GET("/promotions", h.adminPromotions)
+		adminGroup.POST("/promotions", h.requireRole(model.RoleAdmin), h.adminCreatePromotion)
+		adminGroup.GET("/promotions/:id", h.adminPromotion)
+		adminGroup.PUT("/promotions/:id", h.requireRole(model.RoleAdmin), h.adminUpdatePromotion)
+		adminGroup.DELETE("/promotions/:id", h.requireRole(model.RoleAdmin), h.adminDeletePromotion)
+		adminGroup.GET("/promotions/:id/awards", h.adminPromotionAwards)
+	}
+
+	partnerGroup := router.Group("/api/partner")
+	{
+		partnerGroup.Use(h.validationJWTMiddleware(), h.requireRole(model.RolePartner))
+		partnerGroup.POST("/withdrawals/:order/refunds", h.refundWithdrawal)
 	}
 
 	h.logger.Infof("server started on port: %d", conf.Port)
@@ -0,0 +1,44 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"gofermart/internal/gophermart/core/application"
+)
+
+func (h *handler) listSessions(c *gin.Context) {
+	sessions, err := h.server.ListSessions(context.TODO(), c.GetString(loginKey), c.GetString(sessionKey))
+	if err != nil {
+		h.logger.Errorf("failed to list sessions: %v", err)
+		c.Writer.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+func (h *handler) revokeSession(c *gin.Context) {
+	id := c.Param("id")
+
+	err := h.server.RevokeSession(context.TODO(), c.GetString(loginKey), id)
+	if err != nil {
+		if errors.Is(err, application.ErrSessionNotFound) {
+			c.Writer.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		h.logger.Errorf("failed to revoke session: %v", err)
+		c.Writer.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if id == c.GetString(sessionKey) {
+		clearTokenCookies(c)
+	}
+
+	c.Writer.WriteHeader(http.StatusNoContent)
+}
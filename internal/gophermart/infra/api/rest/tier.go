@@ -0,0 +1,19 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *handler) userTier(c *gin.Context) {
+	tier, err := h.server.UserTier(context.TODO(), c.GetString(loginKey))
+	if err != nil {
+		h.logger.Errorf("failed to get tier: %v", err)
+		c.Writer.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, tier)
+}
@@ -0,0 +1,73 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"gofermart/internal/gophermart/core/application"
+	"gofermart/internal/gophermart/core/model"
+)
+
+func (h *handler) enrollTOTP(c *gin.Context) {
+	enrollment, err := h.server.EnrollTOTP(context.TODO(), c.GetString(loginKey))
+	if err != nil {
+		h.totpError(c, "failed to enroll totp", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+func (h *handler) confirmTOTP(c *gin.Context) {
+	var request model.TOTPCodeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		h.logger.Errorf("failed to bind request: %v", err)
+		c.Writer.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	codes, err := h.server.ConfirmTOTP(context.TODO(), c.GetString(loginKey), request.Code)
+	if err != nil {
+		h.totpError(c, "failed to confirm totp", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, codes)
+}
+
+func (h *handler) disableTOTP(c *gin.Context) {
+	var request model.TOTPCodeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		h.logger.Errorf("failed to bind request: %v", err)
+		c.Writer.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := h.server.DisableTOTP(context.TODO(), c.GetString(loginKey), request.Code); err != nil {
+		h.totpError(c, "failed to disable totp", err)
+		return
+	}
+
+	c.Writer.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) totpError(c *gin.Context, msg string, err error) {
+	if throttledError(c, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, application.ErrTOTPAlreadyEnabled):
+		c.Writer.WriteHeader(http.StatusConflict)
+	case errors.Is(err, application.ErrTOTPNotEnabled):
+		c.Writer.WriteHeader(http.StatusNotFound)
+	case errors.Is(err, application.ErrInvalidMFACode):
+		c.Writer.WriteHeader(http.StatusForbidden)
+	default:
+		h.logger.Errorf("%s: %v", msg, err)
+		c.Writer.WriteHeader(http.StatusInternalServerError)
+	}
+}
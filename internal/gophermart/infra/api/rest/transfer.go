@@ -0,0 +1,54 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"gofermart/internal/gophermart/core/application"
+	"gofermart/internal/gophermart/core/model"
+)
+
+func (h *handler) userTransfer(c *gin.Context) {
+	var request model.TransferRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		if errors.Is(err, model.ErrInvalidMoney) || errors.Is(err, model.ErrMoneyPrecision) {
+			c.JSON(http.StatusBadRequest, model.ErrorResponse{Error: err.Error()})
+			return
+		}
+
+		h.logger.Errorf("failed to bind request: %v", err)
+		c.Writer.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	request.TOTPCode = c.GetHeader(totpCodeHeader)
+
+	transfer, err := h.server.TransferPoints(context.TODO(), c.GetString(loginKey), request)
+	if err != nil {
+		if h.withdrawLimitError(c, err) || throttledError(c, err) {
+			return
+		}
+
+		switch {
+		case errors.Is(err, application.ErrTOTPRequired), errors.Is(err, application.ErrInvalidMFACode):
+			c.JSON(http.StatusForbidden, model.ErrorResponse{Error: err.Error()})
+		case errors.Is(err, application.ErrInvalidAmount), errors.Is(err, application.ErrSelfTransfer):
+			c.JSON(http.StatusBadRequest, model.ErrorResponse{Error: err.Error()})
+		case errors.Is(err, application.ErrRecipientNotFound):
+			c.JSON(http.StatusNotFound, model.ErrorResponse{Error: err.Error()})
+		case errors.Is(err, application.ErrInsufficientFunds):
+			c.Writer.WriteHeader(http.StatusPaymentRequired)
+		case errors.Is(err, application.ErrTransferLimitExceeded):
+			c.JSON(http.StatusUnprocessableEntity, model.ErrorResponse{Error: err.Error()})
+		default:
+			h.logger.Errorf("failed to transfer points: %v", err)
+			c.Writer.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, transfer)
+}
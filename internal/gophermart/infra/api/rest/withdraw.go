@@ -3,7 +3,9 @@ package rest
 import (
 	"context"
 	"errors"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 
@@ -11,18 +13,47 @@ import (
 	"gofermart/internal/gophermart/core/model"
 )
 
+const totpCodeHeader = "X-TOTP-Code"
+
 func (h *handler) userWithdraw(c *gin.Context) {
 	login := c.GetString(loginKey)
 
 	var request model.WithdrawRequest
 	if err := c.ShouldBindJSON(&request); err != nil {
+		if errors.Is(err, model.ErrInvalidMoney) || errors.Is(err, model.ErrMoneyPrecision) {
+			c.JSON(http.StatusBadRequest, model.ErrorResponse{Error: err.Error()})
+			return
+		}
+
 		h.logger.Errorf("failed to bind request: %v", err)
 		c.Writer.WriteHeader(http.StatusBadRequest)
 		return
 	}
 
+	request.TOTPCode = c.GetHeader(totpCodeHeader)
+
 	err := h.server.UserWithdraw(context.TODO(), login, request)
 	if err != nil {
+		if throttledError(c, err) {
+			return
+		}
+
+		if errors.Is(err, application.ErrTOTPRequired) ||
+			errors.Is(err, application.ErrInvalidMFACode) {
+			c.JSON(http.StatusForbidden, model.ErrorResponse{Error: err.Error()})
+			return
+		}
+
+		if h.withdrawLimitError(c, err) {
+			return
+		}
+
+		if errors.Is(err, application.ErrInvalidAmount) || errors.Is(err, model.ErrInvalidCurrency) ||
+			errors.Is(err, application.ErrPartnerNotFound) {
+			c.JSON(http.StatusBadRequest, model.ErrorResponse{Error: err.Error()})
+			return
+		}
+
 		if errors.Is(err, application.ErrInsufficientFunds) {
 			c.Writer.WriteHeader(http.StatusPaymentRequired)
 			return
@@ -33,6 +64,11 @@ func (h *handler) userWithdraw(c *gin.Context) {
 			return
 		}
 
+		if errors.Is(err, application.ErrWithdrawExists) {
+			c.JSON(http.StatusConflict, model.ErrorResponse{Error: err.Error()})
+			return
+		}
+
 		h.logger.Errorf("failed to withdraw: %v", err)
 		c.Writer.WriteHeader(http.StatusInternalServerError)
 		return
@@ -41,6 +77,33 @@ func (h *handler) userWithdraw(c *gin.Context) {
 	c.Writer.WriteHeader(http.StatusOK)
 }
 
+// withdrawLimitError writes the response for a withdrawal or hold refused
+// by the withdrawal limits and tells whether err was one.
+func (h *handler) withdrawLimitError(c *gin.Context, err error) bool {
+	if errors.Is(err, application.ErrWithdrawBelowMinimum) || errors.Is(err, application.ErrWithdrawAboveMaximum) {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{Error: err.Error()})
+		return true
+	}
+
+	var blocked *application.WithdrawBlockedError
+	if !errors.As(err, &blocked) {
+		return false
+	}
+
+	if blocked.RetryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(blocked.RetryAfter.Seconds()))))
+	}
+
+	if errors.Is(err, application.ErrWithdrawCooldown) {
+		c.JSON(http.StatusLocked, model.ErrorResponse{Error: err.Error()})
+		return true
+	}
+
+	c.JSON(http.StatusTooManyRequests, model.ErrorResponse{Error: err.Error()})
+
+	return true
+}
+
 func (h *handler) userWithdrawals(c *gin.Context) {
 	login := c.GetString(loginKey)
 
@@ -0,0 +1,93 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+func (s *Memory) SaveAPIKey(ctx context.Context, key model.APIKey) error {
+	s.apiKeyMu.Lock()
+	defer s.apiKeyMu.Unlock()
+
+	if _, ok := s.apiKeys[key.KeyHash]; ok {
+		return repositories.ErrDuplicate
+	}
+
+	key.Scopes = slices.Clone(key.Scopes)
+	s.apiKeys[key.KeyHash] = key
+
+	return nil
+}
+
+func (s *Memory) GetAPIKey(ctx context.Context, keyHash string) (model.APIKey, error) {
+	s.apiKeyMu.Lock()
+	defer s.apiKeyMu.Unlock()
+
+	key, ok := s.apiKeys[keyHash]
+	if !ok {
+		return model.APIKey{}, repositories.ErrNotFound
+	}
+
+	key.Scopes = slices.Clone(key.Scopes)
+
+	return key, nil
+}
+
+func (s *Memory) GetUserAPIKeys(ctx context.Context, login string) ([]model.APIKey, error) {
+	s.apiKeyMu.Lock()
+	defer s.apiKeyMu.Unlock()
+
+	result := make([]model.APIKey, 0)
+	for _, key := range s.apiKeys {
+		if key.Login == login {
+			key.Scopes = slices.Clone(key.Scopes)
+			result = append(result, key)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.After(result[j].CreatedAt)
+	})
+
+	return result, nil
+}
+
+func (s *Memory) RevokeAPIKey(ctx context.Context, login, id string) error {
+	s.apiKeyMu.Lock()
+	defer s.apiKeyMu.Unlock()
+
+	for hash, key := range s.apiKeys {
+		if key.ID != id || key.Login != login || key.RevokedAt != nil {
+			continue
+		}
+
+		now := time.Now()
+		key.RevokedAt = &now
+		s.apiKeys[hash] = key
+
+		return nil
+	}
+
+	return repositories.ErrNotFound
+}
+
+func (s *Memory) TouchAPIKey(ctx context.Context, id string, usedAt time.Time) error {
+	s.apiKeyMu.Lock()
+	defer s.apiKeyMu.Unlock()
+
+	for hash, key := range s.apiKeys {
+		if key.ID == id {
+			key.LastUsedAt = &usedAt
+			s.apiKeys[hash] = key
+
+			return nil
+		}
+	}
+
+	return repositories.ErrNotFound
+}
@@ -0,0 +1,80 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+func TestMemory_APIKeys(t *testing.T) {
+	ctx := context.Background()
+
+	newKey := func(login string) model.APIKey {
+		return model.APIKey{
+			ID:        uuid.NewString(),
+			Login:     login,
+			Name:      "partner",
+			KeyHash:   uuid.NewString(),
+			Scopes:    []string{model.ScopeOrdersWrite},
+			CreatedAt: time.Now(),
+		}
+	}
+
+	t.Run("SaveAndGetAPIKey", func(t *testing.T) {
+		memory, err := New()
+		require.NoError(t, err)
+
+		_, err = memory.GetAPIKey(ctx, uuid.NewString())
+		require.ErrorIs(t, err, repositories.ErrNotFound)
+
+		key := newKey("login")
+		require.NoError(t, memory.SaveAPIKey(ctx, key))
+		require.ErrorIs(t, memory.SaveAPIKey(ctx, key), repositories.ErrDuplicate)
+
+		stored, err := memory.GetAPIKey(ctx, key.KeyHash)
+		require.NoError(t, err)
+		assert.Equal(t, key, stored)
+	})
+
+	t.Run("GetUserAPIKeys", func(t *testing.T) {
+		memory, err := New()
+		require.NoError(t, err)
+
+		first, second, other := newKey("login"), newKey("login"), newKey("other")
+		second.CreatedAt = first.CreatedAt.Add(time.Second)
+		for _, key := range []model.APIKey{first, second, other} {
+			require.NoError(t, memory.SaveAPIKey(ctx, key))
+		}
+
+		keys, err := memory.GetUserAPIKeys(ctx, "login")
+		require.NoError(t, err)
+		require.Len(t, keys, 2)
+		assert.Equal(t, second.ID, keys[0].ID)
+		assert.Equal(t, first.ID, keys[1].ID)
+	})
+
+	t.Run("RevokeAndTouchAPIKey", func(t *testing.T) {
+		memory, err := New()
+		require.NoError(t, err)
+
+		key := newKey("login")
+		require.NoError(t, memory.SaveAPIKey(ctx, key))
+
+		require.ErrorIs(t, memory.RevokeAPIKey(ctx, "other", key.ID), repositories.ErrNotFound)
+		require.NoError(t, memory.TouchAPIKey(ctx, key.ID, time.Now()))
+		require.NoError(t, memory.RevokeAPIKey(ctx, "login", key.ID))
+		require.ErrorIs(t, memory.RevokeAPIKey(ctx, "login", key.ID), repositories.ErrNotFound)
+
+		stored, err := memory.GetAPIKey(ctx, key.KeyHash)
+		require.NoError(t, err)
+		assert.NotNil(t, stored.RevokedAt)
+		assert.NotNil(t, stored.LastUsedAt)
+	})
+}
@@ -0,0 +1,82 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"gofermart/internal/gophermart/core/model"
+)
+
+func (s *Memory) GetLoginAttempts(ctx context.Context, key string) (model.LoginAttempts, error) {
+	s.attemptMu.Lock()
+	defer s.attemptMu.Unlock()
+
+	attempts := s.loginAttempts[key]
+
+	return model.LoginAttempts{
+		Key:           key,
+		Failures:      attempts.Failures,
+		LastFailureAt: attempts.LastFailureAt,
+		LockedUntil:   attempts.LockedUntil,
+	}, nil
+}
+
+func (s *Memory) RecordLoginFailure(ctx context.Context, key string, window time.Duration) (model.LoginAttempts, error) {
+	s.attemptMu.Lock()
+	defer s.attemptMu.Unlock()
+
+	now := time.Now()
+
+	attempts := s.loginAttempts[key]
+	if now.Sub(attempts.LastFailureAt) > window {
+		attempts.Failures = 0
+	}
+
+	attempts.Failures++
+	attempts.LastFailureAt = now
+	s.loginAttempts[key] = attempts
+
+	return model.LoginAttempts{
+		Key:           key,
+		Failures:      attempts.Failures,
+		LastFailureAt: attempts.LastFailureAt,
+		LockedUntil:   attempts.LockedUntil,
+	}, nil
+}
+
+func (s *Memory) LockLogin(ctx context.Context, key string, until time.Time) error {
+	s.attemptMu.Lock()
+	defer s.attemptMu.Unlock()
+
+	attempts := s.loginAttempts[key]
+	attempts.LockedUntil = &until
+	s.loginAttempts[key] = attempts
+
+	return nil
+}
+
+func (s *Memory) ResetLoginAttempts(ctx context.Context, key string) error {
+	s.attemptMu.Lock()
+	defer s.attemptMu.Unlock()
+
+	delete(s.loginAttempts, key)
+
+	return nil
+}
+
+// ReleaseLoginAttempt takes back one failure recorded for an attempt that
+// turned out to succeed.
+func (s *Memory) ReleaseLoginAttempt(ctx context.Context, key string) error {
+	s.attemptMu.Lock()
+	defer s.attemptMu.Unlock()
+
+	attempts, ok := s.loginAttempts[key]
+	if !ok || attempts.Failures == 0 {
+		return nil
+	}
+
+	attempts.Failures--
+	s.loginAttempts[key] = attempts
+
+	return nil
+}
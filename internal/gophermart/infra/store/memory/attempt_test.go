@@ -0,0 +1,86 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemory_LoginAttempts(t *testing.T) {
+	ctx := context.Background()
+	const key = "login:user"
+
+	t.Run("GetLoginAttempts", func(t *testing.T) {
+		memory, err := New()
+		require.NoError(t, err)
+
+		attempts, err := memory.GetLoginAttempts(ctx, key)
+		require.NoError(t, err)
+		assert.Equal(t, key, attempts.Key)
+		assert.Zero(t, attempts.Failures)
+		assert.Nil(t, attempts.LockedUntil)
+	})
+
+	t.Run("RecordLoginFailure", func(t *testing.T) {
+		memory, err := New()
+		require.NoError(t, err)
+
+		for i := 1; i <= 3; i++ {
+			attempts, err := memory.RecordLoginFailure(ctx, key, time.Minute)
+			require.NoError(t, err)
+			assert.Equal(t, i, attempts.Failures)
+		}
+
+		attempts := memory.loginAttempts[key]
+		attempts.LastFailureAt = time.Now().Add(-2 * time.Minute)
+		memory.loginAttempts[key] = attempts
+
+		recorded, err := memory.RecordLoginFailure(ctx, key, time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, 1, recorded.Failures, "failures outside the window are forgotten")
+	})
+
+	t.Run("LockLogin and ResetLoginAttempts", func(t *testing.T) {
+		memory, err := New()
+		require.NoError(t, err)
+
+		_, err = memory.RecordLoginFailure(ctx, key, time.Minute)
+		require.NoError(t, err)
+
+		until := time.Now().Add(time.Hour)
+		require.NoError(t, memory.LockLogin(ctx, key, until))
+
+		attempts, err := memory.GetLoginAttempts(ctx, key)
+		require.NoError(t, err)
+		require.NotNil(t, attempts.LockedUntil)
+		assert.Equal(t, until, *attempts.LockedUntil)
+
+		require.NoError(t, memory.ResetLoginAttempts(ctx, key))
+
+		attempts, err = memory.GetLoginAttempts(ctx, key)
+		require.NoError(t, err)
+		assert.Zero(t, attempts.Failures)
+		assert.Nil(t, attempts.LockedUntil)
+	})
+
+	t.Run("ReleaseLoginAttempt", func(t *testing.T) {
+		memory, err := New()
+		require.NoError(t, err)
+
+		require.NoError(t, memory.ReleaseLoginAttempt(ctx, key))
+
+		for range 2 {
+			_, err = memory.RecordLoginFailure(ctx, key, time.Minute)
+			require.NoError(t, err)
+		}
+
+		require.NoError(t, memory.ReleaseLoginAttempt(ctx, key))
+
+		attempts, err := memory.GetLoginAttempts(ctx, key)
+		require.NoError(t, err)
+		assert.Equal(t, 1, attempts.Failures)
+	})
+}
@@ -0,0 +1,68 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gofermart/internal/gophermart/core/model"
+)
+
+func TestMemory_SetBalanceCorrections(t *testing.T) {
+	ctx := context.Background()
+
+	memory, err := New()
+	require.NoError(t, err)
+	require.NoError(t, memory.CreateUser(ctx, "login", "hash"))
+
+	for _, orderID := range []string{"12345678903", "2377225624"} {
+		require.NoError(t, memory.SaveOrder(ctx, "login", model.OrderRequest{ID: orderID, Status: model.OrderStatusNew}))
+	}
+
+	balance := func() model.UserBalance {
+		balance, err := memory.GetUserBalance(ctx, "login")
+		require.NoError(t, err)
+		return balance
+	}
+
+	accrual := model.Accrual{OrderID: "12345678903", Status: model.OrderStatusDone, Amount: 500, Bonus: 50}
+	require.NoError(t, memory.SetBalance(ctx, accrual))
+
+	// Processing an order twice credits it once.
+	require.NoError(t, memory.SetBalance(ctx, accrual))
+	assert.Equal(t, model.UserBalance{Amount: 550}, balance())
+
+	require.NoError(t, memory.UserWithdraw(ctx, "login", model.Withdraw{OrderID: "79927398713", Amount: 400}))
+
+	// Revoking the accrual takes the rest of the balance and books the
+	// remainder as debt.
+	require.NoError(t, memory.SetBalance(ctx, model.Accrual{OrderID: "12345678903", Status: model.OrderStatusFailed}))
+	assert.Equal(t, model.UserBalance{Withdraw: 400, Debt: 400}, balance())
+
+	require.NoError(t, memory.SetBalance(ctx, model.Accrual{
+		OrderID: "2377225624", Status: model.OrderStatusDone, Amount: 300,
+	}))
+	assert.Equal(t, model.UserBalance{Withdraw: 400, Debt: 100}, balance())
+
+	require.NoError(t, memory.SetBalance(ctx, model.Accrual{
+		OrderID: "2377225624", Status: model.OrderStatusDone, Amount: 450,
+	}))
+	assert.Equal(t, model.UserBalance{Amount: 50, Withdraw: 400}, balance())
+
+	ledger, err := memory.GetUserLedger(ctx, "login")
+	require.NoError(t, err)
+
+	var kinds []string
+	for _, entry := range ledger {
+		if entry.Account == model.LedgerAccountAccrual || entry.Account == model.LedgerAccountTierBonus ||
+			entry.Kind == model.LedgerKindRepayment && entry.Account == model.LedgerAccountDebt {
+			kinds = append(kinds, entry.Kind)
+		}
+	}
+	assert.Equal(t, []string{
+		model.LedgerKindAccrual, model.LedgerKindTierBonus, model.LedgerKindCorrection, model.LedgerKindCorrection,
+		model.LedgerKindAccrual, model.LedgerKindRepayment, model.LedgerKindCorrection, model.LedgerKindRepayment,
+	}, kinds)
+}
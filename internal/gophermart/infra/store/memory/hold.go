@@ -0,0 +1,123 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+func (s *Memory) HoldWithdraw(ctx context.Context, login string, request model.Withdraw) error {
+	s.userBMu.Lock()
+	defer s.userBMu.Unlock()
+
+	s.withdrawMu.Lock()
+	defer s.withdrawMu.Unlock()
+
+	if _, ok := s.withdraws[request.OrderID]; ok {
+		return repositories.ErrDuplicate
+	}
+
+	if err := s.checkCapsLocked(login, request); err != nil {
+		return err
+	}
+
+	transaction := model.HoldTransaction(uuid.NewString(), login, request.OrderID, request.Amount)
+	transaction.Currency = model.WalletCurrency(request.Currency)
+	if err := s.postLocked(transaction); err != nil {
+		return err
+	}
+
+	expiresAt := *request.ExpiresAt
+	s.withdraws[request.OrderID] = Withdraw{
+		Login:     login,
+		Status:    model.WithdrawStatusHeld,
+		Currency:  transaction.Currency,
+		Partner:   request.Partner,
+		Amount:    request.Amount,
+		CreatedAt: time.Now(),
+		ExpiresAt: &expiresAt,
+	}
+
+	return nil
+}
+
+func (s *Memory) CaptureWithdraw(ctx context.Context, login, orderID string, now time.Time) error {
+	s.userBMu.Lock()
+	defer s.userBMu.Unlock()
+
+	s.withdrawMu.Lock()
+	defer s.withdrawMu.Unlock()
+
+	withdraw, ok := s.withdraws[orderID]
+	if !ok || withdraw.Login != login {
+		return repositories.ErrNotFound
+	}
+
+	if withdraw.Status != model.WithdrawStatusHeld || !withdraw.ExpiresAt.After(now) {
+		return repositories.ErrInvalidState
+	}
+
+	transaction := model.CaptureTransaction(uuid.NewString(), login, orderID, withdraw.Amount)
+	transaction.Currency = withdraw.Currency
+	if err := s.postLocked(transaction); err != nil {
+		return err
+	}
+
+	withdraw.Status = model.WithdrawStatusCaptured
+	s.withdraws[orderID] = withdraw
+
+	return nil
+}
+
+func (s *Memory) ReleaseWithdraw(ctx context.Context, login, orderID, status string) error {
+	s.userBMu.Lock()
+	defer s.userBMu.Unlock()
+
+	s.withdrawMu.Lock()
+	defer s.withdrawMu.Unlock()
+
+	withdraw, ok := s.withdraws[orderID]
+	if !ok || withdraw.Login != login {
+		return repositories.ErrNotFound
+	}
+
+	if withdraw.Status != model.WithdrawStatusHeld {
+		return repositories.ErrInvalidState
+	}
+
+	transaction := model.ReleaseTransaction(uuid.NewString(), login, orderID, withdraw.Amount)
+	transaction.Currency = withdraw.Currency
+	if err := s.postLocked(transaction); err != nil {
+		return err
+	}
+
+	withdraw.Status = status
+	s.withdraws[orderID] = withdraw
+
+	return nil
+}
+
+func (s *Memory) GetExpiredHolds(ctx context.Context, now time.Time) ([]model.Withdraw, error) {
+	s.withdrawMu.Lock()
+	defer s.withdrawMu.Unlock()
+
+	var holds []model.Withdraw
+	for orderID, withdraw := range s.withdraws {
+		if withdraw.Status != model.WithdrawStatusHeld || withdraw.ExpiresAt.After(now) {
+			continue
+		}
+
+		holds = append(holds, s.withdrawLocked(orderID))
+	}
+
+	sort.Slice(holds, func(i, j int) bool {
+		return holds[i].ExpiresAt.Before(*holds[j].ExpiresAt)
+	})
+
+	return holds, nil
+}
@@ -0,0 +1,96 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+func TestMemory_Holds(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	expiresAt := now.Add(time.Minute)
+
+	newMemory := func(t *testing.T) *Memory {
+		t.Helper()
+
+		memory, err := New()
+		require.NoError(t, err)
+		require.NoError(t, memory.CreateUser(ctx, "login", "hash"))
+		require.NoError(t, memory.SaveOrder(ctx, "login", model.OrderRequest{ID: "12345678903", Status: model.OrderStatusNew}))
+		require.NoError(t, memory.SetBalance(ctx, model.Accrual{
+			OrderID: "12345678903", Status: model.OrderStatusDone, Amount: 500,
+		}))
+
+		return memory
+	}
+
+	hold := func(t *testing.T, memory *Memory, orderID string, amount model.Money) error {
+		t.Helper()
+
+		return memory.HoldWithdraw(ctx, "login", model.Withdraw{OrderID: orderID, Amount: amount, ExpiresAt: &expiresAt})
+	}
+
+	t.Run("hold and capture", func(t *testing.T) {
+		memory := newMemory(t)
+
+		require.NoError(t, hold(t, memory, "2377225624", 200))
+		require.ErrorIs(t, hold(t, memory, "2377225624", 100), repositories.ErrDuplicate)
+		require.ErrorIs(t, hold(t, memory, "49927398716", 400), repositories.ErrInsufficientFunds)
+
+		balance, err := memory.GetUserBalance(ctx, "login")
+		require.NoError(t, err)
+		assert.Equal(t, model.UserBalance{Amount: 300, Held: 200}, balance)
+
+		require.ErrorIs(t, memory.CaptureWithdraw(ctx, "other", "2377225624", now), repositories.ErrNotFound)
+		require.NoError(t, memory.CaptureWithdraw(ctx, "login", "2377225624", now))
+		require.ErrorIs(t, memory.CaptureWithdraw(ctx, "login", "2377225624", now), repositories.ErrInvalidState)
+
+		balance, err = memory.GetUserBalance(ctx, "login")
+		require.NoError(t, err)
+		assert.Equal(t, model.UserBalance{Amount: 300, Withdraw: 200}, balance)
+
+		withdrawals, err := memory.GetUserWithdrawals(ctx, "login")
+		require.NoError(t, err)
+		require.Len(t, withdrawals, 1)
+		assert.Equal(t, model.WithdrawStatusCaptured, withdrawals[0].Status)
+	})
+
+	t.Run("release", func(t *testing.T) {
+		memory := newMemory(t)
+
+		require.NoError(t, hold(t, memory, "2377225624", 200))
+		require.NoError(t, memory.ReleaseWithdraw(ctx, "login", "2377225624", model.WithdrawStatusReleased))
+		require.ErrorIs(t, memory.ReleaseWithdraw(ctx, "login", "2377225624", model.WithdrawStatusReleased),
+			repositories.ErrInvalidState)
+
+		balance, err := memory.GetUserBalance(ctx, "login")
+		require.NoError(t, err)
+		assert.Equal(t, model.UserBalance{Amount: 500}, balance)
+	})
+
+	t.Run("expired hold can't be captured", func(t *testing.T) {
+		memory := newMemory(t)
+
+		require.NoError(t, hold(t, memory, "2377225624", 200))
+
+		holds, err := memory.GetExpiredHolds(ctx, now)
+		require.NoError(t, err)
+		assert.Empty(t, holds)
+
+		later := expiresAt.Add(time.Second)
+		require.ErrorIs(t, memory.CaptureWithdraw(ctx, "login", "2377225624", later), repositories.ErrInvalidState)
+
+		holds, err = memory.GetExpiredHolds(ctx, later)
+		require.NoError(t, err)
+		require.Len(t, holds, 1)
+		assert.Equal(t, "login", holds[0].Login)
+		assert.Equal(t, model.Money(200), holds[0].Amount)
+	})
+}
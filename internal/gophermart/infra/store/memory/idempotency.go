@@ -0,0 +1,72 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+func idempotencyMapKey(login, key string) string {
+	return login + "\x00" + key
+}
+
+func (s *Memory) SaveIdempotencyKey(ctx context.Context, record model.IdempotencyKey) error {
+	s.idempotencyMu.Lock()
+	defer s.idempotencyMu.Unlock()
+
+	for id, stored := range s.idempotencyKeys {
+		if stored.ExpiresAt.Before(record.CreatedAt) {
+			delete(s.idempotencyKeys, id)
+		}
+	}
+
+	id := idempotencyMapKey(record.Login, record.Key)
+	if _, ok := s.idempotencyKeys[id]; ok {
+		return repositories.ErrDuplicate
+	}
+
+	s.idempotencyKeys[id] = record
+
+	return nil
+}
+
+func (s *Memory) GetIdempotencyKey(ctx context.Context, login, key string) (model.IdempotencyKey, error) {
+	s.idempotencyMu.Lock()
+	defer s.idempotencyMu.Unlock()
+
+	record, ok := s.idempotencyKeys[idempotencyMapKey(login, key)]
+	if !ok {
+		return model.IdempotencyKey{}, repositories.ErrNotFound
+	}
+
+	return record, nil
+}
+
+func (s *Memory) CompleteIdempotencyKey(ctx context.Context, login, key string,
+	response model.IdempotentResponse, completedAt time.Time) error {
+	s.idempotencyMu.Lock()
+	defer s.idempotencyMu.Unlock()
+
+	id := idempotencyMapKey(login, key)
+	record, ok := s.idempotencyKeys[id]
+	if !ok {
+		return repositories.ErrNotFound
+	}
+
+	record.CompletedAt = &completedAt
+	record.Response = response
+	s.idempotencyKeys[id] = record
+
+	return nil
+}
+
+func (s *Memory) DeleteIdempotencyKey(ctx context.Context, login, key string) error {
+	s.idempotencyMu.Lock()
+	defer s.idempotencyMu.Unlock()
+
+	delete(s.idempotencyKeys, idempotencyMapKey(login, key))
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+func TestMemory_IdempotencyKeys(t *testing.T) {
+	ctx := context.Background()
+
+	memory, err := New()
+	require.NoError(t, err)
+
+	now := time.Now()
+	record := model.IdempotencyKey{
+		Login:       "login",
+		Key:         "key",
+		Fingerprint: "fingerprint",
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(time.Hour),
+	}
+
+	require.NoError(t, memory.SaveIdempotencyKey(ctx, record))
+	require.ErrorIs(t, memory.SaveIdempotencyKey(ctx, record), repositories.ErrDuplicate)
+
+	other := record
+	other.Login = "other"
+	require.NoError(t, memory.SaveIdempotencyKey(ctx, other))
+
+	response := model.IdempotentResponse{StatusCode: 202, ContentType: "text/plain"}
+	require.NoError(t, memory.CompleteIdempotencyKey(ctx, "login", "key", response, now))
+	require.ErrorIs(t, memory.CompleteIdempotencyKey(ctx, "login", "unknown", response, now),
+		repositories.ErrNotFound)
+
+	stored, err := memory.GetIdempotencyKey(ctx, "login", "key")
+	require.NoError(t, err)
+	assert.NotNil(t, stored.CompletedAt)
+	assert.Equal(t, response, stored.Response)
+
+	require.NoError(t, memory.DeleteIdempotencyKey(ctx, "login", "key"))
+	_, err = memory.GetIdempotencyKey(ctx, "login", "key")
+	require.ErrorIs(t, err, repositories.ErrNotFound)
+
+	// An expired key can be claimed again.
+	expired := record
+	expired.ExpiresAt = now.Add(-time.Minute)
+	require.NoError(t, memory.SaveIdempotencyKey(ctx, expired))
+
+	later := record
+	later.CreatedAt = now.Add(time.Minute)
+	require.NoError(t, memory.SaveIdempotencyKey(ctx, later))
+}
@@ -0,0 +1,233 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+func (s *Memory) AddLedgerTransaction(ctx context.Context, transaction model.LedgerTransaction) error {
+	s.userBMu.Lock()
+	defer s.userBMu.Unlock()
+
+	if transaction.Kind == model.LedgerKindReversal {
+		for _, entry := range s.ledger {
+			if entry.Kind == model.LedgerKindReversal && entry.Reference == transaction.Reference {
+				return repositories.ErrDuplicate
+			}
+		}
+	}
+
+	return s.postLocked(transaction)
+}
+
+func (s *Memory) GetLedgerTransaction(ctx context.Context, id string) (model.LedgerTransaction, error) {
+	s.userBMu.Lock()
+	defer s.userBMu.Unlock()
+
+	var transaction model.LedgerTransaction
+	for _, entry := range s.ledger {
+		if entry.TransactionID != id {
+			continue
+		}
+
+		transaction.ID = entry.TransactionID
+		transaction.Kind = entry.Kind
+		transaction.Currency = entry.Currency
+		transaction.OrderID = entry.OrderID
+		transaction.Reference = entry.Reference
+		transaction.Description = entry.Description
+		transaction.CreatedAt = entry.CreatedAt
+		transaction.Postings = append(transaction.Postings, model.LedgerPosting{
+			Login:   entry.Login,
+			Account: entry.Account,
+			Amount:  entry.Amount,
+		})
+	}
+
+	if transaction.ID == "" {
+		return model.LedgerTransaction{}, repositories.ErrNotFound
+	}
+
+	return transaction, nil
+}
+
+func (s *Memory) GetUserLedger(ctx context.Context, login string) ([]model.LedgerEntry, error) {
+	s.userBMu.Lock()
+	defer s.userBMu.Unlock()
+
+	var entries []model.LedgerEntry
+	for _, entry := range s.ledger {
+		if entry.Login == login {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, nil
+}
+
+// postLocked appends transaction to the ledger and applies it to the
+// balance snapshots. The caller must hold userBMu.
+func (s *Memory) postLocked(transaction model.LedgerTransaction) error {
+	if !transaction.Balanced() {
+		return fmt.Errorf("ledger transaction %s is not balanced", transaction.ID)
+	}
+
+	currency := model.WalletCurrency(transaction.Currency)
+	logins := transaction.Logins()
+	for _, login := range logins {
+		wallets, ok := s.userBalance[login]
+		if !ok {
+			return repositories.ErrNotFound
+		}
+
+		balance := wallets[currency]
+
+		if balance.Amount+transaction.Delta(login, model.LedgerAccountAvailable) < 0 ||
+			balance.Held+transaction.Delta(login, model.LedgerAccountHeld) < 0 {
+			return repositories.ErrInsufficientFunds
+		}
+	}
+
+	for _, login := range logins {
+		balance := s.userBalance[login][currency]
+		balance.Amount += transaction.Delta(login, model.LedgerAccountAvailable)
+		balance.Held += transaction.Delta(login, model.LedgerAccountHeld)
+		balance.Withdraw += transaction.Delta(login, model.LedgerAccountWithdrawn)
+		balance.Debt -= transaction.Delta(login, model.LedgerAccountDebt)
+		s.userBalance[login][currency] = balance
+	}
+
+	now := time.Now()
+	for _, login := range logins {
+		delta := transaction.Delta(login, model.LedgerAccountAvailable)
+
+		switch {
+		case currency != model.DefaultCurrency:
+			// Only points of the default wallet expire.
+		case delta < 0 && transaction.Kind != model.LedgerKindExpiry:
+			s.consumeLotsLocked(login, -delta, transaction)
+		case delta > 0 && transaction.RestoresLots():
+			s.restoreLotsLocked(login, transaction.OrderID, delta)
+		case delta > 0 && transaction.ExpiresAt != nil:
+			s.lots = append(s.lots, model.PointsLot{
+				ID:            int64(len(s.lots) + 1),
+				TransactionID: transaction.ID,
+				Login:         login,
+				OrderID:       transaction.OrderID,
+				Amount:        delta,
+				Remaining:     delta,
+				CreatedAt:     now,
+				ExpiresAt:     *transaction.ExpiresAt,
+			})
+		}
+	}
+
+	for _, posting := range transaction.Postings {
+		s.ledger = append(s.ledger, model.LedgerEntry{
+			ID:            int64(len(s.ledger) + 1),
+			TransactionID: transaction.ID,
+			Kind:          transaction.Kind,
+			Login:         posting.Login,
+			Account:       posting.Account,
+			Currency:      currency,
+			OrderID:       transaction.OrderID,
+			Reference:     transaction.Reference,
+			Description:   transaction.Description,
+			Amount:        posting.Amount,
+			CreatedAt:     now,
+		})
+	}
+
+	return nil
+}
+
+// repayLocked pays back what it can of the debt of login out of the
+// available balance an accrual of orderID left in the wallet in currency.
+// The caller must hold userBMu.
+func (s *Memory) repayLocked(login, currency, orderID string) error {
+	balance := s.userBalance[login][currency]
+
+	amount := min(balance.Debt, balance.Amount)
+	if amount <= 0 {
+		return nil
+	}
+
+	transaction := model.RepaymentTransaction(uuid.NewString(), login, orderID, amount)
+	transaction.Currency = currency
+
+	return s.postLocked(transaction)
+}
+
+// ledgerBalanceLocked sums the user accounts of login in the wallet in
+// currency. The caller must hold userBMu.
+func (s *Memory) ledgerBalanceLocked(login, currency string) model.UserBalance {
+	var balance model.UserBalance
+	for _, entry := range s.ledger {
+		if entry.Login != login || entry.Currency != currency {
+			continue
+		}
+
+		switch entry.Account {
+		case model.LedgerAccountAvailable:
+			balance.Amount += entry.Amount
+		case model.LedgerAccountHeld:
+			balance.Held += entry.Amount
+		case model.LedgerAccountWithdrawn:
+			balance.Withdraw += entry.Amount
+		case model.LedgerAccountDebt:
+			balance.Debt -= entry.Amount
+		}
+	}
+
+	return balance
+}
+
+func (s *Memory) GetBalanceHistory(ctx context.Context,
+	filter model.BalanceHistoryFilter) ([]model.BalanceHistoryEntry, error) {
+	s.userBMu.Lock()
+	defer s.userBMu.Unlock()
+
+	var (
+		history []model.BalanceHistoryEntry
+		balance model.Money
+	)
+
+	currency := model.WalletCurrency(filter.Currency)
+	for _, entry := range s.ledger {
+		if entry.Login != filter.Login || entry.Currency != currency || entry.Account != model.LedgerAccountAvailable {
+			continue
+		}
+
+		balance += entry.Amount
+
+		if entry.ID <= filter.AfterID ||
+			filter.From != nil && entry.CreatedAt.Before(*filter.From) ||
+			filter.To != nil && !entry.CreatedAt.Before(*filter.To) ||
+			filter.Type == model.HistoryTypeCredit && entry.Amount < 0 ||
+			filter.Type == model.HistoryTypeDebit && entry.Amount > 0 {
+			continue
+		}
+
+		history = append(history, model.BalanceHistoryEntry{
+			ID:            entry.ID,
+			TransactionID: entry.TransactionID,
+			Kind:          entry.Kind,
+			OrderID:       entry.OrderID,
+			Amount:        entry.Amount,
+			Balance:       balance,
+			CreatedAt:     entry.CreatedAt,
+		})
+
+		if filter.Limit > 0 && len(history) == filter.Limit {
+			break
+		}
+	}
+
+	return history, nil
+}
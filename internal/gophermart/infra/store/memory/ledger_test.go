@@ -0,0 +1,120 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+func TestMemory_Ledger(t *testing.T) {
+	ctx := context.Background()
+
+	newMemory := func(t *testing.T) *Memory {
+		t.Helper()
+
+		memory, err := New()
+		require.NoError(t, err)
+		require.NoError(t, memory.CreateUser(ctx, "login", "hash"))
+		require.NoError(t, memory.SaveOrder(ctx, "login", model.OrderRequest{ID: "12345678903", Status: model.OrderStatusNew}))
+		require.NoError(t, memory.SetBalance(ctx, model.Accrual{
+			OrderID: "12345678903", Status: model.OrderStatusDone, Amount: 500,
+		}))
+
+		return memory
+	}
+
+	t.Run("accruals and withdrawals are posted", func(t *testing.T) {
+		memory := newMemory(t)
+
+		require.NoError(t, memory.UserWithdraw(ctx, "login", model.Withdraw{OrderID: "2377225624", Amount: 200}))
+
+		entries, err := memory.GetUserLedger(ctx, "login")
+		require.NoError(t, err)
+		require.Len(t, entries, 4)
+
+		assert.Equal(t, model.LedgerKindAccrual, entries[0].Kind)
+		assert.Equal(t, "12345678903", entries[0].OrderID)
+		assert.Equal(t, model.LedgerKindWithdrawal, entries[2].Kind)
+		assert.Equal(t, "2377225624", entries[2].OrderID)
+
+		balance, err := memory.GetUserBalance(ctx, "login")
+		require.NoError(t, err)
+		assert.Equal(t, model.UserBalance{Amount: 300, Withdraw: 200}, balance)
+	})
+
+	t.Run("adjustment", func(t *testing.T) {
+		memory := newMemory(t)
+
+		err := memory.AddLedgerTransaction(ctx, model.AdjustmentTransaction("tx-1", "login", -600, "fix"))
+		require.ErrorIs(t, err, repositories.ErrInsufficientFunds)
+
+		err = memory.AddLedgerTransaction(ctx, model.AdjustmentTransaction("tx-2", "unknown", 100, "fix"))
+		require.ErrorIs(t, err, repositories.ErrNotFound)
+
+		require.NoError(t, memory.AddLedgerTransaction(ctx, model.AdjustmentTransaction("tx-3", "login", -100, "fix")))
+
+		balance, err := memory.GetUserBalance(ctx, "login")
+		require.NoError(t, err)
+		assert.Equal(t, model.Money(400), balance.Amount)
+	})
+
+	t.Run("reversal", func(t *testing.T) {
+		memory := newMemory(t)
+
+		adjustment := model.AdjustmentTransaction("tx-1", "login", 100, "goodwill")
+		require.NoError(t, memory.AddLedgerTransaction(ctx, adjustment))
+
+		stored, err := memory.GetLedgerTransaction(ctx, "tx-1")
+		require.NoError(t, err)
+		assert.Equal(t, adjustment.Postings, stored.Postings)
+
+		require.NoError(t, memory.AddLedgerTransaction(ctx, stored.Reversal("tx-2", "mistake")))
+		err = memory.AddLedgerTransaction(ctx, stored.Reversal("tx-3", "mistake"))
+		require.ErrorIs(t, err, repositories.ErrDuplicate)
+
+		balance, err := memory.GetUserBalance(ctx, "login")
+		require.NoError(t, err)
+		assert.Equal(t, model.Money(500), balance.Amount)
+
+		_, err = memory.GetLedgerTransaction(ctx, "unknown")
+		require.ErrorIs(t, err, repositories.ErrNotFound)
+	})
+}
+
+func TestMemory_GetBalanceHistory(t *testing.T) {
+	ctx := context.Background()
+
+	memory, err := New()
+	require.NoError(t, err)
+	require.NoError(t, memory.CreateUser(ctx, "login", "hash"))
+
+	for _, id := range []string{"12345678903", "2377225624"} {
+		require.NoError(t, memory.SaveOrder(ctx, "login", model.OrderRequest{ID: id, Status: model.OrderStatusNew}))
+		require.NoError(t, memory.SetBalance(ctx, model.Accrual{
+			OrderID: id, Status: model.OrderStatusDone, Amount: 300,
+		}))
+	}
+	require.NoError(t, memory.UserWithdraw(ctx, "login", model.Withdraw{OrderID: "49927398716", Amount: 100}))
+
+	history, err := memory.GetBalanceHistory(ctx, model.BalanceHistoryFilter{Login: "login"})
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	assert.Equal(t, []model.Money{300, 600, 500}, []model.Money{history[0].Balance, history[1].Balance, history[2].Balance})
+
+	history, err = memory.GetBalanceHistory(ctx, model.BalanceHistoryFilter{Login: "login", Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+
+	history, err = memory.GetBalanceHistory(ctx,
+		model.BalanceHistoryFilter{Login: "login", AfterID: history[0].ID, Type: model.HistoryTypeDebit})
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, model.Money(-100), history[0].Amount)
+	assert.Equal(t, model.Money(500), history[0].Balance)
+	assert.Equal(t, "49927398716", history[0].OrderID)
+}
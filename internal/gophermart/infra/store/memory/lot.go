@@ -0,0 +1,149 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+func (s *Memory) GetExpiredLots(ctx context.Context, now time.Time) ([]model.PointsLot, error) {
+	s.userBMu.Lock()
+	defer s.userBMu.Unlock()
+
+	var lots []model.PointsLot
+	for _, lot := range s.lots {
+		if lot.Remaining > 0 && !lot.ExpiresAt.After(now) {
+			lots = append(lots, lot)
+		}
+	}
+
+	return lots, nil
+}
+
+func (s *Memory) ExpireLot(ctx context.Context, lot model.PointsLot, now time.Time) error {
+	s.userBMu.Lock()
+	defer s.userBMu.Unlock()
+
+	i := slices.IndexFunc(s.lots, func(stored model.PointsLot) bool {
+		return stored.ID == lot.ID
+	})
+	if i < 0 {
+		return repositories.ErrNotFound
+	}
+
+	stored := s.lots[i]
+	if stored.Remaining == 0 || stored.ExpiresAt.After(now) {
+		return repositories.ErrInvalidState
+	}
+
+	transaction := model.ExpiryTransaction(uuid.NewString(), stored.Login, stored.OrderID, stored.Remaining)
+	if err := s.postLocked(transaction); err != nil {
+		return err
+	}
+
+	s.lots[i].Remaining = 0
+
+	return nil
+}
+
+func (s *Memory) GetExpiringPoints(ctx context.Context, login string, before time.Time) (model.Money, error) {
+	s.userBMu.Lock()
+	defer s.userBMu.Unlock()
+
+	var amount model.Money
+	for _, lot := range s.lots {
+		if lot.Login == login && lot.ExpiresAt.Before(before) {
+			amount += lot.Remaining
+		}
+	}
+
+	return amount, nil
+}
+
+// consumeLotsLocked takes amount off the lots of login that expire first,
+// records what it took for holds and withdrawals and carries it over to the
+// recipient of a transfer. Points beyond the lots don't expire and aren't
+// tracked. The caller must hold userBMu.
+func (s *Memory) consumeLotsLocked(login string, amount model.Money, transaction model.LedgerTransaction) {
+	var open []int
+	for i, lot := range s.lots {
+		if lot.Login == login && lot.Remaining > 0 {
+			open = append(open, i)
+		}
+	}
+
+	sort.SliceStable(open, func(i, j int) bool {
+		return s.lots[open[i]].ExpiresAt.Before(s.lots[open[j]].ExpiresAt)
+	})
+
+	for _, i := range open {
+		if amount == 0 {
+			return
+		}
+
+		take := min(amount, s.lots[i].Remaining)
+		s.lots[i].Remaining -= take
+		amount -= take
+
+		if transaction.RecordsLotUse() {
+			s.lotUses = append(s.lotUses, model.PointsLotUse{
+				Login:   login,
+				OrderID: transaction.OrderID,
+				LotID:   s.lots[i].ID,
+				Amount:  take,
+			})
+		}
+
+		if recipient := transaction.LotRecipient(); recipient != "" {
+			s.lots = append(s.lots, model.PointsLot{
+				ID:            int64(len(s.lots) + 1),
+				TransactionID: transaction.ID,
+				Login:         recipient,
+				Amount:        take,
+				Remaining:     take,
+				CreatedAt:     time.Now(),
+				ExpiresAt:     s.lots[i].ExpiresAt,
+			})
+		}
+	}
+}
+
+// restoreLotsLocked puts up to amount points that the hold or withdrawal of
+// orderID took off the lots back into them, the lots that expire last
+// first. Lots that expired meanwhile expire again on the next run. The
+// caller must hold userBMu.
+func (s *Memory) restoreLotsLocked(login, orderID string, amount model.Money) {
+	lots := make(map[int64]int, len(s.lots))
+	for i, lot := range s.lots {
+		lots[lot.ID] = i
+	}
+
+	var uses []int
+	for i, use := range s.lotUses {
+		if use.Login == login && use.OrderID == orderID && use.Amount > 0 {
+			uses = append(uses, i)
+		}
+	}
+
+	sort.SliceStable(uses, func(i, j int) bool {
+		first, second := s.lots[lots[s.lotUses[uses[i]].LotID]], s.lots[lots[s.lotUses[uses[j]].LotID]]
+		return first.ExpiresAt.After(second.ExpiresAt)
+	})
+
+	for _, i := range uses {
+		if amount == 0 {
+			return
+		}
+
+		give := min(amount, s.lotUses[i].Amount)
+		s.lotUses[i].Amount -= give
+		s.lots[lots[s.lotUses[i].LotID]].Remaining += give
+		amount -= give
+	}
+}
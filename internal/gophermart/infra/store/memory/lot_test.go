@@ -0,0 +1,124 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+func TestMemory_PointsLots(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	soon := now.Add(time.Hour)
+	later := now.Add(48 * time.Hour)
+
+	memory, err := New()
+	require.NoError(t, err)
+	require.NoError(t, memory.CreateUser(ctx, "login", "hash"))
+
+	for _, orderID := range []string{"12345678903", "2377225624", "49927398716"} {
+		require.NoError(t, memory.SaveOrder(ctx, "login", model.OrderRequest{ID: orderID, Status: model.OrderStatusNew}))
+	}
+
+	require.NoError(t, memory.SetBalance(ctx, model.Accrual{
+		OrderID: "12345678903", Status: model.OrderStatusDone, Amount: 300, ExpiresAt: &later,
+	}))
+	require.NoError(t, memory.SetBalance(ctx, model.Accrual{
+		OrderID: "2377225624", Status: model.OrderStatusDone, Amount: 200, ExpiresAt: &soon,
+	}))
+	require.NoError(t, memory.SetBalance(ctx, model.Accrual{
+		OrderID: "49927398716", Status: model.OrderStatusDone, Amount: 100,
+	}))
+
+	expiring, err := memory.GetExpiringPoints(ctx, "login", now.Add(24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, model.Money(200), expiring)
+
+	// The lot expiring first is spent first.
+	require.NoError(t, memory.UserWithdraw(ctx, "login", model.Withdraw{OrderID: "79927398713", Amount: 250}))
+
+	expiring, err = memory.GetExpiringPoints(ctx, "login", now.Add(72*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, model.Money(250), expiring)
+
+	lots, err := memory.GetExpiredLots(ctx, now)
+	require.NoError(t, err)
+	assert.Empty(t, lots)
+
+	lots, err = memory.GetExpiredLots(ctx, later)
+	require.NoError(t, err)
+	require.Len(t, lots, 1)
+	assert.Equal(t, "12345678903", lots[0].OrderID)
+	assert.Equal(t, model.Money(250), lots[0].Remaining)
+
+	require.ErrorIs(t, memory.ExpireLot(ctx, lots[0], now), repositories.ErrInvalidState)
+	require.NoError(t, memory.ExpireLot(ctx, lots[0], later))
+	require.ErrorIs(t, memory.ExpireLot(ctx, lots[0], later), repositories.ErrInvalidState)
+	require.ErrorIs(t, memory.ExpireLot(ctx, model.PointsLot{ID: 42}, later), repositories.ErrNotFound)
+
+	balance, err := memory.GetUserBalance(ctx, "login")
+	require.NoError(t, err)
+	assert.Equal(t, model.UserBalance{Amount: 100, Withdraw: 250}, balance)
+
+	ledger, err := memory.GetUserLedger(ctx, "login")
+	require.NoError(t, err)
+	assert.Equal(t, model.LedgerKindExpiry, ledger[len(ledger)-1].Kind)
+}
+
+func TestMemory_PointsLotsRestored(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	soon := now.Add(time.Hour)
+	later := now.Add(48 * time.Hour)
+
+	memory, err := New()
+	require.NoError(t, err)
+	require.NoError(t, memory.CreateUser(ctx, "login", "hash"))
+
+	for _, orderID := range []string{"12345678903", "2377225624"} {
+		require.NoError(t, memory.SaveOrder(ctx, "login", model.OrderRequest{ID: orderID, Status: model.OrderStatusNew}))
+	}
+
+	require.NoError(t, memory.SetBalance(ctx, model.Accrual{
+		OrderID: "12345678903", Status: model.OrderStatusDone, Amount: 300, ExpiresAt: &later,
+	}))
+	require.NoError(t, memory.SetBalance(ctx, model.Accrual{
+		OrderID: "2377225624", Status: model.OrderStatusDone, Amount: 200, ExpiresAt: &soon,
+	}))
+
+	expiring := func(before time.Time) model.Money {
+		amount, err := memory.GetExpiringPoints(ctx, "login", before)
+		require.NoError(t, err)
+
+		return amount
+	}
+
+	// A released hold gives the points back to the lots they came from.
+	expiresAt := now.Add(time.Minute)
+	require.NoError(t, memory.HoldWithdraw(ctx, "login", model.Withdraw{
+		OrderID: "49927398716", Amount: 250, ExpiresAt: &expiresAt,
+	}))
+	assert.Equal(t, model.Money(250), expiring(now.Add(72*time.Hour)))
+
+	require.NoError(t, memory.ReleaseWithdraw(ctx, "login", "49927398716", model.WithdrawStatusReleased))
+	assert.Equal(t, model.Money(200), expiring(now.Add(24*time.Hour)))
+	assert.Equal(t, model.Money(500), expiring(now.Add(72*time.Hour)))
+
+	// Refunds fill the lots that expire last first.
+	require.NoError(t, memory.UserWithdraw(ctx, "login", model.Withdraw{OrderID: "79927398713", Amount: 400}))
+	assert.Equal(t, model.Money(100), expiring(now.Add(72*time.Hour)))
+
+	for _, id := range []string{"r-1", "r-2"} {
+		_, _, err = memory.RefundWithdraw(ctx, model.WithdrawRefund{ID: id, OrderID: "79927398713", Amount: 150})
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, model.Money(100), expiring(now.Add(24*time.Hour)))
+	assert.Equal(t, model.Money(400), expiring(now.Add(72*time.Hour)))
+}
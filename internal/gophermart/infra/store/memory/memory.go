@@ -6,6 +6,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
+
 	"gofermart/internal/gophermart/core/model"
 	"gofermart/internal/gophermart/core/repositories"
 )
@@ -14,44 +16,128 @@ type Config struct {
 }
 
 type Memory struct {
-	mu          *sync.Mutex
-	orderMu     *sync.Mutex
-	userBMu     *sync.Mutex
-	withdrawMu  *sync.Mutex
-	users       map[string]string
-	orders      map[string]Order
-	userBalance map[string]UserBalance
-	withdraws   map[string]Withdraw
+	mu            *sync.Mutex
+	orderMu       *sync.Mutex
+	userBMu       *sync.Mutex
+	withdrawMu    *sync.Mutex
+	tokenMu       *sync.Mutex
+	attemptMu     *sync.Mutex
+	apiKeyMu      *sync.Mutex
+	totpMu        *sync.Mutex
+	sessionMu     *sync.Mutex
+	idempotencyMu *sync.Mutex
+	promotionMu   *sync.Mutex
+	referralMu    *sync.Mutex
+	users         map[string]User
+	orders        map[string]Order
+	userBalance   map[string]map[string]UserBalance
+	withdraws     map[string]Withdraw
+	refunds       map[string]model.WithdrawRefund
+	refreshTokens map[string]RefreshToken
+	revokedTokens map[string]time.Time
+	loginAttempts map[string]LoginAttempts
+	apiKeys       map[string]model.APIKey
+	totps         map[string]model.TOTP
+	recoveryCodes map[string]map[string]bool
+	sessions      map[string]model.Session
+	// idempotencyKeys is keyed by login and key, see idempotencyMapKey.
+	idempotencyKeys map[string]model.IdempotencyKey
+	// ledger is guarded by userBMu, userBalance is its snapshot keyed by
+	// login and wallet currency.
+	ledger []model.LedgerEntry
+	// lots and lotUses are guarded by userBMu as well.
+	lots    []model.PointsLot
+	lotUses []model.PointsLotUse
+	// promotions and promotionAwards are guarded by promotionMu.
+	promotions      map[string]model.Promotion
+	promotionAwards []model.PromotionAward
+	// referrals are guarded by referralMu.
+	referrals []model.Referral
+}
+
+type User struct {
+	CreatedAt         time.Time
+	PasswordChangedAt *time.Time
+	Password          string
+	Role              string
+	Tier              string
+	// ReferralCode is empty until the user first asks for it.
+	ReferralCode string
+	Disabled     bool
 }
 
 type Order struct {
 	CreatedAt time.Time
 	Login     string
 	Status    string
-	Amount    int
+	// Currency is the wallet the first credit of the order went to.
+	Currency string
+	Amount   model.Money
+	Bonus    model.Money
 }
 
 type UserBalance struct {
-	Amount   int
-	Withdraw int
+	Amount   model.Money
+	Held     model.Money
+	Withdraw model.Money
+	Debt     model.Money
 }
 
 type Withdraw struct {
 	CreatedAt time.Time
+	ExpiresAt *time.Time
+	Login     string
+	Status    string
+	Currency  string
+	Partner   string
+	Amount    model.Money
+	Refunded  model.Money
+}
+
+type LoginAttempts struct {
+	LastFailureAt time.Time
+	LockedUntil   *time.Time
+	Failures      int
+}
+
+type RefreshToken struct {
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	UsedAt    *time.Time
 	Login     string
-	Amount    int
+	FamilyID  string
+	Revoked   bool
 }
 
 func New() (*Memory, error) {
 	return &Memory{
-		mu:          &sync.Mutex{},
-		orderMu:     &sync.Mutex{},
-		userBMu:     &sync.Mutex{},
-		withdrawMu:  &sync.Mutex{},
-		users:       make(map[string]string),
-		orders:      make(map[string]Order),
-		userBalance: make(map[string]UserBalance),
-		withdraws:   make(map[string]Withdraw),
+		mu:            &sync.Mutex{},
+		orderMu:       &sync.Mutex{},
+		userBMu:       &sync.Mutex{},
+		withdrawMu:    &sync.Mutex{},
+		tokenMu:       &sync.Mutex{},
+		attemptMu:     &sync.Mutex{},
+		apiKeyMu:      &sync.Mutex{},
+		totpMu:        &sync.Mutex{},
+		sessionMu:     &sync.Mutex{},
+		idempotencyMu: &sync.Mutex{},
+		promotionMu:   &sync.Mutex{},
+		referralMu:    &sync.Mutex{},
+		users:         make(map[string]User),
+		orders:        make(map[string]Order),
+		userBalance:   make(map[string]map[string]UserBalance),
+		withdraws:     make(map[string]Withdraw),
+		refunds:       make(map[string]model.WithdrawRefund),
+		refreshTokens: make(map[string]RefreshToken),
+		revokedTokens: make(map[string]time.Time),
+		loginAttempts: make(map[string]LoginAttempts),
+		apiKeys:       make(map[string]model.APIKey),
+		totps:         make(map[string]model.TOTP),
+		recoveryCodes: make(map[string]map[string]bool),
+		sessions:      make(map[string]model.Session),
+
+		idempotencyKeys: make(map[string]model.IdempotencyKey),
+		promotions:      make(map[string]model.Promotion),
 	}, nil
 }
 
@@ -74,8 +160,12 @@ func (s *Memory) CreateUser(ctx context.Context, login, password string) error {
 		return repositories.ErrDuplicate
 	}
 
-	s.users[login] = password
-	s.userBalance[login] = UserBalance{}
+	s.users[login] = User{
+		Password:  password,
+		Role:      model.RoleUser,
+		CreatedAt: time.Now(),
+	}
+	s.userBalance[login] = map[string]UserBalance{model.DefaultCurrency: {}}
 
 	return nil
 }
@@ -84,12 +174,92 @@ func (s *Memory) GetUserPassword(ctx context.Context, login string) (string, err
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	password, ok := s.users[login]
+	user, ok := s.users[login]
 	if !ok {
 		return "", repositories.ErrNotFound
 	}
 
-	return password, nil
+	return user.Password, nil
+}
+
+func (s *Memory) GetUser(ctx context.Context, login string) (model.Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[login]
+	if !ok {
+		return model.Account{}, repositories.ErrNotFound
+	}
+
+	return model.Account{
+		Login:             login,
+		Password:          user.Password,
+		Role:              user.Role,
+		Disabled:          user.Disabled,
+		CreatedAt:         user.CreatedAt,
+		PasswordChangedAt: user.PasswordChangedAt,
+	}, nil
+}
+
+func (s *Memory) UpdateUserPassword(ctx context.Context, login, password string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[login]
+	if !ok {
+		return repositories.ErrNotFound
+	}
+
+	user.Password = password
+	s.users[login] = user
+
+	return nil
+}
+
+func (s *Memory) ChangeUserPassword(ctx context.Context, login, password string, changedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[login]
+	if !ok {
+		return repositories.ErrNotFound
+	}
+
+	user.Password = password
+	user.PasswordChangedAt = &changedAt
+	s.users[login] = user
+
+	return nil
+}
+
+func (s *Memory) SetUserRole(ctx context.Context, login, role string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[login]
+	if !ok {
+		return repositories.ErrNotFound
+	}
+
+	user.Role = role
+	s.users[login] = user
+
+	return nil
+}
+
+func (s *Memory) SetUserDisabled(ctx context.Context, login string, disabled bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[login]
+	if !ok {
+		return repositories.ErrNotFound
+	}
+
+	user.Disabled = disabled
+	s.users[login] = user
+
+	return nil
 }
 
 func (s *Memory) SaveOrder(ctx context.Context, login string, order model.OrderRequest) error {
@@ -162,26 +332,103 @@ func (s *Memory) GetPendingOrders(ctx context.Context) ([]model.Order, error) {
 	return orders, nil
 }
 
-func (s *Memory) SetBalance(ctx context.Context, orderID, status string, amount int) error {
+func (s *Memory) SetBalance(ctx context.Context, accrual model.Accrual) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	s.userBMu.Lock()
 	defer s.userBMu.Unlock()
 
 	s.orderMu.Lock()
 	defer s.orderMu.Unlock()
 
-	order, ok := s.orders[orderID]
+	s.promotionMu.Lock()
+	defer s.promotionMu.Unlock()
+
+	s.referralMu.Lock()
+	defer s.referralMu.Unlock()
+
+	order, ok := s.orders[accrual.OrderID]
 	if !ok {
 		return repositories.ErrNotFound
 	}
 
-	s.userBalance[order.Login] = UserBalance{
-		Amount:   s.userBalance[order.Login].Amount + amount,
-		Withdraw: s.userBalance[order.Login].Withdraw,
+	for _, award := range accrual.Promotions {
+		if s.promotionAwardedLocked(award, accrual.OrderID, order.Login) {
+			return repositories.ErrDuplicate
+		}
+	}
+
+	if order.Amount == 0 && order.Bonus == 0 {
+		order.Currency = model.WalletCurrency(accrual.Currency)
+	}
+
+	accrual.Currency = order.Currency
+
+	delta := accrual.Amount - order.Amount
+	if delta != 0 {
+		transaction := model.CorrectionTransaction(uuid.NewString(), order.Login, accrual.OrderID,
+			model.LedgerAccountAccrual, delta, s.userBalance[order.Login][order.Currency].Amount, "accrual corrected")
+		if order.Amount == 0 {
+			transaction = model.AccrualTransaction(uuid.NewString(), order.Login, accrual.OrderID, delta)
+		}
+
+		transaction.Currency = order.Currency
+		transaction.ExpiresAt = accrual.ExpiresAt
+		if err := s.postLocked(transaction); err != nil {
+			return err
+		}
+	}
+
+	bonusDelta := accrual.Bonus - order.Bonus
+	if bonusDelta != 0 {
+		transaction := model.CorrectionTransaction(uuid.NewString(), order.Login, accrual.OrderID,
+			model.LedgerAccountTierBonus, bonusDelta, s.userBalance[order.Login][order.Currency].Amount,
+			"tier bonus corrected")
+		if order.Bonus == 0 {
+			transaction = model.TierBonusTransaction(uuid.NewString(), order.Login, accrual.OrderID, bonusDelta,
+				accrual.Tier)
+		}
+
+		transaction.Currency = order.Currency
+		transaction.ExpiresAt = accrual.ExpiresAt
+		if err := s.postLocked(transaction); err != nil {
+			return err
+		}
 	}
 
-	order.Amount = amount
-	order.Status = status
-	s.orders[orderID] = order
+	for _, award := range accrual.Promotions {
+		transaction := model.PromotionTransaction(uuid.NewString(), order.Login, accrual.OrderID, award.Amount,
+			award.Name)
+		transaction.Currency = order.Currency
+		transaction.ExpiresAt = accrual.ExpiresAt
+		if err := s.postLocked(transaction); err != nil {
+			return err
+		}
+
+		award.TransactionID = transaction.ID
+		award.OrderID = accrual.OrderID
+		award.Login = order.Login
+		award.CreatedAt = time.Now()
+		s.promotionAwards = append(s.promotionAwards, award)
+	}
+
+	if delta > 0 || bonusDelta > 0 || len(accrual.Promotions) > 0 {
+		if err := s.repayLocked(order.Login, order.Currency, accrual.OrderID); err != nil {
+			return err
+		}
+	}
+
+	if accrual.Referral != nil {
+		if err := s.rewardReferralLocked(order.Login, accrual); err != nil {
+			return err
+		}
+	}
+
+	order.Amount = accrual.Amount
+	order.Bonus = accrual.Bonus
+	order.Status = accrual.Status
+	s.orders[accrual.OrderID] = order
 
 	return nil
 }
@@ -190,43 +437,67 @@ func (s *Memory) GetUserBalance(ctx context.Context, login string) (model.UserBa
 	s.userBMu.Lock()
 	defer s.userBMu.Unlock()
 
-	balance, ok := s.userBalance[login]
-	if !ok {
+	if _, ok := s.userBalance[login]; !ok {
 		return model.UserBalance{}, repositories.ErrNotFound
 	}
 
-	return model.UserBalance{
-		Amount:   balance.Amount,
-		Withdraw: balance.Withdraw,
-	}, nil
+	return s.ledgerBalanceLocked(login, model.DefaultCurrency), nil
 }
 
-func (s *Memory) UserWithdraw(ctx context.Context, login string, request model.Withdraw) error {
+func (s *Memory) GetUserWallets(ctx context.Context, login string) ([]model.Wallet, error) {
 	s.userBMu.Lock()
 	defer s.userBMu.Unlock()
 
-	s.withdrawMu.Lock()
-	defer s.withdrawMu.Unlock()
-
-	balance, ok := s.userBalance[login]
+	wallets, ok := s.userBalance[login]
 	if !ok {
-		return repositories.ErrNotFound
+		return nil, repositories.ErrNotFound
 	}
 
-	if balance.Amount < request.Amount {
-		return repositories.ErrInsufficientFunds
+	result := make([]model.Wallet, 0, len(wallets))
+	for currency := range wallets {
+		result = append(result, model.Wallet{
+			Currency:    currency,
+			UserBalance: s.ledgerBalanceLocked(login, currency),
+		})
 	}
 
-	balance.Amount -= request.Amount
-	balance.Withdraw += request.Amount
-	s.userBalance[login] = balance
+	sort.Slice(result, func(i, j int) bool {
+		if (result[i].Currency == model.DefaultCurrency) != (result[j].Currency == model.DefaultCurrency) {
+			return result[i].Currency == model.DefaultCurrency
+		}
+
+		return result[i].Currency < result[j].Currency
+	})
+
+	return result, nil
+}
+
+func (s *Memory) UserWithdraw(ctx context.Context, login string, request model.Withdraw) error {
+	s.userBMu.Lock()
+	defer s.userBMu.Unlock()
+
+	s.withdrawMu.Lock()
+	defer s.withdrawMu.Unlock()
 
 	if _, ok := s.withdraws[request.OrderID]; ok {
 		return repositories.ErrDuplicate
 	}
 
+	if err := s.checkCapsLocked(login, request); err != nil {
+		return err
+	}
+
+	transaction := model.WithdrawalTransaction(uuid.NewString(), login, request.OrderID, request.Amount)
+	transaction.Currency = model.WalletCurrency(request.Currency)
+	if err := s.postLocked(transaction); err != nil {
+		return err
+	}
+
 	s.withdraws[request.OrderID] = Withdraw{
 		Login:     login,
+		Status:    model.WithdrawStatusCaptured,
+		Currency:  transaction.Currency,
+		Partner:   request.Partner,
 		Amount:    request.Amount,
 		CreatedAt: time.Now(),
 	}
@@ -234,6 +505,44 @@ func (s *Memory) UserWithdraw(ctx context.Context, login string, request model.W
 	return nil
 }
 
+func (s *Memory) GetUserWithdrawn(ctx context.Context, login, currency string,
+	since time.Time) (model.Money, error) {
+	s.withdrawMu.Lock()
+	defer s.withdrawMu.Unlock()
+
+	return s.withdrawnLocked(login, currency, since), nil
+}
+
+// withdrawnLocked sums what login withdrew from the wallet in currency
+// since then, net of refunds. The caller must hold withdrawMu.
+func (s *Memory) withdrawnLocked(login, currency string, since time.Time) model.Money {
+	var withdrawn model.Money
+	for orderID, withdraw := range s.withdraws {
+		if withdraw.Login != login || withdraw.Currency != currency || withdraw.CreatedAt.Before(since) {
+			continue
+		}
+
+		if w := s.withdrawLocked(orderID); w.Withdrawn() {
+			withdrawn += w.Amount - w.Refunded
+		}
+	}
+
+	return withdrawn
+}
+
+// checkCapsLocked fails with ErrLimitExceeded if request would break one of
+// its caps. The caller must hold withdrawMu.
+func (s *Memory) checkCapsLocked(login string, request model.Withdraw) error {
+	currency := model.WalletCurrency(request.Currency)
+	for _, c := range request.Caps {
+		if s.withdrawnLocked(login, currency, c.Since)+request.Amount > c.Limit {
+			return repositories.ErrLimitExceeded
+		}
+	}
+
+	return nil
+}
+
 func (s *Memory) GetUserWithdrawals(ctx context.Context, login string) ([]model.Withdraw, error) {
 	s.withdrawMu.Lock()
 	defer s.withdrawMu.Unlock()
@@ -241,11 +550,7 @@ func (s *Memory) GetUserWithdrawals(ctx context.Context, login string) ([]model.
 	var withdrawals []model.Withdraw
 	for orderID, withdraw := range s.withdraws {
 		if withdraw.Login == login {
-			withdrawals = append(withdrawals, model.Withdraw{
-				OrderID:   orderID,
-				Amount:    withdraw.Amount,
-				CreatedAt: withdraw.CreatedAt,
-			})
+			withdrawals = append(withdrawals, s.withdrawLocked(orderID))
 		}
 	}
 
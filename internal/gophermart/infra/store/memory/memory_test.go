@@ -3,6 +3,7 @@ package memory
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -20,15 +21,43 @@ func TestNew(t *testing.T) {
 	require.NotNil(t, memory.orderMu)
 	require.NotNil(t, memory.userBMu)
 	require.NotNil(t, memory.withdrawMu)
+	require.NotNil(t, memory.tokenMu)
+	require.NotNil(t, memory.attemptMu)
+	require.NotNil(t, memory.apiKeyMu)
+	require.NotNil(t, memory.totpMu)
+	require.NotNil(t, memory.sessionMu)
+	require.NotNil(t, memory.idempotencyMu)
+	require.NotNil(t, memory.promotionMu)
 	require.NotNil(t, memory.users)
 	require.NotNil(t, memory.orders)
 	require.NotNil(t, memory.userBalance)
 	require.NotNil(t, memory.withdraws)
+	require.NotNil(t, memory.refunds)
+	require.NotNil(t, memory.refreshTokens)
+	require.NotNil(t, memory.revokedTokens)
+	require.NotNil(t, memory.loginAttempts)
+	require.NotNil(t, memory.apiKeys)
+	require.NotNil(t, memory.totps)
+	require.NotNil(t, memory.recoveryCodes)
+	require.NotNil(t, memory.sessions)
+	require.NotNil(t, memory.idempotencyKeys)
+	require.NotNil(t, memory.promotions)
 
 	require.Empty(t, memory.users)
 	require.Empty(t, memory.orders)
 	require.Empty(t, memory.userBalance)
 	require.Empty(t, memory.withdraws)
+	require.Empty(t, memory.refunds)
+	require.Empty(t, memory.refreshTokens)
+	require.Empty(t, memory.revokedTokens)
+	require.Empty(t, memory.loginAttempts)
+	require.Empty(t, memory.apiKeys)
+	require.Empty(t, memory.totps)
+	require.Empty(t, memory.recoveryCodes)
+	require.Empty(t, memory.sessions)
+	require.Empty(t, memory.idempotencyKeys)
+	require.Empty(t, memory.ledger)
+	require.Empty(t, memory.lots)
 }
 
 func TestMemory_PingAndClose(t *testing.T) {
@@ -90,6 +119,83 @@ func TestMemory_OtherFunc(t *testing.T) {
 		assert.Error(t, repositories.ErrNotFound, err.Error())
 	})
 
+	t.Run("UpdateUserPassword", func(t *testing.T) {
+		memory, err := New()
+		require.NoError(t, err)
+
+		err = memory.UpdateUserPassword(ctx, login, "new")
+		require.ErrorIs(t, err, repositories.ErrNotFound)
+
+		err = memory.CreateUser(ctx, login, pass)
+		require.NoError(t, err)
+
+		err = memory.UpdateUserPassword(ctx, login, "new")
+		require.NoError(t, err)
+
+		password, err := memory.GetUserPassword(ctx, login)
+		require.NoError(t, err)
+		require.Equal(t, "new", password)
+	})
+
+	t.Run("ChangeUserPassword", func(t *testing.T) {
+		memory, err := New()
+		require.NoError(t, err)
+
+		changedAt := time.Now()
+
+		err = memory.ChangeUserPassword(ctx, login, "new", changedAt)
+		require.ErrorIs(t, err, repositories.ErrNotFound)
+
+		err = memory.CreateUser(ctx, login, pass)
+		require.NoError(t, err)
+
+		err = memory.ChangeUserPassword(ctx, login, "new", changedAt)
+		require.NoError(t, err)
+
+		account, err := memory.GetUser(ctx, login)
+		require.NoError(t, err)
+		require.Equal(t, "new", account.Password)
+		require.Equal(t, &changedAt, account.PasswordChangedAt)
+	})
+
+	t.Run("GetUser", func(t *testing.T) {
+		memory, err := New()
+		require.NoError(t, err)
+
+		_, err = memory.GetUser(ctx, login)
+		require.ErrorIs(t, err, repositories.ErrNotFound)
+
+		err = memory.CreateUser(ctx, login, pass)
+		require.NoError(t, err)
+
+		account, err := memory.GetUser(ctx, login)
+		require.NoError(t, err)
+		assert.Equal(t, login, account.Login)
+		assert.Equal(t, pass, account.Password)
+		assert.Equal(t, model.RoleUser, account.Role)
+		assert.False(t, account.Disabled)
+	})
+
+	t.Run("SetUserRoleAndDisabled", func(t *testing.T) {
+		memory, err := New()
+		require.NoError(t, err)
+
+		require.ErrorIs(t, memory.SetUserRole(ctx, login, model.RoleAdmin), repositories.ErrNotFound)
+		require.ErrorIs(t, memory.SetUserDisabled(ctx, login, true), repositories.ErrNotFound)
+
+		err = memory.CreateUser(ctx, login, pass)
+		require.NoError(t, err)
+
+		require.NoError(t, memory.SetUserRole(ctx, login, model.RoleSupport))
+		require.NoError(t, memory.SetUserDisabled(ctx, login, true))
+
+		account, err := memory.GetUser(ctx, login)
+		require.NoError(t, err)
+		assert.Equal(t, model.RoleSupport, account.Role)
+		assert.True(t, account.Disabled)
+		assert.Equal(t, pass, account.Password)
+	})
+
 	t.Run("SaveOrder", func(t *testing.T) {
 		memory, err := New()
 		require.NoError(t, err)
@@ -184,9 +290,9 @@ func TestMemory_OtherFunc(t *testing.T) {
 
 		orderID := uuid.NewString()
 
-		amount := 100
+		amount := model.Money(100)
 
-		err = memory.SetBalance(ctx, orderID, model.OrderStatusDone, amount)
+		err = memory.SetBalance(ctx, model.Accrual{OrderID: orderID, Status: model.OrderStatusDone, Amount: amount})
 		require.Error(t, err)
 		assert.Error(t, repositories.ErrNotFound, err.Error())
 
@@ -199,7 +305,7 @@ func TestMemory_OtherFunc(t *testing.T) {
 		})
 		require.NoError(t, err)
 
-		err = memory.SetBalance(ctx, orderID, model.OrderStatusDone, amount)
+		err = memory.SetBalance(ctx, model.Accrual{OrderID: orderID, Status: model.OrderStatusDone, Amount: amount})
 		require.NoError(t, err)
 
 		balance, err := memory.GetUserBalance(ctx, login)
@@ -214,7 +320,7 @@ func TestMemory_OtherFunc(t *testing.T) {
 		})
 		require.NoError(t, err)
 
-		err = memory.SetBalance(ctx, newOrderID, model.OrderStatusDone, amount)
+		err = memory.SetBalance(ctx, model.Accrual{OrderID: newOrderID, Status: model.OrderStatusDone, Amount: amount})
 		require.NoError(t, err)
 
 		balance, err = memory.GetUserBalance(ctx, login)
@@ -235,12 +341,14 @@ func TestMemory_OtherFunc(t *testing.T) {
 
 		balance, err := memory.GetUserBalance(ctx, login)
 		require.NoError(t, err)
-		require.Equal(t, 0, balance.Amount)
-		require.Equal(t, 0, balance.Withdraw)
+		require.Equal(t, model.Money(0), balance.Amount)
+		require.Equal(t, model.Money(0), balance.Withdraw)
 
-		amount := 100
+		amount := model.Money(100)
 
-		err = memory.SetBalance(ctx, uuid.NewString(), model.OrderStatusDone, amount)
+		err = memory.SetBalance(ctx, model.Accrual{
+			OrderID: uuid.NewString(), Status: model.OrderStatusDone, Amount: amount,
+		})
 		require.Error(t, err)
 		assert.Error(t, repositories.ErrNotFound, err.Error())
 
@@ -252,20 +360,20 @@ func TestMemory_OtherFunc(t *testing.T) {
 		})
 		require.NoError(t, err)
 
-		err = memory.SetBalance(ctx, orderID, model.OrderStatusDone, amount)
+		err = memory.SetBalance(ctx, model.Accrual{OrderID: orderID, Status: model.OrderStatusDone, Amount: amount})
 		require.NoError(t, err)
 
 		balance, err = memory.GetUserBalance(ctx, login)
 		require.NoError(t, err)
 		require.Equal(t, amount, balance.Amount)
-		require.Equal(t, 0, balance.Withdraw)
+		require.Equal(t, model.Money(0), balance.Withdraw)
 	})
 
 	t.Run("UserWithdraw", func(t *testing.T) {
 		memory, err := New()
 		require.NoError(t, err)
 
-		amount := 100
+		amount := model.Money(100)
 		orderID := uuid.NewString()
 
 		err = memory.UserWithdraw(ctx, login, model.Withdraw{
@@ -291,7 +399,7 @@ func TestMemory_OtherFunc(t *testing.T) {
 		})
 		require.NoError(t, err)
 
-		err = memory.SetBalance(ctx, orderID, model.OrderStatusDone, amount)
+		err = memory.SetBalance(ctx, model.Accrual{OrderID: orderID, Status: model.OrderStatusDone, Amount: amount})
 		require.NoError(t, err)
 
 		err = memory.UserWithdraw(ctx, login, model.Withdraw{
@@ -302,7 +410,7 @@ func TestMemory_OtherFunc(t *testing.T) {
 
 		balance, err := memory.GetUserBalance(ctx, login)
 		require.NoError(t, err)
-		require.Equal(t, 0, balance.Amount)
+		require.Equal(t, model.Money(0), balance.Amount)
 		require.Equal(t, amount, balance.Withdraw)
 
 		err = memory.UserWithdraw(ctx, login, model.Withdraw{
@@ -313,6 +421,62 @@ func TestMemory_OtherFunc(t *testing.T) {
 		assert.Error(t, repositories.ErrDuplicate, err.Error())
 	})
 
+	t.Run("UserWithdrawCaps", func(t *testing.T) {
+		memory, err := New()
+		require.NoError(t, err)
+
+		orderID := uuid.NewString()
+		since := time.Now().Add(-time.Hour)
+
+		require.NoError(t, memory.CreateUser(ctx, login, pass))
+		require.NoError(t, memory.SaveOrder(ctx, login, model.OrderRequest{ID: orderID, Status: model.OrderStatusNew}))
+		require.NoError(t, memory.SetBalance(ctx, model.Accrual{
+			OrderID: orderID, Status: model.OrderStatusDone, Amount: 500,
+		}))
+
+		caps := []model.WithdrawCap{{Since: since, Limit: 250}}
+
+		err = memory.UserWithdraw(ctx, login, model.Withdraw{Amount: 100, OrderID: "1", Caps: caps})
+		require.NoError(t, err)
+
+		expiresAt := time.Now().Add(time.Hour)
+		err = memory.HoldWithdraw(ctx, login, model.Withdraw{
+			Amount: 100, OrderID: "2", ExpiresAt: &expiresAt, Caps: caps,
+		})
+		require.NoError(t, err)
+
+		err = memory.UserWithdraw(ctx, login, model.Withdraw{Amount: 100, OrderID: "3", Caps: caps})
+		require.ErrorIs(t, err, repositories.ErrLimitExceeded)
+
+		withdrawn, err := memory.GetUserWithdrawn(ctx, login, model.DefaultCurrency, since)
+		require.NoError(t, err)
+		require.Equal(t, model.Money(200), withdrawn)
+
+		// A released hold no longer counts against the cap.
+		require.NoError(t, memory.ReleaseWithdraw(ctx, login, "2", model.WithdrawStatusReleased))
+
+		err = memory.UserWithdraw(ctx, login, model.Withdraw{Amount: 100, OrderID: "3", Caps: caps})
+		require.NoError(t, err)
+
+		err = memory.UserWithdraw(ctx, login, model.Withdraw{Amount: 100, OrderID: "4", Caps: caps})
+		require.ErrorIs(t, err, repositories.ErrLimitExceeded)
+
+		// Neither does a refunded withdrawal.
+		_, _, err = memory.RefundWithdraw(ctx, model.WithdrawRefund{ID: "r-1", OrderID: "1", Reason: "cancelled"})
+		require.NoError(t, err)
+
+		withdrawn, err = memory.GetUserWithdrawn(ctx, login, model.DefaultCurrency, since)
+		require.NoError(t, err)
+		require.Equal(t, model.Money(100), withdrawn)
+
+		err = memory.UserWithdraw(ctx, login, model.Withdraw{Amount: 100, OrderID: "4", Caps: caps})
+		require.NoError(t, err)
+
+		withdrawn, err = memory.GetUserWithdrawn(ctx, login, model.DefaultCurrency, time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		require.Equal(t, model.Money(0), withdrawn)
+	})
+
 	t.Run("GetUserWithdrawals", func(t *testing.T) {
 		memory, err := New()
 		require.NoError(t, err)
@@ -327,7 +491,7 @@ func TestMemory_OtherFunc(t *testing.T) {
 		_, err = memory.GetUserWithdrawals(ctx, login)
 		require.NoError(t, err)
 
-		amount := 100
+		amount := model.Money(100)
 		orderID := uuid.NewString()
 
 		err = memory.SaveOrder(ctx, login, model.OrderRequest{
@@ -336,7 +500,7 @@ func TestMemory_OtherFunc(t *testing.T) {
 		})
 		require.NoError(t, err)
 
-		err = memory.SetBalance(ctx, orderID, model.OrderStatusDone, amount)
+		err = memory.SetBalance(ctx, model.Accrual{OrderID: orderID, Status: model.OrderStatusDone, Amount: amount})
 		require.NoError(t, err)
 
 		err = memory.UserWithdraw(ctx, login, model.Withdraw{
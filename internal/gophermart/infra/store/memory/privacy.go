@@ -0,0 +1,138 @@
+package memory
+
+import (
+	"context"
+
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+// DeleteUser moves the orders, withdrawals, refunds, balance, ledger, points
+// lots and promotion awards of login to anonymizedLogin and drops everything
+// else stored about the user.
+func (s *Memory) DeleteUser(ctx context.Context, login, anonymizedLogin string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.userBMu.Lock()
+	defer s.userBMu.Unlock()
+
+	s.orderMu.Lock()
+	defer s.orderMu.Unlock()
+
+	s.withdrawMu.Lock()
+	defer s.withdrawMu.Unlock()
+
+	s.tokenMu.Lock()
+	defer s.tokenMu.Unlock()
+
+	s.apiKeyMu.Lock()
+	defer s.apiKeyMu.Unlock()
+
+	s.totpMu.Lock()
+	defer s.totpMu.Unlock()
+
+	s.sessionMu.Lock()
+	defer s.sessionMu.Unlock()
+
+	s.idempotencyMu.Lock()
+	defer s.idempotencyMu.Unlock()
+
+	s.promotionMu.Lock()
+	defer s.promotionMu.Unlock()
+
+	s.referralMu.Lock()
+	defer s.referralMu.Unlock()
+
+	if _, ok := s.users[login]; !ok {
+		return repositories.ErrNotFound
+	}
+
+	for id, order := range s.orders {
+		if order.Login == login {
+			order.Login = anonymizedLogin
+			s.orders[id] = order
+		}
+	}
+
+	for id, withdraw := range s.withdraws {
+		if withdraw.Login == login {
+			withdraw.Login = anonymizedLogin
+			s.withdraws[id] = withdraw
+		}
+	}
+
+	for id, refund := range s.refunds {
+		if refund.Login == login {
+			refund.Login = anonymizedLogin
+			s.refunds[id] = refund
+		}
+	}
+
+	if balance, ok := s.userBalance[login]; ok {
+		s.userBalance[anonymizedLogin] = balance
+		delete(s.userBalance, login)
+	}
+
+	for i, entry := range s.ledger {
+		if entry.Login == login {
+			s.ledger[i].Login = anonymizedLogin
+		}
+	}
+
+	for i, lot := range s.lots {
+		if lot.Login == login {
+			s.lots[i].Login = anonymizedLogin
+		}
+	}
+
+	for i, use := range s.lotUses {
+		if use.Login == login {
+			s.lotUses[i].Login = anonymizedLogin
+		}
+	}
+
+	for i, award := range s.promotionAwards {
+		if award.Login == login {
+			s.promotionAwards[i].Login = anonymizedLogin
+		}
+	}
+
+	for i, referral := range s.referrals {
+		if referral.Referrer == login {
+			s.referrals[i].Referrer = anonymizedLogin
+		}
+		if referral.Referred == login {
+			s.referrals[i].Referred = anonymizedLogin
+		}
+	}
+
+	delete(s.users, login)
+	delete(s.totps, login)
+	delete(s.recoveryCodes, login)
+
+	for hash, token := range s.refreshTokens {
+		if token.Login == login {
+			delete(s.refreshTokens, hash)
+		}
+	}
+
+	for hash, key := range s.apiKeys {
+		if key.Login == login {
+			delete(s.apiKeys, hash)
+		}
+	}
+
+	for id, session := range s.sessions {
+		if session.Login == login {
+			delete(s.sessions, id)
+		}
+	}
+
+	for id, record := range s.idempotencyKeys {
+		if record.Login == login {
+			delete(s.idempotencyKeys, id)
+		}
+	}
+
+	return nil
+}
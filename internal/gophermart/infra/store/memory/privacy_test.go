@@ -0,0 +1,65 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+func TestMemory_DeleteUser(t *testing.T) {
+	ctx := context.Background()
+
+	memory, err := New()
+	require.NoError(t, err)
+
+	require.ErrorIs(t, memory.DeleteUser(ctx, "login", "deleted-1"), repositories.ErrNotFound)
+
+	require.NoError(t, memory.CreateUser(ctx, "login", "hash"))
+	require.NoError(t, memory.SaveOrder(ctx, "login", model.OrderRequest{ID: "12345678903", Status: model.OrderStatusNew}))
+	require.NoError(t, memory.SetBalance(ctx, model.Accrual{
+		OrderID: "12345678903", Status: model.OrderStatusDone, Amount: 500,
+	}))
+	require.NoError(t, memory.UserWithdraw(ctx, "login", model.Withdraw{OrderID: "2377225624", Amount: 100}))
+	require.NoError(t, memory.SaveRefreshToken(ctx, model.RefreshToken{TokenHash: "token", Login: "login"}))
+	require.NoError(t, memory.SaveAPIKey(ctx, model.APIKey{ID: "key", KeyHash: "key", Login: "login"}))
+	require.NoError(t, memory.SaveSession(ctx, model.Session{ID: "session", Login: "login", CreatedAt: time.Now()}))
+	require.NoError(t, memory.SaveTOTP(ctx, model.TOTP{Login: "login", Secret: "SECRET"}))
+
+	require.NoError(t, memory.DeleteUser(ctx, "login", "deleted-1"))
+
+	_, err = memory.GetUser(ctx, "login")
+	require.ErrorIs(t, err, repositories.ErrNotFound)
+
+	_, err = memory.GetRefreshToken(ctx, "token")
+	require.ErrorIs(t, err, repositories.ErrNotFound)
+
+	_, err = memory.GetAPIKey(ctx, "key")
+	require.ErrorIs(t, err, repositories.ErrNotFound)
+
+	_, err = memory.GetSession(ctx, "session")
+	require.ErrorIs(t, err, repositories.ErrNotFound)
+
+	_, err = memory.GetTOTP(ctx, "login")
+	require.ErrorIs(t, err, repositories.ErrNotFound)
+
+	orderLogin, err := memory.GetOrderLogin(ctx, "12345678903")
+	require.NoError(t, err)
+	assert.Equal(t, "deleted-1", orderLogin)
+
+	withdrawals, err := memory.GetUserWithdrawals(ctx, "deleted-1")
+	require.NoError(t, err)
+	assert.Len(t, withdrawals, 1)
+
+	balance, err := memory.GetUserBalance(ctx, "deleted-1")
+	require.NoError(t, err)
+	assert.Equal(t, model.UserBalance{Amount: 400, Withdraw: 100}, balance)
+
+	_, err = memory.GetUserBalance(ctx, "login")
+	require.ErrorIs(t, err, repositories.ErrNotFound)
+}
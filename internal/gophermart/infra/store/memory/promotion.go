@@ -0,0 +1,133 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+func (s *Memory) CreatePromotion(ctx context.Context, promotion model.Promotion) error {
+	s.promotionMu.Lock()
+	defer s.promotionMu.Unlock()
+
+	if _, ok := s.promotions[promotion.ID]; ok {
+		return repositories.ErrDuplicate
+	}
+
+	promotion.CreatedAt = time.Now()
+	s.promotions[promotion.ID] = promotion
+
+	return nil
+}
+
+func (s *Memory) UpdatePromotion(ctx context.Context, promotion model.Promotion) error {
+	s.promotionMu.Lock()
+	defer s.promotionMu.Unlock()
+
+	stored, ok := s.promotions[promotion.ID]
+	if !ok {
+		return repositories.ErrNotFound
+	}
+
+	promotion.CreatedAt = stored.CreatedAt
+	s.promotions[promotion.ID] = promotion
+
+	return nil
+}
+
+func (s *Memory) DeletePromotion(ctx context.Context, id string) error {
+	s.promotionMu.Lock()
+	defer s.promotionMu.Unlock()
+
+	if _, ok := s.promotions[id]; !ok {
+		return repositories.ErrNotFound
+	}
+
+	delete(s.promotions, id)
+
+	return nil
+}
+
+func (s *Memory) GetPromotion(ctx context.Context, id string) (model.Promotion, error) {
+	s.promotionMu.Lock()
+	defer s.promotionMu.Unlock()
+
+	promotion, ok := s.promotions[id]
+	if !ok {
+		return model.Promotion{}, repositories.ErrNotFound
+	}
+
+	return promotion, nil
+}
+
+func (s *Memory) GetPromotions(ctx context.Context) ([]model.Promotion, error) {
+	s.promotionMu.Lock()
+	defer s.promotionMu.Unlock()
+
+	return s.promotionsLocked(func(model.Promotion) bool { return true }), nil
+}
+
+func (s *Memory) GetActivePromotions(ctx context.Context, now time.Time) ([]model.Promotion, error) {
+	s.promotionMu.Lock()
+	defer s.promotionMu.Unlock()
+
+	return s.promotionsLocked(func(promotion model.Promotion) bool {
+		return !promotion.StartsAt.After(now) && (promotion.EndsAt == nil || promotion.EndsAt.After(now))
+	}), nil
+}
+
+func (s *Memory) GetPromotionAwards(ctx context.Context, promotionID string) ([]model.PromotionAward, error) {
+	s.promotionMu.Lock()
+	defer s.promotionMu.Unlock()
+
+	var awards []model.PromotionAward
+	for _, award := range s.promotionAwards {
+		if award.PromotionID == promotionID {
+			awards = append(awards, award)
+		}
+	}
+
+	return awards, nil
+}
+
+// promotionsLocked returns the promotions that match, oldest first. The
+// caller must hold promotionMu.
+func (s *Memory) promotionsLocked(match func(model.Promotion) bool) []model.Promotion {
+	var promotions []model.Promotion
+	for _, promotion := range s.promotions {
+		if match(promotion) {
+			promotions = append(promotions, promotion)
+		}
+	}
+
+	sort.Slice(promotions, func(i, j int) bool {
+		if promotions[i].CreatedAt.Equal(promotions[j].CreatedAt) {
+			return promotions[i].ID < promotions[j].ID
+		}
+
+		return promotions[i].CreatedAt.Before(promotions[j].CreatedAt)
+	})
+
+	return promotions
+}
+
+// promotionAwardedLocked tells whether the promotion already awarded a
+// bonus for the order. The caller must hold promotionMu.
+// promotionAwardedLocked tells whether the promotion of award already
+// awarded the order, or login if it is a first order award.
+func (s *Memory) promotionAwardedLocked(award model.PromotionAward, orderID, login string) bool {
+	for _, awarded := range s.promotionAwards {
+		if awarded.PromotionID != award.PromotionID {
+			continue
+		}
+
+		if awarded.OrderID == orderID || award.FirstOrder && awarded.FirstOrder && awarded.Login == login {
+			return true
+		}
+	}
+
+	return false
+}
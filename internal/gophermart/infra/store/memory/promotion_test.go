@@ -0,0 +1,157 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+func TestMemory_Promotions(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	ended := now.Add(-time.Hour)
+
+	memory, err := New()
+	require.NoError(t, err)
+
+	weekend := model.Promotion{ID: "p-1", Name: "weekend", Effect: model.PromotionEffectMultiplier, Multiplier: 200,
+		StartsAt: now.Add(-time.Hour)}
+	past := model.Promotion{ID: "p-2", Name: "past", Effect: model.PromotionEffectFixed, Bonus: 100,
+		StartsAt: now.Add(-2 * time.Hour), EndsAt: &ended}
+
+	require.NoError(t, memory.CreatePromotion(ctx, weekend))
+	require.NoError(t, memory.CreatePromotion(ctx, past))
+	require.ErrorIs(t, memory.CreatePromotion(ctx, weekend), repositories.ErrDuplicate)
+
+	active, err := memory.GetActivePromotions(ctx, now)
+	require.NoError(t, err)
+	require.Len(t, active, 1)
+	assert.Equal(t, "p-1", active[0].ID)
+
+	all, err := memory.GetPromotions(ctx)
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+
+	past.Name = "renamed"
+	require.NoError(t, memory.UpdatePromotion(ctx, past))
+
+	stored, err := memory.GetPromotion(ctx, "p-2")
+	require.NoError(t, err)
+	assert.Equal(t, "renamed", stored.Name)
+	assert.False(t, stored.CreatedAt.IsZero())
+
+	require.NoError(t, memory.DeletePromotion(ctx, "p-2"))
+	require.ErrorIs(t, memory.DeletePromotion(ctx, "p-2"), repositories.ErrNotFound)
+	require.ErrorIs(t, memory.UpdatePromotion(ctx, past), repositories.ErrNotFound)
+
+	_, err = memory.GetPromotion(ctx, "p-2")
+	require.ErrorIs(t, err, repositories.ErrNotFound)
+}
+
+func TestMemory_SetBalanceWithPromotions(t *testing.T) {
+	ctx := context.Background()
+
+	memory, err := New()
+	require.NoError(t, err)
+	require.NoError(t, memory.CreateUser(ctx, "login", "hash"))
+	require.NoError(t, memory.SaveOrder(ctx, "login", model.OrderRequest{ID: "12345678903", Status: model.OrderStatusNew}))
+
+	accrual := model.Accrual{
+		OrderID: "12345678903", Status: model.OrderStatusDone, Amount: 500,
+		Promotions: []model.PromotionAward{{PromotionID: "p-1", Name: "weekend", Amount: 500}},
+	}
+	require.NoError(t, memory.SetBalance(ctx, accrual))
+
+	balance, err := memory.GetUserBalance(ctx, "login")
+	require.NoError(t, err)
+	assert.Equal(t, model.Money(1000), balance.Amount)
+
+	awards, err := memory.GetPromotionAwards(ctx, "p-1")
+	require.NoError(t, err)
+	require.Len(t, awards, 1)
+	assert.Equal(t, "login", awards[0].Login)
+	assert.Equal(t, "12345678903", awards[0].OrderID)
+
+	ledger, err := memory.GetUserLedger(ctx, "login")
+	require.NoError(t, err)
+
+	var promotion model.LedgerEntry
+	for _, entry := range ledger {
+		if entry.Kind == model.LedgerKindPromotion && entry.Account == model.LedgerAccountAvailable {
+			promotion = entry
+		}
+	}
+	assert.Equal(t, awards[0].TransactionID, promotion.TransactionID)
+	assert.Equal(t, "promotion weekend", promotion.Description)
+
+	// A promotion awards an order once, nothing is posted on a second try.
+	require.ErrorIs(t, memory.SetBalance(ctx, accrual), repositories.ErrDuplicate)
+
+	balance, err = memory.GetUserBalance(ctx, "login")
+	require.NoError(t, err)
+	assert.Equal(t, model.Money(1000), balance.Amount)
+}
+
+func TestMemory_SetBalanceWithPromotionsInOtherWallet(t *testing.T) {
+	ctx := context.Background()
+
+	memory, err := New()
+	require.NoError(t, err)
+	require.NoError(t, memory.CreateUser(ctx, "login", "hash"))
+	require.NoError(t, memory.SaveOrder(ctx, "login", model.OrderRequest{ID: "12345678903", Status: model.OrderStatusNew}))
+
+	require.NoError(t, memory.SetBalance(ctx, model.Accrual{
+		OrderID: "12345678903", Status: model.OrderStatusDone, Amount: 500, Currency: "ACME",
+		Promotions: []model.PromotionAward{{PromotionID: "p-1", Name: "weekend", Amount: 50}},
+	}))
+
+	// The award goes to the wallet of the order, like the accrual.
+	wallets, err := memory.GetUserWallets(ctx, "login")
+	require.NoError(t, err)
+	assert.Equal(t, []model.Wallet{
+		{Currency: model.DefaultCurrency},
+		{Currency: "ACME", UserBalance: model.UserBalance{Amount: 550}},
+	}, wallets)
+}
+
+func TestMemory_SetBalanceWithFirstOrderPromotion(t *testing.T) {
+	ctx := context.Background()
+
+	memory, err := New()
+	require.NoError(t, err)
+	require.NoError(t, memory.CreateUser(ctx, "login", "hash"))
+
+	orders := []string{"12345678903", "2377225624"}
+	for _, orderID := range orders {
+		require.NoError(t, memory.SaveOrder(ctx, "login", model.OrderRequest{ID: orderID, Status: model.OrderStatusNew}))
+	}
+
+	// Both orders were taken for the first one when they were evaluated.
+	award := model.PromotionAward{PromotionID: "p-1", Name: "welcome", Amount: 500, FirstOrder: true}
+	require.NoError(t, memory.SetBalance(ctx, model.Accrual{
+		OrderID: orders[0], Status: model.OrderStatusDone, Amount: 100, Promotions: []model.PromotionAward{award},
+	}))
+
+	accrual := model.Accrual{
+		OrderID: orders[1], Status: model.OrderStatusDone, Amount: 100, Promotions: []model.PromotionAward{award},
+	}
+	require.ErrorIs(t, memory.SetBalance(ctx, accrual), repositories.ErrDuplicate)
+
+	accrual.Promotions = nil
+	require.NoError(t, memory.SetBalance(ctx, accrual))
+
+	awards, err := memory.GetPromotionAwards(ctx, "p-1")
+	require.NoError(t, err)
+	require.Len(t, awards, 1)
+	assert.Equal(t, orders[0], awards[0].OrderID)
+
+	balance, err := memory.GetUserBalance(ctx, "login")
+	require.NoError(t, err)
+	assert.Equal(t, model.Money(700), balance.Amount)
+}
@@ -0,0 +1,78 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"gofermart/internal/gophermart/core/model"
+)
+
+func (s *Memory) GetWalletAudits(ctx context.Context) ([]model.WalletAudit, error) {
+	s.userBMu.Lock()
+	defer s.userBMu.Unlock()
+
+	s.orderMu.Lock()
+	defer s.orderMu.Unlock()
+
+	s.withdrawMu.Lock()
+	defer s.withdrawMu.Unlock()
+
+	type walletKey struct {
+		login    string
+		currency string
+	}
+
+	audits := make(map[walletKey]*model.WalletAudit)
+	for login, wallets := range s.userBalance {
+		for currency, balance := range wallets {
+			audits[walletKey{login, currency}] = &model.WalletAudit{
+				Login:    login,
+				Currency: currency,
+				Snapshot: model.UserBalance{Amount: balance.Amount, Held: balance.Held, Withdraw: balance.Withdraw},
+				Ledger:   s.ledgerBalanceLocked(login, currency),
+			}
+		}
+	}
+
+	for _, entry := range s.ledger {
+		audit, ok := audits[walletKey{entry.Login, entry.Currency}]
+		if ok && entry.BooksOrders() {
+			audit.Booked -= entry.Amount
+		}
+	}
+
+	for _, order := range s.orders {
+		if audit, ok := audits[walletKey{order.Login, model.WalletCurrency(order.Currency)}]; ok {
+			audit.Accrued += order.Amount + order.Bonus
+		}
+	}
+
+	for orderID, withdraw := range s.withdraws {
+		audit, ok := audits[walletKey{withdraw.Login, withdraw.Currency}]
+		if !ok {
+			continue
+		}
+
+		switch w := s.withdrawLocked(orderID); {
+		case w.Processed():
+			audit.Withdrawn += w.Amount - w.Refunded
+		case w.Status == model.WithdrawStatusHeld:
+			audit.Held += w.Amount
+		}
+	}
+
+	result := make([]model.WalletAudit, 0, len(audits))
+	for _, audit := range audits {
+		result = append(result, *audit)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Login != result[j].Login {
+			return result[i].Login < result[j].Login
+		}
+
+		return result[i].Currency < result[j].Currency
+	})
+
+	return result, nil
+}
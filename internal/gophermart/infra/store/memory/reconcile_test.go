@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gofermart/internal/gophermart/core/model"
+)
+
+func TestMemory_GetWalletAudits(t *testing.T) {
+	ctx := context.Background()
+
+	memory, err := New()
+	require.NoError(t, err)
+	require.NoError(t, memory.CreateUser(ctx, "login", "hash"))
+	require.NoError(t, memory.SaveOrder(ctx, "login", model.OrderRequest{ID: "12345678903", Status: model.OrderStatusNew}))
+	require.NoError(t, memory.SetBalance(ctx, model.Accrual{
+		OrderID: "12345678903", Status: model.OrderStatusDone, Amount: 500, Bonus: 50,
+	}))
+	require.NoError(t, memory.UserWithdraw(ctx, "login", model.Withdraw{OrderID: "79927398713", Amount: 200}))
+
+	expiresAt := time.Now().Add(time.Hour)
+	require.NoError(t, memory.HoldWithdraw(ctx, "login", model.Withdraw{
+		OrderID: "2377225624", Amount: 100, ExpiresAt: &expiresAt,
+	}))
+
+	audits, err := memory.GetWalletAudits(ctx)
+	require.NoError(t, err)
+	require.Len(t, audits, 1)
+
+	audit := audits[0]
+	assert.Equal(t, model.DefaultCurrency, audit.Currency)
+	assert.Equal(t, model.UserBalance{Amount: 250, Held: 100, Withdraw: 200}, audit.Snapshot)
+	assert.Equal(t, audit.Snapshot, audit.Ledger)
+	assert.Equal(t, model.Money(550), audit.Accrued)
+	assert.Equal(t, model.Money(550), audit.Booked)
+	assert.Equal(t, model.Money(200), audit.Withdrawn)
+	assert.Equal(t, model.Money(100), audit.Held)
+
+	// An adjustment against the accrual account is booked for the orders.
+	transaction := model.ReconciliationTransaction("tx-1", "login", model.LedgerAccountAccrual, -50, "reconciliation")
+	require.NoError(t, memory.AddLedgerTransaction(ctx, transaction))
+
+	audits, err = memory.GetWalletAudits(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, model.Money(500), audits[0].Booked)
+	assert.Equal(t, model.Money(200), audits[0].Ledger.Amount)
+
+	// A reversed tier bonus stays booked for its order.
+	ledger, err := memory.GetUserLedger(ctx, "login")
+	require.NoError(t, err)
+
+	for _, entry := range ledger {
+		if entry.Kind != model.LedgerKindTierBonus || entry.Account != model.LedgerAccountAvailable {
+			continue
+		}
+
+		bonus, err := memory.GetLedgerTransaction(ctx, entry.TransactionID)
+		require.NoError(t, err)
+		require.NoError(t, memory.AddLedgerTransaction(ctx, bonus.Reversal("tx-2", "mistake")))
+	}
+
+	// So is the opening balance of a wallet that predates the ledger.
+	transaction = model.AdjustmentTransaction("tx-3", "login", 100, model.LedgerDescriptionOpeningBalance)
+	require.NoError(t, memory.AddLedgerTransaction(ctx, transaction))
+
+	audits, err = memory.GetWalletAudits(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, model.Money(600), audits[0].Booked)
+	assert.Equal(t, model.Money(250), audits[0].Ledger.Amount)
+}
@@ -0,0 +1,139 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+func (s *Memory) GetReferralCode(ctx context.Context, login string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[login]
+	if !ok {
+		return "", repositories.ErrNotFound
+	}
+
+	return user.ReferralCode, nil
+}
+
+// SetReferralCode gives login the code unless it has one already.
+func (s *Memory) SetReferralCode(ctx context.Context, login, code string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[login]
+	if !ok {
+		return repositories.ErrNotFound
+	}
+
+	if user.ReferralCode != "" {
+		return nil
+	}
+
+	for _, other := range s.users {
+		if other.ReferralCode == code {
+			return repositories.ErrDuplicate
+		}
+	}
+
+	user.ReferralCode = code
+	s.users[login] = user
+
+	return nil
+}
+
+func (s *Memory) GetReferrer(ctx context.Context, code string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for login, user := range s.users {
+		if user.ReferralCode == code {
+			return login, nil
+		}
+	}
+
+	return "", repositories.ErrNotFound
+}
+
+// CreateReferral saves the referral unless the referred user has one
+// already or the referrer has limit referrals, a zero limit doesn't apply.
+func (s *Memory) CreateReferral(ctx context.Context, referral model.Referral, limit int) error {
+	s.referralMu.Lock()
+	defer s.referralMu.Unlock()
+
+	var referred int
+	for _, stored := range s.referrals {
+		if stored.Referred == referral.Referred {
+			return repositories.ErrDuplicate
+		}
+		if stored.Referrer == referral.Referrer {
+			referred++
+		}
+	}
+
+	if limit > 0 && referred >= limit {
+		return repositories.ErrLimitExceeded
+	}
+
+	referral.CreatedAt = time.Now()
+	s.referrals = append(s.referrals, referral)
+
+	return nil
+}
+
+func (s *Memory) GetReferrals(ctx context.Context, referrer string) ([]model.Referral, error) {
+	s.referralMu.Lock()
+	defer s.referralMu.Unlock()
+
+	var referrals []model.Referral
+	for _, referral := range s.referrals {
+		if referral.Referrer == referrer {
+			referrals = append(referrals, referral)
+		}
+	}
+
+	return referrals, nil
+}
+
+// rewardReferralLocked credits the bonuses of the referral of login once.
+// A referrer that deleted their account gets nothing. The caller must hold
+// mu, userBMu and referralMu.
+func (s *Memory) rewardReferralLocked(login string, accrual model.Accrual) error {
+	for i, referral := range s.referrals {
+		if referral.Referred != login || referral.RewardedAt != nil {
+			continue
+		}
+
+		for _, bonus := range []struct {
+			login  string
+			amount model.Money
+		}{
+			{login: referral.Referrer, amount: accrual.Referral.Referrer},
+			{login: referral.Referred, amount: accrual.Referral.Referred},
+		} {
+			if _, ok := s.users[bonus.login]; !ok || bonus.amount == 0 {
+				continue
+			}
+
+			transaction := model.ReferralTransaction(uuid.NewString(), bonus.login, accrual.OrderID, bonus.amount)
+			transaction.ExpiresAt = accrual.ExpiresAt
+			if err := s.postLocked(transaction); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		s.referrals[i].RewardedAt = &now
+		s.referrals[i].OrderID = accrual.OrderID
+
+		return nil
+	}
+
+	return nil
+}
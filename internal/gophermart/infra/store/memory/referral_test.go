@@ -0,0 +1,74 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+func TestMemory_Referrals(t *testing.T) {
+	ctx := context.Background()
+
+	memory, err := New()
+	require.NoError(t, err)
+
+	for _, login := range []string{"referrer", "alice", "bob", "carol"} {
+		require.NoError(t, memory.CreateUser(ctx, login, "hash"))
+	}
+
+	code, err := memory.GetReferralCode(ctx, "referrer")
+	require.NoError(t, err)
+	assert.Empty(t, code)
+
+	require.NoError(t, memory.SetReferralCode(ctx, "referrer", "CODE1"))
+	require.NoError(t, memory.SetReferralCode(ctx, "referrer", "CODE2"))
+	require.ErrorIs(t, memory.SetReferralCode(ctx, "alice", "CODE1"), repositories.ErrDuplicate)
+
+	code, err = memory.GetReferralCode(ctx, "referrer")
+	require.NoError(t, err)
+	assert.Equal(t, "CODE1", code)
+
+	referrer, err := memory.GetReferrer(ctx, "CODE1")
+	require.NoError(t, err)
+	assert.Equal(t, "referrer", referrer)
+
+	_, err = memory.GetReferrer(ctx, "CODE2")
+	require.ErrorIs(t, err, repositories.ErrNotFound)
+
+	require.NoError(t, memory.CreateReferral(ctx, model.Referral{Referrer: "referrer", Referred: "alice"}, 2))
+	require.ErrorIs(t, memory.CreateReferral(ctx, model.Referral{Referrer: "referrer", Referred: "alice"}, 2),
+		repositories.ErrDuplicate)
+	require.NoError(t, memory.CreateReferral(ctx, model.Referral{Referrer: "referrer", Referred: "bob"}, 2))
+	require.ErrorIs(t, memory.CreateReferral(ctx, model.Referral{Referrer: "referrer", Referred: "carol"}, 2),
+		repositories.ErrLimitExceeded)
+
+	bonus := &model.ReferralBonus{Referrer: 100, Referred: 50}
+	for _, orderID := range []string{"12345678903", "2377225624"} {
+		require.NoError(t, memory.SaveOrder(ctx, "alice", model.OrderRequest{ID: orderID, Status: model.OrderStatusNew}))
+		require.NoError(t, memory.SetBalance(ctx, model.Accrual{
+			OrderID: orderID, Status: model.OrderStatusDone, Amount: 200, Referral: bonus,
+		}))
+	}
+
+	// Only the first processed order is rewarded.
+	balance, err := memory.GetUserBalance(ctx, "referrer")
+	require.NoError(t, err)
+	assert.Equal(t, model.Money(100), balance.Amount)
+
+	balance, err = memory.GetUserBalance(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, model.Money(450), balance.Amount)
+
+	referrals, err := memory.GetReferrals(ctx, "referrer")
+	require.NoError(t, err)
+	require.Len(t, referrals, 2)
+	assert.Equal(t, "alice", referrals[0].Referred)
+	assert.Equal(t, "12345678903", referrals[0].OrderID)
+	assert.NotNil(t, referrals[0].RewardedAt)
+	assert.Nil(t, referrals[1].RewardedAt)
+}
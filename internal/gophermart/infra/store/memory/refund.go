@@ -0,0 +1,83 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+func (s *Memory) RefundWithdraw(ctx context.Context,
+	refund model.WithdrawRefund) (model.WithdrawRefund, model.Withdraw, error) {
+	s.userBMu.Lock()
+	defer s.userBMu.Unlock()
+
+	s.withdrawMu.Lock()
+	defer s.withdrawMu.Unlock()
+
+	stored, ok := s.withdraws[refund.OrderID]
+	if !ok || refund.Partner != "" && stored.Partner != refund.Partner {
+		return model.WithdrawRefund{}, model.Withdraw{}, repositories.ErrNotFound
+	}
+
+	if existing, ok := s.refunds[refund.ID]; ok {
+		if existing.OrderID != refund.OrderID || refund.Amount != 0 && refund.Amount != existing.Amount {
+			return model.WithdrawRefund{}, model.Withdraw{}, repositories.ErrDuplicate
+		}
+
+		return existing, s.withdrawLocked(refund.OrderID), nil
+	}
+
+	withdraw := s.withdrawLocked(refund.OrderID)
+	if !withdraw.Processed() {
+		return model.WithdrawRefund{}, model.Withdraw{}, repositories.ErrInvalidState
+	}
+
+	left := withdraw.Amount - withdraw.Refunded
+	if refund.Amount == 0 {
+		refund.Amount = left
+	}
+
+	if refund.Amount <= 0 || refund.Amount > left {
+		return model.WithdrawRefund{}, model.Withdraw{}, repositories.ErrInsufficientFunds
+	}
+
+	refund.Login = withdraw.Login
+	refund.TransactionID = uuid.NewString()
+	refund.CreatedAt = time.Now()
+
+	transaction := model.RefundTransaction(refund.TransactionID, refund.Login, refund.OrderID, refund.Amount,
+		refund.Reason)
+	transaction.Currency = withdraw.Currency
+	if err := s.postLocked(transaction); err != nil {
+		return model.WithdrawRefund{}, model.Withdraw{}, err
+	}
+
+	stored.Refunded += refund.Amount
+	stored.Status = model.RefundedStatus(stored.Amount, stored.Refunded)
+	s.withdraws[refund.OrderID] = stored
+	s.refunds[refund.ID] = refund
+
+	return refund, s.withdrawLocked(refund.OrderID), nil
+}
+
+// withdrawLocked returns the withdrawal of orderID. The caller must hold
+// withdrawMu.
+func (s *Memory) withdrawLocked(orderID string) model.Withdraw {
+	withdraw := s.withdraws[orderID]
+
+	return model.Withdraw{
+		Login:     withdraw.Login,
+		OrderID:   orderID,
+		Status:    withdraw.Status,
+		Currency:  withdraw.Currency,
+		Partner:   withdraw.Partner,
+		Amount:    withdraw.Amount,
+		Refunded:  withdraw.Refunded,
+		CreatedAt: withdraw.CreatedAt,
+		ExpiresAt: withdraw.ExpiresAt,
+	}
+}
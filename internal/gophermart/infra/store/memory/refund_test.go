@@ -0,0 +1,78 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+func TestMemory_RefundWithdraw(t *testing.T) {
+	ctx := context.Background()
+
+	memory, err := New()
+	require.NoError(t, err)
+	require.NoError(t, memory.CreateUser(ctx, "login", "hash"))
+	require.NoError(t, memory.SaveOrder(ctx, "login", model.OrderRequest{ID: "12345678903", Status: model.OrderStatusNew}))
+	require.NoError(t, memory.SetBalance(ctx, model.Accrual{
+		OrderID: "12345678903", Status: model.OrderStatusDone, Amount: 500,
+	}))
+	require.NoError(t, memory.UserWithdraw(ctx, "login", model.Withdraw{
+		OrderID: "2377225624", Amount: 300, Partner: "shop",
+	}))
+
+	refund := func(id string, amount model.Money) (model.WithdrawRefund, model.Withdraw, error) {
+		return memory.RefundWithdraw(ctx, model.WithdrawRefund{
+			ID: id, OrderID: "2377225624", Actor: "shop", Partner: "shop", Reason: "cancelled", Amount: amount,
+		})
+	}
+
+	// Other partners don't see the withdrawal.
+	_, _, err = memory.RefundWithdraw(ctx, model.WithdrawRefund{
+		ID: "r-0", OrderID: "2377225624", Actor: "other", Partner: "other", Reason: "cancelled",
+	})
+	require.ErrorIs(t, err, repositories.ErrNotFound)
+
+	stored, withdraw, err := refund("r-1", 100)
+	require.NoError(t, err)
+	assert.Equal(t, "login", stored.Login)
+	assert.Equal(t, model.WithdrawStatusPartiallyRefunded, withdraw.Status)
+	assert.Equal(t, model.Money(100), withdraw.Refunded)
+
+	replayed, _, err := refund("r-1", 100)
+	require.NoError(t, err)
+	assert.Equal(t, stored, replayed)
+
+	_, _, err = refund("r-1", 50)
+	require.ErrorIs(t, err, repositories.ErrDuplicate)
+
+	_, _, err = refund("r-2", 250)
+	require.ErrorIs(t, err, repositories.ErrInsufficientFunds)
+
+	stored, withdraw, err = refund("r-3", 0)
+	require.NoError(t, err)
+	assert.Equal(t, model.Money(200), stored.Amount)
+	assert.Equal(t, model.WithdrawStatusRefunded, withdraw.Status)
+
+	_, _, err = refund("r-4", 0)
+	require.ErrorIs(t, err, repositories.ErrInsufficientFunds)
+
+	balance, err := memory.GetUserBalance(ctx, "login")
+	require.NoError(t, err)
+	assert.Equal(t, model.UserBalance{Amount: 500}, balance)
+
+	_, _, err = memory.RefundWithdraw(ctx, model.WithdrawRefund{ID: "r-5", OrderID: "49927398716"})
+	require.ErrorIs(t, err, repositories.ErrNotFound)
+
+	expiresAt := time.Now().Add(time.Minute)
+	require.NoError(t, memory.HoldWithdraw(ctx, "login",
+		model.Withdraw{OrderID: "49927398716", Amount: 100, ExpiresAt: &expiresAt}))
+
+	_, _, err = memory.RefundWithdraw(ctx, model.WithdrawRefund{ID: "r-6", OrderID: "49927398716"})
+	require.ErrorIs(t, err, repositories.ErrInvalidState)
+}
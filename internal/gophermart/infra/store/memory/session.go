@@ -0,0 +1,99 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+func (s *Memory) SaveSession(ctx context.Context, session model.Session) error {
+	s.sessionMu.Lock()
+	defer s.sessionMu.Unlock()
+
+	if _, ok := s.sessions[session.ID]; ok {
+		return repositories.ErrDuplicate
+	}
+
+	s.sessions[session.ID] = session
+
+	return nil
+}
+
+func (s *Memory) GetSession(ctx context.Context, id string) (model.Session, error) {
+	s.sessionMu.Lock()
+	defer s.sessionMu.Unlock()
+
+	session, ok := s.sessions[id]
+	if !ok {
+		return model.Session{}, repositories.ErrNotFound
+	}
+
+	return session, nil
+}
+
+func (s *Memory) GetUserSessions(ctx context.Context, login string) ([]model.Session, error) {
+	s.sessionMu.Lock()
+	defer s.sessionMu.Unlock()
+
+	result := make([]model.Session, 0)
+	for _, session := range s.sessions {
+		if session.Login == login && session.RevokedAt == nil {
+			result = append(result, session)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].LastSeenAt.After(result[j].LastSeenAt)
+	})
+
+	return result, nil
+}
+
+func (s *Memory) TouchSession(ctx context.Context, id string, seenAt time.Time) error {
+	s.sessionMu.Lock()
+	defer s.sessionMu.Unlock()
+
+	session, ok := s.sessions[id]
+	if !ok {
+		return repositories.ErrNotFound
+	}
+
+	session.LastSeenAt = seenAt
+	s.sessions[id] = session
+
+	return nil
+}
+
+func (s *Memory) RevokeSession(ctx context.Context, login, id string) error {
+	s.sessionMu.Lock()
+	defer s.sessionMu.Unlock()
+
+	session, ok := s.sessions[id]
+	if !ok || session.Login != login || session.RevokedAt != nil {
+		return repositories.ErrNotFound
+	}
+
+	now := time.Now()
+	session.RevokedAt = &now
+	s.sessions[id] = session
+
+	return nil
+}
+
+func (s *Memory) RevokeUserSessions(ctx context.Context, login, exceptID string) error {
+	s.sessionMu.Lock()
+	defer s.sessionMu.Unlock()
+
+	now := time.Now()
+	for id, session := range s.sessions {
+		if session.Login == login && id != exceptID && session.RevokedAt == nil {
+			session.RevokedAt = &now
+			s.sessions[id] = session
+		}
+	}
+
+	return nil
+}
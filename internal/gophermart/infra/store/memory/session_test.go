@@ -0,0 +1,117 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+func TestMemory_Sessions(t *testing.T) {
+	ctx := context.Background()
+
+	newSession := func(login string) model.Session {
+		return model.Session{
+			ID:         uuid.NewString(),
+			Login:      login,
+			UserAgent:  "agent",
+			IP:         "127.0.0.1",
+			CreatedAt:  time.Now(),
+			LastSeenAt: time.Now(),
+		}
+	}
+
+	t.Run("SaveAndGetSession", func(t *testing.T) {
+		memory, err := New()
+		require.NoError(t, err)
+
+		_, err = memory.GetSession(ctx, uuid.NewString())
+		require.ErrorIs(t, err, repositories.ErrNotFound)
+
+		session := newSession("login")
+		require.NoError(t, memory.SaveSession(ctx, session))
+		require.ErrorIs(t, memory.SaveSession(ctx, session), repositories.ErrDuplicate)
+
+		stored, err := memory.GetSession(ctx, session.ID)
+		require.NoError(t, err)
+		assert.Equal(t, session, stored)
+	})
+
+	t.Run("GetUserSessions", func(t *testing.T) {
+		memory, err := New()
+		require.NoError(t, err)
+
+		older, newer, revoked, other := newSession("login"), newSession("login"), newSession("login"), newSession("other")
+		newer.LastSeenAt = older.LastSeenAt.Add(time.Minute)
+		for _, session := range []model.Session{older, newer, revoked, other} {
+			require.NoError(t, memory.SaveSession(ctx, session))
+		}
+		require.NoError(t, memory.RevokeSession(ctx, "login", revoked.ID))
+
+		sessions, err := memory.GetUserSessions(ctx, "login")
+		require.NoError(t, err)
+		require.Len(t, sessions, 2)
+		assert.Equal(t, newer.ID, sessions[0].ID)
+		assert.Equal(t, older.ID, sessions[1].ID)
+	})
+
+	t.Run("TouchSession", func(t *testing.T) {
+		memory, err := New()
+		require.NoError(t, err)
+
+		seenAt := time.Now().Add(time.Hour)
+		require.ErrorIs(t, memory.TouchSession(ctx, uuid.NewString(), seenAt), repositories.ErrNotFound)
+
+		session := newSession("login")
+		require.NoError(t, memory.SaveSession(ctx, session))
+		require.NoError(t, memory.TouchSession(ctx, session.ID, seenAt))
+
+		stored, err := memory.GetSession(ctx, session.ID)
+		require.NoError(t, err)
+		assert.Equal(t, seenAt, stored.LastSeenAt)
+	})
+
+	t.Run("RevokeSession", func(t *testing.T) {
+		memory, err := New()
+		require.NoError(t, err)
+
+		session := newSession("login")
+		require.NoError(t, memory.SaveSession(ctx, session))
+
+		require.ErrorIs(t, memory.RevokeSession(ctx, "other", session.ID), repositories.ErrNotFound)
+		require.NoError(t, memory.RevokeSession(ctx, "login", session.ID))
+		require.ErrorIs(t, memory.RevokeSession(ctx, "login", session.ID), repositories.ErrNotFound)
+
+		stored, err := memory.GetSession(ctx, session.ID)
+		require.NoError(t, err)
+		assert.NotNil(t, stored.RevokedAt)
+	})
+
+	t.Run("RevokeUserSessions", func(t *testing.T) {
+		memory, err := New()
+		require.NoError(t, err)
+
+		first, second, other := newSession("login"), newSession("login"), newSession("other")
+		current := newSession("login")
+		for _, session := range []model.Session{first, second, current, other} {
+			require.NoError(t, memory.SaveSession(ctx, session))
+		}
+
+		require.NoError(t, memory.RevokeUserSessions(ctx, "login", current.ID))
+
+		sessions, err := memory.GetUserSessions(ctx, "login")
+		require.NoError(t, err)
+		require.Len(t, sessions, 1)
+		assert.Equal(t, current.ID, sessions[0].ID)
+
+		sessions, err = memory.GetUserSessions(ctx, "other")
+		require.NoError(t, err)
+		assert.Len(t, sessions, 1)
+	})
+}
@@ -0,0 +1,80 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+func (s *Memory) GetUserTier(ctx context.Context, login string, since time.Time) (model.UserTier, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.userBMu.Lock()
+	defer s.userBMu.Unlock()
+
+	user, ok := s.users[login]
+	if !ok {
+		return model.UserTier{}, repositories.ErrNotFound
+	}
+
+	return model.UserTier{
+		Login:   login,
+		Tier:    user.Tier,
+		Accrued: s.accruedLocked(since)[login],
+	}, nil
+}
+
+func (s *Memory) GetUserTiers(ctx context.Context, since time.Time) ([]model.UserTier, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.userBMu.Lock()
+	defer s.userBMu.Unlock()
+
+	accrued := s.accruedLocked(since)
+
+	tiers := make([]model.UserTier, 0, len(s.users))
+	for login, user := range s.users {
+		tiers = append(tiers, model.UserTier{Login: login, Tier: user.Tier, Accrued: accrued[login]})
+	}
+
+	sort.Slice(tiers, func(i, j int) bool {
+		return tiers[i].Login < tiers[j].Login
+	})
+
+	return tiers, nil
+}
+
+func (s *Memory) SetUserTier(ctx context.Context, login, tier string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[login]
+	if !ok {
+		return repositories.ErrNotFound
+	}
+
+	user.Tier = tier
+	s.users[login] = user
+
+	return nil
+}
+
+// accruedLocked sums the accruals credited since then per login, net of
+// their corrections. The caller must hold userBMu.
+func (s *Memory) accruedLocked(since time.Time) map[string]model.Money {
+	accrued := make(map[string]model.Money)
+	for _, entry := range s.ledger {
+		if (entry.Kind == model.LedgerKindAccrual || entry.Kind == model.LedgerKindCorrection) &&
+			entry.Account == model.LedgerAccountAccrual && entry.Currency == model.DefaultCurrency &&
+			!entry.CreatedAt.Before(since) {
+			accrued[entry.Login] -= entry.Amount
+		}
+	}
+
+	return accrued
+}
@@ -0,0 +1,61 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+func TestMemory_Tiers(t *testing.T) {
+	ctx := context.Background()
+	since := time.Now().AddDate(-1, 0, 0)
+
+	memory, err := New()
+	require.NoError(t, err)
+	require.NoError(t, memory.CreateUser(ctx, "alice", "hash"))
+	require.NoError(t, memory.CreateUser(ctx, "bob", "hash"))
+	require.NoError(t, memory.SaveOrder(ctx, "alice", model.OrderRequest{ID: "12345678903", Status: model.OrderStatusNew}))
+
+	require.NoError(t, memory.SetBalance(ctx, model.Accrual{
+		OrderID: "12345678903", Status: model.OrderStatusDone, Amount: 1000, Tier: "silver", Bonus: 50,
+	}))
+
+	balance, err := memory.GetUserBalance(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, model.Money(1050), balance.Amount)
+
+	ledger, err := memory.GetUserLedger(ctx, "alice")
+	require.NoError(t, err)
+	require.Len(t, ledger, 4)
+	assert.Equal(t, model.LedgerKindTierBonus, ledger[2].Kind)
+	assert.Equal(t, model.Money(50), ledger[2].Amount)
+	assert.Equal(t, "tier silver", ledger[2].Description)
+
+	// Only the accrual counts towards the tier, not the bonus.
+	tier, err := memory.GetUserTier(ctx, "alice", since)
+	require.NoError(t, err)
+	assert.Equal(t, model.UserTier{Login: "alice", Accrued: 1000}, tier)
+
+	tier, err = memory.GetUserTier(ctx, "alice", time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, model.Money(0), tier.Accrued)
+
+	require.NoError(t, memory.SetUserTier(ctx, "alice", "silver"))
+	require.ErrorIs(t, memory.SetUserTier(ctx, "carol", "silver"), repositories.ErrNotFound)
+
+	tiers, err := memory.GetUserTiers(ctx, since)
+	require.NoError(t, err)
+	assert.Equal(t, []model.UserTier{
+		{Login: "alice", Tier: "silver", Accrued: 1000},
+		{Login: "bob"},
+	}, tiers)
+
+	_, err = memory.GetUserTier(ctx, "carol", since)
+	require.ErrorIs(t, err, repositories.ErrNotFound)
+}
@@ -0,0 +1,120 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+func (s *Memory) SaveRefreshToken(ctx context.Context, token model.RefreshToken) error {
+	s.tokenMu.Lock()
+	defer s.tokenMu.Unlock()
+
+	if _, ok := s.refreshTokens[token.TokenHash]; ok {
+		return repositories.ErrDuplicate
+	}
+
+	s.refreshTokens[token.TokenHash] = RefreshToken{
+		Login:     token.Login,
+		FamilyID:  token.FamilyID,
+		ExpiresAt: token.ExpiresAt,
+		CreatedAt: token.CreatedAt,
+	}
+
+	return nil
+}
+
+func (s *Memory) GetRefreshToken(ctx context.Context, tokenHash string) (model.RefreshToken, error) {
+	s.tokenMu.Lock()
+	defer s.tokenMu.Unlock()
+
+	token, ok := s.refreshTokens[tokenHash]
+	if !ok {
+		return model.RefreshToken{}, repositories.ErrNotFound
+	}
+
+	return model.RefreshToken{
+		TokenHash: tokenHash,
+		Login:     token.Login,
+		FamilyID:  token.FamilyID,
+		ExpiresAt: token.ExpiresAt,
+		CreatedAt: token.CreatedAt,
+		UsedAt:    token.UsedAt,
+		Revoked:   token.Revoked,
+	}, nil
+}
+
+func (s *Memory) UseRefreshToken(ctx context.Context, tokenHash string) error {
+	s.tokenMu.Lock()
+	defer s.tokenMu.Unlock()
+
+	token, ok := s.refreshTokens[tokenHash]
+	if !ok {
+		return repositories.ErrNotFound
+	}
+
+	if token.UsedAt != nil || token.Revoked {
+		return repositories.ErrAlreadyUsed
+	}
+
+	now := time.Now()
+	token.UsedAt = &now
+	s.refreshTokens[tokenHash] = token
+
+	return nil
+}
+
+func (s *Memory) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	s.tokenMu.Lock()
+	defer s.tokenMu.Unlock()
+
+	for hash, token := range s.refreshTokens {
+		if token.FamilyID == familyID {
+			token.Revoked = true
+			s.refreshTokens[hash] = token
+		}
+	}
+
+	return nil
+}
+
+func (s *Memory) RevokeUserRefreshTokens(ctx context.Context, login, exceptFamilyID string) error {
+	s.tokenMu.Lock()
+	defer s.tokenMu.Unlock()
+
+	for hash, token := range s.refreshTokens {
+		if token.Login == login && token.FamilyID != exceptFamilyID {
+			token.Revoked = true
+			s.refreshTokens[hash] = token
+		}
+	}
+
+	return nil
+}
+
+func (s *Memory) RevokeToken(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	s.tokenMu.Lock()
+	defer s.tokenMu.Unlock()
+
+	now := time.Now()
+	for id, exp := range s.revokedTokens {
+		if exp.Before(now) {
+			delete(s.revokedTokens, id)
+		}
+	}
+
+	s.revokedTokens[tokenID] = expiresAt
+
+	return nil
+}
+
+func (s *Memory) IsTokenRevoked(ctx context.Context, tokenID string) (bool, error) {
+	s.tokenMu.Lock()
+	defer s.tokenMu.Unlock()
+
+	_, ok := s.revokedTokens[tokenID]
+
+	return ok, nil
+}
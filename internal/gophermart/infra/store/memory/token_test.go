@@ -0,0 +1,152 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+func TestMemory_RefreshTokens(t *testing.T) {
+	ctx := context.Background()
+
+	newToken := func(familyID string) model.RefreshToken {
+		return model.RefreshToken{
+			TokenHash: uuid.NewString(),
+			Login:     "login",
+			FamilyID:  familyID,
+			ExpiresAt: time.Now().Add(time.Hour),
+			CreatedAt: time.Now(),
+		}
+	}
+
+	t.Run("SaveRefreshToken", func(t *testing.T) {
+		memory, err := New()
+		require.NoError(t, err)
+
+		token := newToken(uuid.NewString())
+
+		err = memory.SaveRefreshToken(ctx, token)
+		require.NoError(t, err)
+
+		err = memory.SaveRefreshToken(ctx, token)
+		require.ErrorIs(t, err, repositories.ErrDuplicate)
+	})
+
+	t.Run("GetRefreshToken", func(t *testing.T) {
+		memory, err := New()
+		require.NoError(t, err)
+
+		_, err = memory.GetRefreshToken(ctx, uuid.NewString())
+		require.ErrorIs(t, err, repositories.ErrNotFound)
+
+		token := newToken(uuid.NewString())
+		require.NoError(t, memory.SaveRefreshToken(ctx, token))
+
+		stored, err := memory.GetRefreshToken(ctx, token.TokenHash)
+		require.NoError(t, err)
+		assert.Equal(t, token.Login, stored.Login)
+		assert.Equal(t, token.FamilyID, stored.FamilyID)
+		assert.Nil(t, stored.UsedAt)
+		assert.False(t, stored.Revoked)
+	})
+
+	t.Run("UseRefreshToken", func(t *testing.T) {
+		memory, err := New()
+		require.NoError(t, err)
+
+		err = memory.UseRefreshToken(ctx, uuid.NewString())
+		require.ErrorIs(t, err, repositories.ErrNotFound)
+
+		token := newToken(uuid.NewString())
+		require.NoError(t, memory.SaveRefreshToken(ctx, token))
+
+		err = memory.UseRefreshToken(ctx, token.TokenHash)
+		require.NoError(t, err)
+
+		err = memory.UseRefreshToken(ctx, token.TokenHash)
+		require.ErrorIs(t, err, repositories.ErrAlreadyUsed)
+
+		stored, err := memory.GetRefreshToken(ctx, token.TokenHash)
+		require.NoError(t, err)
+		assert.NotNil(t, stored.UsedAt)
+	})
+
+	t.Run("RevokeRefreshTokenFamily", func(t *testing.T) {
+		memory, err := New()
+		require.NoError(t, err)
+
+		familyID := uuid.NewString()
+		first, second, other := newToken(familyID), newToken(familyID), newToken(uuid.NewString())
+		require.NoError(t, memory.SaveRefreshToken(ctx, first))
+		require.NoError(t, memory.SaveRefreshToken(ctx, second))
+		require.NoError(t, memory.SaveRefreshToken(ctx, other))
+
+		err = memory.RevokeRefreshTokenFamily(ctx, familyID)
+		require.NoError(t, err)
+
+		stored, err := memory.GetRefreshToken(ctx, second.TokenHash)
+		require.NoError(t, err)
+		assert.True(t, stored.Revoked)
+
+		err = memory.UseRefreshToken(ctx, second.TokenHash)
+		require.ErrorIs(t, err, repositories.ErrAlreadyUsed)
+
+		stored, err = memory.GetRefreshToken(ctx, other.TokenHash)
+		require.NoError(t, err)
+		assert.False(t, stored.Revoked)
+	})
+
+	t.Run("RevokeUserRefreshTokens", func(t *testing.T) {
+		memory, err := New()
+		require.NoError(t, err)
+
+		first, second, current := newToken(uuid.NewString()), newToken(uuid.NewString()), newToken(uuid.NewString())
+		other := newToken(uuid.NewString())
+		other.Login = "other"
+		for _, token := range []model.RefreshToken{first, second, current, other} {
+			require.NoError(t, memory.SaveRefreshToken(ctx, token))
+		}
+
+		require.NoError(t, memory.RevokeUserRefreshTokens(ctx, "login", current.FamilyID))
+
+		for _, token := range []model.RefreshToken{first, second} {
+			stored, err := memory.GetRefreshToken(ctx, token.TokenHash)
+			require.NoError(t, err)
+			assert.True(t, stored.Revoked)
+		}
+
+		for _, token := range []model.RefreshToken{current, other} {
+			stored, err := memory.GetRefreshToken(ctx, token.TokenHash)
+			require.NoError(t, err)
+			assert.False(t, stored.Revoked)
+		}
+	})
+
+	t.Run("RevokeToken", func(t *testing.T) {
+		memory, err := New()
+		require.NoError(t, err)
+
+		tokenID := uuid.NewString()
+
+		revoked, err := memory.IsTokenRevoked(ctx, tokenID)
+		require.NoError(t, err)
+		assert.False(t, revoked)
+
+		expired := uuid.NewString()
+		require.NoError(t, memory.RevokeToken(ctx, expired, time.Now().Add(-time.Minute)))
+		require.NoError(t, memory.RevokeToken(ctx, tokenID, time.Now().Add(time.Hour)))
+
+		revoked, err = memory.IsTokenRevoked(ctx, tokenID)
+		require.NoError(t, err)
+		assert.True(t, revoked)
+
+		assert.NotContains(t, memory.revokedTokens, expired)
+	})
+}
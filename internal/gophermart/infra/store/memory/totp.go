@@ -0,0 +1,102 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+func (s *Memory) SaveTOTP(ctx context.Context, totp model.TOTP) error {
+	s.totpMu.Lock()
+	defer s.totpMu.Unlock()
+
+	if stored, ok := s.totps[totp.Login]; ok && stored.ConfirmedAt != nil {
+		return repositories.ErrDuplicate
+	}
+
+	totp.ConfirmedAt = nil
+	totp.LastStep = 0
+	s.totps[totp.Login] = totp
+
+	return nil
+}
+
+func (s *Memory) GetTOTP(ctx context.Context, login string) (model.TOTP, error) {
+	s.totpMu.Lock()
+	defer s.totpMu.Unlock()
+
+	totp, ok := s.totps[login]
+	if !ok {
+		return model.TOTP{}, repositories.ErrNotFound
+	}
+
+	return totp, nil
+}
+
+func (s *Memory) ConfirmTOTP(ctx context.Context, login string, step int64, recoveryCodeHashes []string) error {
+	s.totpMu.Lock()
+	defer s.totpMu.Unlock()
+
+	totp, ok := s.totps[login]
+	if !ok || totp.ConfirmedAt != nil {
+		return repositories.ErrNotFound
+	}
+
+	now := time.Now()
+	totp.ConfirmedAt = &now
+	totp.LastStep = step
+	s.totps[login] = totp
+
+	codes := make(map[string]bool, len(recoveryCodeHashes))
+	for _, hash := range recoveryCodeHashes {
+		codes[hash] = false
+	}
+	s.recoveryCodes[login] = codes
+
+	return nil
+}
+
+func (s *Memory) UseTOTPStep(ctx context.Context, login string, step int64) error {
+	s.totpMu.Lock()
+	defer s.totpMu.Unlock()
+
+	totp, ok := s.totps[login]
+	if !ok {
+		return repositories.ErrNotFound
+	}
+
+	if totp.LastStep >= step {
+		return repositories.ErrAlreadyUsed
+	}
+
+	totp.LastStep = step
+	s.totps[login] = totp
+
+	return nil
+}
+
+func (s *Memory) UseRecoveryCode(ctx context.Context, login, codeHash string) error {
+	s.totpMu.Lock()
+	defer s.totpMu.Unlock()
+
+	used, ok := s.recoveryCodes[login][codeHash]
+	if !ok || used {
+		return repositories.ErrNotFound
+	}
+
+	s.recoveryCodes[login][codeHash] = true
+
+	return nil
+}
+
+func (s *Memory) DeleteTOTP(ctx context.Context, login string) error {
+	s.totpMu.Lock()
+	defer s.totpMu.Unlock()
+
+	delete(s.totps, login)
+	delete(s.recoveryCodes, login)
+
+	return nil
+}
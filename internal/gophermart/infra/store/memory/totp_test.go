@@ -0,0 +1,77 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+func TestMemory_TOTP(t *testing.T) {
+	ctx := context.Background()
+	pending := model.TOTP{Login: "login", Secret: "SECRET", CreatedAt: time.Now()}
+
+	t.Run("SaveAndConfirmTOTP", func(t *testing.T) {
+		memory, err := New()
+		require.NoError(t, err)
+
+		_, err = memory.GetTOTP(ctx, "login")
+		require.ErrorIs(t, err, repositories.ErrNotFound)
+
+		require.NoError(t, memory.SaveTOTP(ctx, pending))
+		require.NoError(t, memory.SaveTOTP(ctx, pending))
+
+		require.NoError(t, memory.ConfirmTOTP(ctx, "login", 10, []string{"hash"}))
+		require.ErrorIs(t, memory.ConfirmTOTP(ctx, "login", 11, nil), repositories.ErrNotFound)
+		require.ErrorIs(t, memory.SaveTOTP(ctx, pending), repositories.ErrDuplicate)
+
+		stored, err := memory.GetTOTP(ctx, "login")
+		require.NoError(t, err)
+		assert.NotNil(t, stored.ConfirmedAt)
+		assert.Equal(t, int64(10), stored.LastStep)
+	})
+
+	t.Run("UseTOTPStep", func(t *testing.T) {
+		memory, err := New()
+		require.NoError(t, err)
+
+		require.ErrorIs(t, memory.UseTOTPStep(ctx, "login", 1), repositories.ErrNotFound)
+
+		require.NoError(t, memory.SaveTOTP(ctx, pending))
+		require.NoError(t, memory.ConfirmTOTP(ctx, "login", 10, nil))
+
+		require.ErrorIs(t, memory.UseTOTPStep(ctx, "login", 10), repositories.ErrAlreadyUsed)
+		require.NoError(t, memory.UseTOTPStep(ctx, "login", 11))
+		require.ErrorIs(t, memory.UseTOTPStep(ctx, "login", 11), repositories.ErrAlreadyUsed)
+	})
+
+	t.Run("UseRecoveryCode", func(t *testing.T) {
+		memory, err := New()
+		require.NoError(t, err)
+
+		require.NoError(t, memory.SaveTOTP(ctx, pending))
+		require.NoError(t, memory.ConfirmTOTP(ctx, "login", 10, []string{"first", "second"}))
+
+		require.NoError(t, memory.UseRecoveryCode(ctx, "login", "first"))
+		require.ErrorIs(t, memory.UseRecoveryCode(ctx, "login", "first"), repositories.ErrNotFound)
+		require.ErrorIs(t, memory.UseRecoveryCode(ctx, "other", "second"), repositories.ErrNotFound)
+	})
+
+	t.Run("DeleteTOTP", func(t *testing.T) {
+		memory, err := New()
+		require.NoError(t, err)
+
+		require.NoError(t, memory.SaveTOTP(ctx, pending))
+		require.NoError(t, memory.ConfirmTOTP(ctx, "login", 10, []string{"first"}))
+		require.NoError(t, memory.DeleteTOTP(ctx, "login"))
+
+		_, err = memory.GetTOTP(ctx, "login")
+		require.ErrorIs(t, err, repositories.ErrNotFound)
+		require.ErrorIs(t, memory.UseRecoveryCode(ctx, "login", "first"), repositories.ErrNotFound)
+	})
+}
@@ -0,0 +1,58 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+func (s *Memory) TransferPoints(ctx context.Context, transfer model.Transfer) error {
+	s.userBMu.Lock()
+	defer s.userBMu.Unlock()
+
+	for _, login := range []string{transfer.Sender, transfer.Recipient} {
+		if _, ok := s.userBalance[login]; !ok {
+			return repositories.ErrNotFound
+		}
+	}
+
+	if transfer.SentLimit > 0 {
+		sent, _ := s.transferredLocked(transfer.Sender, transfer.Since)
+		if sent+transfer.Amount > transfer.SentLimit {
+			return repositories.ErrLimitExceeded
+		}
+	}
+
+	if transfer.ReceivedLimit > 0 {
+		_, received := s.transferredLocked(transfer.Recipient, transfer.Since)
+		if received+transfer.Amount > transfer.ReceivedLimit {
+			return repositories.ErrLimitExceeded
+		}
+	}
+
+	transaction := model.TransferTransaction(transfer.ID, transfer.Sender, transfer.Recipient, transfer.Amount)
+
+	return s.postLocked(transaction)
+}
+
+// transferredLocked sums what login sent and received in transfers since
+// then. The caller must hold userBMu.
+func (s *Memory) transferredLocked(login string, since time.Time) (model.Money, model.Money) {
+	var sent, received model.Money
+	for _, entry := range s.ledger {
+		if entry.Login != login || entry.Kind != model.LedgerKindTransfer ||
+			entry.Account != model.LedgerAccountAvailable || entry.CreatedAt.Before(since) {
+			continue
+		}
+
+		if entry.Amount < 0 {
+			sent -= entry.Amount
+		} else {
+			received += entry.Amount
+		}
+	}
+
+	return sent, received
+}
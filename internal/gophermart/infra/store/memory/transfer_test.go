@@ -0,0 +1,69 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+func TestMemory_TransferPoints(t *testing.T) {
+	ctx := context.Background()
+	since := time.Now().Add(-time.Hour)
+	expiresAt := time.Now().Add(time.Hour)
+
+	memory, err := New()
+	require.NoError(t, err)
+	require.NoError(t, memory.CreateUser(ctx, "sender", "hash"))
+	require.NoError(t, memory.CreateUser(ctx, "recipient", "hash"))
+	require.NoError(t, memory.SaveOrder(ctx, "sender", model.OrderRequest{ID: "12345678903", Status: model.OrderStatusNew}))
+	require.NoError(t, memory.SetBalance(ctx, model.Accrual{
+		OrderID: "12345678903", Status: model.OrderStatusDone, Amount: 500, ExpiresAt: &expiresAt,
+	}))
+
+	transfer := model.Transfer{
+		ID: "transfer-1", Sender: "sender", Recipient: "recipient", Amount: 200,
+		Since: since, SentLimit: 300,
+	}
+	require.NoError(t, memory.TransferPoints(ctx, transfer))
+
+	transfer.ID = "transfer-2"
+	require.ErrorIs(t, memory.TransferPoints(ctx, transfer), repositories.ErrLimitExceeded)
+
+	transfer.SentLimit = 0
+	transfer.ReceivedLimit = 350
+	require.ErrorIs(t, memory.TransferPoints(ctx, transfer), repositories.ErrLimitExceeded)
+
+	transfer.ReceivedLimit = 0
+	transfer.Amount = 400
+	require.ErrorIs(t, memory.TransferPoints(ctx, transfer), repositories.ErrInsufficientFunds)
+
+	transfer.Recipient = "unknown"
+	require.ErrorIs(t, memory.TransferPoints(ctx, transfer), repositories.ErrNotFound)
+
+	balance, err := memory.GetUserBalance(ctx, "sender")
+	require.NoError(t, err)
+	assert.Equal(t, model.Money(300), balance.Amount)
+
+	balance, err = memory.GetUserBalance(ctx, "recipient")
+	require.NoError(t, err)
+	assert.Equal(t, model.Money(200), balance.Amount)
+
+	ledger, err := memory.GetUserLedger(ctx, "recipient")
+	require.NoError(t, err)
+	require.Len(t, ledger, 1)
+	assert.Equal(t, model.LedgerKindTransfer, ledger[0].Kind)
+	assert.Equal(t, "transfer-1", ledger[0].TransactionID)
+
+	// Transferred points keep the expiry they had for the sender.
+	for login, want := range map[string]model.Money{"sender": 300, "recipient": 200} {
+		expiring, err := memory.GetExpiringPoints(ctx, login, expiresAt.Add(time.Second))
+		require.NoError(t, err)
+		assert.Equal(t, want, expiring, login)
+	}
+}
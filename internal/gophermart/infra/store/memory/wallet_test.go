@@ -0,0 +1,73 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+func TestMemory_Wallets(t *testing.T) {
+	ctx := context.Background()
+
+	memory, err := New()
+	require.NoError(t, err)
+	require.NoError(t, memory.CreateUser(ctx, "login", "hash"))
+
+	for _, orderID := range []string{"12345678903", "2377225624"} {
+		require.NoError(t, memory.SaveOrder(ctx, "login", model.OrderRequest{ID: orderID, Status: model.OrderStatusNew}))
+	}
+
+	expiresAt := time.Now().Add(time.Hour)
+	require.NoError(t, memory.SetBalance(ctx, model.Accrual{
+		OrderID: "12345678903", Status: model.OrderStatusDone, Amount: 500, ExpiresAt: &expiresAt,
+	}))
+	require.NoError(t, memory.SetBalance(ctx, model.Accrual{
+		OrderID: "2377225624", Status: model.OrderStatusInProgress, Amount: 300, Currency: "ACME",
+	}))
+
+	// The wallet of an order is fixed by its first credit.
+	require.NoError(t, memory.SetBalance(ctx, model.Accrual{
+		OrderID: "2377225624", Status: model.OrderStatusDone, Amount: 400,
+	}))
+
+	require.NoError(t, memory.UserWithdraw(ctx, "login", model.Withdraw{
+		OrderID: "79927398713", Amount: 150, Currency: "ACME",
+	}))
+
+	err = memory.UserWithdraw(ctx, "login", model.Withdraw{OrderID: "49927398716", Amount: 300, Currency: "ACME"})
+	require.ErrorIs(t, err, repositories.ErrInsufficientFunds)
+
+	withdrawn, err := memory.GetUserWithdrawn(ctx, "login", "ACME", time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, model.Money(150), withdrawn)
+
+	balance, err := memory.GetUserBalance(ctx, "login")
+	require.NoError(t, err)
+	assert.Equal(t, model.UserBalance{Amount: 500}, balance)
+
+	wallets, err := memory.GetUserWallets(ctx, "login")
+	require.NoError(t, err)
+	assert.Equal(t, []model.Wallet{
+		{Currency: model.DefaultCurrency, UserBalance: model.UserBalance{Amount: 500}},
+		{Currency: "ACME", UserBalance: model.UserBalance{Amount: 250, Withdraw: 150}},
+	}, wallets)
+
+	// Only the default wallet has points that expire.
+	expiring, err := memory.GetExpiringPoints(ctx, "login", expiresAt.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, model.Money(500), expiring)
+
+	history, err := memory.GetBalanceHistory(ctx, model.BalanceHistoryFilter{Login: "login", Currency: "ACME"})
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	assert.Equal(t, model.Money(250), history[2].Balance)
+
+	_, err = memory.GetUserWallets(ctx, "nobody")
+	require.ErrorIs(t, err, repositories.ErrNotFound)
+}
@@ -0,0 +1,97 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+func (p *Postgresql) SaveAPIKey(ctx context.Context, key model.APIKey) error {
+	query := `INSERT INTO api_keys (id, login, name, prefix, key_hash, scopes, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7);`
+
+	return retry(func() error {
+		_, err := p.pool.Exec(ctx, query, key.ID, key.Login, key.Name, key.Prefix, key.KeyHash, key.Scopes, key.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("can't exec: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (p *Postgresql) GetAPIKey(ctx context.Context, keyHash string) (model.APIKey, error) {
+	query := `SELECT id, login, name, prefix, scopes, created_at, last_used_at, revoked_at
+	FROM api_keys WHERE key_hash = $1;`
+
+	key := model.APIKey{KeyHash: keyHash}
+	row := p.pool.QueryRow(ctx, query, keyHash)
+
+	if err := retry(func() error {
+		return row.Scan(&key.ID, &key.Login, &key.Name, &key.Prefix, &key.Scopes,
+			&key.CreatedAt, &key.LastUsedAt, &key.RevokedAt)
+	}); err != nil {
+		return model.APIKey{}, fmt.Errorf("can't scan: %w", err)
+	}
+
+	return key, nil
+}
+
+func (p *Postgresql) GetUserAPIKeys(ctx context.Context, login string) ([]model.APIKey, error) {
+	query := `SELECT id, name, prefix, scopes, created_at, last_used_at, revoked_at
+	FROM api_keys WHERE login = $1 ORDER BY created_at DESC;`
+
+	result := make([]model.APIKey, 0)
+	return result, retry(func() error {
+		rows, err := p.pool.Query(ctx, query, login)
+		if err != nil {
+			return fmt.Errorf("can't query: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			key := model.APIKey{Login: login}
+			if err := rows.Scan(&key.ID, &key.Name, &key.Prefix, &key.Scopes,
+				&key.CreatedAt, &key.LastUsedAt, &key.RevokedAt); err != nil {
+				return fmt.Errorf("can't scan: %w", err)
+			}
+
+			result = append(result, key)
+		}
+
+		return nil
+	})
+}
+
+func (p *Postgresql) RevokeAPIKey(ctx context.Context, login, id string) error {
+	query := `UPDATE api_keys SET revoked_at = now() WHERE id = $1 AND login = $2 AND revoked_at IS NULL;`
+
+	return retry(func() error {
+		tag, err := p.pool.Exec(ctx, query, id, login)
+		if err != nil {
+			return fmt.Errorf("can't exec: %w", err)
+		}
+
+		if tag.RowsAffected() == 0 {
+			return repositories.ErrNotFound
+		}
+
+		return nil
+	})
+}
+
+func (p *Postgresql) TouchAPIKey(ctx context.Context, id string, usedAt time.Time) error {
+	query := `UPDATE api_keys SET last_used_at = $1 WHERE id = $2;`
+
+	return retry(func() error {
+		_, err := p.pool.Exec(ctx, query, usedAt, id)
+		if err != nil {
+			return fmt.Errorf("can't exec: %w", err)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,114 @@
+//nolint:wrapcheck,gocritic,nolintlint,errcheck,forcetypeassert
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+func TestPostgresql_SaveAPIKey(t *testing.T) {
+	key := model.APIKey{
+		ID:        "id",
+		Login:     "login",
+		Name:      "partner",
+		Prefix:    "gm_abcdefgh",
+		KeyHash:   "hash",
+		Scopes:    []string{model.ScopeOrdersWrite},
+		CreatedAt: time.Now(),
+	}
+
+	t.Run("successful save", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockPool.On("Exec", mock.Anything, mock.Anything,
+			[]interface{}{key.ID, key.Login, key.Name, key.Prefix, key.KeyHash, key.Scopes, key.CreatedAt}).
+			Return(pgconn.NewCommandTag("INSERT 0 1"), nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		assert.NoError(t, postgres.SaveAPIKey(context.TODO(), key))
+		mockPool.AssertExpectations(t)
+	})
+
+	t.Run("failed exec", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockPool.On("Exec", mock.Anything, mock.Anything, mock.Anything).
+			Return(pgconn.CommandTag{}, errors.New("exec error"))
+
+		postgres := &Postgresql{pool: mockPool}
+
+		err := postgres.SaveAPIKey(context.TODO(), key)
+
+		assert.EqualError(t, err, "operation failed after 3 retries: can't exec: exec error")
+	})
+}
+
+func TestPostgresql_GetAPIKey(t *testing.T) {
+	t.Run("successful get", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockRow := new(MockRow)
+		mockPool.On("QueryRow", mock.Anything, mock.Anything, []interface{}{"hash"}).Return(mockRow)
+		mockRow.On("Scan", mock.Anything, mock.Anything, mock.Anything, mock.Anything,
+			mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			*(args.Get(0).(*string)) = "id"
+			*(args.Get(1).(*string)) = "login"
+			*(args.Get(4).(*[]string)) = []string{model.ScopeBalanceRead}
+		}).Return(nil)
+
+		postgres := &Postgresql{pool: mockPool}
+		key, err := postgres.GetAPIKey(context.TODO(), "hash")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "id", key.ID)
+		assert.Equal(t, "login", key.Login)
+		assert.Equal(t, "hash", key.KeyHash)
+		assert.Equal(t, []string{model.ScopeBalanceRead}, key.Scopes)
+		mockPool.AssertExpectations(t)
+		mockRow.AssertExpectations(t)
+	})
+
+	t.Run("key not found", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockRow := new(MockRow)
+		mockPool.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(mockRow)
+		mockRow.On("Scan", mock.Anything, mock.Anything, mock.Anything, mock.Anything,
+			mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(pgx.ErrNoRows)
+
+		postgres := &Postgresql{pool: mockPool}
+		_, err := postgres.GetAPIKey(context.TODO(), "hash")
+
+		assert.ErrorIs(t, err, repositories.ErrNotFound)
+	})
+}
+
+func TestPostgresql_RevokeAPIKey(t *testing.T) {
+	t.Run("successful revoke", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockPool.On("Exec", mock.Anything, mock.Anything, []interface{}{"id", "login"}).
+			Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		assert.NoError(t, postgres.RevokeAPIKey(context.TODO(), "login", "id"))
+		mockPool.AssertExpectations(t)
+	})
+
+	t.Run("key not found", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockPool.On("Exec", mock.Anything, mock.Anything, mock.Anything).
+			Return(pgconn.NewCommandTag("UPDATE 0"), nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		assert.ErrorIs(t, postgres.RevokeAPIKey(context.TODO(), "login", "id"), repositories.ErrNotFound)
+	})
+}
@@ -0,0 +1,99 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+func (p *Postgresql) GetLoginAttempts(ctx context.Context, key string) (model.LoginAttempts, error) {
+	query := `SELECT failures, last_failure_at, locked_until FROM login_attempts WHERE key = $1;`
+
+	attempts := model.LoginAttempts{Key: key}
+	row := p.pool.QueryRow(ctx, query, key)
+
+	err := retry(func() error {
+		return row.Scan(&attempts.Failures, &attempts.LastFailureAt, &attempts.LockedUntil)
+	})
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return model.LoginAttempts{Key: key}, nil
+		}
+
+		return model.LoginAttempts{}, fmt.Errorf("can't scan: %w", err)
+	}
+
+	return attempts, nil
+}
+
+// RecordLoginFailure counts one more failure in a single upsert, so that
+// concurrent attempts can't lose increments. A failure older than window
+// starts the count anew.
+func (p *Postgresql) RecordLoginFailure(
+	ctx context.Context,
+	key string,
+	window time.Duration,
+) (model.LoginAttempts, error) {
+	query := `INSERT INTO login_attempts (key, failures, last_failure_at) VALUES ($1, 1, $2)
+	ON CONFLICT (key) DO UPDATE SET
+		failures = CASE WHEN login_attempts.last_failure_at < $3 THEN 1 ELSE login_attempts.failures + 1 END,
+		last_failure_at = excluded.last_failure_at
+	RETURNING failures, last_failure_at, locked_until;`
+
+	now := time.Now()
+	attempts := model.LoginAttempts{Key: key}
+
+	if err := retry(func() error {
+		return p.pool.QueryRow(ctx, query, key, now, now.Add(-window)).
+			Scan(&attempts.Failures, &attempts.LastFailureAt, &attempts.LockedUntil)
+	}); err != nil {
+		return model.LoginAttempts{}, fmt.Errorf("can't scan: %w", err)
+	}
+
+	return attempts, nil
+}
+
+func (p *Postgresql) LockLogin(ctx context.Context, key string, until time.Time) error {
+	query := `UPDATE login_attempts SET locked_until = $1 WHERE key = $2;`
+
+	return retry(func() error {
+		_, err := p.pool.Exec(ctx, query, until, key)
+		if err != nil {
+			return fmt.Errorf("can't exec: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (p *Postgresql) ResetLoginAttempts(ctx context.Context, key string) error {
+	query := `DELETE FROM login_attempts WHERE key = $1;`
+
+	return retry(func() error {
+		_, err := p.pool.Exec(ctx, query, key)
+		if err != nil {
+			return fmt.Errorf("can't exec: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// ReleaseLoginAttempt takes back one failure recorded for an attempt that
+// turned out to succeed.
+func (p *Postgresql) ReleaseLoginAttempt(ctx context.Context, key string) error {
+	query := `UPDATE login_attempts SET failures = failures - 1 WHERE key = $1 AND failures > 0;`
+
+	return retry(func() error {
+		_, err := p.pool.Exec(ctx, query, key)
+		if err != nil {
+			return fmt.Errorf("can't exec: %w", err)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,102 @@
+//nolint:wrapcheck,gocritic,nolintlint,errcheck,forcetypeassert
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPostgresql_GetLoginAttempts(t *testing.T) {
+	t.Run("no attempts", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockRow := new(MockRow)
+		mockPool.On("QueryRow", mock.Anything, mock.Anything, []interface{}{"login:user"}).Return(mockRow)
+		mockRow.On("Scan", mock.Anything, mock.Anything, mock.Anything).Return(pgx.ErrNoRows)
+
+		postgres := &Postgresql{pool: mockPool}
+		attempts, err := postgres.GetLoginAttempts(context.TODO(), "login:user")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "login:user", attempts.Key)
+		assert.Zero(t, attempts.Failures)
+		mockPool.AssertExpectations(t)
+		mockRow.AssertExpectations(t)
+	})
+
+	t.Run("failed scan", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockRow := new(MockRow)
+		mockPool.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(mockRow)
+		mockRow.On("Scan", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("scan error"))
+
+		postgres := &Postgresql{pool: mockPool}
+		_, err := postgres.GetLoginAttempts(context.TODO(), "login:user")
+
+		assert.EqualError(t, err, "can't scan: operation failed after 3 retries: scan error")
+	})
+}
+
+func TestPostgresql_RecordLoginFailure(t *testing.T) {
+	t.Run("successful record", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockRow := new(MockRow)
+		mockPool.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(mockRow)
+		mockRow.On("Scan", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			*(args.Get(0).(*int)) = 4
+		}).Return(nil)
+
+		postgres := &Postgresql{pool: mockPool}
+		attempts, err := postgres.RecordLoginFailure(context.TODO(), "ip:127.0.0.1", time.Minute)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 4, attempts.Failures)
+		mockPool.AssertExpectations(t)
+		mockRow.AssertExpectations(t)
+	})
+}
+
+func TestPostgresql_LockAndResetLogin(t *testing.T) {
+	t.Run("successful lock", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockPool.On("Exec", mock.Anything, "UPDATE login_attempts SET locked_until = $1 WHERE key = $2;",
+			mock.Anything).Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+
+		postgres := &Postgresql{pool: mockPool}
+		err := postgres.LockLogin(context.TODO(), "login:user", time.Now().Add(time.Hour))
+
+		assert.NoError(t, err)
+		mockPool.AssertExpectations(t)
+	})
+
+	t.Run("successful reset", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockPool.On("Exec", mock.Anything, "DELETE FROM login_attempts WHERE key = $1;",
+			[]interface{}{"login:user"}).Return(pgconn.NewCommandTag("DELETE 1"), nil)
+
+		postgres := &Postgresql{pool: mockPool}
+		err := postgres.ResetLoginAttempts(context.TODO(), "login:user")
+
+		assert.NoError(t, err)
+		mockPool.AssertExpectations(t)
+	})
+
+	t.Run("successful release", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockPool.On("Exec", mock.Anything,
+			"UPDATE login_attempts SET failures = failures - 1 WHERE key = $1 AND failures > 0;",
+			[]interface{}{"ip:127.0.0.1"}).Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+
+		postgres := &Postgresql{pool: mockPool}
+		err := postgres.ReleaseLoginAttempt(context.TODO(), "ip:127.0.0.1")
+
+		assert.NoError(t, err)
+		mockPool.AssertExpectations(t)
+	})
+}
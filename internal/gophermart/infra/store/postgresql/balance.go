@@ -7,18 +7,68 @@ import (
 	"gofermart/internal/gophermart/core/model"
 )
 
+// GetUserBalance returns the default wallet of login.
+//
 //nolint:gocritic,goconst,nolintlint
 func (p *Postgresql) GetUserBalance(ctx context.Context, login string) (model.UserBalance, error) {
-	query := `SELECT amount, withdraw FROM balance WHERE login = $1;`
+	query := `SELECT
+	    COALESCE(SUM(l.amount) FILTER (WHERE l.account = $2), 0),
+	    COALESCE(SUM(l.amount) FILTER (WHERE l.account = $3), 0),
+	    COALESCE(SUM(l.amount) FILTER (WHERE l.account = $4), 0),
+	    COALESCE(-SUM(l.amount) FILTER (WHERE l.account = $5), 0)
+	FROM balance b
+	    LEFT JOIN ledger_entries l ON l.login = b.login AND l.currency = b.currency
+	WHERE b.login = $1 AND b.currency = $6
+	GROUP BY b.login;`
 
 	var balance model.UserBalance
-	row := p.pool.QueryRow(ctx, query, login)
+	row := p.pool.QueryRow(ctx, query, login, model.LedgerAccountAvailable, model.LedgerAccountHeld,
+		model.LedgerAccountWithdrawn, model.LedgerAccountDebt, model.DefaultCurrency)
 
 	if err := retry(func() error {
-		return row.Scan(&balance.Amount, &balance.Withdraw)
+		return row.Scan(&balance.Amount, &balance.Held, &balance.Withdraw, &balance.Debt)
 	}); err != nil {
 		return model.UserBalance{}, fmt.Errorf("can't scan: %w", err)
 	}
 
 	return balance, nil
 }
+
+// GetUserWallets returns every wallet of login, the default one first.
+//
+//nolint:gocritic,goconst,nolintlint
+func (p *Postgresql) GetUserWallets(ctx context.Context, login string) ([]model.Wallet, error) {
+	query := `SELECT b.currency,
+	    COALESCE(SUM(l.amount) FILTER (WHERE l.account = $2), 0),
+	    COALESCE(SUM(l.amount) FILTER (WHERE l.account = $3), 0),
+	    COALESCE(SUM(l.amount) FILTER (WHERE l.account = $4), 0),
+	    COALESCE(-SUM(l.amount) FILTER (WHERE l.account = $5), 0)
+	FROM balance b
+	    LEFT JOIN ledger_entries l ON l.login = b.login AND l.currency = b.currency
+	WHERE b.login = $1
+	GROUP BY b.currency
+	ORDER BY b.currency <> $6, b.currency;`
+
+	var wallets []model.Wallet
+	return wallets, retry(func() error {
+		rows, err := p.pool.Query(ctx, query, login, model.LedgerAccountAvailable, model.LedgerAccountHeld,
+			model.LedgerAccountWithdrawn, model.LedgerAccountDebt, model.DefaultCurrency)
+		if err != nil {
+			return fmt.Errorf("can't query: %w", err)
+		}
+		defer rows.Close()
+
+		wallets = nil
+		for rows.Next() {
+			var wallet model.Wallet
+			if err := rows.Scan(&wallet.Currency, &wallet.Amount, &wallet.Held, &wallet.Withdraw,
+				&wallet.Debt); err != nil {
+				return fmt.Errorf("can't scan: %w", err)
+			}
+
+			wallets = append(wallets, wallet)
+		}
+
+		return rows.Err()
+	})
+}
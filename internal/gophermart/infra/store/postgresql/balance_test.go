@@ -8,6 +8,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+
+	"gofermart/internal/gophermart/core/model"
 )
 
 func TestPostgresql_GetUserBalance(t *testing.T) {
@@ -21,9 +23,9 @@ func TestPostgresql_GetUserBalance(t *testing.T) {
 		mockPool := new(MockPool)
 		mockRow := new(MockRow)
 		mockPool.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(mockRow)
-		mockRow.On("Scan", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
-			*(args.Get(0).(*int)) = amount
-			*(args.Get(1).(*int)) = withdraw
+		mockRow.On("Scan", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			*(args.Get(0).(*model.Money)) = amount
+			*(args.Get(2).(*model.Money)) = withdraw
 		}).Return(nil)
 
 		postgres := &Postgresql{pool: mockPool}
@@ -38,7 +40,7 @@ func TestPostgresql_GetUserBalance(t *testing.T) {
 		mockPool := new(MockPool)
 		mockRow := new(MockRow)
 		mockPool.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(mockRow)
-		mockRow.On("Scan", mock.Anything, mock.Anything).Return(errors.New("scan error"))
+		mockRow.On("Scan", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(errors.New("scan error"))
 
 		postgres := &Postgresql{pool: mockPool}
 		_, err := postgres.GetUserBalance(context.TODO(), login)
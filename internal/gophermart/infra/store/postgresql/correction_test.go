@@ -0,0 +1,133 @@
+//nolint:wrapcheck,gocritic,nolintlint,errcheck,forcetypeassert
+package postgresql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"gofermart/internal/gophermart/core/model"
+)
+
+const (
+	querySetOrder = `update orders o set status = $1, amount = $2, bonus = $3,
+	    currency = case when prev.amount = 0 and prev.bonus = 0 then $5 else prev.currency end,
+	    updated_at = now()
+	from (select order_id, amount, bonus, currency from orders where order_id = $4 for update) prev
+	where o.order_id = prev.order_id
+	returning o.login, prev.amount, prev.bonus, o.currency;`
+	queryUserFunds = `select b.amount, coalesce((
+	    select -sum(l.amount) from ledger_entries l
+	    where l.login = b.login and l.currency = b.currency and l.account = $3
+	), 0)
+	from balance b where b.login = $1 and b.currency = $2 for update;`
+)
+
+func TestPostgresql_SetBalanceCorrections(t *testing.T) {
+	setUp := func(prevAmount, available, debt model.Money) (*MockPool, *MockTx) {
+		mockPool := new(MockPool)
+		mockTx := new(MockTx)
+		orderRow := new(MockRow)
+		fundsRow := new(MockRow)
+
+		mockPool.On("Begin", mock.Anything).Return(mockTx, nil)
+		mockTx.On("QueryRow", mock.Anything, querySetOrder, mock.Anything).Return(orderRow)
+		orderRow.On("Scan", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			*(args.Get(0).(*string)) = "login"
+			*(args.Get(1).(*model.Money)) = prevAmount
+			*(args.Get(3).(*string)) = model.DefaultCurrency
+		}).Return(nil)
+		mockTx.On("QueryRow", mock.Anything, queryUserFunds,
+			[]interface{}{"login", model.DefaultCurrency, model.LedgerAccountDebt}).
+			Return(fundsRow)
+		fundsRow.On("Scan", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			*(args.Get(0).(*model.Money)) = available
+			*(args.Get(1).(*model.Money)) = debt
+		}).Return(nil)
+		mockTx.On("Commit", mock.Anything).Return(nil)
+
+		return mockPool, mockTx
+	}
+
+	entry := func(kind, account string, amount model.Money) interface{} {
+		return mock.MatchedBy(func(args []interface{}) bool {
+			return args[1] == kind && args[3] == account && args[5] == amount
+		})
+	}
+
+	t.Run("clawback beyond the balance", func(t *testing.T) {
+		mockPool, mockTx := setUp(300, 50, 0)
+		mockTx.On("Exec", mock.Anything, queryLedgerBalance,
+			[]interface{}{model.Money(-50), model.Money(0), model.Money(0), "login", model.DefaultCurrency}).
+			Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+		mockTx.On("Exec", mock.Anything, queryConsumeLots, mock.Anything).
+			Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+		mockTx.On("Exec", mock.Anything, queryLedgerEntry,
+			entry(model.LedgerKindCorrection, model.LedgerAccountAvailable, -50)).
+			Return(pgconn.NewCommandTag("INSERT 1"), nil)
+		mockTx.On("Exec", mock.Anything, queryLedgerEntry,
+			entry(model.LedgerKindCorrection, model.LedgerAccountDebt, -150)).
+			Return(pgconn.NewCommandTag("INSERT 1"), nil)
+		mockTx.On("Exec", mock.Anything, queryLedgerEntry,
+			entry(model.LedgerKindCorrection, model.LedgerAccountAccrual, 200)).
+			Return(pgconn.NewCommandTag("INSERT 1"), nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		err := postgres.SetBalance(context.TODO(), model.Accrual{
+			OrderID: "12345678903", Status: model.OrderStatusDone, Amount: 100,
+		})
+
+		assert.NoError(t, err)
+		mockTx.AssertExpectations(t)
+	})
+
+	t.Run("accrual repays debt", func(t *testing.T) {
+		mockPool, mockTx := setUp(0, 0, 100)
+		mockTx.On("Exec", mock.Anything, queryLedgerBalance,
+			[]interface{}{model.Money(300), model.Money(0), model.Money(0), "login", model.DefaultCurrency}).
+			Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+		mockTx.On("Exec", mock.Anything, queryLedgerBalance,
+			[]interface{}{model.Money(-100), model.Money(0), model.Money(0), "login", model.DefaultCurrency}).
+			Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+		mockTx.On("Exec", mock.Anything, queryConsumeLots, mock.Anything).
+			Return(pgconn.NewCommandTag("UPDATE 0"), nil)
+		mockTx.On("Exec", mock.Anything, queryLedgerEntry,
+			entry(model.LedgerKindAccrual, model.LedgerAccountAvailable, 300)).
+			Return(pgconn.NewCommandTag("INSERT 1"), nil)
+		mockTx.On("Exec", mock.Anything, queryLedgerEntry,
+			entry(model.LedgerKindAccrual, model.LedgerAccountAccrual, -300)).
+			Return(pgconn.NewCommandTag("INSERT 1"), nil)
+		mockTx.On("Exec", mock.Anything, queryLedgerEntry,
+			entry(model.LedgerKindRepayment, model.LedgerAccountAvailable, -100)).
+			Return(pgconn.NewCommandTag("INSERT 1"), nil)
+		mockTx.On("Exec", mock.Anything, queryLedgerEntry,
+			entry(model.LedgerKindRepayment, model.LedgerAccountDebt, 100)).
+			Return(pgconn.NewCommandTag("INSERT 1"), nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		err := postgres.SetBalance(context.TODO(), model.Accrual{
+			OrderID: "12345678903", Status: model.OrderStatusDone, Amount: 300,
+		})
+
+		assert.NoError(t, err)
+		mockTx.AssertExpectations(t)
+	})
+
+	t.Run("same accrual again", func(t *testing.T) {
+		mockPool, mockTx := setUp(300, 300, 0)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		err := postgres.SetBalance(context.TODO(), model.Accrual{
+			OrderID: "12345678903", Status: model.OrderStatusDone, Amount: 300,
+		})
+
+		assert.NoError(t, err)
+		mockTx.AssertNotCalled(t, "Exec", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
@@ -11,10 +11,21 @@ func createTables(ctx context.Context, pool PgxPool) error {
 	    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
 	    login VARCHAR(255) NOT NULL,
 	    password VARCHAR(255) NOT NULL,
+	    role VARCHAR(32) NOT NULL default 'user',
+	    disabled BOOLEAN NOT NULL default false,
+	    tier VARCHAR(32) NOT NULL default '',
+	    referral_code VARCHAR(32),
+	    password_changed_at TIMESTAMP,
 	    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
 	    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
 	    CONSTRAINT users_login_key UNIQUE (login)
-);`
+);
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(32) NOT NULL default 'user';
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS disabled BOOLEAN NOT NULL default false;
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS tier VARCHAR(32) NOT NULL default '';
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS referral_code VARCHAR(32);
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS password_changed_at TIMESTAMP;
+	CREATE UNIQUE INDEX IF NOT EXISTS users_referral_code_key ON users (referral_code);`
 
 	orderTable := `
 	CREATE TABLE IF NOT EXISTS orders (
@@ -23,21 +34,31 @@ func createTables(ctx context.Context, pool PgxPool) error {
 	    order_id VARCHAR(255) NOT NULL,
 	    status VARCHAR(255) NOT NULL default 'NEW',
 	    amount bigint NOT NULL default 0,
+	    bonus bigint NOT NULL default 0,
+	    currency VARCHAR(16) NOT NULL default 'POINTS',
 	    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
 	    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
 	    CONSTRAINT orders_id_key UNIQUE (order_id)
-);`
+);
+	ALTER TABLE orders ADD COLUMN IF NOT EXISTS bonus bigint NOT NULL default 0;
+	ALTER TABLE orders ADD COLUMN IF NOT EXISTS currency VARCHAR(16) NOT NULL default 'POINTS';`
 
 	balanceTable := `
 	CREATE TABLE IF NOT EXISTS balance (
 	    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
 	    login VARCHAR(255) NOT NULL,
+	    currency VARCHAR(16) NOT NULL default 'POINTS',
 	    amount bigint NOT NULL default 0 CHECK (amount >= 0),
+	    held bigint NOT NULL default 0 CHECK (held >= 0),
 	    withdraw bigint NOT NULL default 0,
 	    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
 	    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
-	    CONSTRAINT balance_login_key UNIQUE (login)
-);`
+	    CONSTRAINT balance_login_currency_key UNIQUE (login, currency)
+);
+	ALTER TABLE balance ADD COLUMN IF NOT EXISTS held bigint NOT NULL default 0 CHECK (held >= 0);
+	ALTER TABLE balance ADD COLUMN IF NOT EXISTS currency VARCHAR(16) NOT NULL default 'POINTS';
+	ALTER TABLE balance DROP CONSTRAINT IF EXISTS balance_login_key;
+	CREATE UNIQUE INDEX IF NOT EXISTS balance_login_currency_key ON balance (login, currency);`
 
 	withdrawTable := `
 	CREATE TABLE IF NOT EXISTS withdraw (
@@ -45,8 +66,227 @@ func createTables(ctx context.Context, pool PgxPool) error {
 	    login VARCHAR(255) NOT NULL,
 	    amount bigint NOT NULL default 0 CHECK (amount > 0),
 	    order_id VARCHAR(255) NOT NULL,
+	    status VARCHAR(32) NOT NULL default 'CAPTURED',
+	    refunded bigint NOT NULL default 0,
+	    currency VARCHAR(16) NOT NULL default 'POINTS',
+	    partner VARCHAR(255) NOT NULL default '',
+	    expires_at TIMESTAMP,
 	    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
 	    CONSTRAINT withdraw_order_id_key UNIQUE (order_id)
+);
+	ALTER TABLE withdraw ADD COLUMN IF NOT EXISTS status VARCHAR(32) NOT NULL default 'CAPTURED';
+	ALTER TABLE withdraw ALTER COLUMN status TYPE VARCHAR(32);
+	ALTER TABLE withdraw ADD COLUMN IF NOT EXISTS refunded bigint NOT NULL default 0;
+	ALTER TABLE withdraw ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP;
+	ALTER TABLE withdraw ADD COLUMN IF NOT EXISTS currency VARCHAR(16) NOT NULL default 'POINTS';
+	ALTER TABLE withdraw ADD COLUMN IF NOT EXISTS partner VARCHAR(255) NOT NULL default '';
+	CREATE INDEX IF NOT EXISTS withdraw_held_idx ON withdraw (expires_at) WHERE status = 'HELD';
+	CREATE INDEX IF NOT EXISTS withdraw_login_created_idx ON withdraw (login, created_at);`
+
+	refundTable := `
+	CREATE TABLE IF NOT EXISTS withdraw_refunds (
+	    id VARCHAR(64) PRIMARY KEY,
+	    transaction_id VARCHAR(36) NOT NULL,
+	    order_id VARCHAR(255) NOT NULL,
+	    login VARCHAR(255) NOT NULL,
+	    actor VARCHAR(255) NOT NULL,
+	    reason TEXT NOT NULL default '',
+	    amount bigint NOT NULL CHECK (amount > 0),
+	    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+	CREATE INDEX IF NOT EXISTS withdraw_refunds_order_idx ON withdraw_refunds (order_id);`
+
+	// Balances that predate the ledger get an opening adjustment so that
+	// the ledger sums up to the stored snapshot.
+	ledgerTable := `
+	CREATE TABLE IF NOT EXISTS ledger_entries (
+	    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+	    transaction_id VARCHAR(36) NOT NULL,
+	    kind VARCHAR(32) NOT NULL,
+	    login VARCHAR(255) NOT NULL,
+	    account VARCHAR(64) NOT NULL,
+	    currency VARCHAR(16) NOT NULL default 'POINTS',
+	    amount bigint NOT NULL CHECK (amount <> 0),
+	    order_id VARCHAR(255) NOT NULL default '',
+	    reference VARCHAR(36) NOT NULL default '',
+	    description TEXT NOT NULL default '',
+	    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+	ALTER TABLE ledger_entries ADD COLUMN IF NOT EXISTS currency VARCHAR(16) NOT NULL default 'POINTS';
+	CREATE INDEX IF NOT EXISTS ledger_entries_login_idx ON ledger_entries (login, account);
+	CREATE INDEX IF NOT EXISTS ledger_entries_transaction_idx ON ledger_entries (transaction_id);
+	CREATE INDEX IF NOT EXISTS ledger_entries_kind_idx ON ledger_entries (kind, created_at);
+	CREATE UNIQUE INDEX IF NOT EXISTS ledger_entries_reversal_key ON ledger_entries (reference, login, account)
+	    WHERE kind = 'reversal';
+	WITH opening AS (
+	    SELECT b.login, b.amount, b.withdraw, gen_random_uuid()::text AS transaction_id
+	    FROM balance b
+	    WHERE b.currency = 'POINTS' AND (b.amount <> 0 OR b.withdraw <> 0)
+	      AND NOT EXISTS (SELECT 1 FROM ledger_entries l WHERE l.login = b.login)
+	)
+	INSERT INTO ledger_entries (transaction_id, kind, login, account, amount, description)
+	SELECT transaction_id, 'adjustment', login, 'user.available', amount, 'opening balance'
+	FROM opening WHERE amount <> 0
+	UNION ALL
+	SELECT transaction_id, 'adjustment', login, 'user.withdrawn', withdraw, 'opening balance'
+	FROM opening WHERE withdraw <> 0
+	UNION ALL
+	SELECT transaction_id, 'adjustment', login, 'system.adjustment', -(amount + withdraw), 'opening balance'
+	FROM opening WHERE amount + withdraw <> 0;`
+
+	lotTable := `
+	CREATE TABLE IF NOT EXISTS points_lots (
+	    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+	    transaction_id VARCHAR(36) NOT NULL,
+	    login VARCHAR(255) NOT NULL,
+	    order_id VARCHAR(255) NOT NULL default '',
+	    amount bigint NOT NULL CHECK (amount > 0),
+	    remaining bigint NOT NULL CHECK (remaining >= 0),
+	    expires_at TIMESTAMP NOT NULL,
+	    expired_at TIMESTAMP,
+	    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+	CREATE INDEX IF NOT EXISTS points_lots_open_idx ON points_lots (login, expires_at) WHERE remaining > 0;
+	CREATE INDEX IF NOT EXISTS points_lots_expiry_idx ON points_lots (expires_at) WHERE remaining > 0;
+	CREATE TABLE IF NOT EXISTS points_lot_uses (
+	    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+	    lot_id BIGINT NOT NULL,
+	    login VARCHAR(255) NOT NULL,
+	    order_id VARCHAR(255) NOT NULL,
+	    amount bigint NOT NULL CHECK (amount >= 0),
+	    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+	CREATE INDEX IF NOT EXISTS points_lot_uses_order_idx ON points_lot_uses (login, order_id) WHERE amount > 0;`
+
+	promotionTable := `
+	CREATE TABLE IF NOT EXISTS promotions (
+	    id VARCHAR(36) PRIMARY KEY,
+	    name VARCHAR(255) NOT NULL,
+	    effect VARCHAR(16) NOT NULL,
+	    bonus bigint NOT NULL default 0,
+	    multiplier bigint NOT NULL default 0,
+	    tier VARCHAR(32) NOT NULL default '',
+	    min_accrual bigint NOT NULL default 0,
+	    min_orders INTEGER NOT NULL default 0,
+	    first_order BOOLEAN NOT NULL default false,
+	    starts_at TIMESTAMP NOT NULL,
+	    ends_at TIMESTAMP,
+	    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+	CREATE TABLE IF NOT EXISTS promotion_awards (
+	    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+	    promotion_id VARCHAR(36) NOT NULL,
+	    name VARCHAR(255) NOT NULL,
+	    transaction_id VARCHAR(36) NOT NULL,
+	    order_id VARCHAR(255) NOT NULL,
+	    login VARCHAR(255) NOT NULL,
+	    amount bigint NOT NULL CHECK (amount > 0),
+	    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	    CONSTRAINT promotion_awards_order_key UNIQUE (promotion_id, order_id)
+);
+	ALTER TABLE promotion_awards ADD COLUMN IF NOT EXISTS first_order BOOLEAN NOT NULL default false;
+	CREATE UNIQUE INDEX IF NOT EXISTS promotion_awards_first_order_key ON promotion_awards (promotion_id, login)
+	    WHERE first_order;`
+
+	referralTable := `
+	CREATE TABLE IF NOT EXISTS referrals (
+	    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+	    referrer VARCHAR(255) NOT NULL,
+	    referred VARCHAR(255) NOT NULL,
+	    order_id VARCHAR(255) NOT NULL default '',
+	    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	    rewarded_at TIMESTAMP,
+	    CONSTRAINT referrals_referred_key UNIQUE (referred)
+);
+	CREATE INDEX IF NOT EXISTS referrals_referrer_idx ON referrals (referrer);`
+
+	refreshTokenTable := `
+	CREATE TABLE IF NOT EXISTS refresh_tokens (
+	    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+	    token_hash VARCHAR(64) NOT NULL,
+	    login VARCHAR(255) NOT NULL,
+	    family_id VARCHAR(36) NOT NULL,
+	    expires_at TIMESTAMP NOT NULL,
+	    used_at TIMESTAMP,
+	    revoked BOOLEAN NOT NULL default false,
+	    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	    CONSTRAINT refresh_tokens_hash_key UNIQUE (token_hash)
+);
+	CREATE INDEX IF NOT EXISTS refresh_tokens_family_idx ON refresh_tokens (family_id);`
+
+	revokedTokenTable := `
+	CREATE TABLE IF NOT EXISTS revoked_tokens (
+	    token_id VARCHAR(36) PRIMARY KEY,
+	    expires_at TIMESTAMP NOT NULL,
+	    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
+);`
+
+	loginAttemptTable := `
+	CREATE TABLE IF NOT EXISTS login_attempts (
+	    key VARCHAR(300) PRIMARY KEY,
+	    failures INT NOT NULL default 0,
+	    last_failure_at TIMESTAMP NOT NULL,
+	    locked_until TIMESTAMP
+);`
+
+	apiKeyTable := `
+	CREATE TABLE IF NOT EXISTS api_keys (
+	    id VARCHAR(36) PRIMARY KEY,
+	    login VARCHAR(255) NOT NULL,
+	    name VARCHAR(255) NOT NULL,
+	    prefix VARCHAR(16) NOT NULL,
+	    key_hash VARCHAR(64) NOT NULL,
+	    scopes TEXT[] NOT NULL,
+	    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	    last_used_at TIMESTAMP,
+	    revoked_at TIMESTAMP,
+	    CONSTRAINT api_keys_hash_key UNIQUE (key_hash)
+);
+	CREATE INDEX IF NOT EXISTS api_keys_login_idx ON api_keys (login);`
+
+	totpTable := `
+	CREATE TABLE IF NOT EXISTS user_totp (
+	    login VARCHAR(255) PRIMARY KEY,
+	    secret VARCHAR(64) NOT NULL,
+	    last_step BIGINT NOT NULL default 0,
+	    confirmed_at TIMESTAMP,
+	    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
+);`
+
+	recoveryCodeTable := `
+	CREATE TABLE IF NOT EXISTS totp_recovery_codes (
+	    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+	    login VARCHAR(255) NOT NULL,
+	    code_hash VARCHAR(64) NOT NULL,
+	    used_at TIMESTAMP,
+	    CONSTRAINT totp_recovery_codes_login_hash_key UNIQUE (login, code_hash)
+);`
+
+	sessionTable := `
+	CREATE TABLE IF NOT EXISTS sessions (
+	    id VARCHAR(36) PRIMARY KEY,
+	    login VARCHAR(255) NOT NULL,
+	    user_agent TEXT NOT NULL default '',
+	    ip VARCHAR(64) NOT NULL default '',
+	    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	    last_seen_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	    revoked_at TIMESTAMP
+);
+	CREATE INDEX IF NOT EXISTS sessions_login_idx ON sessions (login);`
+
+	idempotencyKeyTable := `
+	CREATE TABLE IF NOT EXISTS idempotency_keys (
+	    login VARCHAR(255) NOT NULL,
+	    key VARCHAR(255) NOT NULL,
+	    fingerprint VARCHAR(64) NOT NULL,
+	    status_code INT NOT NULL default 0,
+	    content_type VARCHAR(255) NOT NULL default '',
+	    body BYTEA,
+	    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	    expires_at TIMESTAMP NOT NULL,
+	    completed_at TIMESTAMP,
+	    PRIMARY KEY (login, key)
 );`
 
 	tx, err := pool.Begin(ctx)
@@ -74,6 +314,71 @@ func createTables(ctx context.Context, pool PgxPool) error {
 		return fmt.Errorf("err creating withdraw table: %w", err)
 	}
 
+	if _, err := tx.Exec(ctx, refundTable); err != nil {
+		_ = tx.Rollback(ctx)
+		return fmt.Errorf("err creating withdraw_refunds table: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, ledgerTable); err != nil {
+		_ = tx.Rollback(ctx)
+		return fmt.Errorf("err creating ledger_entries table: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, lotTable); err != nil {
+		_ = tx.Rollback(ctx)
+		return fmt.Errorf("err creating points_lots table: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, promotionTable); err != nil {
+		_ = tx.Rollback(ctx)
+		return fmt.Errorf("err creating promotions table: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, referralTable); err != nil {
+		_ = tx.Rollback(ctx)
+		return fmt.Errorf("err creating referrals table: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, refreshTokenTable); err != nil {
+		_ = tx.Rollback(ctx)
+		return fmt.Errorf("err creating refresh_tokens table: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, revokedTokenTable); err != nil {
+		_ = tx.Rollback(ctx)
+		return fmt.Errorf("err creating revoked_tokens table: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, loginAttemptTable); err != nil {
+		_ = tx.Rollback(ctx)
+		return fmt.Errorf("err creating login_attempts table: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, apiKeyTable); err != nil {
+		_ = tx.Rollback(ctx)
+		return fmt.Errorf("err creating api_keys table: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, totpTable); err != nil {
+		_ = tx.Rollback(ctx)
+		return fmt.Errorf("err creating user_totp table: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, recoveryCodeTable); err != nil {
+		_ = tx.Rollback(ctx)
+		return fmt.Errorf("err creating totp_recovery_codes table: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, sessionTable); err != nil {
+		_ = tx.Rollback(ctx)
+		return fmt.Errorf("err creating sessions table: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, idempotencyKeyTable); err != nil {
+		_ = tx.Rollback(ctx)
+		return fmt.Errorf("err creating idempotency_keys table: %w", err)
+	}
+
 	if err := tx.Commit(ctx); err != nil {
 		return fmt.Errorf("err committing transaction: %w", err)
 	}
@@ -0,0 +1,174 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+func (p *Postgresql) HoldWithdraw(ctx context.Context, login string, request model.Withdraw) error {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("can't begin transaction: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+			return
+		}
+		_ = tx.Commit(ctx)
+	}()
+
+	currency := model.WalletCurrency(request.Currency)
+
+	if len(request.Caps) > 0 {
+		queryLock := `select amount from balance where login = $1 and currency = $2 for update;`
+
+		var amount model.Money
+		err = tx.QueryRow(ctx, queryLock, login, currency).Scan(&amount)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				err = repositories.ErrInsufficientFunds
+				return err
+			}
+
+			return fmt.Errorf("can't scan: %w", err)
+		}
+
+		err = checkCaps(ctx, tx, login, request)
+		if err != nil {
+			return err
+		}
+	}
+
+	query := `insert into withdraw (login, amount, order_id, status, currency, partner, expires_at)
+	values ($1, $2, $3, $4, $5, $6, $7);`
+
+	_, err = tx.Exec(ctx, query, login, request.Amount, request.OrderID, model.WithdrawStatusHeld, currency,
+		request.Partner, request.ExpiresAt)
+	if err != nil {
+		if isDuplicateError(err) {
+			err = repositories.ErrDuplicate
+			return err
+		}
+
+		return fmt.Errorf("can't exec: %w", err)
+	}
+
+	transaction := model.HoldTransaction(uuid.NewString(), login, request.OrderID, request.Amount)
+	transaction.Currency = currency
+
+	err = postLedger(ctx, tx, transaction)
+
+	return err
+}
+
+func (p *Postgresql) CaptureWithdraw(ctx context.Context, login, orderID string, now time.Time) error {
+	return p.settleHold(ctx, login, orderID, func(hold model.Withdraw) (model.LedgerTransaction, string, error) {
+		if !hold.ExpiresAt.After(now) {
+			return model.LedgerTransaction{}, "", repositories.ErrInvalidState
+		}
+
+		return model.CaptureTransaction(uuid.NewString(), login, orderID, hold.Amount),
+			model.WithdrawStatusCaptured, nil
+	})
+}
+
+func (p *Postgresql) ReleaseWithdraw(ctx context.Context, login, orderID, status string) error {
+	return p.settleHold(ctx, login, orderID, func(hold model.Withdraw) (model.LedgerTransaction, string, error) {
+		return model.ReleaseTransaction(uuid.NewString(), login, orderID, hold.Amount), status, nil
+	})
+}
+
+// settleHold locks a held withdrawal, posts the transaction settle returns
+// for it and moves it to the returned status.
+func (p *Postgresql) settleHold(ctx context.Context, login, orderID string,
+	settle func(hold model.Withdraw) (model.LedgerTransaction, string, error)) error {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("can't begin transaction: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+			return
+		}
+		_ = tx.Commit(ctx)
+	}()
+
+	queryHold := `select amount, status, currency, expires_at from withdraw
+	where login = $1 and order_id = $2 for update;`
+
+	hold := model.Withdraw{Login: login, OrderID: orderID}
+	err = tx.QueryRow(ctx, queryHold, login, orderID).Scan(&hold.Amount, &hold.Status, &hold.Currency,
+		&hold.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = repositories.ErrNotFound
+			return err
+		}
+
+		return fmt.Errorf("can't scan: %w", err)
+	}
+
+	if hold.Status != model.WithdrawStatusHeld {
+		err = repositories.ErrInvalidState
+		return err
+	}
+
+	transaction, status, err := settle(hold)
+	if err != nil {
+		return err
+	}
+	transaction.Currency = hold.Currency
+
+	err = postLedger(ctx, tx, transaction)
+	if err != nil {
+		return err
+	}
+
+	queryStatus := `update withdraw set status = $1 where order_id = $2;`
+
+	_, err = tx.Exec(ctx, queryStatus, status, orderID)
+	if err != nil {
+		return fmt.Errorf("can't exec: %w", err)
+	}
+
+	return nil
+}
+
+func (p *Postgresql) GetExpiredHolds(ctx context.Context, now time.Time) ([]model.Withdraw, error) {
+	query := `SELECT login, order_id, amount, created_at, expires_at FROM withdraw
+	WHERE status = $1 AND expires_at <= $2 ORDER BY expires_at;`
+
+	var holds []model.Withdraw
+	return holds, retry(func() error {
+		rows, err := p.pool.Query(ctx, query, model.WithdrawStatusHeld, now)
+		if err != nil {
+			return fmt.Errorf("can't query: %w", err)
+		}
+		defer rows.Close()
+
+		holds = nil
+		for rows.Next() {
+			hold := model.Withdraw{Status: model.WithdrawStatusHeld}
+			if err := rows.Scan(&hold.Login, &hold.OrderID, &hold.Amount, &hold.CreatedAt,
+				&hold.ExpiresAt); err != nil {
+				return fmt.Errorf("can't scan: %w", err)
+			}
+
+			holds = append(holds, hold)
+		}
+
+		return rows.Err()
+	})
+}
@@ -0,0 +1,227 @@
+//nolint:wrapcheck,gocritic,nolintlint,errcheck,forcetypeassert
+package postgresql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+const (
+	queryInsertHold = `insert into withdraw (login, amount, order_id, status, currency, partner, expires_at)
+	values ($1, $2, $3, $4, $5, $6, $7);`
+	querySelectHold = `select amount, status, currency, expires_at from withdraw
+	where login = $1 and order_id = $2 for update;`
+	queryHoldStatus = `update withdraw set status = $1 where order_id = $2;`
+)
+
+func TestPostgresql_HoldWithdraw(t *testing.T) {
+	expiresAt := time.Now().Add(time.Minute)
+	request := model.Withdraw{OrderID: "2377225624", Amount: 200, ExpiresAt: &expiresAt}
+
+	t.Run("successful hold", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockTx := new(MockTx)
+
+		mockPool.On("Begin", mock.Anything).Return(mockTx, nil)
+		mockTx.On("Exec", mock.Anything, queryInsertHold,
+			[]interface{}{"login", model.Money(200), "2377225624", model.WithdrawStatusHeld, model.DefaultCurrency,
+				"", &expiresAt}).
+			Return(pgconn.NewCommandTag("INSERT 1"), nil)
+		mockTx.On("Exec", mock.Anything, queryLedgerBalance,
+			[]interface{}{model.Money(-200), model.Money(200), model.Money(0), "login", model.DefaultCurrency}).
+			Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+		mockTx.On("Exec", mock.Anything, queryConsumeLots, []interface{}{"login", model.Money(200), "2377225624"}).
+			Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+		mockTx.On("Exec", mock.Anything, queryLedgerEntry, mock.Anything).
+			Return(pgconn.NewCommandTag("INSERT 1"), nil).Twice()
+		mockTx.On("Commit", mock.Anything).Return(nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		err := postgres.HoldWithdraw(context.TODO(), "login", request)
+
+		assert.NoError(t, err)
+		mockPool.AssertExpectations(t)
+		mockTx.AssertExpectations(t)
+	})
+
+	t.Run("order already used", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockTx := new(MockTx)
+
+		mockPool.On("Begin", mock.Anything).Return(mockTx, nil)
+		mockTx.On("Exec", mock.Anything, queryInsertHold, mock.Anything).
+			Return(pgconn.CommandTag{}, &pgconn.PgError{Code: pgerrcode.UniqueViolation})
+		mockTx.On("Rollback", mock.Anything).Return(nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		err := postgres.HoldWithdraw(context.TODO(), "login", request)
+
+		assert.ErrorIs(t, err, repositories.ErrDuplicate)
+		mockTx.AssertExpectations(t)
+	})
+
+	t.Run("insufficient funds", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockTx := new(MockTx)
+
+		mockPool.On("Begin", mock.Anything).Return(mockTx, nil)
+		mockTx.On("Exec", mock.Anything, queryInsertHold, mock.Anything).
+			Return(pgconn.NewCommandTag("INSERT 1"), nil)
+		mockTx.On("Exec", mock.Anything, queryLedgerBalance, mock.Anything).
+			Return(pgconn.CommandTag{}, &pgconn.PgError{Code: pgerrcode.CheckViolation})
+		mockTx.On("Rollback", mock.Anything).Return(nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		err := postgres.HoldWithdraw(context.TODO(), "login", request)
+
+		assert.ErrorIs(t, err, repositories.ErrInsufficientFunds)
+		mockTx.AssertExpectations(t)
+	})
+}
+
+func TestPostgresql_CaptureWithdraw(t *testing.T) {
+	now := time.Now()
+
+	scanHold := func(status string, expiresAt time.Time) func(args mock.Arguments) {
+		return func(args mock.Arguments) {
+			*(args.Get(0).(*model.Money)) = 200
+			*(args.Get(1).(*string)) = status
+			*(args.Get(2).(*string)) = model.DefaultCurrency
+			*(args.Get(3).(**time.Time)) = &expiresAt
+		}
+	}
+
+	t.Run("successful capture", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockTx := new(MockTx)
+		mockRow := new(MockRow)
+
+		mockPool.On("Begin", mock.Anything).Return(mockTx, nil)
+		mockTx.On("QueryRow", mock.Anything, querySelectHold, []interface{}{"login", "2377225624"}).Return(mockRow)
+		mockRow.On("Scan", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Run(scanHold(model.WithdrawStatusHeld, now.Add(time.Minute))).Return(nil)
+		mockTx.On("Exec", mock.Anything, queryLedgerBalance,
+			[]interface{}{model.Money(0), model.Money(-200), model.Money(200), "login", model.DefaultCurrency}).
+			Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+		mockTx.On("Exec", mock.Anything, queryLedgerEntry, mock.Anything).
+			Return(pgconn.NewCommandTag("INSERT 1"), nil).Twice()
+		mockTx.On("Exec", mock.Anything, queryHoldStatus, []interface{}{model.WithdrawStatusCaptured, "2377225624"}).
+			Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+		mockTx.On("Commit", mock.Anything).Return(nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		err := postgres.CaptureWithdraw(context.TODO(), "login", "2377225624", now)
+
+		assert.NoError(t, err)
+		mockPool.AssertExpectations(t)
+		mockTx.AssertExpectations(t)
+		mockRow.AssertExpectations(t)
+	})
+
+	t.Run("expired hold", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockTx := new(MockTx)
+		mockRow := new(MockRow)
+
+		mockPool.On("Begin", mock.Anything).Return(mockTx, nil)
+		mockTx.On("QueryRow", mock.Anything, querySelectHold, mock.Anything).Return(mockRow)
+		mockRow.On("Scan", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Run(scanHold(model.WithdrawStatusHeld, now.Add(-time.Minute))).Return(nil)
+		mockTx.On("Rollback", mock.Anything).Return(nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		err := postgres.CaptureWithdraw(context.TODO(), "login", "2377225624", now)
+
+		assert.ErrorIs(t, err, repositories.ErrInvalidState)
+		mockTx.AssertExpectations(t)
+	})
+
+	t.Run("hold not found", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockTx := new(MockTx)
+		mockRow := new(MockRow)
+
+		mockPool.On("Begin", mock.Anything).Return(mockTx, nil)
+		mockTx.On("QueryRow", mock.Anything, querySelectHold, mock.Anything).Return(mockRow)
+		mockRow.On("Scan", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(pgx.ErrNoRows)
+		mockTx.On("Rollback", mock.Anything).Return(nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		err := postgres.CaptureWithdraw(context.TODO(), "login", "2377225624", now)
+
+		assert.ErrorIs(t, err, repositories.ErrNotFound)
+		mockTx.AssertExpectations(t)
+	})
+}
+
+func TestPostgresql_ReleaseWithdraw(t *testing.T) {
+	expiresAt := time.Now().Add(time.Minute)
+
+	t.Run("successful release", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockTx := new(MockTx)
+		mockRow := new(MockRow)
+
+		mockPool.On("Begin", mock.Anything).Return(mockTx, nil)
+		mockTx.On("QueryRow", mock.Anything, querySelectHold, mock.Anything).Return(mockRow)
+		mockRow.On("Scan", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			*(args.Get(0).(*model.Money)) = 200
+			*(args.Get(1).(*string)) = model.WithdrawStatusHeld
+			*(args.Get(2).(*string)) = model.DefaultCurrency
+			*(args.Get(3).(**time.Time)) = &expiresAt
+		}).Return(nil)
+		mockTx.On("Exec", mock.Anything, queryLedgerBalance,
+			[]interface{}{model.Money(200), model.Money(-200), model.Money(0), "login", model.DefaultCurrency}).
+			Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+		mockTx.On("Exec", mock.Anything, queryRestoreLots, []interface{}{"login", "2377225624", model.Money(200)}).
+			Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+		mockTx.On("Exec", mock.Anything, queryLedgerEntry, mock.Anything).
+			Return(pgconn.NewCommandTag("INSERT 1"), nil).Twice()
+		mockTx.On("Exec", mock.Anything, queryHoldStatus, []interface{}{model.WithdrawStatusExpired, "2377225624"}).
+			Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+		mockTx.On("Commit", mock.Anything).Return(nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		err := postgres.ReleaseWithdraw(context.TODO(), "login", "2377225624", model.WithdrawStatusExpired)
+
+		assert.NoError(t, err)
+		mockTx.AssertExpectations(t)
+	})
+
+	t.Run("already captured", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockTx := new(MockTx)
+		mockRow := new(MockRow)
+
+		mockPool.On("Begin", mock.Anything).Return(mockTx, nil)
+		mockTx.On("QueryRow", mock.Anything, querySelectHold, mock.Anything).Return(mockRow)
+		mockRow.On("Scan", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			*(args.Get(1).(*string)) = model.WithdrawStatusCaptured
+		}).Return(nil)
+		mockTx.On("Rollback", mock.Anything).Return(nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		err := postgres.ReleaseWithdraw(context.TODO(), "login", "2377225624", model.WithdrawStatusReleased)
+
+		assert.ErrorIs(t, err, repositories.ErrInvalidState)
+		mockTx.AssertExpectations(t)
+	})
+}
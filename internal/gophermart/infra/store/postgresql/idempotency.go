@@ -0,0 +1,94 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+// SaveIdempotencyKey claims a key. A key that is still in use fails with
+// ErrDuplicate, an expired one is taken over.
+func (p *Postgresql) SaveIdempotencyKey(ctx context.Context, record model.IdempotencyKey) error {
+	cleanupQuery := `DELETE FROM idempotency_keys WHERE expires_at < now();`
+	query := `INSERT INTO idempotency_keys (login, key, fingerprint, created_at, expires_at)
+	VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT (login, key) DO UPDATE SET
+	    fingerprint = EXCLUDED.fingerprint,
+	    created_at = EXCLUDED.created_at,
+	    expires_at = EXCLUDED.expires_at,
+	    completed_at = NULL,
+	    status_code = 0,
+	    content_type = '',
+	    body = NULL
+	WHERE idempotency_keys.expires_at < EXCLUDED.created_at;`
+
+	return retry(func() error {
+		if _, err := p.pool.Exec(ctx, cleanupQuery); err != nil {
+			return fmt.Errorf("can't exec: %w", err)
+		}
+
+		tag, err := p.pool.Exec(ctx, query, record.Login, record.Key, record.Fingerprint,
+			record.CreatedAt, record.ExpiresAt)
+		if err != nil {
+			return fmt.Errorf("can't exec: %w", err)
+		}
+
+		if tag.RowsAffected() == 0 {
+			return repositories.ErrDuplicate
+		}
+
+		return nil
+	})
+}
+
+func (p *Postgresql) GetIdempotencyKey(ctx context.Context, login, key string) (model.IdempotencyKey, error) {
+	query := `SELECT fingerprint, created_at, expires_at, completed_at, status_code, content_type, body
+	FROM idempotency_keys WHERE login = $1 AND key = $2;`
+
+	record := model.IdempotencyKey{Login: login, Key: key}
+	row := p.pool.QueryRow(ctx, query, login, key)
+
+	if err := retry(func() error {
+		return row.Scan(&record.Fingerprint, &record.CreatedAt, &record.ExpiresAt, &record.CompletedAt,
+			&record.Response.StatusCode, &record.Response.ContentType, &record.Response.Body)
+	}); err != nil {
+		return model.IdempotencyKey{}, fmt.Errorf("can't scan: %w", err)
+	}
+
+	return record, nil
+}
+
+func (p *Postgresql) CompleteIdempotencyKey(ctx context.Context, login, key string,
+	response model.IdempotentResponse, completedAt time.Time) error {
+	query := `UPDATE idempotency_keys SET completed_at = $3, status_code = $4, content_type = $5, body = $6
+	WHERE login = $1 AND key = $2;`
+
+	return retry(func() error {
+		tag, err := p.pool.Exec(ctx, query, login, key, completedAt, response.StatusCode,
+			response.ContentType, response.Body)
+		if err != nil {
+			return fmt.Errorf("can't exec: %w", err)
+		}
+
+		if tag.RowsAffected() == 0 {
+			return repositories.ErrNotFound
+		}
+
+		return nil
+	})
+}
+
+func (p *Postgresql) DeleteIdempotencyKey(ctx context.Context, login, key string) error {
+	query := `DELETE FROM idempotency_keys WHERE login = $1 AND key = $2;`
+
+	return retry(func() error {
+		if _, err := p.pool.Exec(ctx, query, login, key); err != nil {
+			return fmt.Errorf("can't exec: %w", err)
+		}
+
+		return nil
+	})
+}
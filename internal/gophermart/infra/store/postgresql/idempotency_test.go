@@ -0,0 +1,102 @@
+//nolint:wrapcheck,gocritic,nolintlint,errcheck,forcetypeassert
+package postgresql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+func TestPostgresql_SaveIdempotencyKey(t *testing.T) {
+	record := model.IdempotencyKey{
+		Login:       "login",
+		Key:         "key",
+		Fingerprint: "fingerprint",
+		CreatedAt:   time.Now(),
+		ExpiresAt:   time.Now().Add(time.Hour),
+	}
+	args := []interface{}{record.Login, record.Key, record.Fingerprint, record.CreatedAt, record.ExpiresAt}
+
+	t.Run("successful save", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockPool.On("Exec", mock.Anything, "DELETE FROM idempotency_keys WHERE expires_at < now();",
+			[]interface{}(nil)).Return(pgconn.NewCommandTag("DELETE 0"), nil)
+		mockPool.On("Exec", mock.Anything, mock.Anything, args).Return(pgconn.NewCommandTag("INSERT 0 1"), nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		assert.NoError(t, postgres.SaveIdempotencyKey(context.TODO(), record))
+		mockPool.AssertExpectations(t)
+	})
+
+	t.Run("key in use", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockPool.On("Exec", mock.Anything, "DELETE FROM idempotency_keys WHERE expires_at < now();",
+			[]interface{}(nil)).Return(pgconn.NewCommandTag("DELETE 0"), nil)
+		mockPool.On("Exec", mock.Anything, mock.Anything, args).Return(pgconn.NewCommandTag("INSERT 0 0"), nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		assert.ErrorIs(t, postgres.SaveIdempotencyKey(context.TODO(), record), repositories.ErrDuplicate)
+		mockPool.AssertExpectations(t)
+	})
+}
+
+func TestPostgresql_GetIdempotencyKey(t *testing.T) {
+	t.Run("successful get", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockRow := new(MockRow)
+		mockPool.On("QueryRow", mock.Anything, mock.Anything, []interface{}{"login", "key"}).Return(mockRow)
+		mockRow.On("Scan", mock.Anything, mock.Anything, mock.Anything, mock.Anything,
+			mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			*(args.Get(0).(*string)) = "fingerprint"
+			*(args.Get(4).(*int)) = 200
+			*(args.Get(6).(*[]byte)) = []byte("{}")
+		}).Return(nil)
+
+		postgres := &Postgresql{pool: mockPool}
+		record, err := postgres.GetIdempotencyKey(context.TODO(), "login", "key")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "fingerprint", record.Fingerprint)
+		assert.Equal(t, model.IdempotentResponse{StatusCode: 200, Body: []byte("{}")}, record.Response)
+		mockRow.AssertExpectations(t)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockRow := new(MockRow)
+		mockPool.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(mockRow)
+		mockRow.On("Scan", mock.Anything, mock.Anything, mock.Anything, mock.Anything,
+			mock.Anything, mock.Anything, mock.Anything).Return(pgx.ErrNoRows)
+
+		postgres := &Postgresql{pool: mockPool}
+		_, err := postgres.GetIdempotencyKey(context.TODO(), "login", "key")
+
+		assert.ErrorIs(t, err, repositories.ErrNotFound)
+	})
+}
+
+func TestPostgresql_CompleteIdempotencyKey(t *testing.T) {
+	completedAt := time.Now()
+	response := model.IdempotentResponse{StatusCode: 202, ContentType: "text/plain"}
+
+	mockPool := new(MockPool)
+	mockPool.On("Exec", mock.Anything, mock.Anything,
+		[]interface{}{"login", "key", completedAt, 202, "text/plain", []byte(nil)}).
+		Return(pgconn.NewCommandTag("UPDATE 0"), nil)
+
+	postgres := &Postgresql{pool: mockPool}
+
+	err := postgres.CompleteIdempotencyKey(context.TODO(), "login", "key", response, completedAt)
+	assert.ErrorIs(t, err, repositories.ErrNotFound)
+	mockPool.AssertExpectations(t)
+}
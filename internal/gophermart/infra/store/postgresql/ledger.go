@@ -0,0 +1,293 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+func (p *Postgresql) AddLedgerTransaction(ctx context.Context, transaction model.LedgerTransaction) error {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("can't begin transaction: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+			return
+		}
+		_ = tx.Commit(ctx)
+	}()
+
+	err = postLedger(ctx, tx, transaction)
+
+	return err
+}
+
+func (p *Postgresql) GetLedgerTransaction(ctx context.Context, id string) (model.LedgerTransaction, error) {
+	query := `SELECT kind, login, account, currency, amount, order_id, reference, description, created_at
+	FROM ledger_entries WHERE transaction_id = $1 ORDER BY id;`
+
+	transaction := model.LedgerTransaction{ID: id}
+	err := retry(func() error {
+		rows, err := p.pool.Query(ctx, query, id)
+		if err != nil {
+			return fmt.Errorf("can't query: %w", err)
+		}
+		defer rows.Close()
+
+		transaction.Postings = nil
+		for rows.Next() {
+			var posting model.LedgerPosting
+			if err := rows.Scan(&transaction.Kind, &posting.Login, &posting.Account, &transaction.Currency,
+				&posting.Amount, &transaction.OrderID, &transaction.Reference, &transaction.Description,
+				&transaction.CreatedAt); err != nil {
+				return fmt.Errorf("can't scan: %w", err)
+			}
+
+			transaction.Postings = append(transaction.Postings, posting)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return model.LedgerTransaction{}, err
+	}
+
+	if len(transaction.Postings) == 0 {
+		return model.LedgerTransaction{}, repositories.ErrNotFound
+	}
+
+	return transaction, nil
+}
+
+func (p *Postgresql) GetUserLedger(ctx context.Context, login string) ([]model.LedgerEntry, error) {
+	query := `SELECT id, transaction_id, kind, account, currency, amount, order_id, reference, description,
+	    created_at
+	FROM ledger_entries WHERE login = $1 ORDER BY id;`
+
+	var entries []model.LedgerEntry
+	return entries, retry(func() error {
+		rows, err := p.pool.Query(ctx, query, login)
+		if err != nil {
+			return fmt.Errorf("can't query: %w", err)
+		}
+		defer rows.Close()
+
+		entries = nil
+		for rows.Next() {
+			entry := model.LedgerEntry{Login: login}
+			if err := rows.Scan(&entry.ID, &entry.TransactionID, &entry.Kind, &entry.Account, &entry.Currency,
+				&entry.Amount, &entry.OrderID, &entry.Reference, &entry.Description, &entry.CreatedAt); err != nil {
+				return fmt.Errorf("can't scan: %w", err)
+			}
+
+			entries = append(entries, entry)
+		}
+
+		return rows.Err()
+	})
+}
+
+// postLedger writes transaction and applies it to the wallet snapshots and
+// points lots inside tx. The balance CHECK constraints reject postings that
+// would take an available or held balance below zero. The balance row lock
+// also serialises all changes to the lots of a user. Wallets other than the
+// default one are opened by their first posting, only points of the default
+// wallet are kept in lots.
+func postLedger(ctx context.Context, tx pgx.Tx, transaction model.LedgerTransaction) error {
+	if !transaction.Balanced() {
+		return fmt.Errorf("ledger transaction %s is not balanced", transaction.ID)
+	}
+
+	currency := model.WalletCurrency(transaction.Currency)
+
+	queryOpen := `insert into balance (login, currency)
+	select login, $2 from balance where login = $1 and currency = $3
+	on conflict (login, currency) do nothing;`
+
+	queryBalance := `update balance set amount = amount + $1, held = held + $2, withdraw = withdraw + $3,
+	    updated_at = now()
+	where login = $4 and currency = $5;`
+
+	for _, login := range transaction.Logins() {
+		if currency != model.DefaultCurrency {
+			if _, err := tx.Exec(ctx, queryOpen, login, currency, model.DefaultCurrency); err != nil {
+				return ledgerError(err)
+			}
+		}
+
+		tag, err := tx.Exec(ctx, queryBalance, transaction.Delta(login, model.LedgerAccountAvailable),
+			transaction.Delta(login, model.LedgerAccountHeld), transaction.Delta(login, model.LedgerAccountWithdrawn),
+			login, currency)
+		if err != nil {
+			return ledgerError(err)
+		}
+
+		if tag.RowsAffected() == 0 {
+			return repositories.ErrNotFound
+		}
+	}
+
+	if currency == model.DefaultCurrency {
+		if err := postLots(ctx, tx, transaction); err != nil {
+			return err
+		}
+	}
+
+	queryEntry := `insert into ledger_entries
+	    (transaction_id, kind, login, account, currency, amount, order_id, reference, description)
+	values ($1, $2, $3, $4, $5, $6, $7, $8, $9);`
+
+	for _, posting := range transaction.Postings {
+		_, err := tx.Exec(ctx, queryEntry, transaction.ID, transaction.Kind, posting.Login, posting.Account,
+			currency, posting.Amount, transaction.OrderID, transaction.Reference, transaction.Description)
+		if err != nil {
+			return ledgerError(err)
+		}
+	}
+
+	return nil
+}
+
+// postLots opens a lot for points credited with an expiry date and takes
+// debits off the lots that expire first. Expiries settle their own lot.
+// Holds and withdrawals record the lots they take points off against their
+// order, releases and refunds put the points back, the lots that expire
+// last first. Transfers carry the lots they take points off over to the
+// recipient.
+func postLots(ctx context.Context, tx pgx.Tx, transaction model.LedgerTransaction) error {
+	queryLot := `insert into points_lots (transaction_id, login, order_id, amount, remaining, expires_at)
+	values ($1, $2, $3, $4, $4, $5);`
+
+	queryConsume := `with c as (
+	    select id, least(remaining, greatest($2 - coalesce(sum(remaining) over (
+	        order by expires_at, id rows between unbounded preceding and 1 preceding), 0), 0)) as take
+	    from points_lots
+	    where login = $1 and remaining > 0
+	), consumed as (
+	    update points_lots l set remaining = l.remaining - c.take
+	    from c
+	    where l.id = c.id and c.take > 0
+	    returning l.id, c.take
+	)
+	insert into points_lot_uses (lot_id, login, order_id, amount)
+	select id, $1, $3::text, take from consumed where $3::text <> '';`
+
+	queryCarry := `with c as (
+	    select id, least(remaining, greatest($2 - coalesce(sum(remaining) over (
+	        order by expires_at, id rows between unbounded preceding and 1 preceding), 0), 0)) as take
+	    from points_lots
+	    where login = $1 and remaining > 0
+	), consumed as (
+	    update points_lots l set remaining = l.remaining - c.take
+	    from c
+	    where l.id = c.id and c.take > 0
+	    returning l.expires_at, c.take
+	)
+	insert into points_lots (transaction_id, login, amount, remaining, expires_at)
+	select $3, $4, take, take, expires_at from consumed;`
+
+	queryRestore := `with r as (
+	    select u.id, u.lot_id, least(u.amount, greatest($3 - coalesce(sum(u.amount) over (
+	        order by l.expires_at desc, u.id desc rows between unbounded preceding and 1 preceding), 0), 0)) as give
+	    from points_lot_uses u join points_lots l on l.id = u.lot_id
+	    where u.login = $1 and u.order_id = $2 and u.amount > 0
+	), restored as (
+	    update points_lot_uses u set amount = u.amount - r.give
+	    from r
+	    where u.id = r.id and r.give > 0
+	    returning r.lot_id, r.give
+	)
+	update points_lots l set remaining = l.remaining + restored.give
+	from restored
+	where l.id = restored.lot_id;`
+
+	for _, login := range transaction.Logins() {
+		delta := transaction.Delta(login, model.LedgerAccountAvailable)
+
+		var err error
+		switch {
+		case delta < 0 && transaction.LotRecipient() != "":
+			_, err = tx.Exec(ctx, queryCarry, login, -delta, transaction.ID, transaction.LotRecipient())
+		case delta < 0 && transaction.Kind != model.LedgerKindExpiry:
+			var orderID string
+			if transaction.RecordsLotUse() {
+				orderID = transaction.OrderID
+			}
+
+			_, err = tx.Exec(ctx, queryConsume, login, -delta, orderID)
+		case delta > 0 && transaction.RestoresLots():
+			_, err = tx.Exec(ctx, queryRestore, login, transaction.OrderID, delta)
+		case delta > 0 && transaction.ExpiresAt != nil:
+			_, err = tx.Exec(ctx, queryLot, transaction.ID, login, transaction.OrderID, delta, transaction.ExpiresAt)
+		}
+
+		if err != nil {
+			return fmt.Errorf("can't exec: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func ledgerError(err error) error {
+	switch {
+	case isCheckViolation(err):
+		return fmt.Errorf("insufficient funds: %w", repositories.ErrInsufficientFunds)
+	case isDuplicateError(err):
+		return repositories.ErrDuplicate
+	case errors.Is(err, pgx.ErrNoRows):
+		return repositories.ErrNotFound
+	}
+
+	return fmt.Errorf("can't exec: %w", err)
+}
+
+func (p *Postgresql) GetBalanceHistory(ctx context.Context,
+	filter model.BalanceHistoryFilter) ([]model.BalanceHistoryEntry, error) {
+	// The running balance is summed over every posting of the user before
+	// the filters apply.
+	query := `SELECT id, transaction_id, kind, order_id, amount, balance, created_at
+	FROM (
+	    SELECT id, transaction_id, kind, order_id, amount, created_at,
+	        SUM(amount) OVER (ORDER BY id) AS balance
+	    FROM ledger_entries
+	    WHERE login = $1 AND account = $2 AND currency = $8
+	) history
+	WHERE id > $3
+	  AND ($4::timestamp IS NULL OR created_at >= $4)
+	  AND ($5::timestamp IS NULL OR created_at < $5)
+	  AND ($6 = '' OR ($6 = 'credit' AND amount > 0) OR ($6 = 'debit' AND amount < 0))
+	ORDER BY id
+	LIMIT $7;`
+
+	var history []model.BalanceHistoryEntry
+	return history, retry(func() error {
+		rows, err := p.pool.Query(ctx, query, filter.Login, model.LedgerAccountAvailable, filter.AfterID,
+			filter.From, filter.To, filter.Type, filter.Limit, model.WalletCurrency(filter.Currency))
+		if err != nil {
+			return fmt.Errorf("can't query: %w", err)
+		}
+		defer rows.Close()
+
+		history = nil
+		for rows.Next() {
+			var entry model.BalanceHistoryEntry
+			if err := rows.Scan(&entry.ID, &entry.TransactionID, &entry.Kind, &entry.OrderID, &entry.Amount,
+				&entry.Balance, &entry.CreatedAt); err != nil {
+				return fmt.Errorf("can't scan: %w", err)
+			}
+
+			history = append(history, entry)
+		}
+
+		return rows.Err()
+	})
+}
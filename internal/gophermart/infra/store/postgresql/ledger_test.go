@@ -0,0 +1,184 @@
+//nolint:wrapcheck,gocritic,nolintlint,errcheck,forcetypeassert
+package postgresql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+const (
+	queryLedgerBalance = `update balance set amount = amount + $1, held = held + $2, withdraw = withdraw + $3,
+	    updated_at = now()
+	where login = $4 and currency = $5;`
+	queryLedgerEntry = `insert into ledger_entries
+	    (transaction_id, kind, login, account, currency, amount, order_id, reference, description)
+	values ($1, $2, $3, $4, $5, $6, $7, $8, $9);`
+	queryOpenWallet = `insert into balance (login, currency)
+	select login, $2 from balance where login = $1 and currency = $3
+	on conflict (login, currency) do nothing;`
+	queryConsumeLots = `with c as (
+	    select id, least(remaining, greatest($2 - coalesce(sum(remaining) over (
+	        order by expires_at, id rows between unbounded preceding and 1 preceding), 0), 0)) as take
+	    from points_lots
+	    where login = $1 and remaining > 0
+	), consumed as (
+	    update points_lots l set remaining = l.remaining - c.take
+	    from c
+	    where l.id = c.id and c.take > 0
+	    returning l.id, c.take
+	)
+	insert into points_lot_uses (lot_id, login, order_id, amount)
+	select id, $1, $3::text, take from consumed where $3::text <> '';`
+	queryRestoreLots = `with r as (
+	    select u.id, u.lot_id, least(u.amount, greatest($3 - coalesce(sum(u.amount) over (
+	        order by l.expires_at desc, u.id desc rows between unbounded preceding and 1 preceding), 0), 0)) as give
+	    from points_lot_uses u join points_lots l on l.id = u.lot_id
+	    where u.login = $1 and u.order_id = $2 and u.amount > 0
+	), restored as (
+	    update points_lot_uses u set amount = u.amount - r.give
+	    from r
+	    where u.id = r.id and r.give > 0
+	    returning r.lot_id, r.give
+	)
+	update points_lots l set remaining = l.remaining + restored.give
+	from restored
+	where l.id = restored.lot_id;`
+	queryInsertLot = `insert into points_lots (transaction_id, login, order_id, amount, remaining, expires_at)
+	values ($1, $2, $3, $4, $4, $5);`
+)
+
+func TestPostgresql_AddLedgerTransaction(t *testing.T) {
+	adjustment := model.AdjustmentTransaction("tx-1", "login", 150, "goodwill")
+
+	t.Run("successful add", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockTx := new(MockTx)
+
+		mockPool.On("Begin", mock.Anything).Return(mockTx, nil)
+		mockTx.On("Exec", mock.Anything, queryLedgerBalance,
+			[]interface{}{model.Money(150), model.Money(0), model.Money(0), "login", model.DefaultCurrency}).
+			Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+		mockTx.On("Exec", mock.Anything, queryLedgerEntry, []interface{}{"tx-1", model.LedgerKindAdjustment,
+			"login", model.LedgerAccountAvailable, model.DefaultCurrency, model.Money(150), "", "", "goodwill"}).
+			Return(pgconn.NewCommandTag("INSERT 1"), nil)
+		mockTx.On("Exec", mock.Anything, queryLedgerEntry, []interface{}{"tx-1", model.LedgerKindAdjustment,
+			"login", model.LedgerAccountAdjustment, model.DefaultCurrency, model.Money(-150), "", "", "goodwill"}).
+			Return(pgconn.NewCommandTag("INSERT 1"), nil)
+		mockTx.On("Commit", mock.Anything).Return(nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		err := postgres.AddLedgerTransaction(context.TODO(), adjustment)
+
+		assert.NoError(t, err)
+		mockPool.AssertExpectations(t)
+		mockTx.AssertExpectations(t)
+	})
+
+	t.Run("partner wallet is opened", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockTx := new(MockTx)
+
+		partner := adjustment
+		partner.Currency = "PARTNER"
+
+		mockPool.On("Begin", mock.Anything).Return(mockTx, nil)
+		mockTx.On("Exec", mock.Anything, queryOpenWallet, []interface{}{"login", "PARTNER", model.DefaultCurrency}).
+			Return(pgconn.NewCommandTag("INSERT 1"), nil)
+		mockTx.On("Exec", mock.Anything, queryLedgerBalance,
+			[]interface{}{model.Money(150), model.Money(0), model.Money(0), "login", "PARTNER"}).
+			Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+		mockTx.On("Exec", mock.Anything, queryLedgerEntry, mock.MatchedBy(func(args []interface{}) bool {
+			return args[4] == "PARTNER"
+		})).Return(pgconn.NewCommandTag("INSERT 1"), nil).Twice()
+		mockTx.On("Commit", mock.Anything).Return(nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		err := postgres.AddLedgerTransaction(context.TODO(), partner)
+
+		assert.NoError(t, err)
+		mockTx.AssertExpectations(t)
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockTx := new(MockTx)
+
+		mockPool.On("Begin", mock.Anything).Return(mockTx, nil)
+		mockTx.On("Exec", mock.Anything, queryLedgerBalance, mock.Anything).
+			Return(pgconn.NewCommandTag("UPDATE 0"), nil)
+		mockTx.On("Rollback", mock.Anything).Return(nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		err := postgres.AddLedgerTransaction(context.TODO(), adjustment)
+
+		assert.ErrorIs(t, err, repositories.ErrNotFound)
+		mockTx.AssertExpectations(t)
+	})
+
+	t.Run("insufficient funds", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockTx := new(MockTx)
+
+		mockPool.On("Begin", mock.Anything).Return(mockTx, nil)
+		mockTx.On("Exec", mock.Anything, queryLedgerBalance, mock.Anything).
+			Return(pgconn.CommandTag{}, &pgconn.PgError{Code: pgerrcode.CheckViolation})
+		mockTx.On("Rollback", mock.Anything).Return(nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		err := postgres.AddLedgerTransaction(context.TODO(), model.AdjustmentTransaction("tx-2", "login", -500, "fix"))
+
+		assert.ErrorIs(t, err, repositories.ErrInsufficientFunds)
+		mockTx.AssertExpectations(t)
+	})
+
+	t.Run("already reversed", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockTx := new(MockTx)
+
+		mockPool.On("Begin", mock.Anything).Return(mockTx, nil)
+		mockTx.On("Exec", mock.Anything, queryLedgerBalance, mock.Anything).
+			Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+		mockTx.On("Exec", mock.Anything, queryConsumeLots, mock.Anything).
+			Return(pgconn.NewCommandTag("UPDATE 0"), nil)
+		mockTx.On("Exec", mock.Anything, queryLedgerEntry, mock.Anything).
+			Return(pgconn.CommandTag{}, &pgconn.PgError{Code: pgerrcode.UniqueViolation})
+		mockTx.On("Rollback", mock.Anything).Return(nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		err := postgres.AddLedgerTransaction(context.TODO(), adjustment.Reversal("tx-3", "mistake"))
+
+		assert.ErrorIs(t, err, repositories.ErrDuplicate)
+		mockTx.AssertExpectations(t)
+	})
+
+	t.Run("unbalanced transaction", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockTx := new(MockTx)
+
+		mockPool.On("Begin", mock.Anything).Return(mockTx, nil)
+		mockTx.On("Rollback", mock.Anything).Return(nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		unbalanced := adjustment
+		unbalanced.Postings = unbalanced.Postings[:1]
+
+		err := postgres.AddLedgerTransaction(context.TODO(), unbalanced)
+
+		assert.ErrorContains(t, err, "not balanced")
+		mockTx.AssertExpectations(t)
+	})
+}
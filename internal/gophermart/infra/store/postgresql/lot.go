@@ -0,0 +1,120 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+func (p *Postgresql) GetExpiredLots(ctx context.Context, now time.Time) ([]model.PointsLot, error) {
+	query := `SELECT id, transaction_id, login, order_id, amount, remaining, created_at, expires_at
+	FROM points_lots WHERE remaining > 0 AND expires_at <= $1 ORDER BY expires_at, id;`
+
+	var lots []model.PointsLot
+	return lots, retry(func() error {
+		rows, err := p.pool.Query(ctx, query, now)
+		if err != nil {
+			return fmt.Errorf("can't query: %w", err)
+		}
+		defer rows.Close()
+
+		lots = nil
+		for rows.Next() {
+			var lot model.PointsLot
+			if err := rows.Scan(&lot.ID, &lot.TransactionID, &lot.Login, &lot.OrderID, &lot.Amount,
+				&lot.Remaining, &lot.CreatedAt, &lot.ExpiresAt); err != nil {
+				return fmt.Errorf("can't scan: %w", err)
+			}
+
+			lots = append(lots, lot)
+		}
+
+		return rows.Err()
+	})
+}
+
+// ExpireLot takes what is left of an expired lot off the balance. The
+// balance row is locked first, like every other change to the lots.
+func (p *Postgresql) ExpireLot(ctx context.Context, lot model.PointsLot, now time.Time) error {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("can't begin transaction: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+			return
+		}
+		_ = tx.Commit(ctx)
+	}()
+
+	queryBalance := `select amount from balance where login = $1 and currency = $2 for update;`
+
+	var amount model.Money
+	err = tx.QueryRow(ctx, queryBalance, lot.Login, model.DefaultCurrency).Scan(&amount)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = repositories.ErrNotFound
+			return err
+		}
+
+		return fmt.Errorf("can't scan: %w", err)
+	}
+
+	queryLot := `select order_id, remaining, expires_at from points_lots where id = $1 and login = $2;`
+
+	stored := lot
+	err = tx.QueryRow(ctx, queryLot, lot.ID, lot.Login).Scan(&stored.OrderID, &stored.Remaining, &stored.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = repositories.ErrNotFound
+			return err
+		}
+
+		return fmt.Errorf("can't scan: %w", err)
+	}
+
+	if stored.Remaining == 0 || stored.ExpiresAt.After(now) {
+		err = repositories.ErrInvalidState
+		return err
+	}
+
+	err = postLedger(ctx, tx, model.ExpiryTransaction(uuid.NewString(), stored.Login, stored.OrderID,
+		stored.Remaining))
+	if err != nil {
+		return err
+	}
+
+	queryExpire := `update points_lots set remaining = 0, expired_at = $1 where id = $2;`
+
+	_, err = tx.Exec(ctx, queryExpire, now, lot.ID)
+	if err != nil {
+		return fmt.Errorf("can't exec: %w", err)
+	}
+
+	return nil
+}
+
+func (p *Postgresql) GetExpiringPoints(ctx context.Context, login string, before time.Time) (model.Money, error) {
+	query := `SELECT COALESCE(SUM(remaining), 0) FROM points_lots
+	WHERE login = $1 AND remaining > 0 AND expires_at < $2;`
+
+	var amount model.Money
+	row := p.pool.QueryRow(ctx, query, login, before)
+
+	if err := retry(func() error {
+		return row.Scan(&amount)
+	}); err != nil {
+		return 0, fmt.Errorf("can't scan: %w", err)
+	}
+
+	return amount, nil
+}
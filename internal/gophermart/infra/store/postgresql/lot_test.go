@@ -0,0 +1,162 @@
+//nolint:wrapcheck,gocritic,nolintlint,errcheck,forcetypeassert
+package postgresql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+const (
+	queryLotBalance = `select amount from balance where login = $1 and currency = $2 for update;`
+	querySelectLot  = `select order_id, remaining, expires_at from points_lots where id = $1 and login = $2;`
+	queryExpireLot  = `update points_lots set remaining = 0, expired_at = $1 where id = $2;`
+)
+
+func TestPostgresql_ExpireLot(t *testing.T) {
+	now := time.Now()
+	lot := model.PointsLot{ID: 7, Login: "login"}
+
+	scanLot := func(remaining model.Money, expiresAt time.Time) func(args mock.Arguments) {
+		return func(args mock.Arguments) {
+			*(args.Get(0).(*string)) = "12345678903"
+			*(args.Get(1).(*model.Money)) = remaining
+			*(args.Get(2).(*time.Time)) = expiresAt
+		}
+	}
+
+	t.Run("expire the rest", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockTx := new(MockTx)
+		balanceRow := new(MockRow)
+		lotRow := new(MockRow)
+
+		mockPool.On("Begin", mock.Anything).Return(mockTx, nil)
+		mockTx.On("QueryRow", mock.Anything, queryLotBalance, []interface{}{"login", model.DefaultCurrency}).
+			Return(balanceRow)
+		balanceRow.On("Scan", mock.Anything).Return(nil)
+		mockTx.On("QueryRow", mock.Anything, querySelectLot, []interface{}{int64(7), "login"}).Return(lotRow)
+		lotRow.On("Scan", mock.Anything, mock.Anything, mock.Anything).
+			Run(scanLot(150, now.Add(-time.Minute))).Return(nil)
+		mockTx.On("Exec", mock.Anything, queryLedgerBalance,
+			[]interface{}{model.Money(-150), model.Money(0), model.Money(0), "login", model.DefaultCurrency}).
+			Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+		mockTx.On("Exec", mock.Anything, queryLedgerEntry, mock.Anything).
+			Return(pgconn.NewCommandTag("INSERT 1"), nil).Twice()
+		mockTx.On("Exec", mock.Anything, queryExpireLot, []interface{}{now, int64(7)}).
+			Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+		mockTx.On("Commit", mock.Anything).Return(nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		err := postgres.ExpireLot(context.TODO(), lot, now)
+
+		assert.NoError(t, err)
+		mockPool.AssertExpectations(t)
+		mockTx.AssertExpectations(t)
+	})
+
+	t.Run("already spent", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockTx := new(MockTx)
+		balanceRow := new(MockRow)
+		lotRow := new(MockRow)
+
+		mockPool.On("Begin", mock.Anything).Return(mockTx, nil)
+		mockTx.On("QueryRow", mock.Anything, queryLotBalance, mock.Anything).Return(balanceRow)
+		balanceRow.On("Scan", mock.Anything).Return(nil)
+		mockTx.On("QueryRow", mock.Anything, querySelectLot, mock.Anything).Return(lotRow)
+		lotRow.On("Scan", mock.Anything, mock.Anything, mock.Anything).
+			Run(scanLot(0, now.Add(-time.Minute))).Return(nil)
+		mockTx.On("Rollback", mock.Anything).Return(nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		err := postgres.ExpireLot(context.TODO(), lot, now)
+
+		assert.ErrorIs(t, err, repositories.ErrInvalidState)
+		mockTx.AssertExpectations(t)
+	})
+
+	t.Run("lot not found", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockTx := new(MockTx)
+		balanceRow := new(MockRow)
+		lotRow := new(MockRow)
+
+		mockPool.On("Begin", mock.Anything).Return(mockTx, nil)
+		mockTx.On("QueryRow", mock.Anything, queryLotBalance, mock.Anything).Return(balanceRow)
+		balanceRow.On("Scan", mock.Anything).Return(nil)
+		mockTx.On("QueryRow", mock.Anything, querySelectLot, mock.Anything).Return(lotRow)
+		lotRow.On("Scan", mock.Anything, mock.Anything, mock.Anything).Return(pgx.ErrNoRows)
+		mockTx.On("Rollback", mock.Anything).Return(nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		err := postgres.ExpireLot(context.TODO(), lot, now)
+
+		assert.ErrorIs(t, err, repositories.ErrNotFound)
+		mockTx.AssertExpectations(t)
+	})
+}
+
+func TestPostgresql_SetBalanceOpensLot(t *testing.T) {
+	expiresAt := time.Now().AddDate(1, 0, 0)
+
+	mockPool := new(MockPool)
+	mockTx := new(MockTx)
+	mockRow := new(MockRow)
+
+	mockPool.On("Begin", mock.Anything).Return(mockTx, nil)
+	mockTx.On("QueryRow", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(mockRow)
+	// The order and then the funds of its user are scanned.
+	mockRow.On("Scan", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		if userLogin, ok := args.Get(0).(*string); ok {
+			*userLogin = "login"
+		}
+	}).Return(nil)
+	mockTx.On("Exec", mock.Anything, queryInsertLot, mock.MatchedBy(func(args []interface{}) bool {
+		return assert.ObjectsAreEqual([]interface{}{"login", "12345678903", model.Money(300), &expiresAt}, args[1:])
+	})).
+		Return(pgconn.NewCommandTag("INSERT 1"), nil)
+	mockTx.On("Exec", mock.Anything, mock.Anything, mock.Anything).
+		Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+	mockTx.On("Commit", mock.Anything).Return(nil)
+
+	postgres := &Postgresql{pool: mockPool}
+
+	err := postgres.SetBalance(context.TODO(), model.Accrual{
+		OrderID: "12345678903", Status: model.OrderStatusDone, Amount: 300, ExpiresAt: &expiresAt,
+	})
+
+	assert.NoError(t, err)
+	mockTx.AssertExpectations(t)
+}
+
+func TestPostgresql_GetExpiringPoints(t *testing.T) {
+	before := time.Now().Add(time.Hour)
+
+	mockPool := new(MockPool)
+	mockRow := new(MockRow)
+
+	mockPool.On("QueryRow", mock.Anything, mock.Anything, []interface{}{"login", before}).Return(mockRow)
+	mockRow.On("Scan", mock.Anything).Run(func(args mock.Arguments) {
+		*(args.Get(0).(*model.Money)) = 250
+	}).Return(nil)
+
+	postgres := &Postgresql{pool: mockPool}
+
+	amount, err := postgres.GetExpiringPoints(context.TODO(), "login", before)
+
+	assert.NoError(t, err)
+	assert.Equal(t, model.Money(250), amount)
+	mockPool.AssertExpectations(t)
+}
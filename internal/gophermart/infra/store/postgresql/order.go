@@ -3,9 +3,13 @@ package postgresql
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
 	"gofermart/internal/gophermart/core/model"
 )
 
@@ -22,7 +26,7 @@ func (p *Postgresql) SaveOrder(ctx context.Context, login string, request model.
 	})
 }
 
-func (p *Postgresql) SetBalance(ctx context.Context, orderID, status string, amount int) error {
+func (p *Postgresql) SetBalance(ctx context.Context, accrual model.Accrual) error {
 	tx, err := p.pool.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("can't begin transaction: %w", err)
@@ -36,23 +40,137 @@ func (p *Postgresql) SetBalance(ctx context.Context, orderID, status string, amo
 		_ = tx.Commit(ctx)
 	}()
 
-	queryOrder := `update orders set status = $1, amount = $2, updated_at = now() where order_id = $3 returning login;`
-
-	var userLogin string
-	err = tx.QueryRow(ctx, queryOrder, status, amount, orderID).Scan(&userLogin)
+	// The subquery locks the order and returns what it credited before. The
+	// wallet of an order is fixed by its first credit.
+	queryOrder := `update orders o set status = $1, amount = $2, bonus = $3,
+	    currency = case when prev.amount = 0 and prev.bonus = 0 then $5 else prev.currency end,
+	    updated_at = now()
+	from (select order_id, amount, bonus, currency from orders where order_id = $4 for update) prev
+	where o.order_id = prev.order_id
+	returning o.login, prev.amount, prev.bonus, o.currency;`
+
+	var (
+		userLogin             string
+		prevAmount, prevBonus model.Money
+	)
+	err = tx.QueryRow(ctx, queryOrder, accrual.Status, accrual.Amount, accrual.Bonus, accrual.OrderID,
+		model.WalletCurrency(accrual.Currency)).Scan(&userLogin, &prevAmount, &prevBonus, &accrual.Currency)
 	if err != nil {
 		return fmt.Errorf("can't query: %w", err)
 	}
 
-	queryBalance := `update balance set amount = amount + $1, updated_at = now() where login = $2;`
-	_, err = tx.Exec(ctx, queryBalance, amount, userLogin)
+	err = creditOrder(ctx, tx, userLogin, accrual, prevAmount, prevBonus)
+	if err != nil {
+		return err
+	}
+
+	if accrual.Referral != nil {
+		err = rewardReferral(ctx, tx, userLogin, accrual)
+	}
+
+	return err
+}
+
+// creditOrder posts what the accrual changes about the credit of an order
+// to the wallet of the order: the accrual and tier bonus deltas against the
+// previous accrual, the promotion awards and then what the credits repay of
+// the debt of the user.
+func creditOrder(ctx context.Context, tx pgx.Tx, userLogin string, accrual model.Accrual,
+	prevAmount, prevBonus model.Money) error {
+	delta := accrual.Amount - prevAmount
+	bonusDelta := accrual.Bonus - prevBonus
+	if delta == 0 && bonusDelta == 0 && len(accrual.Promotions) == 0 {
+		return nil
+	}
+
+	available, debt, err := userFunds(ctx, tx, userLogin, accrual.Currency)
 	if err != nil {
-		return fmt.Errorf("can't exec: %w", err)
+		return err
+	}
+
+	post := func(transaction model.LedgerTransaction) error {
+		transaction.Currency = accrual.Currency
+		transaction.ExpiresAt = accrual.ExpiresAt
+		if err := postLedger(ctx, tx, transaction); err != nil {
+			return err
+		}
+
+		available += transaction.Delta(userLogin, model.LedgerAccountAvailable)
+		debt -= transaction.Delta(userLogin, model.LedgerAccountDebt)
+
+		return nil
+	}
+
+	if delta != 0 {
+		transaction := model.CorrectionTransaction(uuid.NewString(), userLogin, accrual.OrderID,
+			model.LedgerAccountAccrual, delta, available, "accrual corrected")
+		if prevAmount == 0 {
+			transaction = model.AccrualTransaction(uuid.NewString(), userLogin, accrual.OrderID, delta)
+		}
+
+		if err := post(transaction); err != nil {
+			return err
+		}
+	}
+
+	if bonusDelta != 0 {
+		transaction := model.CorrectionTransaction(uuid.NewString(), userLogin, accrual.OrderID,
+			model.LedgerAccountTierBonus, bonusDelta, available, "tier bonus corrected")
+		if prevBonus == 0 {
+			transaction = model.TierBonusTransaction(uuid.NewString(), userLogin, accrual.OrderID, bonusDelta,
+				accrual.Tier)
+		}
+
+		if err := post(transaction); err != nil {
+			return err
+		}
+	}
+
+	for _, award := range accrual.Promotions {
+		award.OrderID = accrual.OrderID
+		award.Login = userLogin
+
+		if err := postPromotionAward(ctx, tx, award, accrual.Currency, accrual.ExpiresAt); err != nil {
+			return err
+		}
+
+		available += award.Amount
+	}
+
+	// Only what the order credits pays back debt.
+	credited := delta > 0 || bonusDelta > 0 || len(accrual.Promotions) > 0
+	if repay := min(debt, available); credited && repay > 0 {
+		transaction := model.RepaymentTransaction(uuid.NewString(), userLogin, accrual.OrderID, repay)
+		transaction.Currency = accrual.Currency
+
+		return postLedger(ctx, tx, transaction)
 	}
 
 	return nil
 }
 
+// userFunds locks the wallet of login in currency and returns its available
+// points and debt. A wallet that isn't open yet has neither.
+func userFunds(ctx context.Context, tx pgx.Tx, login, currency string) (model.Money, model.Money, error) {
+	query := `select b.amount, coalesce((
+	    select -sum(l.amount) from ledger_entries l
+	    where l.login = b.login and l.currency = b.currency and l.account = $3
+	), 0)
+	from balance b where b.login = $1 and b.currency = $2 for update;`
+
+	var available, debt model.Money
+	err := tx.QueryRow(ctx, query, login, currency, model.LedgerAccountDebt).Scan(&available, &debt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) && currency != model.DefaultCurrency {
+			return 0, 0, nil
+		}
+
+		return 0, 0, fmt.Errorf("can't scan: %w", err)
+	}
+
+	return available, debt, nil
+}
+
 func (p *Postgresql) GetOrderLogin(ctx context.Context, orderID string) (string, error) {
 	query := `SELECT login FROM orders WHERE order_id = $1;`
 
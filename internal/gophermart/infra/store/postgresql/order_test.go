@@ -88,8 +88,11 @@ func TestPostgresql_SetBalance(t *testing.T) {
 
 		mockPool.On("Begin", mock.Anything).Return(mockTx, nil)
 		mockTx.On("QueryRow", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(mockRow)
-		mockRow.On("Scan", mock.Anything).Run(func(args mock.Arguments) {
-			*(args.Get(0).(*string)) = login
+		// The order and then the funds of its user are scanned.
+		mockRow.On("Scan", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			if userLogin, ok := args.Get(0).(*string); ok {
+				*userLogin = login
+			}
 		}).Return(nil)
 		mockTx.On("Exec", mock.Anything, mock.Anything, mock.Anything).
 			Return(pgconn.NewCommandTag("UPDATE 1"), nil)
@@ -97,7 +100,9 @@ func TestPostgresql_SetBalance(t *testing.T) {
 
 		postgres := &Postgresql{pool: mockPool}
 
-		err := postgres.SetBalance(context.TODO(), uuid.NewString(), model.OrderStatusNew, amount)
+		err := postgres.SetBalance(context.TODO(), model.Accrual{
+			OrderID: uuid.NewString(), Status: model.OrderStatusNew, Amount: amount,
+		})
 
 		assert.NoError(t, err)
 		mockPool.AssertExpectations(t)
@@ -112,12 +117,14 @@ func TestPostgresql_SetBalance(t *testing.T) {
 
 		mockPool.On("Begin", mock.Anything).Return(mockTx, nil)
 		mockTx.On("QueryRow", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(mockRow)
-		mockRow.On("Scan", mock.Anything).Return(errors.New("query row error"))
+		mockRow.On("Scan", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(errors.New("query row error"))
 		mockTx.On("Rollback", mock.Anything).Return(nil)
 
 		postgres := &Postgresql{pool: mockPool}
 
-		err := postgres.SetBalance(context.TODO(), uuid.NewString(), model.OrderStatusNew, amount)
+		err := postgres.SetBalance(context.TODO(), model.Accrual{
+			OrderID: uuid.NewString(), Status: model.OrderStatusNew, Amount: amount,
+		})
 
 		assert.Error(t, err)
 		assert.EqualError(t, err, "can't query: query row error")
@@ -133,8 +140,11 @@ func TestPostgresql_SetBalance(t *testing.T) {
 
 		mockPool.On("Begin", mock.Anything).Return(mockTx, nil)
 		mockTx.On("QueryRow", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(mockRow)
-		mockRow.On("Scan", mock.Anything).Run(func(args mock.Arguments) {
-			*(args.Get(0).(*string)) = login
+		// The order and then the funds of its user are scanned.
+		mockRow.On("Scan", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			if userLogin, ok := args.Get(0).(*string); ok {
+				*userLogin = login
+			}
 		}).Return(nil)
 		mockTx.On("Exec", mock.Anything, mock.Anything, mock.Anything).
 			Return(pgconn.CommandTag{}, errors.New("exec error"))
@@ -142,7 +152,9 @@ func TestPostgresql_SetBalance(t *testing.T) {
 
 		postgres := &Postgresql{pool: mockPool}
 
-		err := postgres.SetBalance(context.TODO(), uuid.NewString(), model.OrderStatusNew, amount)
+		err := postgres.SetBalance(context.TODO(), model.Accrual{
+			OrderID: uuid.NewString(), Status: model.OrderStatusNew, Amount: amount,
+		})
 
 		assert.Error(t, err)
 		assert.EqualError(t, err, "can't exec: exec error")
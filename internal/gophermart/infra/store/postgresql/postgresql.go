@@ -118,3 +118,11 @@ func isDuplicateError(err error) bool {
 	}
 	return false
 }
+
+func isCheckViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == pgerrcode.CheckViolation
+	}
+	return false
+}
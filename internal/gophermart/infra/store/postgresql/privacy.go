@@ -0,0 +1,72 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+// DeleteUser moves the orders, withdrawals, refunds, balance, ledger, points
+// lots and promotion awards of login to anonymizedLogin and drops everything
+// else stored about the user.
+func (p *Postgresql) DeleteUser(ctx context.Context, login, anonymizedLogin string) error {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("can't begin transaction: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+			return
+		}
+		_ = tx.Commit(ctx)
+	}()
+
+	tag, err := tx.Exec(ctx, `DELETE FROM users WHERE login = $1;`, login)
+	if err != nil {
+		return fmt.Errorf("can't delete user: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		err = repositories.ErrNotFound
+		return err
+	}
+
+	anonymizeQueries := []string{
+		`UPDATE orders SET login = $2 WHERE login = $1;`,
+		`UPDATE withdraw SET login = $2 WHERE login = $1;`,
+		`UPDATE withdraw_refunds SET login = $2 WHERE login = $1;`,
+		`UPDATE balance SET login = $2 WHERE login = $1;`,
+		`UPDATE ledger_entries SET login = $2 WHERE login = $1;`,
+		`UPDATE points_lots SET login = $2 WHERE login = $1;`,
+		`UPDATE points_lot_uses SET login = $2 WHERE login = $1;`,
+		`UPDATE promotion_awards SET login = $2 WHERE login = $1;`,
+		`UPDATE referrals SET referrer = $2 WHERE referrer = $1;`,
+		`UPDATE referrals SET referred = $2 WHERE referred = $1;`,
+	}
+
+	for _, query := range anonymizeQueries {
+		if _, err = tx.Exec(ctx, query, login, anonymizedLogin); err != nil {
+			return fmt.Errorf("can't anonymize: %w", err)
+		}
+	}
+
+	deleteQueries := []string{
+		`DELETE FROM refresh_tokens WHERE login = $1;`,
+		`DELETE FROM api_keys WHERE login = $1;`,
+		`DELETE FROM totp_recovery_codes WHERE login = $1;`,
+		`DELETE FROM user_totp WHERE login = $1;`,
+		`DELETE FROM sessions WHERE login = $1;`,
+		`DELETE FROM idempotency_keys WHERE login = $1;`,
+	}
+
+	for _, query := range deleteQueries {
+		if _, err = tx.Exec(ctx, query, login); err != nil {
+			return fmt.Errorf("can't delete: %w", err)
+		}
+	}
+
+	return nil
+}
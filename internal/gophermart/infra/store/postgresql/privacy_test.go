@@ -0,0 +1,74 @@
+//nolint:wrapcheck,gocritic,nolintlint,errcheck,forcetypeassert
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+func TestPostgresql_DeleteUser(t *testing.T) {
+	t.Run("successful delete", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockTx := new(MockTx)
+
+		mockPool.On("Begin", mock.Anything).Return(mockTx, nil)
+		mockTx.On("Exec", mock.Anything, "DELETE FROM users WHERE login = $1;", []interface{}{"login"}).
+			Return(pgconn.NewCommandTag("DELETE 1"), nil)
+		mockTx.On("Exec", mock.Anything, mock.Anything, []interface{}{"login", "deleted-1"}).
+			Return(pgconn.NewCommandTag("UPDATE 1"), nil).Times(10)
+		mockTx.On("Exec", mock.Anything, mock.Anything, []interface{}{"login"}).
+			Return(pgconn.NewCommandTag("DELETE 1"), nil).Times(6)
+		mockTx.On("Commit", mock.Anything).Return(nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		err := postgres.DeleteUser(context.TODO(), "login", "deleted-1")
+
+		assert.NoError(t, err)
+		mockPool.AssertExpectations(t)
+		mockTx.AssertExpectations(t)
+	})
+
+	t.Run("user not found", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockTx := new(MockTx)
+
+		mockPool.On("Begin", mock.Anything).Return(mockTx, nil)
+		mockTx.On("Exec", mock.Anything, "DELETE FROM users WHERE login = $1;", []interface{}{"login"}).
+			Return(pgconn.NewCommandTag("DELETE 0"), nil)
+		mockTx.On("Rollback", mock.Anything).Return(nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		err := postgres.DeleteUser(context.TODO(), "login", "deleted-1")
+
+		assert.ErrorIs(t, err, repositories.ErrNotFound)
+		mockTx.AssertExpectations(t)
+	})
+
+	t.Run("error on anonymize", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockTx := new(MockTx)
+
+		mockPool.On("Begin", mock.Anything).Return(mockTx, nil)
+		mockTx.On("Exec", mock.Anything, "DELETE FROM users WHERE login = $1;", []interface{}{"login"}).
+			Return(pgconn.NewCommandTag("DELETE 1"), nil)
+		mockTx.On("Exec", mock.Anything, mock.Anything, []interface{}{"login", "deleted-1"}).
+			Return(pgconn.CommandTag{}, errors.New("update error"))
+		mockTx.On("Rollback", mock.Anything).Return(nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		err := postgres.DeleteUser(context.TODO(), "login", "deleted-1")
+
+		assert.ErrorContains(t, err, "can't anonymize")
+		mockTx.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,184 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+const promotionColumns = `id, name, effect, bonus, multiplier, tier, min_accrual, min_orders, first_order,
+	starts_at, ends_at, created_at`
+
+func (p *Postgresql) CreatePromotion(ctx context.Context, promotion model.Promotion) error {
+	query := `INSERT INTO promotions (id, name, effect, bonus, multiplier, tier, min_accrual, min_orders,
+	first_order, starts_at, ends_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11);`
+
+	return retry(func() error {
+		_, err := p.pool.Exec(ctx, query, promotion.ID, promotion.Name, promotion.Effect, promotion.Bonus,
+			promotion.Multiplier, promotion.Conditions.Tier, promotion.Conditions.MinAccrual,
+			promotion.Conditions.MinOrders, promotion.Conditions.FirstOrder, promotion.StartsAt, promotion.EndsAt)
+		if err != nil {
+			return fmt.Errorf("can't exec: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (p *Postgresql) UpdatePromotion(ctx context.Context, promotion model.Promotion) error {
+	query := `UPDATE promotions SET name = $2, effect = $3, bonus = $4, multiplier = $5, tier = $6,
+	min_accrual = $7, min_orders = $8, first_order = $9, starts_at = $10, ends_at = $11, updated_at = now()
+	WHERE id = $1;`
+
+	return retry(func() error {
+		tag, err := p.pool.Exec(ctx, query, promotion.ID, promotion.Name, promotion.Effect, promotion.Bonus,
+			promotion.Multiplier, promotion.Conditions.Tier, promotion.Conditions.MinAccrual,
+			promotion.Conditions.MinOrders, promotion.Conditions.FirstOrder, promotion.StartsAt, promotion.EndsAt)
+		if err != nil {
+			return fmt.Errorf("can't exec: %w", err)
+		}
+
+		if tag.RowsAffected() == 0 {
+			return repositories.ErrNotFound
+		}
+
+		return nil
+	})
+}
+
+func (p *Postgresql) DeletePromotion(ctx context.Context, id string) error {
+	query := `DELETE FROM promotions WHERE id = $1;`
+
+	return retry(func() error {
+		tag, err := p.pool.Exec(ctx, query, id)
+		if err != nil {
+			return fmt.Errorf("can't exec: %w", err)
+		}
+
+		if tag.RowsAffected() == 0 {
+			return repositories.ErrNotFound
+		}
+
+		return nil
+	})
+}
+
+func (p *Postgresql) GetPromotion(ctx context.Context, id string) (model.Promotion, error) {
+	query := `SELECT ` + promotionColumns + ` FROM promotions WHERE id = $1;`
+
+	var promotion model.Promotion
+	row := p.pool.QueryRow(ctx, query, id)
+
+	if err := retry(func() error {
+		return scanPromotion(row, &promotion)
+	}); err != nil {
+		return model.Promotion{}, fmt.Errorf("can't scan: %w", err)
+	}
+
+	return promotion, nil
+}
+
+func (p *Postgresql) GetPromotions(ctx context.Context) ([]model.Promotion, error) {
+	query := `SELECT ` + promotionColumns + ` FROM promotions ORDER BY created_at, id;`
+
+	return p.queryPromotions(ctx, query)
+}
+
+func (p *Postgresql) GetActivePromotions(ctx context.Context, now time.Time) ([]model.Promotion, error) {
+	query := `SELECT ` + promotionColumns + ` FROM promotions
+	WHERE starts_at <= $1 AND (ends_at IS NULL OR ends_at > $1) ORDER BY created_at, id;`
+
+	return p.queryPromotions(ctx, query, now)
+}
+
+func (p *Postgresql) GetPromotionAwards(ctx context.Context, promotionID string) ([]model.PromotionAward, error) {
+	query := `SELECT name, transaction_id, order_id, login, amount, created_at FROM promotion_awards
+	WHERE promotion_id = $1 ORDER BY id;`
+
+	var awards []model.PromotionAward
+	return awards, retry(func() error {
+		rows, err := p.pool.Query(ctx, query, promotionID)
+		if err != nil {
+			return fmt.Errorf("can't query: %w", err)
+		}
+		defer rows.Close()
+
+		awards = nil
+		for rows.Next() {
+			award := model.PromotionAward{PromotionID: promotionID}
+			if err := rows.Scan(&award.Name, &award.TransactionID, &award.OrderID, &award.Login, &award.Amount,
+				&award.CreatedAt); err != nil {
+				return fmt.Errorf("can't scan: %w", err)
+			}
+
+			awards = append(awards, award)
+		}
+
+		return rows.Err()
+	})
+}
+
+func (p *Postgresql) queryPromotions(ctx context.Context, query string, args ...any) ([]model.Promotion, error) {
+	var promotions []model.Promotion
+	return promotions, retry(func() error {
+		rows, err := p.pool.Query(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("can't query: %w", err)
+		}
+		defer rows.Close()
+
+		promotions = nil
+		for rows.Next() {
+			var promotion model.Promotion
+			if err := scanPromotion(rows, &promotion); err != nil {
+				return fmt.Errorf("can't scan: %w", err)
+			}
+
+			promotions = append(promotions, promotion)
+		}
+
+		return rows.Err()
+	})
+}
+
+func scanPromotion(row pgx.Row, promotion *model.Promotion) error {
+	return row.Scan(&promotion.ID, &promotion.Name, &promotion.Effect, &promotion.Bonus, &promotion.Multiplier,
+		&promotion.Conditions.Tier, &promotion.Conditions.MinAccrual, &promotion.Conditions.MinOrders,
+		&promotion.Conditions.FirstOrder, &promotion.StartsAt, &promotion.EndsAt, &promotion.CreatedAt)
+}
+
+// postPromotionAward credits the bonus of a promotion to the wallet in
+// currency inside tx and records the award. A promotion awards an order
+// only once, and a user only once if it is for the first order.
+func postPromotionAward(ctx context.Context, tx pgx.Tx, award model.PromotionAward, currency string,
+	expiresAt *time.Time) error {
+	transaction := model.PromotionTransaction(uuid.NewString(), award.Login, award.OrderID, award.Amount, award.Name)
+	transaction.Currency = currency
+	transaction.ExpiresAt = expiresAt
+
+	if err := postLedger(ctx, tx, transaction); err != nil {
+		return err
+	}
+
+	query := `insert into promotion_awards
+	    (promotion_id, name, transaction_id, order_id, login, amount, first_order)
+	values ($1, $2, $3, $4, $5, $6, $7);`
+
+	_, err := tx.Exec(ctx, query, award.PromotionID, award.Name, transaction.ID, award.OrderID, award.Login,
+		award.Amount, award.FirstOrder)
+	if err != nil {
+		if isDuplicateError(err) {
+			return repositories.ErrDuplicate
+		}
+
+		return fmt.Errorf("can't exec: %w", err)
+	}
+
+	return nil
+}
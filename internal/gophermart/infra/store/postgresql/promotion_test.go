@@ -0,0 +1,93 @@
+//nolint:wrapcheck,gocritic,nolintlint,errcheck,forcetypeassert
+package postgresql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+const queryInsertAward = `insert into promotion_awards
+	    (promotion_id, name, transaction_id, order_id, login, amount, first_order)
+	values ($1, $2, $3, $4, $5, $6, $7);`
+
+func TestPostgresql_SetBalanceWithPromotions(t *testing.T) {
+	accrual := model.Accrual{
+		OrderID: "12345678903", Status: model.OrderStatusDone, Amount: 500,
+		Promotions: []model.PromotionAward{{PromotionID: "p-1", Name: "weekend", Amount: 500}},
+	}
+
+	setUp := func(currency string, awardErr error) (*MockPool, *MockTx) {
+		mockPool := new(MockPool)
+		mockTx := new(MockTx)
+		mockRow := new(MockRow)
+
+		mockPool.On("Begin", mock.Anything).Return(mockTx, nil)
+		mockTx.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(mockRow)
+		// The order and then the funds of its user are scanned.
+		mockRow.On("Scan", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			if userLogin, ok := args.Get(0).(*string); ok {
+				*userLogin = "login"
+				*(args.Get(3).(*string)) = currency
+			}
+		}).Return(nil)
+		// The accrual and the award go to the wallet of the order.
+		mockTx.On("Exec", mock.Anything, queryLedgerBalance,
+			[]interface{}{model.Money(500), model.Money(0), model.Money(0), "login", currency}).
+			Return(pgconn.NewCommandTag("UPDATE 1"), nil).Twice()
+		mockTx.On("Exec", mock.Anything, queryLedgerEntry, mock.Anything).
+			Return(pgconn.NewCommandTag("INSERT 1"), nil).Times(4)
+		mockTx.On("Exec", mock.Anything, queryInsertAward, mock.MatchedBy(func(args []interface{}) bool {
+			return args[0] == "p-1" && args[1] == "weekend" && args[3] == "12345678903" && args[4] == "login"
+		})).Return(pgconn.NewCommandTag("INSERT 1"), awardErr)
+
+		return mockPool, mockTx
+	}
+
+	t.Run("award posted with the accrual", func(t *testing.T) {
+		mockPool, mockTx := setUp(model.DefaultCurrency, nil)
+		mockTx.On("Commit", mock.Anything).Return(nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		err := postgres.SetBalance(context.TODO(), accrual)
+
+		assert.NoError(t, err)
+		mockTx.AssertExpectations(t)
+	})
+
+	t.Run("award posted to another wallet", func(t *testing.T) {
+		mockPool, mockTx := setUp("ACME", nil)
+		mockTx.On("Exec", mock.Anything, queryOpenWallet, []interface{}{"login", "ACME", model.DefaultCurrency}).
+			Return(pgconn.NewCommandTag("INSERT 0"), nil).Twice()
+		mockTx.On("Commit", mock.Anything).Return(nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		accrual := accrual
+		accrual.Currency = "ACME"
+		err := postgres.SetBalance(context.TODO(), accrual)
+
+		assert.NoError(t, err)
+		mockTx.AssertExpectations(t)
+	})
+
+	t.Run("order already awarded", func(t *testing.T) {
+		mockPool, mockTx := setUp(model.DefaultCurrency, &pgconn.PgError{Code: pgerrcode.UniqueViolation})
+		mockTx.On("Rollback", mock.Anything).Return(nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		err := postgres.SetBalance(context.TODO(), accrual)
+
+		assert.ErrorIs(t, err, repositories.ErrDuplicate)
+		mockTx.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,71 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"gofermart/internal/gophermart/core/model"
+)
+
+// queryWalletAudits sums, per wallet, the user accounts of the ledger, what
+// it booked for orders, the orders themselves and the withdrawals next to
+// the balance snapshot. What the ledger booked follows
+// model.LedgerEntry.BooksOrders.
+const queryWalletAudits = `select b.login, b.currency, b.amount, b.held, b.withdraw,
+	    coalesce(l.available, 0), coalesce(l.held, 0), coalesce(l.withdrawn, 0), coalesce(l.booked, 0),
+	    coalesce(o.accrued, 0), coalesce(w.withdrawn, 0), coalesce(w.held, 0)
+	from balance b
+	left join (
+	    select login, currency,
+	        sum(amount) filter (where account = $1) as available,
+	        sum(amount) filter (where account = $2) as held,
+	        sum(amount) filter (where account = $3) as withdrawn,
+	        -sum(amount) filter (where kind <> $7
+	            and (account = any($4) or kind = $8 and account = $9 and description = $10)) as booked
+	    from ledger_entries group by login, currency
+	) l on l.login = b.login and l.currency = b.currency
+	left join (
+	    select login, currency, sum(amount + bonus) as accrued from orders group by login, currency
+	) o on o.login = b.login and o.currency = b.currency
+	left join (
+	    select login, currency,
+	        sum(amount - refunded) filter (where status = any($5)) as withdrawn,
+	        sum(amount) filter (where status = $6) as held
+	    from withdraw group by login, currency
+	) w on w.login = b.login and w.currency = b.currency
+	order by b.login, b.currency;`
+
+// processedStatuses are the statuses of withdrawals whose points left the
+// wallet, see model.Withdraw.Processed.
+var processedStatuses = []string{
+	model.WithdrawStatusCaptured, model.WithdrawStatusPartiallyRefunded, model.WithdrawStatusRefunded,
+}
+
+func (p *Postgresql) GetWalletAudits(ctx context.Context) ([]model.WalletAudit, error) {
+	var audits []model.WalletAudit
+	return audits, retry(func() error {
+		rows, err := p.pool.Query(ctx, queryWalletAudits, model.LedgerAccountAvailable, model.LedgerAccountHeld,
+			model.LedgerAccountWithdrawn, []string{model.LedgerAccountAccrual, model.LedgerAccountTierBonus},
+			processedStatuses, model.WithdrawStatusHeld, model.LedgerKindReversal, model.LedgerKindAdjustment,
+			model.LedgerAccountAdjustment, model.LedgerDescriptionOpeningBalance)
+		if err != nil {
+			return fmt.Errorf("can't query: %w", err)
+		}
+		defer rows.Close()
+
+		audits = nil
+		for rows.Next() {
+			var audit model.WalletAudit
+			if err := rows.Scan(&audit.Login, &audit.Currency,
+				&audit.Snapshot.Amount, &audit.Snapshot.Held, &audit.Snapshot.Withdraw,
+				&audit.Ledger.Amount, &audit.Ledger.Held, &audit.Ledger.Withdraw, &audit.Booked,
+				&audit.Accrued, &audit.Withdrawn, &audit.Held); err != nil {
+				return fmt.Errorf("can't scan: %w", err)
+			}
+
+			audits = append(audits, audit)
+		}
+
+		return rows.Err()
+	})
+}
@@ -0,0 +1,75 @@
+//nolint:errcheck,gocritic,nolintlint,forcetypeassert
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"gofermart/internal/gophermart/core/model"
+)
+
+func TestPostgresql_GetWalletAudits(t *testing.T) {
+	args := []interface{}{
+		model.LedgerAccountAvailable, model.LedgerAccountHeld, model.LedgerAccountWithdrawn,
+		[]string{model.LedgerAccountAccrual, model.LedgerAccountTierBonus}, processedStatuses,
+		model.WithdrawStatusHeld, model.LedgerKindReversal, model.LedgerKindAdjustment,
+		model.LedgerAccountAdjustment, model.LedgerDescriptionOpeningBalance,
+	}
+
+	columns := make([]interface{}, 12)
+	for i := range columns {
+		columns[i] = mock.Anything
+	}
+
+	t.Run("migrated legacy balance", func(t *testing.T) {
+		// The wallet predates the ledger: its 500 accrued points, 200 of
+		// them withdrawn, were booked by the opening balance adjustment.
+		mockPool := new(MockPool)
+		mockRows := new(MockRows)
+		mockPool.On("Query", mock.Anything, queryWalletAudits, args).Return(mockRows, nil)
+		mockRows.On("Next").Return(true).Once()
+		mockRows.On("Next").Return(false).Once()
+		mockRows.On("Scan", columns...).Run(func(args mock.Arguments) {
+			*(args.Get(0).(*string)) = "legacy"
+			*(args.Get(1).(*string)) = model.DefaultCurrency
+			for i, amount := range []model.Money{300, 0, 200, 300, 0, 200, 500, 500, 200, 0} {
+				*(args.Get(i + 2).(*model.Money)) = amount
+			}
+		}).Return(nil)
+		mockRows.On("Close").Return()
+		mockRows.On("Err").Return(nil)
+
+		postgres := &Postgresql{pool: mockPool}
+		audits, err := postgres.GetWalletAudits(context.TODO())
+
+		require.NoError(t, err)
+		require.Len(t, audits, 1)
+		assert.Equal(t, model.WalletAudit{
+			Login:     "legacy",
+			Currency:  model.DefaultCurrency,
+			Snapshot:  model.UserBalance{Amount: 300, Withdraw: 200},
+			Ledger:    model.UserBalance{Amount: 300, Withdraw: 200},
+			Accrued:   500,
+			Booked:    500,
+			Withdrawn: 200,
+		}, audits[0])
+		mockPool.AssertExpectations(t)
+		mockRows.AssertExpectations(t)
+	})
+
+	t.Run("failed query", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockPool.On("Query", mock.Anything, queryWalletAudits, args).Return((*MockRows)(nil), errors.New("query error"))
+
+		postgres := &Postgresql{pool: mockPool}
+		_, err := postgres.GetWalletAudits(context.TODO())
+
+		assert.EqualError(t, err, "operation failed after 3 retries: can't query: query error")
+		mockPool.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,181 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+func (p *Postgresql) GetReferralCode(ctx context.Context, login string) (string, error) {
+	query := `SELECT COALESCE(referral_code, '') FROM users WHERE login = $1;`
+
+	var code string
+	row := p.pool.QueryRow(ctx, query, login)
+
+	if err := retry(func() error {
+		return row.Scan(&code)
+	}); err != nil {
+		return "", fmt.Errorf("can't scan: %w", err)
+	}
+
+	return code, nil
+}
+
+// SetReferralCode gives login the code unless it has one already.
+func (p *Postgresql) SetReferralCode(ctx context.Context, login, code string) error {
+	query := `UPDATE users SET referral_code = $2, updated_at = now() WHERE login = $1 AND referral_code IS NULL;`
+
+	return retry(func() error {
+		_, err := p.pool.Exec(ctx, query, login, code)
+		if err != nil {
+			return fmt.Errorf("can't exec: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (p *Postgresql) GetReferrer(ctx context.Context, code string) (string, error) {
+	query := `SELECT login FROM users WHERE referral_code = $1;`
+
+	var login string
+	row := p.pool.QueryRow(ctx, query, code)
+
+	if err := retry(func() error {
+		return row.Scan(&login)
+	}); err != nil {
+		return "", fmt.Errorf("can't scan: %w", err)
+	}
+
+	return login, nil
+}
+
+// CreateReferral saves the referral unless the referred user has one
+// already or the referrer has limit referrals, a zero limit doesn't apply.
+// The row of the referrer is locked so that concurrent registrations can't
+// pass the limit together.
+func (p *Postgresql) CreateReferral(ctx context.Context, referral model.Referral, limit int) error {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("can't begin transaction: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+			return
+		}
+		_ = tx.Commit(ctx)
+	}()
+
+	queryLock := `select login from users where login = $1 for update;`
+
+	var referrer string
+	err = tx.QueryRow(ctx, queryLock, referral.Referrer).Scan(&referrer)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = repositories.ErrNotFound
+			return err
+		}
+
+		return fmt.Errorf("can't scan: %w", err)
+	}
+
+	queryCount := `select count(*) from referrals where referrer = $1;`
+
+	var referred int
+	err = tx.QueryRow(ctx, queryCount, referral.Referrer).Scan(&referred)
+	if err != nil {
+		return fmt.Errorf("can't scan: %w", err)
+	}
+
+	if limit > 0 && referred >= limit {
+		err = repositories.ErrLimitExceeded
+		return err
+	}
+
+	queryInsert := `insert into referrals (referrer, referred) values ($1, $2);`
+
+	_, err = tx.Exec(ctx, queryInsert, referral.Referrer, referral.Referred)
+	if err != nil {
+		if isDuplicateError(err) {
+			err = repositories.ErrDuplicate
+			return err
+		}
+
+		return fmt.Errorf("can't exec: %w", err)
+	}
+
+	return nil
+}
+
+func (p *Postgresql) GetReferrals(ctx context.Context, referrer string) ([]model.Referral, error) {
+	query := `SELECT referred, order_id, created_at, rewarded_at FROM referrals WHERE referrer = $1 ORDER BY id;`
+
+	var referrals []model.Referral
+	return referrals, retry(func() error {
+		rows, err := p.pool.Query(ctx, query, referrer)
+		if err != nil {
+			return fmt.Errorf("can't query: %w", err)
+		}
+		defer rows.Close()
+
+		referrals = nil
+		for rows.Next() {
+			referral := model.Referral{Referrer: referrer}
+			if err := rows.Scan(&referral.Referred, &referral.OrderID, &referral.CreatedAt,
+				&referral.RewardedAt); err != nil {
+				return fmt.Errorf("can't scan: %w", err)
+			}
+
+			referrals = append(referrals, referral)
+		}
+
+		return rows.Err()
+	})
+}
+
+// rewardReferral credits the bonuses of the referral of login once. A
+// referrer that deleted their account gets nothing.
+func rewardReferral(ctx context.Context, tx pgx.Tx, login string, accrual model.Accrual) error {
+	query := `update referrals r set rewarded_at = now(), order_id = $2
+	where r.referred = $1 and r.rewarded_at is null
+	returning r.referrer, exists (select 1 from users u where u.login = r.referrer);`
+
+	var (
+		referrer string
+		active   bool
+	)
+	if err := tx.QueryRow(ctx, query, login, accrual.OrderID).Scan(&referrer, &active); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+
+		return fmt.Errorf("can't scan: %w", err)
+	}
+
+	credit := func(login string, amount model.Money) error {
+		if amount == 0 {
+			return nil
+		}
+
+		transaction := model.ReferralTransaction(uuid.NewString(), login, accrual.OrderID, amount)
+		transaction.ExpiresAt = accrual.ExpiresAt
+
+		return postLedger(ctx, tx, transaction)
+	}
+
+	if active {
+		if err := credit(referrer, accrual.Referral.Referrer); err != nil {
+			return err
+		}
+	}
+
+	return credit(login, accrual.Referral.Referred)
+}
@@ -0,0 +1,152 @@
+//nolint:wrapcheck,gocritic,nolintlint,errcheck,forcetypeassert
+package postgresql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+const (
+	queryLockReferrer   = `select login from users where login = $1 for update;`
+	queryCountReferred  = `select count(*) from referrals where referrer = $1;`
+	queryInsertReferral = `insert into referrals (referrer, referred) values ($1, $2);`
+	queryRewardReferral = `update referrals r set rewarded_at = now(), order_id = $2
+	where r.referred = $1 and r.rewarded_at is null
+	returning r.referrer, exists (select 1 from users u where u.login = r.referrer);`
+)
+
+func TestPostgresql_CreateReferral(t *testing.T) {
+	referral := model.Referral{Referrer: "referrer", Referred: "alice"}
+
+	setUp := func(referred int) (*MockPool, *MockTx) {
+		mockPool := new(MockPool)
+		mockTx := new(MockTx)
+		lockRow := new(MockRow)
+		countRow := new(MockRow)
+
+		mockPool.On("Begin", mock.Anything).Return(mockTx, nil)
+		mockTx.On("QueryRow", mock.Anything, queryLockReferrer, []interface{}{"referrer"}).Return(lockRow)
+		lockRow.On("Scan", mock.Anything).Return(nil)
+		mockTx.On("QueryRow", mock.Anything, queryCountReferred, []interface{}{"referrer"}).Return(countRow)
+		countRow.On("Scan", mock.Anything).Run(func(args mock.Arguments) {
+			*(args.Get(0).(*int)) = referred
+		}).Return(nil)
+
+		return mockPool, mockTx
+	}
+
+	t.Run("referral saved", func(t *testing.T) {
+		mockPool, mockTx := setUp(1)
+		mockTx.On("Exec", mock.Anything, queryInsertReferral, []interface{}{"referrer", "alice"}).
+			Return(pgconn.NewCommandTag("INSERT 1"), nil)
+		mockTx.On("Commit", mock.Anything).Return(nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		err := postgres.CreateReferral(context.TODO(), referral, 2)
+
+		assert.NoError(t, err)
+		mockTx.AssertExpectations(t)
+	})
+
+	t.Run("referrer at the limit", func(t *testing.T) {
+		mockPool, mockTx := setUp(2)
+		mockTx.On("Rollback", mock.Anything).Return(nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		err := postgres.CreateReferral(context.TODO(), referral, 2)
+
+		assert.ErrorIs(t, err, repositories.ErrLimitExceeded)
+		mockTx.AssertExpectations(t)
+	})
+
+	t.Run("user referred already", func(t *testing.T) {
+		mockPool, mockTx := setUp(0)
+		mockTx.On("Exec", mock.Anything, queryInsertReferral, mock.Anything).
+			Return(pgconn.CommandTag{}, &pgconn.PgError{Code: pgerrcode.UniqueViolation})
+		mockTx.On("Rollback", mock.Anything).Return(nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		err := postgres.CreateReferral(context.TODO(), referral, 0)
+
+		assert.ErrorIs(t, err, repositories.ErrDuplicate)
+		mockTx.AssertExpectations(t)
+	})
+}
+
+func TestPostgresql_SetBalanceRewardsReferral(t *testing.T) {
+	accrual := model.Accrual{
+		OrderID: "12345678903", Status: model.OrderStatusDone,
+		Referral: &model.ReferralBonus{Referrer: 100, Referred: 50},
+	}
+
+	setUp := func(rewardRow *MockRow) (*MockPool, *MockTx) {
+		mockPool := new(MockPool)
+		mockTx := new(MockTx)
+		orderRow := new(MockRow)
+
+		mockPool.On("Begin", mock.Anything).Return(mockTx, nil)
+		mockTx.On("QueryRow", mock.Anything, querySetOrder, mock.Anything).Return(orderRow)
+		orderRow.On("Scan", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			*(args.Get(0).(*string)) = "alice"
+			*(args.Get(3).(*string)) = model.DefaultCurrency
+		}).Return(nil)
+		mockTx.On("QueryRow", mock.Anything, queryRewardReferral, []interface{}{"alice", "12345678903"}).
+			Return(rewardRow)
+		mockTx.On("Commit", mock.Anything).Return(nil)
+
+		return mockPool, mockTx
+	}
+
+	t.Run("both users credited", func(t *testing.T) {
+		rewardRow := new(MockRow)
+		rewardRow.On("Scan", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			*(args.Get(0).(*string)) = "referrer"
+			*(args.Get(1).(*bool)) = true
+		}).Return(nil)
+
+		mockPool, mockTx := setUp(rewardRow)
+		mockTx.On("Exec", mock.Anything, queryLedgerBalance,
+			[]interface{}{model.Money(100), model.Money(0), model.Money(0), "referrer", model.DefaultCurrency}).
+			Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+		mockTx.On("Exec", mock.Anything, queryLedgerBalance,
+			[]interface{}{model.Money(50), model.Money(0), model.Money(0), "alice", model.DefaultCurrency}).
+			Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+		mockTx.On("Exec", mock.Anything, queryLedgerEntry, mock.MatchedBy(func(args []interface{}) bool {
+			return args[1] == model.LedgerKindReferral && args[6] == "12345678903"
+		})).Return(pgconn.NewCommandTag("INSERT 1"), nil).Times(4)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		err := postgres.SetBalance(context.TODO(), accrual)
+
+		assert.NoError(t, err)
+		mockTx.AssertExpectations(t)
+	})
+
+	t.Run("no referral to reward", func(t *testing.T) {
+		rewardRow := new(MockRow)
+		rewardRow.On("Scan", mock.Anything, mock.Anything).Return(pgx.ErrNoRows)
+
+		mockPool, mockTx := setUp(rewardRow)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		err := postgres.SetBalance(context.TODO(), accrual)
+
+		assert.NoError(t, err)
+		mockTx.AssertNotCalled(t, "Exec", mock.Anything, mock.Anything, mock.Anything)
+		mockTx.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,123 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+// RefundWithdraw posts a refund of a processed withdrawal. The withdrawal
+// row lock serialises refunds of the same order, so a retried refund finds
+// the one stored first.
+func (p *Postgresql) RefundWithdraw(ctx context.Context,
+	refund model.WithdrawRefund) (model.WithdrawRefund, model.Withdraw, error) {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return model.WithdrawRefund{}, model.Withdraw{}, fmt.Errorf("can't begin transaction: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+			return
+		}
+		_ = tx.Commit(ctx)
+	}()
+
+	queryWithdraw := `select login, amount, refunded, status, currency, partner, created_at from withdraw
+	where order_id = $1 for update;`
+
+	withdraw := model.Withdraw{OrderID: refund.OrderID}
+	err = tx.QueryRow(ctx, queryWithdraw, refund.OrderID).Scan(&withdraw.Login, &withdraw.Amount,
+		&withdraw.Refunded, &withdraw.Status, &withdraw.Currency, &withdraw.Partner, &withdraw.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = repositories.ErrNotFound
+			return model.WithdrawRefund{}, model.Withdraw{}, err
+		}
+
+		return model.WithdrawRefund{}, model.Withdraw{}, fmt.Errorf("can't scan: %w", err)
+	}
+
+	if refund.Partner != "" && withdraw.Partner != refund.Partner {
+		err = repositories.ErrNotFound
+		return model.WithdrawRefund{}, model.Withdraw{}, err
+	}
+
+	queryRefund := `select transaction_id, order_id, login, actor, reason, amount, created_at
+	from withdraw_refunds where id = $1;`
+
+	existing := model.WithdrawRefund{ID: refund.ID}
+	err = tx.QueryRow(ctx, queryRefund, refund.ID).Scan(&existing.TransactionID, &existing.OrderID,
+		&existing.Login, &existing.Actor, &existing.Reason, &existing.Amount, &existing.CreatedAt)
+	switch {
+	case err == nil:
+		if existing.OrderID != refund.OrderID || refund.Amount != 0 && refund.Amount != existing.Amount {
+			err = repositories.ErrDuplicate
+			return model.WithdrawRefund{}, model.Withdraw{}, err
+		}
+
+		return existing, withdraw, nil
+	case !errors.Is(err, pgx.ErrNoRows):
+		return model.WithdrawRefund{}, model.Withdraw{}, fmt.Errorf("can't scan: %w", err)
+	}
+
+	if !withdraw.Processed() {
+		err = repositories.ErrInvalidState
+		return model.WithdrawRefund{}, model.Withdraw{}, err
+	}
+
+	left := withdraw.Amount - withdraw.Refunded
+	if refund.Amount == 0 {
+		refund.Amount = left
+	}
+
+	if refund.Amount <= 0 || refund.Amount > left {
+		err = repositories.ErrInsufficientFunds
+		return model.WithdrawRefund{}, model.Withdraw{}, err
+	}
+
+	refund.Login = withdraw.Login
+	refund.TransactionID = uuid.NewString()
+
+	transaction := model.RefundTransaction(refund.TransactionID, refund.Login, refund.OrderID, refund.Amount,
+		refund.Reason)
+	transaction.Currency = withdraw.Currency
+
+	err = postLedger(ctx, tx, transaction)
+	if err != nil {
+		return model.WithdrawRefund{}, model.Withdraw{}, err
+	}
+
+	queryInsert := `insert into withdraw_refunds (id, transaction_id, order_id, login, actor, reason, amount)
+	values ($1, $2, $3, $4, $5, $6, $7) returning created_at;`
+
+	err = tx.QueryRow(ctx, queryInsert, refund.ID, refund.TransactionID, refund.OrderID, refund.Login,
+		refund.Actor, refund.Reason, refund.Amount).Scan(&refund.CreatedAt)
+	if err != nil {
+		if isDuplicateError(err) {
+			err = repositories.ErrDuplicate
+			return model.WithdrawRefund{}, model.Withdraw{}, err
+		}
+
+		return model.WithdrawRefund{}, model.Withdraw{}, fmt.Errorf("can't scan: %w", err)
+	}
+
+	withdraw.Refunded += refund.Amount
+	withdraw.Status = model.RefundedStatus(withdraw.Amount, withdraw.Refunded)
+
+	queryUpdate := `update withdraw set refunded = $1, status = $2 where order_id = $3;`
+
+	_, err = tx.Exec(ctx, queryUpdate, withdraw.Refunded, withdraw.Status, refund.OrderID)
+	if err != nil {
+		return model.WithdrawRefund{}, model.Withdraw{}, fmt.Errorf("can't exec: %w", err)
+	}
+
+	return refund, withdraw, nil
+}
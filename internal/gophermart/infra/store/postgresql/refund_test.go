@@ -0,0 +1,175 @@
+//nolint:wrapcheck,gocritic,nolintlint,errcheck,forcetypeassert
+package postgresql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+const (
+	queryRefundWithdraw = `select login, amount, refunded, status, currency, partner, created_at from withdraw
+	where order_id = $1 for update;`
+	queryRefundExisting = `select transaction_id, order_id, login, actor, reason, amount, created_at
+	from withdraw_refunds where id = $1;`
+	queryRefundInsert = `insert into withdraw_refunds (id, transaction_id, order_id, login, actor, reason, amount)
+	values ($1, $2, $3, $4, $5, $6, $7) returning created_at;`
+	queryRefundUpdate = `update withdraw set refunded = $1, status = $2 where order_id = $3;`
+)
+
+func TestPostgresql_RefundWithdraw(t *testing.T) {
+	request := model.WithdrawRefund{ID: "r-1", OrderID: "2377225624", Actor: "shop", Reason: "cancelled"}
+
+	scanWithdraw := func(status string, refunded model.Money) func(args mock.Arguments) {
+		return func(args mock.Arguments) {
+			*(args.Get(0).(*string)) = "login"
+			*(args.Get(1).(*model.Money)) = 300
+			*(args.Get(2).(*model.Money)) = refunded
+			*(args.Get(3).(*string)) = status
+			*(args.Get(4).(*string)) = model.DefaultCurrency
+			*(args.Get(5).(*string)) = "shop"
+		}
+	}
+
+	anyColumns := func(n int) []interface{} {
+		columns := make([]interface{}, n)
+		for i := range columns {
+			columns[i] = mock.Anything
+		}
+
+		return columns
+	}
+
+	t.Run("refund the rest", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockTx := new(MockTx)
+		withdrawRow := new(MockRow)
+		refundRow := new(MockRow)
+		insertRow := new(MockRow)
+
+		mockPool.On("Begin", mock.Anything).Return(mockTx, nil)
+		mockTx.On("QueryRow", mock.Anything, queryRefundWithdraw, []interface{}{"2377225624"}).Return(withdrawRow)
+		withdrawRow.On("Scan", anyColumns(7)...).Run(scanWithdraw(model.WithdrawStatusPartiallyRefunded, 100)).
+			Return(nil)
+		mockTx.On("QueryRow", mock.Anything, queryRefundExisting, []interface{}{"r-1"}).Return(refundRow)
+		refundRow.On("Scan", anyColumns(7)...).Return(pgx.ErrNoRows)
+		mockTx.On("Exec", mock.Anything, queryLedgerBalance,
+			[]interface{}{model.Money(200), model.Money(0), model.Money(-200), "login", model.DefaultCurrency}).
+			Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+		mockTx.On("Exec", mock.Anything, queryRestoreLots, []interface{}{"login", "2377225624", model.Money(200)}).
+			Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+		mockTx.On("Exec", mock.Anything, queryLedgerEntry, mock.Anything).
+			Return(pgconn.NewCommandTag("INSERT 1"), nil).Twice()
+		mockTx.On("QueryRow", mock.Anything, queryRefundInsert, mock.Anything).Return(insertRow)
+		insertRow.On("Scan", mock.Anything).Return(nil)
+		mockTx.On("Exec", mock.Anything, queryRefundUpdate,
+			[]interface{}{model.Money(300), model.WithdrawStatusRefunded, "2377225624"}).
+			Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+		mockTx.On("Commit", mock.Anything).Return(nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		refund, withdraw, err := postgres.RefundWithdraw(context.TODO(), request)
+
+		assert.NoError(t, err)
+		assert.Equal(t, model.Money(200), refund.Amount)
+		assert.Equal(t, model.WithdrawStatusRefunded, withdraw.Status)
+		mockPool.AssertExpectations(t)
+		mockTx.AssertExpectations(t)
+	})
+
+	t.Run("more than is left", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockTx := new(MockTx)
+		withdrawRow := new(MockRow)
+		refundRow := new(MockRow)
+
+		mockPool.On("Begin", mock.Anything).Return(mockTx, nil)
+		mockTx.On("QueryRow", mock.Anything, queryRefundWithdraw, mock.Anything).Return(withdrawRow)
+		withdrawRow.On("Scan", anyColumns(7)...).Run(scanWithdraw(model.WithdrawStatusCaptured, 0)).Return(nil)
+		mockTx.On("QueryRow", mock.Anything, queryRefundExisting, mock.Anything).Return(refundRow)
+		refundRow.On("Scan", anyColumns(7)...).Return(pgx.ErrNoRows)
+		mockTx.On("Rollback", mock.Anything).Return(nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		overdrawn := request
+		overdrawn.Amount = 301
+
+		_, _, err := postgres.RefundWithdraw(context.TODO(), overdrawn)
+
+		assert.ErrorIs(t, err, repositories.ErrInsufficientFunds)
+		mockTx.AssertExpectations(t)
+	})
+
+	t.Run("retried refund", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockTx := new(MockTx)
+		withdrawRow := new(MockRow)
+		refundRow := new(MockRow)
+
+		mockPool.On("Begin", mock.Anything).Return(mockTx, nil)
+		mockTx.On("QueryRow", mock.Anything, queryRefundWithdraw, mock.Anything).Return(withdrawRow)
+		withdrawRow.On("Scan", anyColumns(7)...).Run(scanWithdraw(model.WithdrawStatusRefunded, 300)).Return(nil)
+		mockTx.On("QueryRow", mock.Anything, queryRefundExisting, mock.Anything).Return(refundRow)
+		refundRow.On("Scan", anyColumns(7)...).Run(func(args mock.Arguments) {
+			*(args.Get(1).(*string)) = "2377225624"
+			*(args.Get(5).(*model.Money)) = 300
+		}).Return(nil)
+		mockTx.On("Commit", mock.Anything).Return(nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		refund, withdraw, err := postgres.RefundWithdraw(context.TODO(), request)
+
+		assert.NoError(t, err)
+		assert.Equal(t, model.Money(300), refund.Amount)
+		assert.Equal(t, model.Money(300), withdraw.Refunded)
+		mockTx.AssertExpectations(t)
+	})
+
+	t.Run("withdrawal at another partner", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockTx := new(MockTx)
+		withdrawRow := new(MockRow)
+
+		mockPool.On("Begin", mock.Anything).Return(mockTx, nil)
+		mockTx.On("QueryRow", mock.Anything, queryRefundWithdraw, mock.Anything).Return(withdrawRow)
+		withdrawRow.On("Scan", anyColumns(7)...).Run(scanWithdraw(model.WithdrawStatusCaptured, 0)).Return(nil)
+		mockTx.On("Rollback", mock.Anything).Return(nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		other := request
+		other.Partner = "other-shop"
+		_, _, err := postgres.RefundWithdraw(context.TODO(), other)
+
+		assert.ErrorIs(t, err, repositories.ErrNotFound)
+		mockTx.AssertExpectations(t)
+	})
+
+	t.Run("withdrawal not found", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockTx := new(MockTx)
+		withdrawRow := new(MockRow)
+
+		mockPool.On("Begin", mock.Anything).Return(mockTx, nil)
+		mockTx.On("QueryRow", mock.Anything, queryRefundWithdraw, mock.Anything).Return(withdrawRow)
+		withdrawRow.On("Scan", anyColumns(7)...).Return(pgx.ErrNoRows)
+		mockTx.On("Rollback", mock.Anything).Return(nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		_, _, err := postgres.RefundWithdraw(context.TODO(), request)
+
+		assert.ErrorIs(t, err, repositories.ErrNotFound)
+		mockTx.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,110 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+func (p *Postgresql) SaveSession(ctx context.Context, session model.Session) error {
+	query := `INSERT INTO sessions (id, login, user_agent, ip, created_at, last_seen_at)
+	VALUES ($1, $2, $3, $4, $5, $6);`
+
+	return retry(func() error {
+		_, err := p.pool.Exec(ctx, query, session.ID, session.Login, session.UserAgent, session.IP,
+			session.CreatedAt, session.LastSeenAt)
+		if err != nil {
+			return fmt.Errorf("can't exec: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (p *Postgresql) GetSession(ctx context.Context, id string) (model.Session, error) {
+	query := `SELECT login, user_agent, ip, created_at, last_seen_at, revoked_at FROM sessions WHERE id = $1;`
+
+	session := model.Session{ID: id}
+	row := p.pool.QueryRow(ctx, query, id)
+
+	if err := retry(func() error {
+		return row.Scan(&session.Login, &session.UserAgent, &session.IP,
+			&session.CreatedAt, &session.LastSeenAt, &session.RevokedAt)
+	}); err != nil {
+		return model.Session{}, fmt.Errorf("can't scan: %w", err)
+	}
+
+	return session, nil
+}
+
+func (p *Postgresql) GetUserSessions(ctx context.Context, login string) ([]model.Session, error) {
+	query := `SELECT id, user_agent, ip, created_at, last_seen_at FROM sessions
+	WHERE login = $1 AND revoked_at IS NULL ORDER BY last_seen_at DESC;`
+
+	result := make([]model.Session, 0)
+	return result, retry(func() error {
+		rows, err := p.pool.Query(ctx, query, login)
+		if err != nil {
+			return fmt.Errorf("can't query: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			session := model.Session{Login: login}
+			if err := rows.Scan(&session.ID, &session.UserAgent, &session.IP,
+				&session.CreatedAt, &session.LastSeenAt); err != nil {
+				return fmt.Errorf("can't scan: %w", err)
+			}
+
+			result = append(result, session)
+		}
+
+		return nil
+	})
+}
+
+func (p *Postgresql) TouchSession(ctx context.Context, id string, seenAt time.Time) error {
+	query := `UPDATE sessions SET last_seen_at = $1 WHERE id = $2;`
+
+	return retry(func() error {
+		_, err := p.pool.Exec(ctx, query, seenAt, id)
+		if err != nil {
+			return fmt.Errorf("can't exec: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (p *Postgresql) RevokeSession(ctx context.Context, login, id string) error {
+	query := `UPDATE sessions SET revoked_at = now() WHERE id = $1 AND login = $2 AND revoked_at IS NULL;`
+
+	return retry(func() error {
+		tag, err := p.pool.Exec(ctx, query, id, login)
+		if err != nil {
+			return fmt.Errorf("can't exec: %w", err)
+		}
+
+		if tag.RowsAffected() == 0 {
+			return repositories.ErrNotFound
+		}
+
+		return nil
+	})
+}
+
+func (p *Postgresql) RevokeUserSessions(ctx context.Context, login, exceptID string) error {
+	query := `UPDATE sessions SET revoked_at = now() WHERE login = $1 AND id <> $2 AND revoked_at IS NULL;`
+
+	return retry(func() error {
+		_, err := p.pool.Exec(ctx, query, login, exceptID)
+		if err != nil {
+			return fmt.Errorf("can't exec: %w", err)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,108 @@
+//nolint:wrapcheck,gocritic,nolintlint,errcheck,forcetypeassert
+package postgresql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+func TestPostgresql_SaveSession(t *testing.T) {
+	session := model.Session{
+		ID:         "id",
+		Login:      "login",
+		UserAgent:  "agent",
+		IP:         "127.0.0.1",
+		CreatedAt:  time.Now(),
+		LastSeenAt: time.Now(),
+	}
+
+	mockPool := new(MockPool)
+	mockPool.On("Exec", mock.Anything, mock.Anything, []interface{}{session.ID, session.Login, session.UserAgent,
+		session.IP, session.CreatedAt, session.LastSeenAt}).Return(pgconn.NewCommandTag("INSERT 0 1"), nil)
+
+	postgres := &Postgresql{pool: mockPool}
+
+	assert.NoError(t, postgres.SaveSession(context.TODO(), session))
+	mockPool.AssertExpectations(t)
+}
+
+func TestPostgresql_GetSession(t *testing.T) {
+	t.Run("successful get", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockRow := new(MockRow)
+		mockPool.On("QueryRow", mock.Anything, mock.Anything, []interface{}{"id"}).Return(mockRow)
+		mockRow.On("Scan", mock.Anything, mock.Anything, mock.Anything,
+			mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			*(args.Get(0).(*string)) = "login"
+			*(args.Get(1).(*string)) = "agent"
+		}).Return(nil)
+
+		postgres := &Postgresql{pool: mockPool}
+		session, err := postgres.GetSession(context.TODO(), "id")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "id", session.ID)
+		assert.Equal(t, "login", session.Login)
+		assert.Equal(t, "agent", session.UserAgent)
+		assert.Nil(t, session.RevokedAt)
+		mockPool.AssertExpectations(t)
+		mockRow.AssertExpectations(t)
+	})
+
+	t.Run("session not found", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockRow := new(MockRow)
+		mockPool.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(mockRow)
+		mockRow.On("Scan", mock.Anything, mock.Anything, mock.Anything,
+			mock.Anything, mock.Anything, mock.Anything).Return(pgx.ErrNoRows)
+
+		postgres := &Postgresql{pool: mockPool}
+		_, err := postgres.GetSession(context.TODO(), "id")
+
+		assert.ErrorIs(t, err, repositories.ErrNotFound)
+	})
+}
+
+func TestPostgresql_RevokeSession(t *testing.T) {
+	t.Run("successful revoke", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockPool.On("Exec", mock.Anything, mock.Anything, []interface{}{"id", "login"}).
+			Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		assert.NoError(t, postgres.RevokeSession(context.TODO(), "login", "id"))
+		mockPool.AssertExpectations(t)
+	})
+
+	t.Run("session not found", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockPool.On("Exec", mock.Anything, mock.Anything, mock.Anything).
+			Return(pgconn.NewCommandTag("UPDATE 0"), nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		assert.ErrorIs(t, postgres.RevokeSession(context.TODO(), "login", "id"), repositories.ErrNotFound)
+	})
+}
+
+func TestPostgresql_RevokeUserSessions(t *testing.T) {
+	mockPool := new(MockPool)
+	mockPool.On("Exec", mock.Anything,
+		"UPDATE sessions SET revoked_at = now() WHERE login = $1 AND id <> $2 AND revoked_at IS NULL;",
+		[]interface{}{"login", "current"}).Return(pgconn.NewCommandTag("UPDATE 2"), nil)
+
+	postgres := &Postgresql{pool: mockPool}
+
+	assert.NoError(t, postgres.RevokeUserSessions(context.TODO(), "login", "current"))
+	mockPool.AssertExpectations(t)
+}
@@ -0,0 +1,66 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gofermart/internal/gophermart/core/model"
+)
+
+// accruedKinds are the ledger kinds that count towards tiers: accruals net
+// of their corrections. Only accruals to the default wallet count.
+var accruedKinds = []string{model.LedgerKindAccrual, model.LedgerKindCorrection}
+
+func (p *Postgresql) GetUserTier(ctx context.Context, login string, since time.Time) (model.UserTier, error) {
+	query := `SELECT u.tier, COALESCE(-SUM(e.amount), 0) FROM users u
+	LEFT JOIN ledger_entries e ON e.login = u.login AND e.kind = any($2) AND e.account = $3 AND e.created_at >= $4
+	    AND e.currency = $5
+	WHERE u.login = $1 GROUP BY u.tier;`
+
+	tier := model.UserTier{Login: login}
+	row := p.pool.QueryRow(ctx, query, login, accruedKinds, model.LedgerAccountAccrual, since,
+		model.DefaultCurrency)
+
+	if err := retry(func() error {
+		return row.Scan(&tier.Tier, &tier.Accrued)
+	}); err != nil {
+		return model.UserTier{}, fmt.Errorf("can't scan: %w", err)
+	}
+
+	return tier, nil
+}
+
+func (p *Postgresql) GetUserTiers(ctx context.Context, since time.Time) ([]model.UserTier, error) {
+	query := `SELECT u.login, u.tier, COALESCE(-SUM(e.amount), 0) FROM users u
+	LEFT JOIN ledger_entries e ON e.login = u.login AND e.kind = any($1) AND e.account = $2 AND e.created_at >= $3
+	    AND e.currency = $4
+	GROUP BY u.login, u.tier ORDER BY u.login;`
+
+	var tiers []model.UserTier
+	return tiers, retry(func() error {
+		rows, err := p.pool.Query(ctx, query, accruedKinds, model.LedgerAccountAccrual, since, model.DefaultCurrency)
+		if err != nil {
+			return fmt.Errorf("can't query: %w", err)
+		}
+		defer rows.Close()
+
+		tiers = nil
+		for rows.Next() {
+			var tier model.UserTier
+			if err := rows.Scan(&tier.Login, &tier.Tier, &tier.Accrued); err != nil {
+				return fmt.Errorf("can't scan: %w", err)
+			}
+
+			tiers = append(tiers, tier)
+		}
+
+		return rows.Err()
+	})
+}
+
+func (p *Postgresql) SetUserTier(ctx context.Context, login, tier string) error {
+	query := `UPDATE users SET tier = $1, updated_at = now() WHERE login = $2;`
+
+	return p.updateUser(ctx, query, tier, login)
+}
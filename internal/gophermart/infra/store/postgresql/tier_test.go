@@ -0,0 +1,87 @@
+//nolint:wrapcheck,gocritic,nolintlint,errcheck,forcetypeassert
+package postgresql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+func TestPostgresql_GetUserTier(t *testing.T) {
+	since := time.Now().AddDate(-1, 0, 0)
+
+	t.Run("tier with accruals", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockRow := new(MockRow)
+
+		mockPool.On("QueryRow", mock.Anything, mock.Anything,
+			[]interface{}{"login", accruedKinds, model.LedgerAccountAccrual, since, model.DefaultCurrency}).Return(mockRow)
+		mockRow.On("Scan", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			*(args.Get(0).(*string)) = "silver"
+			*(args.Get(1).(*model.Money)) = 120000
+		}).Return(nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		tier, err := postgres.GetUserTier(context.TODO(), "login", since)
+
+		assert.NoError(t, err)
+		assert.Equal(t, model.UserTier{Login: "login", Tier: "silver", Accrued: 120000}, tier)
+		mockPool.AssertExpectations(t)
+	})
+
+	t.Run("user not found", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockRow := new(MockRow)
+
+		mockPool.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(mockRow)
+		mockRow.On("Scan", mock.Anything, mock.Anything).Return(pgx.ErrNoRows)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		_, err := postgres.GetUserTier(context.TODO(), "login", since)
+
+		assert.ErrorIs(t, err, repositories.ErrNotFound)
+	})
+}
+
+func TestPostgresql_SetBalanceWithTierBonus(t *testing.T) {
+	mockPool := new(MockPool)
+	mockTx := new(MockTx)
+	mockRow := new(MockRow)
+
+	mockPool.On("Begin", mock.Anything).Return(mockTx, nil)
+	mockTx.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(mockRow)
+	// The order and then the funds of its user are scanned.
+	mockRow.On("Scan", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		if userLogin, ok := args.Get(0).(*string); ok {
+			*userLogin = "login"
+		}
+	}).Return(nil)
+	mockTx.On("Exec", mock.Anything, queryLedgerBalance,
+		[]interface{}{model.Money(1000), model.Money(0), model.Money(0), "login", model.DefaultCurrency}).
+		Return(pgconn.NewCommandTag("UPDATE 1"), nil).Once()
+	mockTx.On("Exec", mock.Anything, queryLedgerBalance,
+		[]interface{}{model.Money(50), model.Money(0), model.Money(0), "login", model.DefaultCurrency}).
+		Return(pgconn.NewCommandTag("UPDATE 1"), nil).Once()
+	mockTx.On("Exec", mock.Anything, queryLedgerEntry, mock.Anything).
+		Return(pgconn.NewCommandTag("INSERT 1"), nil).Times(4)
+	mockTx.On("Commit", mock.Anything).Return(nil)
+
+	postgres := &Postgresql{pool: mockPool}
+
+	err := postgres.SetBalance(context.TODO(), model.Accrual{
+		OrderID: "12345678903", Status: model.OrderStatusDone, Amount: 1000, Tier: "silver", Bonus: 50,
+	})
+
+	assert.NoError(t, err)
+	mockTx.AssertExpectations(t)
+}
@@ -0,0 +1,116 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+func (p *Postgresql) SaveRefreshToken(ctx context.Context, token model.RefreshToken) error {
+	query := `INSERT INTO refresh_tokens (token_hash, login, family_id, expires_at, created_at)
+	VALUES ($1, $2, $3, $4, $5);`
+
+	return retry(func() error {
+		_, err := p.pool.Exec(ctx, query, token.TokenHash, token.Login, token.FamilyID, token.ExpiresAt, token.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("can't exec: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (p *Postgresql) GetRefreshToken(ctx context.Context, tokenHash string) (model.RefreshToken, error) {
+	query := `SELECT login, family_id, expires_at, created_at, used_at, revoked
+	FROM refresh_tokens WHERE token_hash = $1;`
+
+	token := model.RefreshToken{TokenHash: tokenHash}
+	row := p.pool.QueryRow(ctx, query, tokenHash)
+
+	if err := retry(func() error {
+		return row.Scan(&token.Login, &token.FamilyID, &token.ExpiresAt, &token.CreatedAt, &token.UsedAt, &token.Revoked)
+	}); err != nil {
+		return model.RefreshToken{}, fmt.Errorf("can't scan: %w", err)
+	}
+
+	return token, nil
+}
+
+func (p *Postgresql) UseRefreshToken(ctx context.Context, tokenHash string) error {
+	query := `UPDATE refresh_tokens SET used_at = now()
+	WHERE token_hash = $1 AND used_at IS NULL AND NOT revoked;`
+
+	return retry(func() error {
+		tag, err := p.pool.Exec(ctx, query, tokenHash)
+		if err != nil {
+			return fmt.Errorf("can't exec: %w", err)
+		}
+
+		if tag.RowsAffected() == 0 {
+			return repositories.ErrAlreadyUsed
+		}
+
+		return nil
+	})
+}
+
+func (p *Postgresql) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	query := `UPDATE refresh_tokens SET revoked = true WHERE family_id = $1;`
+
+	return retry(func() error {
+		_, err := p.pool.Exec(ctx, query, familyID)
+		if err != nil {
+			return fmt.Errorf("can't exec: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (p *Postgresql) RevokeUserRefreshTokens(ctx context.Context, login, exceptFamilyID string) error {
+	query := `UPDATE refresh_tokens SET revoked = true WHERE login = $1 AND family_id <> $2 AND NOT revoked;`
+
+	return retry(func() error {
+		_, err := p.pool.Exec(ctx, query, login, exceptFamilyID)
+		if err != nil {
+			return fmt.Errorf("can't exec: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (p *Postgresql) RevokeToken(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	cleanupQuery := `DELETE FROM revoked_tokens WHERE expires_at < now();`
+	query := `INSERT INTO revoked_tokens (token_id, expires_at) VALUES ($1, $2) ON CONFLICT (token_id) DO NOTHING;`
+
+	return retry(func() error {
+		if _, err := p.pool.Exec(ctx, cleanupQuery); err != nil {
+			return fmt.Errorf("can't exec: %w", err)
+		}
+
+		if _, err := p.pool.Exec(ctx, query, tokenID, expiresAt); err != nil {
+			return fmt.Errorf("can't exec: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (p *Postgresql) IsTokenRevoked(ctx context.Context, tokenID string) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE token_id = $1);`
+
+	var revoked bool
+	row := p.pool.QueryRow(ctx, query, tokenID)
+
+	if err := retry(func() error {
+		return row.Scan(&revoked)
+	}); err != nil {
+		return false, fmt.Errorf("can't scan: %w", err)
+	}
+
+	return revoked, nil
+}
@@ -0,0 +1,156 @@
+//nolint:wrapcheck,gocritic,nolintlint,errcheck,forcetypeassert
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+func TestPostgresql_SaveRefreshToken(t *testing.T) {
+	t.Run("successful save", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockPool.On("Exec", mock.Anything, mock.Anything, mock.Anything).
+			Return(pgconn.NewCommandTag("INSERT 0 1"), nil)
+
+		postgres := &Postgresql{pool: mockPool}
+		err := postgres.SaveRefreshToken(context.TODO(), model.RefreshToken{
+			TokenHash: "hash",
+			Login:     "login",
+			FamilyID:  "family",
+			ExpiresAt: time.Now().Add(time.Hour),
+			CreatedAt: time.Now(),
+		})
+
+		assert.NoError(t, err)
+		mockPool.AssertExpectations(t)
+	})
+}
+
+func TestPostgresql_GetRefreshToken(t *testing.T) {
+	t.Run("successful get", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockRow := new(MockRow)
+		mockPool.On("QueryRow", mock.Anything, mock.Anything, []interface{}{"hash"}).Return(mockRow)
+		mockRow.On("Scan", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) {
+				*(args.Get(0).(*string)) = "login"
+				*(args.Get(1).(*string)) = "family"
+			}).Return(nil)
+
+		postgres := &Postgresql{pool: mockPool}
+		token, err := postgres.GetRefreshToken(context.TODO(), "hash")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "hash", token.TokenHash)
+		assert.Equal(t, "login", token.Login)
+		assert.Equal(t, "family", token.FamilyID)
+		mockPool.AssertExpectations(t)
+		mockRow.AssertExpectations(t)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockRow := new(MockRow)
+		mockPool.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(mockRow)
+		mockRow.On("Scan", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(pgx.ErrNoRows)
+
+		postgres := &Postgresql{pool: mockPool}
+		_, err := postgres.GetRefreshToken(context.TODO(), "hash")
+
+		assert.ErrorIs(t, err, repositories.ErrNotFound)
+	})
+}
+
+func TestPostgresql_UseRefreshToken(t *testing.T) {
+	t.Run("successful use", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockPool.On("Exec", mock.Anything, mock.Anything, []interface{}{"hash"}).
+			Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+
+		postgres := &Postgresql{pool: mockPool}
+		err := postgres.UseRefreshToken(context.TODO(), "hash")
+
+		assert.NoError(t, err)
+		mockPool.AssertExpectations(t)
+	})
+
+	t.Run("already used", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockPool.On("Exec", mock.Anything, mock.Anything, []interface{}{"hash"}).
+			Return(pgconn.NewCommandTag("UPDATE 0"), nil)
+
+		postgres := &Postgresql{pool: mockPool}
+		err := postgres.UseRefreshToken(context.TODO(), "hash")
+
+		assert.ErrorIs(t, err, repositories.ErrAlreadyUsed)
+	})
+}
+
+func TestPostgresql_RevokeUserRefreshTokens(t *testing.T) {
+	mockPool := new(MockPool)
+	mockPool.On("Exec", mock.Anything,
+		"UPDATE refresh_tokens SET revoked = true WHERE login = $1 AND family_id <> $2 AND NOT revoked;",
+		[]interface{}{"login", "current"}).Return(pgconn.NewCommandTag("UPDATE 2"), nil)
+
+	postgres := &Postgresql{pool: mockPool}
+
+	assert.NoError(t, postgres.RevokeUserRefreshTokens(context.TODO(), "login", "current"))
+	mockPool.AssertExpectations(t)
+}
+
+func TestPostgresql_RevokeToken(t *testing.T) {
+	t.Run("successful revoke", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockPool.On("Exec", mock.Anything, "DELETE FROM revoked_tokens WHERE expires_at < now();", mock.Anything).
+			Return(pgconn.NewCommandTag("DELETE 0"), nil)
+		mockPool.On("Exec", mock.Anything, mock.Anything, mock.Anything).
+			Return(pgconn.NewCommandTag("INSERT 0 1"), nil)
+
+		postgres := &Postgresql{pool: mockPool}
+		err := postgres.RevokeToken(context.TODO(), "id", time.Now().Add(time.Hour))
+
+		assert.NoError(t, err)
+		mockPool.AssertExpectations(t)
+	})
+
+	t.Run("failed cleanup", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockPool.On("Exec", mock.Anything, mock.Anything, mock.Anything).
+			Return(pgconn.NewCommandTag(""), errors.New("exec error"))
+
+		postgres := &Postgresql{pool: mockPool}
+		err := postgres.RevokeToken(context.TODO(), "id", time.Now().Add(time.Hour))
+
+		assert.EqualError(t, err, "operation failed after 3 retries: can't exec: exec error")
+	})
+}
+
+func TestPostgresql_IsTokenRevoked(t *testing.T) {
+	t.Run("revoked", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockRow := new(MockRow)
+		mockPool.On("QueryRow", mock.Anything, mock.Anything, []interface{}{"id"}).Return(mockRow)
+		mockRow.On("Scan", mock.Anything).Run(func(args mock.Arguments) {
+			*(args.Get(0).(*bool)) = true
+		}).Return(nil)
+
+		postgres := &Postgresql{pool: mockPool}
+		revoked, err := postgres.IsTokenRevoked(context.TODO(), "id")
+
+		assert.NoError(t, err)
+		assert.True(t, revoked)
+		mockPool.AssertExpectations(t)
+		mockRow.AssertExpectations(t)
+	})
+}
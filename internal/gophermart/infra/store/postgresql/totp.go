@@ -0,0 +1,150 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+// SaveTOTP stores a pending enrolment, replacing an earlier pending one.
+// A confirmed TOTP is never replaced.
+func (p *Postgresql) SaveTOTP(ctx context.Context, totp model.TOTP) error {
+	query := `INSERT INTO user_totp (login, secret, created_at) VALUES ($1, $2, $3)
+	ON CONFLICT (login) DO UPDATE SET secret = EXCLUDED.secret, created_at = EXCLUDED.created_at, last_step = 0
+	WHERE user_totp.confirmed_at IS NULL;`
+
+	return retry(func() error {
+		tag, err := p.pool.Exec(ctx, query, totp.Login, totp.Secret, totp.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("can't exec: %w", err)
+		}
+
+		if tag.RowsAffected() == 0 {
+			return repositories.ErrDuplicate
+		}
+
+		return nil
+	})
+}
+
+func (p *Postgresql) GetTOTP(ctx context.Context, login string) (model.TOTP, error) {
+	query := `SELECT secret, last_step, confirmed_at, created_at FROM user_totp WHERE login = $1;`
+
+	totp := model.TOTP{Login: login}
+	row := p.pool.QueryRow(ctx, query, login)
+
+	if err := retry(func() error {
+		return row.Scan(&totp.Secret, &totp.LastStep, &totp.ConfirmedAt, &totp.CreatedAt)
+	}); err != nil {
+		return model.TOTP{}, fmt.Errorf("can't scan: %w", err)
+	}
+
+	return totp, nil
+}
+
+func (p *Postgresql) ConfirmTOTP(ctx context.Context, login string, step int64, recoveryCodeHashes []string) error {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("can't begin transaction: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+			return
+		}
+		_ = tx.Commit(ctx)
+	}()
+
+	confirmQuery := `UPDATE user_totp SET confirmed_at = now(), last_step = $2
+	WHERE login = $1 AND confirmed_at IS NULL;`
+
+	tag, err := tx.Exec(ctx, confirmQuery, login, step)
+	if err != nil {
+		return fmt.Errorf("can't exec: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		err = repositories.ErrNotFound
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `DELETE FROM totp_recovery_codes WHERE login = $1;`, login)
+	if err != nil {
+		return fmt.Errorf("can't exec: %w", err)
+	}
+
+	codeQuery := `INSERT INTO totp_recovery_codes (login, code_hash) VALUES ($1, $2);`
+	for _, hash := range recoveryCodeHashes {
+		_, err = tx.Exec(ctx, codeQuery, login, hash)
+		if err != nil {
+			return fmt.Errorf("can't exec: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (p *Postgresql) UseTOTPStep(ctx context.Context, login string, step int64) error {
+	query := `UPDATE user_totp SET last_step = $2 WHERE login = $1 AND last_step < $2;`
+
+	return retry(func() error {
+		tag, err := p.pool.Exec(ctx, query, login, step)
+		if err != nil {
+			return fmt.Errorf("can't exec: %w", err)
+		}
+
+		if tag.RowsAffected() == 0 {
+			return repositories.ErrAlreadyUsed
+		}
+
+		return nil
+	})
+}
+
+func (p *Postgresql) UseRecoveryCode(ctx context.Context, login, codeHash string) error {
+	query := `UPDATE totp_recovery_codes SET used_at = now()
+	WHERE login = $1 AND code_hash = $2 AND used_at IS NULL;`
+
+	return retry(func() error {
+		tag, err := p.pool.Exec(ctx, query, login, codeHash)
+		if err != nil {
+			return fmt.Errorf("can't exec: %w", err)
+		}
+
+		if tag.RowsAffected() == 0 {
+			return repositories.ErrNotFound
+		}
+
+		return nil
+	})
+}
+
+func (p *Postgresql) DeleteTOTP(ctx context.Context, login string) error {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("can't begin transaction: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+			return
+		}
+		_ = tx.Commit(ctx)
+	}()
+
+	_, err = tx.Exec(ctx, `DELETE FROM totp_recovery_codes WHERE login = $1;`, login)
+	if err != nil {
+		return fmt.Errorf("can't exec: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `DELETE FROM user_totp WHERE login = $1;`, login)
+	if err != nil {
+		return fmt.Errorf("can't exec: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,125 @@
+//nolint:wrapcheck,gocritic,nolintlint,errcheck,forcetypeassert
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+func TestPostgresql_SaveTOTP(t *testing.T) {
+	totp := model.TOTP{Login: "login", Secret: "SECRET", CreatedAt: time.Now()}
+
+	t.Run("successful save", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockPool.On("Exec", mock.Anything, mock.Anything, []interface{}{"login", "SECRET", totp.CreatedAt}).
+			Return(pgconn.NewCommandTag("INSERT 0 1"), nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		assert.NoError(t, postgres.SaveTOTP(context.TODO(), totp))
+		mockPool.AssertExpectations(t)
+	})
+
+	t.Run("already confirmed", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockPool.On("Exec", mock.Anything, mock.Anything, mock.Anything).
+			Return(pgconn.NewCommandTag("INSERT 0 0"), nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		assert.ErrorIs(t, postgres.SaveTOTP(context.TODO(), totp), repositories.ErrDuplicate)
+	})
+}
+
+func TestPostgresql_ConfirmTOTP(t *testing.T) {
+	t.Run("successful confirm", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockTx := new(MockTx)
+
+		mockPool.On("Begin", mock.Anything).Return(mockTx, nil)
+		mockTx.On("Exec", mock.Anything, mock.Anything, []interface{}{"login", int64(10)}).
+			Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+		mockTx.On("Exec", mock.Anything, "DELETE FROM totp_recovery_codes WHERE login = $1;",
+			[]interface{}{"login"}).Return(pgconn.NewCommandTag("DELETE 0"), nil)
+		mockTx.On("Exec", mock.Anything, "INSERT INTO totp_recovery_codes (login, code_hash) VALUES ($1, $2);",
+			mock.Anything).Return(pgconn.NewCommandTag("INSERT 0 1"), nil).Twice()
+		mockTx.On("Commit", mock.Anything).Return(nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		err := postgres.ConfirmTOTP(context.TODO(), "login", 10, []string{"first", "second"})
+
+		assert.NoError(t, err)
+		mockPool.AssertExpectations(t)
+		mockTx.AssertExpectations(t)
+	})
+
+	t.Run("nothing to confirm", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockTx := new(MockTx)
+
+		mockPool.On("Begin", mock.Anything).Return(mockTx, nil)
+		mockTx.On("Exec", mock.Anything, mock.Anything, mock.Anything).
+			Return(pgconn.NewCommandTag("UPDATE 0"), nil)
+		mockTx.On("Rollback", mock.Anything).Return(nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		err := postgres.ConfirmTOTP(context.TODO(), "login", 10, []string{"first"})
+
+		assert.ErrorIs(t, err, repositories.ErrNotFound)
+		mockTx.AssertExpectations(t)
+	})
+
+	t.Run("error on begin transaction", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockPool.On("Begin", mock.Anything).Return((*MockTx)(nil), errors.New("begin error"))
+
+		postgres := &Postgresql{pool: mockPool}
+
+		err := postgres.ConfirmTOTP(context.TODO(), "login", 10, nil)
+
+		assert.ErrorContains(t, err, "can't begin transaction")
+	})
+}
+
+func TestPostgresql_UseTOTPStep(t *testing.T) {
+	mockPool := new(MockPool)
+	mockPool.On("Exec", mock.Anything, mock.Anything, []interface{}{"login", int64(11)}).
+		Return(pgconn.NewCommandTag("UPDATE 0"), nil)
+
+	postgres := &Postgresql{pool: mockPool}
+
+	assert.ErrorIs(t, postgres.UseTOTPStep(context.TODO(), "login", 11), repositories.ErrAlreadyUsed)
+}
+
+func TestPostgresql_UseRecoveryCode(t *testing.T) {
+	t.Run("successful use", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockPool.On("Exec", mock.Anything, mock.Anything, []interface{}{"login", "hash"}).
+			Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		assert.NoError(t, postgres.UseRecoveryCode(context.TODO(), "login", "hash"))
+	})
+
+	t.Run("unknown or used code", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockPool.On("Exec", mock.Anything, mock.Anything, mock.Anything).
+			Return(pgconn.NewCommandTag("UPDATE 0"), nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		assert.ErrorIs(t, postgres.UseRecoveryCode(context.TODO(), "login", "hash"), repositories.ErrNotFound)
+	})
+}
@@ -0,0 +1,108 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/jackc/pgx/v5"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+// TransferPoints posts a transfer between default wallets once both balance
+// rows are locked. The rows are locked in login order so that opposite
+// transfers can't deadlock, and the locks keep concurrent transfers from
+// both passing a daily limit.
+func (p *Postgresql) TransferPoints(ctx context.Context, transfer model.Transfer) error {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("can't begin transaction: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+			return
+		}
+		_ = tx.Commit(ctx)
+	}()
+
+	queryLock := `select amount from balance where login = $1 and currency = $2 for update;`
+
+	logins := []string{transfer.Sender, transfer.Recipient}
+	slices.Sort(logins)
+
+	balances := make(map[string]model.Money, len(logins))
+	for _, login := range logins {
+		var amount model.Money
+		err = tx.QueryRow(ctx, queryLock, login, model.DefaultCurrency).Scan(&amount)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				err = repositories.ErrNotFound
+				return err
+			}
+
+			return fmt.Errorf("can't scan: %w", err)
+		}
+
+		balances[login] = amount
+	}
+
+	if balances[transfer.Sender] < transfer.Amount {
+		err = repositories.ErrInsufficientFunds
+		return err
+	}
+
+	if transfer.SentLimit > 0 {
+		var sent model.Money
+		sent, _, err = transferred(ctx, tx, transfer.Sender, transfer)
+		if err != nil {
+			return err
+		}
+
+		if sent+transfer.Amount > transfer.SentLimit {
+			err = repositories.ErrLimitExceeded
+			return err
+		}
+	}
+
+	if transfer.ReceivedLimit > 0 {
+		var received model.Money
+		_, received, err = transferred(ctx, tx, transfer.Recipient, transfer)
+		if err != nil {
+			return err
+		}
+
+		if received+transfer.Amount > transfer.ReceivedLimit {
+			err = repositories.ErrLimitExceeded
+			return err
+		}
+	}
+
+	transaction := model.TransferTransaction(transfer.ID, transfer.Sender, transfer.Recipient, transfer.Amount)
+
+	err = postLedger(ctx, tx, transaction)
+
+	return err
+}
+
+// transferred sums what login sent and received in transfers since
+// transfer.Since.
+func transferred(ctx context.Context, tx pgx.Tx, login string,
+	transfer model.Transfer) (model.Money, model.Money, error) {
+	query := `select coalesce(sum(-amount) filter (where amount < 0), 0),
+	    coalesce(sum(amount) filter (where amount > 0), 0)
+	from ledger_entries where login = $1 and kind = $2 and account = $3 and created_at >= $4;`
+
+	var sent, received model.Money
+	err := tx.QueryRow(ctx, query, login, model.LedgerKindTransfer, model.LedgerAccountAvailable,
+		transfer.Since).Scan(&sent, &received)
+	if err != nil {
+		return 0, 0, fmt.Errorf("can't scan: %w", err)
+	}
+
+	return sent, received, nil
+}
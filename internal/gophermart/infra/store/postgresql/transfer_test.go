@@ -0,0 +1,154 @@
+//nolint:wrapcheck,gocritic,nolintlint,errcheck,forcetypeassert
+package postgresql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
+)
+
+const (
+	queryTransferred = `select coalesce(sum(-amount) filter (where amount < 0), 0),
+	    coalesce(sum(amount) filter (where amount > 0), 0)
+	from ledger_entries where login = $1 and kind = $2 and account = $3 and created_at >= $4;`
+	queryCarryLots = `with c as (
+	    select id, least(remaining, greatest($2 - coalesce(sum(remaining) over (
+	        order by expires_at, id rows between unbounded preceding and 1 preceding), 0), 0)) as take
+	    from points_lots
+	    where login = $1 and remaining > 0
+	), consumed as (
+	    update points_lots l set remaining = l.remaining - c.take
+	    from c
+	    where l.id = c.id and c.take > 0
+	    returning l.expires_at, c.take
+	)
+	insert into points_lots (transaction_id, login, amount, remaining, expires_at)
+	select $3, $4, take, take, expires_at from consumed;`
+)
+
+func TestPostgresql_TransferPoints(t *testing.T) {
+	since := time.Now().Truncate(24 * time.Hour)
+	transfer := model.Transfer{
+		ID: "transfer-1", Sender: "bob", Recipient: "alice", Amount: 200,
+		Since: since, SentLimit: 1000,
+	}
+
+	scanAmount := func(amount model.Money) func(args mock.Arguments) {
+		return func(args mock.Arguments) {
+			*(args.Get(0).(*model.Money)) = amount
+		}
+	}
+
+	scanTransferred := func(sent, received model.Money) func(args mock.Arguments) {
+		return func(args mock.Arguments) {
+			*(args.Get(0).(*model.Money)) = sent
+			*(args.Get(1).(*model.Money)) = received
+		}
+	}
+
+	t.Run("transfer", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockTx := new(MockTx)
+		aliceRow := new(MockRow)
+		bobRow := new(MockRow)
+		sumRow := new(MockRow)
+
+		mockPool.On("Begin", mock.Anything).Return(mockTx, nil)
+		mockTx.On("QueryRow", mock.Anything, queryLotBalance, []interface{}{"alice", model.DefaultCurrency}).Return(aliceRow)
+		aliceRow.On("Scan", mock.Anything).Run(scanAmount(0)).Return(nil)
+		mockTx.On("QueryRow", mock.Anything, queryLotBalance, []interface{}{"bob", model.DefaultCurrency}).Return(bobRow)
+		bobRow.On("Scan", mock.Anything).Run(scanAmount(500)).Return(nil)
+		mockTx.On("QueryRow", mock.Anything, queryTransferred,
+			[]interface{}{"bob", model.LedgerKindTransfer, model.LedgerAccountAvailable, since}).Return(sumRow)
+		sumRow.On("Scan", mock.Anything, mock.Anything).Run(scanTransferred(700, 0)).Return(nil)
+		mockTx.On("Exec", mock.Anything, queryLedgerBalance,
+			[]interface{}{model.Money(-200), model.Money(0), model.Money(0), "bob", model.DefaultCurrency}).
+			Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+		mockTx.On("Exec", mock.Anything, queryLedgerBalance,
+			[]interface{}{model.Money(200), model.Money(0), model.Money(0), "alice", model.DefaultCurrency}).
+			Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+		// The recipient gets the points with the expiry of the sender's lots.
+		mockTx.On("Exec", mock.Anything, queryCarryLots,
+			[]interface{}{"bob", model.Money(200), "transfer-1", "alice"}).
+			Return(pgconn.NewCommandTag("INSERT 1"), nil)
+		mockTx.On("Exec", mock.Anything, queryLedgerEntry, mock.Anything).
+			Return(pgconn.NewCommandTag("INSERT 1"), nil).Twice()
+		mockTx.On("Commit", mock.Anything).Return(nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		err := postgres.TransferPoints(context.TODO(), transfer)
+
+		assert.NoError(t, err)
+		mockTx.AssertExpectations(t)
+
+		// Balance rows are locked in login order whoever sends.
+		assert.Equal(t, []interface{}{"alice", model.DefaultCurrency}, mockTx.Calls[0].Arguments.Get(2))
+		assert.Equal(t, []interface{}{"bob", model.DefaultCurrency}, mockTx.Calls[1].Arguments.Get(2))
+	})
+
+	t.Run("limit exceeded", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockTx := new(MockTx)
+		balanceRow := new(MockRow)
+		sumRow := new(MockRow)
+
+		mockPool.On("Begin", mock.Anything).Return(mockTx, nil)
+		mockTx.On("QueryRow", mock.Anything, queryLotBalance, mock.Anything).Return(balanceRow)
+		balanceRow.On("Scan", mock.Anything).Run(scanAmount(500)).Return(nil)
+		mockTx.On("QueryRow", mock.Anything, queryTransferred, mock.Anything).Return(sumRow)
+		sumRow.On("Scan", mock.Anything, mock.Anything).Run(scanTransferred(900, 0)).Return(nil)
+		mockTx.On("Rollback", mock.Anything).Return(nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		err := postgres.TransferPoints(context.TODO(), transfer)
+
+		assert.ErrorIs(t, err, repositories.ErrLimitExceeded)
+		mockTx.AssertExpectations(t)
+	})
+
+	t.Run("insufficient funds", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockTx := new(MockTx)
+		balanceRow := new(MockRow)
+
+		mockPool.On("Begin", mock.Anything).Return(mockTx, nil)
+		mockTx.On("QueryRow", mock.Anything, queryLotBalance, mock.Anything).Return(balanceRow)
+		balanceRow.On("Scan", mock.Anything).Run(scanAmount(100)).Return(nil)
+		mockTx.On("Rollback", mock.Anything).Return(nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		err := postgres.TransferPoints(context.TODO(), transfer)
+
+		assert.ErrorIs(t, err, repositories.ErrInsufficientFunds)
+		mockTx.AssertExpectations(t)
+	})
+
+	t.Run("unknown recipient", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockTx := new(MockTx)
+		balanceRow := new(MockRow)
+
+		mockPool.On("Begin", mock.Anything).Return(mockTx, nil)
+		mockTx.On("QueryRow", mock.Anything, queryLotBalance, mock.Anything).Return(balanceRow)
+		balanceRow.On("Scan", mock.Anything).Return(pgx.ErrNoRows)
+		mockTx.On("Rollback", mock.Anything).Return(nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		err := postgres.TransferPoints(context.TODO(), transfer)
+
+		assert.ErrorIs(t, err, repositories.ErrNotFound)
+		mockTx.AssertExpectations(t)
+	})
+}
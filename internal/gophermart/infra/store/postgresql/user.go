@@ -3,6 +3,10 @@ package postgresql
 import (
 	"context"
 	"fmt"
+	"time"
+
+	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
 )
 
 func (p *Postgresql) CreateUser(ctx context.Context, login, password string) error {
@@ -49,3 +53,71 @@ func (p *Postgresql) GetUserPassword(ctx context.Context, login string) (string,
 
 	return password, nil
 }
+
+func (p *Postgresql) UpdateUserPassword(ctx context.Context, login, password string) error {
+	query := `UPDATE users SET password = $1, updated_at = now() WHERE login = $2;`
+
+	return retry(func() error {
+		tag, err := p.pool.Exec(ctx, query, password, login)
+		if err != nil {
+			return fmt.Errorf("can't exec: %w", err)
+		}
+
+		if tag.RowsAffected() == 0 {
+			return repositories.ErrNotFound
+		}
+
+		return nil
+	})
+}
+
+// ChangeUserPassword stores a password the user chose, unlike
+// UpdateUserPassword it records when that happened.
+func (p *Postgresql) ChangeUserPassword(ctx context.Context, login, password string, changedAt time.Time) error {
+	query := `UPDATE users SET password = $1, password_changed_at = $2, updated_at = now() WHERE login = $3;`
+
+	return p.updateUser(ctx, query, password, changedAt, login)
+}
+
+func (p *Postgresql) GetUser(ctx context.Context, login string) (model.Account, error) {
+	query := `SELECT password, role, disabled, created_at, password_changed_at FROM users WHERE login = $1;`
+
+	account := model.Account{Login: login}
+	row := p.pool.QueryRow(ctx, query, login)
+
+	if err := retry(func() error {
+		return row.Scan(&account.Password, &account.Role, &account.Disabled, &account.CreatedAt,
+			&account.PasswordChangedAt)
+	}); err != nil {
+		return model.Account{}, fmt.Errorf("can't scan: %w", err)
+	}
+
+	return account, nil
+}
+
+func (p *Postgresql) SetUserRole(ctx context.Context, login, role string) error {
+	query := `UPDATE users SET role = $1, updated_at = now() WHERE login = $2;`
+
+	return p.updateUser(ctx, query, role, login)
+}
+
+func (p *Postgresql) SetUserDisabled(ctx context.Context, login string, disabled bool) error {
+	query := `UPDATE users SET disabled = $1, updated_at = now() WHERE login = $2;`
+
+	return p.updateUser(ctx, query, disabled, login)
+}
+
+func (p *Postgresql) updateUser(ctx context.Context, query string, args ...any) error {
+	return retry(func() error {
+		tag, err := p.pool.Exec(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("can't exec: %w", err)
+		}
+
+		if tag.RowsAffected() == 0 {
+			return repositories.ErrNotFound
+		}
+
+		return nil
+	})
+}
@@ -5,12 +5,14 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
+	"gofermart/internal/gophermart/core/model"
 	"gofermart/internal/gophermart/core/repositories"
 )
 
@@ -176,3 +178,153 @@ func TestPostgresql_GetUserPassword(t *testing.T) {
 		mockRow.AssertExpectations(t)
 	})
 }
+
+func TestPostgresql_UpdateUserPassword(t *testing.T) {
+	t.Run("successful update", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockPool.On("Exec", mock.Anything, "UPDATE users SET password = $1, updated_at = now() WHERE login = $2;",
+			[]interface{}{"hash", "testuser"}).Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		err := postgres.UpdateUserPassword(context.TODO(), "testuser", "hash")
+
+		assert.NoError(t, err)
+		mockPool.AssertExpectations(t)
+	})
+
+	t.Run("user not found", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockPool.On("Exec", mock.Anything, mock.Anything, mock.Anything).
+			Return(pgconn.NewCommandTag("UPDATE 0"), nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		err := postgres.UpdateUserPassword(context.TODO(), "testuser", "hash")
+
+		assert.ErrorIs(t, err, repositories.ErrNotFound)
+	})
+}
+
+func TestPostgresql_ChangeUserPassword(t *testing.T) {
+	t.Run("successful change", func(t *testing.T) {
+		mockPool := new(MockPool)
+		changedAt := time.Now()
+
+		mockPool.On("Exec", mock.Anything,
+			"UPDATE users SET password = $1, password_changed_at = $2, updated_at = now() WHERE login = $3;",
+			[]interface{}{"hash", changedAt, "testuser"}).Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		err := postgres.ChangeUserPassword(context.TODO(), "testuser", "hash", changedAt)
+
+		assert.NoError(t, err)
+		mockPool.AssertExpectations(t)
+	})
+
+	t.Run("user not found", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockPool.On("Exec", mock.Anything, mock.Anything, mock.Anything).
+			Return(pgconn.NewCommandTag("UPDATE 0"), nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		err := postgres.ChangeUserPassword(context.TODO(), "testuser", "hash", time.Now())
+
+		assert.ErrorIs(t, err, repositories.ErrNotFound)
+	})
+}
+
+func TestPostgresql_GetUser(t *testing.T) {
+	t.Run("successful get user", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockRow := new(MockRow)
+		createdAt := time.Now()
+
+		mockPool.On("QueryRow", mock.Anything,
+			"SELECT password, role, disabled, created_at, password_changed_at FROM users WHERE login = $1;",
+			[]interface{}{"testuser"}).Return(mockRow)
+
+		mockRow.On("Scan", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) {
+				*(args.Get(0).(*string)) = "hash"
+				*(args.Get(1).(*string)) = model.RoleAdmin
+				*(args.Get(2).(*bool)) = true
+				*(args.Get(3).(*time.Time)) = createdAt
+				*(args.Get(4).(**time.Time)) = &createdAt
+			}).Return(nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		account, err := postgres.GetUser(context.TODO(), "testuser")
+
+		assert.NoError(t, err)
+		assert.Equal(t, model.Account{
+			Login:             "testuser",
+			Password:          "hash",
+			Role:              model.RoleAdmin,
+			Disabled:          true,
+			CreatedAt:         createdAt,
+			PasswordChangedAt: &createdAt,
+		}, account)
+
+		mockPool.AssertExpectations(t)
+		mockRow.AssertExpectations(t)
+	})
+
+	t.Run("err no rows", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockRow := new(MockRow)
+
+		mockPool.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(mockRow)
+		mockRow.On("Scan", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(pgx.ErrNoRows)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		_, err := postgres.GetUser(context.TODO(), "testuser")
+
+		assert.ErrorIs(t, err, repositories.ErrNotFound)
+	})
+}
+
+func TestPostgresql_SetUserRole(t *testing.T) {
+	t.Run("successful update", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockPool.On("Exec", mock.Anything, "UPDATE users SET role = $1, updated_at = now() WHERE login = $2;",
+			[]interface{}{model.RoleSupport, "testuser"}).Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		err := postgres.SetUserRole(context.TODO(), "testuser", model.RoleSupport)
+
+		assert.NoError(t, err)
+		mockPool.AssertExpectations(t)
+	})
+
+	t.Run("user not found", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockPool.On("Exec", mock.Anything, mock.Anything, mock.Anything).
+			Return(pgconn.NewCommandTag("UPDATE 0"), nil)
+
+		postgres := &Postgresql{pool: mockPool}
+
+		err := postgres.SetUserRole(context.TODO(), "testuser", model.RoleSupport)
+
+		assert.ErrorIs(t, err, repositories.ErrNotFound)
+	})
+}
+
+func TestPostgresql_SetUserDisabled(t *testing.T) {
+	mockPool := new(MockPool)
+	mockPool.On("Exec", mock.Anything, "UPDATE users SET disabled = $1, updated_at = now() WHERE login = $2;",
+		[]interface{}{true, "testuser"}).Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+
+	postgres := &Postgresql{pool: mockPool}
+
+	err := postgres.SetUserDisabled(context.TODO(), "testuser", true)
+
+	assert.NoError(t, err)
+	mockPool.AssertExpectations(t)
+}
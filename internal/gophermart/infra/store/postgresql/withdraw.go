@@ -2,7 +2,12 @@ package postgresql
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 
 	"gofermart/internal/gophermart/core/model"
 	"gofermart/internal/gophermart/core/repositories"
@@ -22,11 +27,18 @@ func (p *Postgresql) UserWithdraw(ctx context.Context, login string, request mod
 		_ = tx.Commit(ctx)
 	}()
 
-	queryGetBalance := `select amount from balance where login = $1;`
+	currency := model.WalletCurrency(request.Currency)
+
+	queryGetBalance := `select amount from balance where login = $1 and currency = $2 for update;`
 
-	var amount int
-	err = tx.QueryRow(ctx, queryGetBalance, login).Scan(&amount)
+	var amount model.Money
+	err = tx.QueryRow(ctx, queryGetBalance, login, currency).Scan(&amount)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = repositories.ErrInsufficientFunds
+			return err
+		}
+
 		return fmt.Errorf("can't query: %w", err)
 	}
 
@@ -34,26 +46,78 @@ func (p *Postgresql) UserWithdraw(ctx context.Context, login string, request mod
 		return fmt.Errorf("insufficient funds: %w", repositories.ErrInsufficientFunds)
 	}
 
-	queryBalance := `update balance set amount = amount - $1, withdraw = withdraw + $1 
-               where login = $2;`
+	err = checkCaps(ctx, tx, login, request)
+	if err != nil {
+		return err
+	}
+
+	transaction := model.WithdrawalTransaction(uuid.NewString(), login, request.OrderID, request.Amount)
+	transaction.Currency = currency
 
-	_, err = tx.Exec(ctx, queryBalance, request.Amount, login)
+	err = postLedger(ctx, tx, transaction)
 	if err != nil {
-		return fmt.Errorf("can't exec: %w", err)
+		return err
 	}
 
-	queryWithdraw := `insert into withdraw (login, amount, order_id) values ($1, $2, $3);`
+	queryWithdraw := `insert into withdraw (login, amount, order_id, currency, partner) values ($1, $2, $3, $4, $5);`
 
-	_, err = tx.Exec(ctx, queryWithdraw, login, request.Amount, request.OrderID)
+	_, err = tx.Exec(ctx, queryWithdraw, login, request.Amount, request.OrderID, currency, request.Partner)
 	if err != nil {
+		if isDuplicateError(err) {
+			err = repositories.ErrDuplicate
+			return err
+		}
+
 		return fmt.Errorf("can't exec: %w", err)
 	}
 
 	return nil
 }
 
+// queryWithdrawn sums what a user withdrew from a wallet since a time, net
+// of refunds. Released and expired holds don't count.
+const queryWithdrawn = `select coalesce(sum(amount - refunded), 0) from withdraw
+	where login = $1 and currency = $2 and created_at >= $3 and status <> all($4);`
+
+var notWithdrawnStatuses = []string{model.WithdrawStatusReleased, model.WithdrawStatusExpired}
+
+func (p *Postgresql) GetUserWithdrawn(ctx context.Context, login, currency string,
+	since time.Time) (model.Money, error) {
+	var withdrawn model.Money
+	row := p.pool.QueryRow(ctx, queryWithdrawn, login, model.WalletCurrency(currency), since, notWithdrawnStatuses)
+
+	if err := retry(func() error {
+		return row.Scan(&withdrawn)
+	}); err != nil {
+		return 0, fmt.Errorf("can't scan: %w", err)
+	}
+
+	return withdrawn, nil
+}
+
+// checkCaps fails with ErrLimitExceeded if request would break one of its
+// caps. The balance row of login must be locked by tx so that concurrent
+// withdrawals are checked one after another.
+func checkCaps(ctx context.Context, tx pgx.Tx, login string, request model.Withdraw) error {
+	for _, c := range request.Caps {
+		var withdrawn model.Money
+		err := tx.QueryRow(ctx, queryWithdrawn, login, model.WalletCurrency(request.Currency), c.Since,
+			notWithdrawnStatuses).Scan(&withdrawn)
+		if err != nil {
+			return fmt.Errorf("can't scan: %w", err)
+		}
+
+		if withdrawn+request.Amount > c.Limit {
+			return repositories.ErrLimitExceeded
+		}
+	}
+
+	return nil
+}
+
 func (p *Postgresql) GetUserWithdrawals(ctx context.Context, login string) ([]model.Withdraw, error) {
-	query := `SELECT amount, order_id, created_at FROM withdraw WHERE login = $1;`
+	query := `SELECT amount, refunded, order_id, status, currency, created_at, expires_at
+	FROM withdraw WHERE login = $1;`
 
 	rows, err := p.pool.Query(ctx, query, login)
 	if err != nil {
@@ -63,8 +127,9 @@ func (p *Postgresql) GetUserWithdrawals(ctx context.Context, login string) ([]mo
 
 	var withdrawals []model.Withdraw
 	for rows.Next() {
-		var w model.Withdraw
-		if err := rows.Scan(&w.Amount, &w.OrderID, &w.CreatedAt); err != nil {
+		w := model.Withdraw{Login: login}
+		if err := rows.Scan(&w.Amount, &w.Refunded, &w.OrderID, &w.Status, &w.Currency, &w.CreatedAt,
+			&w.ExpiresAt); err != nil {
 			return nil, fmt.Errorf("can't scan: %w", err)
 		}
 
@@ -4,12 +4,15 @@ package postgresql
 import (
 	"context"
 	"testing"
+	"time"
 
+	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
 	"gofermart/internal/gophermart/core/model"
+	"gofermart/internal/gophermart/core/repositories"
 )
 
 func TestPostgresql_UserWithdraw(t *testing.T) {
@@ -19,16 +22,21 @@ func TestPostgresql_UserWithdraw(t *testing.T) {
 		mockRow := new(MockRow)
 
 		mockPool.On("Begin", mock.Anything).Return(mockTx, nil)
-		mockTx.On("QueryRow", mock.Anything, "select amount from balance where login = $1;",
+		mockTx.On("QueryRow", mock.Anything, "select amount from balance where login = $1 and currency = $2 for update;",
 			mock.Anything).Return(mockRow)
 		mockRow.On("Scan", mock.Anything).Run(func(args mock.Arguments) {
-			*(args.Get(0).(*int)) = 100
+			*(args.Get(0).(*model.Money)) = 100
 		}).Return(nil)
-		mockTx.On("Exec", mock.Anything, `update balance set amount = amount - $1, withdraw = withdraw + $1 
-               where login = $2;`, mock.Anything, mock.Anything).Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+		mockTx.On("Exec", mock.Anything, queryLedgerBalance,
+			[]interface{}{model.Money(-50), model.Money(0), model.Money(50), "testuser", model.DefaultCurrency}).
+			Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+		mockTx.On("Exec", mock.Anything, queryConsumeLots, []interface{}{"testuser", model.Money(50), "order123"}).
+			Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+		mockTx.On("Exec", mock.Anything, queryLedgerEntry, mock.Anything).
+			Return(pgconn.NewCommandTag("INSERT 1"), nil).Twice()
 
 		mockTx.On("Exec", mock.Anything,
-			"insert into withdraw (login, amount, order_id) values ($1, $2, $3);",
+			"insert into withdraw (login, amount, order_id, currency, partner) values ($1, $2, $3, $4, $5);",
 			mock.Anything, mock.Anything, mock.Anything).Return(pgconn.NewCommandTag("INSERT 1"), nil)
 
 		mockTx.On("Commit", mock.Anything).Return(nil)
@@ -48,6 +56,41 @@ func TestPostgresql_UserWithdraw(t *testing.T) {
 		mockRow.AssertExpectations(t)
 	})
 
+	t.Run("order already used", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockTx := new(MockTx)
+		mockRow := new(MockRow)
+
+		mockPool.On("Begin", mock.Anything).Return(mockTx, nil)
+		mockTx.On("QueryRow", mock.Anything, "select amount from balance where login = $1 and currency = $2 for update;",
+			mock.Anything).Return(mockRow)
+		mockRow.On("Scan", mock.Anything).Run(func(args mock.Arguments) {
+			*(args.Get(0).(*model.Money)) = 100
+		}).Return(nil)
+		mockTx.On("Exec", mock.Anything, queryLedgerBalance, mock.Anything).
+			Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+		mockTx.On("Exec", mock.Anything, queryConsumeLots, mock.Anything).
+			Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+		mockTx.On("Exec", mock.Anything, queryLedgerEntry, mock.Anything).
+			Return(pgconn.NewCommandTag("INSERT 1"), nil).Twice()
+		mockTx.On("Exec", mock.Anything,
+			"insert into withdraw (login, amount, order_id, currency, partner) values ($1, $2, $3, $4, $5);",
+			mock.Anything, mock.Anything, mock.Anything).
+			Return(pgconn.CommandTag{}, &pgconn.PgError{Code: pgerrcode.UniqueViolation})
+		mockTx.On("Rollback", mock.Anything).Return(nil)
+
+		postgres := &Postgresql{pool: mockPool}
+		request := model.Withdraw{
+			Amount:  50,
+			OrderID: "order123",
+		}
+
+		err := postgres.UserWithdraw(context.TODO(), "testuser", request)
+
+		assert.ErrorIs(t, err, repositories.ErrDuplicate)
+		mockTx.AssertExpectations(t)
+	})
+
 	t.Run("insufficient funds", func(t *testing.T) {
 		mockPool := new(MockPool)
 		mockTx := new(MockTx)
@@ -55,10 +98,10 @@ func TestPostgresql_UserWithdraw(t *testing.T) {
 
 		mockPool.On("Begin", mock.Anything).Return(mockTx, nil)
 
-		mockTx.On("QueryRow", mock.Anything, "select amount from balance where login = $1;",
+		mockTx.On("QueryRow", mock.Anything, "select amount from balance where login = $1 and currency = $2 for update;",
 			mock.Anything).Return(mockRow)
 		mockRow.On("Scan", mock.Anything).Run(func(args mock.Arguments) {
-			*(args.Get(0).(*int)) = 100
+			*(args.Get(0).(*model.Money)) = 100
 		}).Return(nil)
 
 		mockTx.On("Commit", mock.Anything).Return(nil)
@@ -86,7 +129,7 @@ func TestPostgresql_UserWithdraw(t *testing.T) {
 
 		mockPool.On("Begin", mock.Anything).Return(mockTx, nil)
 
-		mockTx.On("QueryRow", mock.Anything, "select amount from balance where login = $1;",
+		mockTx.On("QueryRow", mock.Anything, "select amount from balance where login = $1 and currency = $2 for update;",
 			mock.Anything).Return(mockRow)
 		mockRow.On("Scan", mock.Anything).Return(assert.AnError)
 
@@ -115,14 +158,13 @@ func TestPostgresql_UserWithdraw(t *testing.T) {
 
 		mockPool.On("Begin", mock.Anything).Return(mockTx, nil)
 
-		mockTx.On("QueryRow", mock.Anything, "select amount from balance where login = $1;",
+		mockTx.On("QueryRow", mock.Anything, "select amount from balance where login = $1 and currency = $2 for update;",
 			mock.Anything).Return(mockRow)
 		mockRow.On("Scan", mock.Anything).Run(func(args mock.Arguments) {
-			*(args.Get(0).(*int)) = 100
+			*(args.Get(0).(*model.Money)) = 100
 		}).Return(nil)
 
-		mockTx.On("Exec", mock.Anything, `update balance set amount = amount - $1, withdraw = withdraw + $1 
-               where login = $2;`, mock.Anything).
+		mockTx.On("Exec", mock.Anything, queryLedgerBalance, mock.Anything).
 			Return(pgconn.NewCommandTag("UPDATE 0"), assert.AnError)
 
 		mockTx.On("Rollback", mock.Anything).Return(nil)
@@ -150,18 +192,19 @@ func TestPostgresql_UserWithdraw(t *testing.T) {
 
 		mockPool.On("Begin", mock.Anything).Return(mockTx, nil)
 
-		mockTx.On("QueryRow", mock.Anything, "select amount from balance where login = $1;",
+		mockTx.On("QueryRow", mock.Anything, "select amount from balance where login = $1 and currency = $2 for update;",
 			mock.Anything).Return(mockRow)
 		mockRow.On("Scan", mock.Anything).Run(func(args mock.Arguments) {
-			*(args.Get(0).(*int)) = 100
+			*(args.Get(0).(*model.Money)) = 100
 		}).Return(nil)
 
-		mockTx.On("Exec", mock.Anything, `update balance set amount = amount - $1, withdraw = withdraw + $1 
-               where login = $2;`, mock.Anything).Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+		mockTx.On("Exec", mock.Anything, queryLedgerBalance, mock.Anything).Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+		mockTx.On("Exec", mock.Anything, queryConsumeLots, mock.Anything).Return(pgconn.NewCommandTag("UPDATE 0"), nil)
+		mockTx.On("Exec", mock.Anything, queryLedgerEntry, mock.Anything).Return(pgconn.NewCommandTag("INSERT 1"), nil)
 
 		mockTx.On("Exec", mock.Anything,
-			"insert into withdraw (login, amount, order_id) values ($1, $2, $3);", mock.Anything).
-			Return(pgconn.NewCommandTag("UPDATE 0"), assert.AnError)
+			"insert into withdraw (login, amount, order_id, currency, partner) values ($1, $2, $3, $4, $5);",
+			mock.Anything).Return(pgconn.NewCommandTag("UPDATE 0"), assert.AnError)
 
 		mockTx.On("Rollback", mock.Anything).Return(nil)
 
@@ -180,4 +223,63 @@ func TestPostgresql_UserWithdraw(t *testing.T) {
 		mockTx.AssertExpectations(t)
 		mockRow.AssertExpectations(t)
 	})
+
+	t.Run("daily cap exceeded", func(t *testing.T) {
+		mockPool := new(MockPool)
+		mockTx := new(MockTx)
+		balanceRow := new(MockRow)
+		withdrawnRow := new(MockRow)
+		since := time.Date(2025, 3, 14, 0, 0, 0, 0, time.UTC)
+
+		mockPool.On("Begin", mock.Anything).Return(mockTx, nil)
+		mockTx.On("QueryRow", mock.Anything, "select amount from balance where login = $1 and currency = $2 for update;",
+			mock.Anything).Return(balanceRow)
+		balanceRow.On("Scan", mock.Anything).Run(func(args mock.Arguments) {
+			*(args.Get(0).(*model.Money)) = 100
+		}).Return(nil)
+		mockTx.On("QueryRow", mock.Anything, queryWithdrawn,
+			[]interface{}{"testuser", model.DefaultCurrency, since, notWithdrawnStatuses}).Return(withdrawnRow)
+		withdrawnRow.On("Scan", mock.Anything).Run(func(args mock.Arguments) {
+			*(args.Get(0).(*model.Money)) = 60
+		}).Return(nil)
+
+		mockTx.On("Rollback", mock.Anything).Return(nil)
+
+		postgres := &Postgresql{pool: mockPool}
+		request := model.Withdraw{
+			Amount:  50,
+			OrderID: "order123",
+			Caps:    []model.WithdrawCap{{Since: since, Limit: 100}},
+		}
+
+		err := postgres.UserWithdraw(context.TODO(), "testuser", request)
+
+		assert.ErrorIs(t, err, repositories.ErrLimitExceeded)
+
+		mockPool.AssertExpectations(t)
+		mockTx.AssertExpectations(t)
+		withdrawnRow.AssertExpectations(t)
+	})
+}
+
+func TestPostgresql_GetUserWithdrawn(t *testing.T) {
+	mockPool := new(MockPool)
+	mockRow := new(MockRow)
+	since := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	mockPool.On("QueryRow", mock.Anything, queryWithdrawn,
+		[]interface{}{"testuser", "PARTNER", since, notWithdrawnStatuses}).Return(mockRow)
+	mockRow.On("Scan", mock.Anything).Run(func(args mock.Arguments) {
+		*(args.Get(0).(*model.Money)) = 250
+	}).Return(nil)
+
+	postgres := &Postgresql{pool: mockPool}
+
+	withdrawn, err := postgres.GetUserWithdrawn(context.TODO(), "testuser", "PARTNER", since)
+
+	assert.NoError(t, err)
+	assert.Equal(t, model.Money(250), withdrawn)
+
+	mockPool.AssertExpectations(t)
+	mockRow.AssertExpectations(t)
 }
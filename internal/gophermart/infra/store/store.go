@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"gofermart/internal/gophermart/core/model"
 	"gofermart/internal/gophermart/infra/store/memory"
@@ -21,18 +22,99 @@ type Store interface {
 
 	CreateUser(ctx context.Context, login, password string) error
 	GetUserPassword(ctx context.Context, login string) (string, error)
+	UpdateUserPassword(ctx context.Context, login, password string) error
+	ChangeUserPassword(ctx context.Context, login, password string, changedAt time.Time) error
+	GetUser(ctx context.Context, login string) (model.Account, error)
+	SetUserRole(ctx context.Context, login, role string) error
+	SetUserDisabled(ctx context.Context, login string, disabled bool) error
+	DeleteUser(ctx context.Context, login, anonymizedLogin string) error
+
+	SaveRefreshToken(ctx context.Context, token model.RefreshToken) error
+	GetRefreshToken(ctx context.Context, tokenHash string) (model.RefreshToken, error)
+	UseRefreshToken(ctx context.Context, tokenHash string) error
+	RevokeRefreshTokenFamily(ctx context.Context, familyID string) error
+	RevokeUserRefreshTokens(ctx context.Context, login, exceptFamilyID string) error
+	RevokeToken(ctx context.Context, tokenID string, expiresAt time.Time) error
+	IsTokenRevoked(ctx context.Context, tokenID string) (bool, error)
+
+	SaveSession(ctx context.Context, session model.Session) error
+	GetSession(ctx context.Context, id string) (model.Session, error)
+	GetUserSessions(ctx context.Context, login string) ([]model.Session, error)
+	TouchSession(ctx context.Context, id string, seenAt time.Time) error
+	RevokeSession(ctx context.Context, login, id string) error
+	RevokeUserSessions(ctx context.Context, login, exceptID string) error
+
+	SaveAPIKey(ctx context.Context, key model.APIKey) error
+	GetAPIKey(ctx context.Context, keyHash string) (model.APIKey, error)
+	GetUserAPIKeys(ctx context.Context, login string) ([]model.APIKey, error)
+	RevokeAPIKey(ctx context.Context, login, id string) error
+	TouchAPIKey(ctx context.Context, id string, usedAt time.Time) error
+
+	SaveTOTP(ctx context.Context, totp model.TOTP) error
+	GetTOTP(ctx context.Context, login string) (model.TOTP, error)
+	ConfirmTOTP(ctx context.Context, login string, step int64, recoveryCodeHashes []string) error
+	UseTOTPStep(ctx context.Context, login string, step int64) error
+	UseRecoveryCode(ctx context.Context, login, codeHash string) error
+	DeleteTOTP(ctx context.Context, login string) error
+
+	SaveIdempotencyKey(ctx context.Context, record model.IdempotencyKey) error
+	GetIdempotencyKey(ctx context.Context, login, key string) (model.IdempotencyKey, error)
+	CompleteIdempotencyKey(ctx context.Context, login, key string,
+		response model.IdempotentResponse, completedAt time.Time) error
+	DeleteIdempotencyKey(ctx context.Context, login, key string) error
+
+	GetLoginAttempts(ctx context.Context, key string) (model.LoginAttempts, error)
+	RecordLoginFailure(ctx context.Context, key string, window time.Duration) (model.LoginAttempts, error)
+	LockLogin(ctx context.Context, key string, until time.Time) error
+	ResetLoginAttempts(ctx context.Context, key string) error
+	ReleaseLoginAttempt(ctx context.Context, key string) error
 
 	SaveOrder(ctx context.Context, login string, request model.OrderRequest) error
-	SetBalance(ctx context.Context, orderID, status string, amount int) error
+	SetBalance(ctx context.Context, accrual model.Accrual) error
 
 	GetOrderLogin(ctx context.Context, orderID string) (string, error)
 	GetUserOrders(ctx context.Context, login string) ([]model.Order, error)
 	GetPendingOrders(ctx context.Context) ([]model.Order, error)
 
 	GetUserBalance(ctx context.Context, login string) (model.UserBalance, error)
+	GetUserWallets(ctx context.Context, login string) ([]model.Wallet, error)
 
 	UserWithdraw(ctx context.Context, login string, request model.Withdraw) error
 	GetUserWithdrawals(ctx context.Context, login string) ([]model.Withdraw, error)
+	GetUserWithdrawn(ctx context.Context, login, currency string, since time.Time) (model.Money, error)
+	HoldWithdraw(ctx context.Context, login string, request model.Withdraw) error
+	CaptureWithdraw(ctx context.Context, login, orderID string, now time.Time) error
+	ReleaseWithdraw(ctx context.Context, login, orderID, status string) error
+	GetExpiredHolds(ctx context.Context, now time.Time) ([]model.Withdraw, error)
+	RefundWithdraw(ctx context.Context, refund model.WithdrawRefund) (model.WithdrawRefund, model.Withdraw, error)
+	TransferPoints(ctx context.Context, transfer model.Transfer) error
+	GetReferralCode(ctx context.Context, login string) (string, error)
+	SetReferralCode(ctx context.Context, login, code string) error
+	GetReferrer(ctx context.Context, code string) (string, error)
+	CreateReferral(ctx context.Context, referral model.Referral, limit int) error
+	GetReferrals(ctx context.Context, referrer string) ([]model.Referral, error)
+
+	AddLedgerTransaction(ctx context.Context, transaction model.LedgerTransaction) error
+	GetLedgerTransaction(ctx context.Context, id string) (model.LedgerTransaction, error)
+	GetUserLedger(ctx context.Context, login string) ([]model.LedgerEntry, error)
+	GetBalanceHistory(ctx context.Context, filter model.BalanceHistoryFilter) ([]model.BalanceHistoryEntry, error)
+	GetWalletAudits(ctx context.Context) ([]model.WalletAudit, error)
+
+	GetExpiredLots(ctx context.Context, now time.Time) ([]model.PointsLot, error)
+	ExpireLot(ctx context.Context, lot model.PointsLot, now time.Time) error
+	GetExpiringPoints(ctx context.Context, login string, before time.Time) (model.Money, error)
+
+	GetUserTier(ctx context.Context, login string, since time.Time) (model.UserTier, error)
+	GetUserTiers(ctx context.Context, since time.Time) ([]model.UserTier, error)
+	SetUserTier(ctx context.Context, login, tier string) error
+
+	CreatePromotion(ctx context.Context, promotion model.Promotion) error
+	UpdatePromotion(ctx context.Context, promotion model.Promotion) error
+	DeletePromotion(ctx context.Context, id string) error
+	GetPromotion(ctx context.Context, id string) (model.Promotion, error)
+	GetPromotions(ctx context.Context) ([]model.Promotion, error)
+	GetActivePromotions(ctx context.Context, now time.Time) ([]model.Promotion, error)
+	GetPromotionAwards(ctx context.Context, promotionID string) ([]model.PromotionAward, error)
 }
 
 func NewStore(conf Config) (Store, error) {